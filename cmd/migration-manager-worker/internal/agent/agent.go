@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	incus "github.com/lxc/incus/v7/client"
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"golang.org/x/sys/unix"
+
+	"github.com/FuturFusion/migration-manager/internal/logger"
+	"github.com/FuturFusion/migration-manager/internal/target"
+)
+
+// Agent watches the local Incus host for instances handed to the import agent, and runs an import for each of them.
+type Agent struct {
+	client   incus.InstanceServer
+	interval time.Duration
+
+	// spawn starts the import of an instance in a separate process.
+	spawn func(project string, instanceName string) (*exec.Cmd, error)
+
+	imports map[string]*importProcess
+
+	// Import attempts that exited, keyed by instance, so they aren't started again.
+	// A failed attempt has already reported its error to Migration Manager, which retries by starting a new attempt.
+	completed map[string]string
+}
+
+type importProcess struct {
+	cmd     *exec.Cmd
+	attempt string
+	exited  chan struct{}
+	err     error
+}
+
+// NewAgent returns an agent that checks the instances of the Incus host at the given interval.
+func NewAgent(client incus.InstanceServer, interval time.Duration, spawn func(project string, instanceName string) (*exec.Cmd, error)) *Agent {
+	return &Agent{
+		client:    client,
+		interval:  interval,
+		spawn:     spawn,
+		imports:   map[string]*importProcess{},
+		completed: map[string]string{},
+	}
+}
+
+// Run starts and stops imports as instances are handed to the agent, until the context is cancelled.
+func (a *Agent) Run(ctx context.Context) {
+	defer a.stopAll()
+
+	for {
+		err := a.reconcile()
+		if err != nil {
+			slog.Error("Failed to check instances for import", logger.Err(err))
+		}
+
+		t := time.NewTimer(a.interval)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+			t.Stop()
+		}
+	}
+}
+
+// reconcile starts an import for each stopped instance handed to the agent, and stops imports of instances that no longer are.
+func (a *Agent) reconcile() error {
+	instances, err := a.client.GetInstancesAllProjects(incusAPI.InstanceTypeVM)
+	if err != nil {
+		return err
+	}
+
+	// The current import attempt of each instance that should be importing.
+	attempts := map[string]string{}
+	eligible := map[string]incusAPI.Instance{}
+	for _, inst := range instances {
+		attempt := inst.Config[target.ImportAgentConfigKey]
+		if attempt == "" || inst.StatusCode != incusAPI.Stopped {
+			continue
+		}
+
+		key := inst.Project + "/" + inst.Name
+		attempts[key] = attempt
+		eligible[key] = inst
+	}
+
+	for key, proc := range a.imports {
+		select {
+		case <-proc.exited:
+			delete(a.imports, key)
+			a.completed[key] = proc.attempt
+			if proc.err != nil {
+				slog.Error("Import exited", slog.String("instance", key), logger.Err(proc.err))
+				continue
+			}
+
+			slog.Info("Import completed", slog.String("instance", key))
+			continue
+		default:
+		}
+
+		if attempts[key] != proc.attempt {
+			slog.Info("Stopping import", slog.String("instance", key))
+			_ = proc.cmd.Process.Signal(unix.SIGTERM)
+		}
+	}
+
+	for key, attempt := range attempts {
+		_, running := a.imports[key]
+		if running || a.completed[key] == attempt {
+			continue
+		}
+
+		inst := eligible[key]
+		cmd, err := a.spawn(inst.Project, inst.Name)
+		if err != nil {
+			slog.Error("Failed to start import", slog.String("instance", key), logger.Err(err))
+			continue
+		}
+
+		slog.Info("Started import", slog.String("instance", key), slog.Int("pid", cmd.Process.Pid))
+		proc := &importProcess{cmd: cmd, attempt: attempt, exited: make(chan struct{})}
+		go func() {
+			proc.err = cmd.Wait()
+			close(proc.exited)
+		}()
+
+		a.imports[key] = proc
+	}
+
+	return nil
+}
+
+// stopAll stops all running imports and waits for them to exit.
+func (a *Agent) stopAll() {
+	for _, proc := range a.imports {
+		_ = proc.cmd.Process.Signal(unix.SIGTERM)
+	}
+
+	for key, proc := range a.imports {
+		<-proc.exited
+		delete(a.imports, key)
+	}
+}
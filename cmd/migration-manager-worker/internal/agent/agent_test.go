@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"os/exec"
+	"testing"
+
+	incus "github.com/lxc/incus/v7/client"
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/target"
+)
+
+type instanceServer struct {
+	incus.InstanceServer
+
+	instances []incusAPI.Instance
+}
+
+func (s *instanceServer) GetInstancesAllProjects(instanceType incusAPI.InstanceType) ([]incusAPI.Instance, error) {
+	return s.instances, nil
+}
+
+func TestAgent_reconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+	}{
+		{
+			name:    "success",
+			program: "true",
+		},
+		{
+			name:    "failed import isn't restarted for the same attempt",
+			program: "false",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &instanceServer{
+				instances: []incusAPI.Instance{{
+					Name:       "vm1",
+					Project:    "default",
+					StatusCode: incusAPI.Stopped,
+					InstancePut: incusAPI.InstancePut{
+						Config: map[string]string{target.ImportAgentConfigKey: "attempt-1"},
+					},
+				}},
+			}
+
+			spawned := 0
+			a := NewAgent(client, 0, func(project string, instanceName string) (*exec.Cmd, error) {
+				spawned++
+				cmd := exec.Command(tc.program)
+				return cmd, cmd.Start()
+			})
+
+			require.NoError(t, a.reconcile())
+			require.Equal(t, 1, spawned)
+			<-a.imports["default/vm1"].exited
+
+			// The exited import is recorded, and not started again.
+			require.NoError(t, a.reconcile())
+			require.NoError(t, a.reconcile())
+			require.Equal(t, 1, spawned)
+			require.Equal(t, "attempt-1", a.completed["default/vm1"])
+
+			// A new attempt starts a new import.
+			client.instances[0].Config[target.ImportAgentConfigKey] = "attempt-2"
+			require.NoError(t, a.reconcile())
+			require.Equal(t, 2, spawned)
+			a.stopAll()
+		})
+	}
+}
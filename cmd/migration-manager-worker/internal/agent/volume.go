@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	incus "github.com/lxc/incus/v7/client"
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"github.com/lxc/incus/v7/shared/subprocess"
+	"github.com/lxc/incus/v7/shared/util"
+)
+
+const (
+	volumeTypeVM     = "virtual-machines"
+	volumeTypeCustom = "custom"
+)
+
+// VolumeLocator finds the storage volumes backing the disks of a stopped instance on the local Incus host.
+type VolumeLocator struct {
+	client   incus.InstanceServer
+	incusDir string
+	project  string
+	instance *incusAPI.Instance
+
+	// Datasets of the ZFS volumes that were exposed as block devices to import into them.
+	activated []string
+	lock      sync.Mutex
+}
+
+// NewVolumeLocator returns a locator for the disks of the instance in the given project.
+func NewVolumeLocator(client incus.InstanceServer, incusDir string, project string, instance *incusAPI.Instance) *VolumeLocator {
+	return &VolumeLocator{
+		client:   client,
+		incusDir: incusDir,
+		project:  project,
+		instance: instance,
+	}
+}
+
+// Locate returns the path of the volume backing the instance disk for the named source disk, and whether it is the root disk.
+func (l *VolumeLocator) Locate(ctx context.Context, diskName string) (string, bool, error) {
+	for _, dev := range l.instance.ExpandedDevices {
+		if dev["type"] != "disk" || dev["user.migration_source"] != diskName {
+			continue
+		}
+
+		pool, _, err := l.client.GetStoragePool(dev["pool"])
+		if err != nil {
+			return "", false, fmt.Errorf("Failed to get storage pool %q: %w", dev["pool"], err)
+		}
+
+		isRoot := dev["path"] == "/"
+		volType := volumeTypeVM
+		volName := storageName(l.project, l.instance.Name)
+		if !isRoot {
+			volProject, err := l.customVolumeProject()
+			if err != nil {
+				return "", false, err
+			}
+
+			volType = volumeTypeCustom
+			volName = storageName(volProject, dev["source"])
+		}
+
+		path, err := volumePath(l.incusDir, *pool, volType, volName)
+		if err != nil {
+			return "", false, err
+		}
+
+		if pool.Driver == "zfs" && !util.PathExists(path) {
+			err := l.activateZvol(ctx, path)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		return path, isRoot, nil
+	}
+
+	return "", false, fmt.Errorf("Failed to find any disk with migration source %q", diskName)
+}
+
+// Release hides the ZFS volumes that were exposed for the import again, so the host does not keep scanning them.
+func (l *VolumeLocator) Release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, dataset := range l.activated {
+		_, err := subprocess.RunCommand("zfs", "set", "volmode=none", dataset)
+		if err != nil {
+			slog.Warn("Failed to hide ZFS volume", slog.String("dataset", dataset), slog.Any("error", err))
+		}
+	}
+
+	l.activated = nil
+}
+
+// customVolumeProject returns the project that holds the custom volumes of the instance's project.
+func (l *VolumeLocator) customVolumeProject() (string, error) {
+	if l.project == incusAPI.ProjectDefaultName {
+		return l.project, nil
+	}
+
+	project, _, err := l.client.GetProject(l.project)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get project %q: %w", l.project, err)
+	}
+
+	if util.IsTrue(project.Config["features.storage.volumes"]) {
+		return l.project, nil
+	}
+
+	return incusAPI.ProjectDefaultName, nil
+}
+
+// activateZvol exposes the ZFS volume at the given path as a block device, and waits for the device to appear.
+func (l *VolumeLocator) activateZvol(ctx context.Context, path string) error {
+	dataset, err := filepath.Rel("/dev/zvol", path)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	_, err = subprocess.RunCommandContext(ctx, "zfs", "set", "volmode=dev", dataset)
+	if err != nil {
+		return fmt.Errorf("Failed to expose ZFS volume %q: %w", dataset, err)
+	}
+
+	l.activated = append(l.activated, dataset)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Timed out waiting for ZFS volume %q to appear: %w", path, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// storageName returns the name of an instance or volume in the storage pool, which is prefixed by its project.
+func storageName(project string, name string) string {
+	if project == incusAPI.ProjectDefaultName {
+		return name
+	}
+
+	return project + "_" + name
+}
+
+// volumePath returns the path on the host of the disk image or block device that backs a storage volume.
+func volumePath(incusDir string, pool incusAPI.StoragePool, volType string, volName string) (string, error) {
+	switch pool.Driver {
+	case "dir":
+		return filepath.Join(incusDir, "storage-pools", pool.Name, volType, volName, "root.img"), nil
+
+	case "zfs":
+		dataset := pool.Config["zfs.pool_name"]
+		if dataset == "" {
+			dataset = pool.Name
+		}
+
+		// The block volume of a VM sits next to its config filesystem, so it is suffixed.
+		if volType == volumeTypeVM {
+			volName += ".block"
+		}
+
+		return filepath.Join("/dev/zvol", dataset, volType, volName), nil
+	}
+
+	return "", fmt.Errorf("Storage pool %q uses the %q driver, which is not supported by the import agent", pool.Name, pool.Driver)
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"testing"
+
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolumePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    incusAPI.StoragePool
+		project string
+		volType string
+		volName string
+
+		assertErr require.ErrorAssertionFunc
+		wantPath  string
+	}{
+		{
+			name:    "success - dir root volume",
+			pool:    incusAPI.StoragePool{Name: "default", Driver: "dir"},
+			project: "default",
+			volType: volumeTypeVM,
+			volName: "vm1",
+
+			assertErr: require.NoError,
+			wantPath:  "/var/lib/incus/storage-pools/default/virtual-machines/vm1/root.img",
+		},
+		{
+			name:    "success - dir custom volume in project",
+			pool:    incusAPI.StoragePool{Name: "local", Driver: "dir"},
+			project: "migrated",
+			volType: volumeTypeCustom,
+			volName: "vm1-disk2",
+
+			assertErr: require.NoError,
+			wantPath:  "/var/lib/incus/storage-pools/local/custom/migrated_vm1-disk2/root.img",
+		},
+		{
+			name:    "success - zfs root volume",
+			pool:    incusAPI.StoragePool{Name: "local", Driver: "zfs", StoragePoolPut: incusAPI.StoragePoolPut{Config: map[string]string{"zfs.pool_name": "tank/incus"}}},
+			project: "migrated",
+			volType: volumeTypeVM,
+			volName: "vm1",
+
+			assertErr: require.NoError,
+			wantPath:  "/dev/zvol/tank/incus/virtual-machines/migrated_vm1.block",
+		},
+		{
+			name:    "success - zfs custom volume without pool name",
+			pool:    incusAPI.StoragePool{Name: "local", Driver: "zfs"},
+			project: "default",
+			volType: volumeTypeCustom,
+			volName: "vm1-disk2",
+
+			assertErr: require.NoError,
+			wantPath:  "/dev/zvol/local/custom/vm1-disk2",
+		},
+		{
+			name:    "error - unsupported driver",
+			pool:    incusAPI.StoragePool{Name: "remote", Driver: "ceph"},
+			project: "default",
+			volType: volumeTypeVM,
+			volName: "vm1",
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := volumePath("/var/lib/incus", tc.pool, tc.volType, storageName(tc.project, tc.volName))
+
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantPath, path)
+		})
+	}
+}
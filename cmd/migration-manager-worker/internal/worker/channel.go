@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/FuturFusion/migration-manager/internal/logger"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// channel is the control channel to migration manager. Commands, and changes to the running command, are pushed to the worker over it,
+// and the worker streams the status of its commands back.
+type channel struct {
+	conn *websocket.Conn
+
+	// Guards writes to the connection, as status updates may be sent from several goroutines.
+	writeLock sync.Mutex
+
+	// Commands received from migration manager. Migration manager only sends a command once the previous one has finished.
+	commands chan api.WorkerCommand
+
+	// Closed once the connection is lost.
+	closed chan struct{}
+}
+
+// openChannel returns the control channel to migration manager, connecting it if needed.
+// Returns nil if migration manager doesn't support the channel, in which case the worker polls for commands instead.
+// The channel is only opened while the worker is idle, so that migration manager knows the worker isn't running a command when it connects.
+func (w *Worker) openChannel(ctx context.Context) (*channel, error) {
+	w.channelLock.Lock()
+	defer w.channelLock.Unlock()
+
+	if w.channel != nil {
+		select {
+		case <-w.channel.closed:
+			w.channel = nil
+		default:
+			return w.channel, nil
+		}
+	}
+
+	if w.channelUnsupported {
+		return nil, nil
+	}
+
+	endpoint := *w.endpoint
+	endpoint.Path = "/internal/worker/" + w.uuid + "/:channel"
+	endpoint.RawQuery = ""
+	switch endpoint.Scheme {
+	case "https":
+		endpoint.Scheme = "wss"
+	case "http":
+		endpoint.Scheme = "ws"
+	}
+
+	header := http.Header{}
+	header.Set(api.WorkerSecretHeader, w.token)
+	header.Set(api.WorkerInstanceHeader, w.uuid)
+
+	dialer := websocket.Dialer{
+		TLSClientConfig:  w.tlsConfig(),
+		HandshakeTimeout: 30 * time.Second,
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, endpoint.String(), header)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			slog.Info("Migration manager doesn't provide a worker channel, polling for commands instead")
+			w.channelUnsupported = true
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("Failed to connect worker channel: %w", err)
+	}
+
+	ch := &channel{
+		conn:     conn,
+		commands: make(chan api.WorkerCommand, 1),
+		closed:   make(chan struct{}),
+	}
+
+	go w.readChannel(ch)
+
+	w.channel = ch
+	return ch, nil
+}
+
+// readChannel hands the messages received over the channel to the worker until the connection is lost.
+func (w *Worker) readChannel(ch *channel) {
+	defer close(ch.closed)
+
+	for {
+		var msg api.WorkerChannelMessage
+		err := ch.conn.ReadJSON(&msg)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) && !errors.Is(err, websocket.ErrCloseSent) {
+				slog.Warn("Worker channel closed", logger.Err(err))
+			}
+
+			_ = ch.conn.Close()
+			return
+		}
+
+		if msg.Update != nil {
+			w.handleUpdate(*msg.Update)
+		}
+
+		if msg.Command != nil {
+			select {
+			case ch.commands <- *msg.Command:
+			default:
+				slog.Error("Received a command while another one is pending", slog.Any("command", msg.Command.Command))
+			}
+		}
+	}
+}
+
+// sendOverChannel sends the status update over the control channel, if it is connected.
+// Returns false if the update must be sent over HTTP instead.
+func (w *Worker) sendOverChannel(resp api.WorkerResponse) bool {
+	w.channelLock.Lock()
+	ch := w.channel
+	w.channelLock.Unlock()
+
+	if ch == nil {
+		return false
+	}
+
+	select {
+	case <-ch.closed:
+		return false
+	default:
+	}
+
+	ch.writeLock.Lock()
+	defer ch.writeLock.Unlock()
+
+	err := ch.conn.WriteJSON(resp)
+	if err != nil {
+		slog.Warn("Failed to send status over worker channel, sending it over HTTP instead", logger.Err(err))
+		_ = ch.conn.Close()
+		return false
+	}
+
+	return true
+}
+
+// closeChannel closes the control channel, if it is connected.
+func (w *Worker) closeChannel() {
+	w.channelLock.Lock()
+	ch := w.channel
+	w.channel = nil
+	w.channelLock.Unlock()
+
+	if ch == nil {
+		return
+	}
+
+	ch.writeLock.Lock()
+	_ = ch.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	ch.writeLock.Unlock()
+
+	_ = ch.conn.Close()
+	<-ch.closed
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,7 +15,9 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -34,6 +37,12 @@ import (
 	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
+// commandWait is how long migration manager may hold a request for the next command.
+const commandWait = 30 * time.Second
+
+// errAborted is the cause of the cancellation of a command that migration manager asked the worker to abort.
+var errAborted = errors.New("Migration was canceled")
+
 type Worker struct {
 	endpoint           *url.URL
 	trustedFingerprint string
@@ -45,6 +54,26 @@ type Worker struct {
 	idleSleep           time.Duration
 	lastArtifactUpdates map[uuid.UUID]time.Time
 	logFile             string
+
+	// Guards the number of disks being imported and the bandwidth rate file, which status updates may rewrite while the import starts.
+	bandwidthLock sync.Mutex
+
+	// Number of disks being imported at once, or 0 if no import is running.
+	concurrentDisks int
+
+	// Cancels the context of the running command, if any.
+	abortLock    sync.Mutex
+	abortCommand context.CancelCauseFunc
+
+	// Control channel to migration manager, if connected.
+	channelLock sync.Mutex
+	channel     *channel
+
+	// Set once migration manager is found not to provide the control channel, in which case commands are polled for.
+	channelUnsupported bool
+
+	diskLocator       source.DiskLocator
+	postImportHandoff func(ctx context.Context) error
 }
 
 type WorkerOption func(*Worker) error
@@ -72,6 +101,21 @@ func NewWorker(ctx context.Context, client *http.Client, logFile string, opts ..
 		return nil, fmt.Errorf("Failed to find instance UUID from Incus: %w", err)
 	}
 
+	return newWorker(endpoint, token, fingerprint, workerUUID, logFile, opts...)
+}
+
+// NewWorkerWithConfig returns a worker for the instance with the given config, for workers that run outside of the instance.
+func NewWorkerWithConfig(config map[string]string, logFile string, opts ...WorkerOption) (*Worker, error) {
+	for _, key := range []string{"user.migration.endpoint", "user.migration.token", "user.migration.fingerprint", "user.migration.uuid"} {
+		if config[key] == "" {
+			return nil, fmt.Errorf("Instance config key %q is not set", key)
+		}
+	}
+
+	return newWorker(config["user.migration.endpoint"], config["user.migration.token"], config["user.migration.fingerprint"], config["user.migration.uuid"], logFile, opts...)
+}
+
+func newWorker(endpoint string, token string, fingerprint string, workerUUID string, logFile string, opts ...WorkerOption) (*Worker, error) {
 	parsedURL, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, err
@@ -119,44 +163,65 @@ func WithSource(src source.Source) WorkerOption {
 	}
 }
 
+// WithDiskLocator sets how the target devices of the disks are found, for workers that don't run inside the instance.
+func WithDiskLocator(locator source.DiskLocator) WorkerOption {
+	return func(w *Worker) error {
+		w.diskLocator = locator
+		return nil
+	}
+}
+
+// WithPostImportHandoff makes the worker hand post-import tasks over to the instance instead of running them itself.
+// The handoff is expected to start the instance, whose own worker then receives the post-import command.
+func WithPostImportHandoff(handoff func(ctx context.Context) error) WorkerOption {
+	return func(w *Worker) error {
+		w.postImportHandoff = handoff
+		return nil
+	}
+}
+
 func (w *Worker) Run(ctx context.Context) {
 	slog.Info("Starting up", slog.String("version", version.Version))
 
 	// Try to clean up artifacts when the worker first starts, or restarts.
 	_ = w.cleanupArtifacts()
 	defer func() { _ = w.cleanupArtifacts() }()
+	defer w.closeChannel()
 
 	for {
+		start := time.Now()
 		done := func() (done bool) {
-			resp, err := w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:command", http.MethodPost, "secret="+w.token, nil)
+			cmd, err := w.nextCommand(ctx)
 			if err != nil {
-				slog.Error("HTTP request failed", logger.Err(err))
+				slog.Error("Failed to get next command", logger.Err(err))
 				return false
 			}
 
-			cmd := api.WorkerCommand{}
-
-			err = responseToStruct(resp, &cmd)
-			if err != nil {
-				slog.Error("Failed to unmarshal http response", logger.Err(err))
-				return false
-			}
+			cmdCtx, endCommand := w.startCommand(ctx)
+			defer endCommand()
 
 			switch cmd.Command {
 			case api.WORKERCOMMAND_IDLE:
-				slog.Debug("Received IDLE command, sleeping")
+				slog.Debug("Received IDLE command, waiting")
 				return false
 
 			case api.WORKERCOMMAND_IMPORT_DISKS:
-				w.importDisks(ctx, cmd)
+				w.importDisks(cmdCtx, cmd)
 				return false
 
 			case api.WORKERCOMMAND_FINALIZE_IMPORT:
-				w.importDisks(ctx, cmd)
+				w.importDisks(cmdCtx, cmd)
 				return false
 
 			case api.WORKERCOMMAND_POST_IMPORT:
-				return w.doPostImportTasks(ctx, cmd)
+				if w.postImportHandoff != nil {
+					return w.handOffPostImportTasks(cmdCtx)
+				}
+
+				return w.doPostImportTasks(cmdCtx, cmd)
+
+			case api.WORKERCOMMAND_ABORT:
+				return w.abort(ctx, cmd)
 
 			default:
 				slog.Error("Received unknown command", slog.Any("command", cmd.Command))
@@ -164,10 +229,12 @@ func (w *Worker) Run(ctx context.Context) {
 			}
 		}()
 		if done {
+			w.uploadLogs()
 			return
 		}
 
-		t := time.NewTimer(w.idleSleep)
+		// Avoid busy looping if the request returned early, such as on errors, or with a migration manager that doesn't hold the request.
+		t := time.NewTimer(max(w.idleSleep-time.Since(start), 0))
 
 		select {
 		case <-ctx.Done():
@@ -179,6 +246,40 @@ func (w *Worker) Run(ctx context.Context) {
 	}
 }
 
+// nextCommand waits for the next command from migration manager.
+// Commands are pushed over the control channel if it is available. Otherwise, migration manager holds the request until there is something to do,
+// so commands are received as soon as they are available either way.
+func (w *Worker) nextCommand(ctx context.Context) (api.WorkerCommand, error) {
+	ch, err := w.openChannel(ctx)
+	if err != nil {
+		slog.Warn("Polling for commands instead of using the worker channel", logger.Err(err))
+	}
+
+	if ch != nil {
+		select {
+		case cmd := <-ch.commands:
+			return cmd, nil
+		case <-ch.closed:
+			return api.WorkerCommand{}, fmt.Errorf("Worker channel closed")
+		case <-ctx.Done():
+			return api.WorkerCommand{}, ctx.Err()
+		}
+	}
+
+	resp, err := w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:command", http.MethodPost, fmt.Sprintf("wait=%d", int(commandWait.Seconds())), nil)
+	if err != nil {
+		return api.WorkerCommand{}, fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	cmd := api.WorkerCommand{}
+	err = responseToStruct(resp, &cmd)
+	if err != nil {
+		return api.WorkerCommand{}, fmt.Errorf("Failed to unmarshal http response: %w", err)
+	}
+
+	return cmd, nil
+}
+
 func (w *Worker) importDisks(ctx context.Context, cmd api.WorkerCommand) {
 	if w.source == nil {
 		err := w.connectSource(ctx, cmd.SourceType, cmd.Source)
@@ -188,82 +289,146 @@ func (w *Worker) importDisks(ctx context.Context, cmd api.WorkerCommand) {
 		}
 	}
 
+	var powerOffTime time.Time
 	if cmd.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
 		slog.Info("Shutting down source VM")
-
-		err := w.source.PowerOffVM(ctx, cmd.Location)
+		w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, "Shutting down source VM")
+
+		// Report each step as it finishes, so that attempts are recorded even if the shutdown fails.
+		var attempts []api.ShutdownAttempt
+		err := w.source.ShutdownVM(ctx, cmd.Location, cmd.ShutdownPolicy, cmd.GuestPassword, func(attempt api.ShutdownAttempt) {
+			attempts = append(attempts, attempt)
+			w.handleUpdateResponse(w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_RUNNING, StatusMessage: shutdownAttemptMessage(attempt), ShutdownAttempts: attempts}))
+		})
 		if err != nil {
-			w.sendErrorResponse(err)
+			if commandAborted(ctx) {
+				slog.Warn("Source VM shutdown aborted", logger.Err(err))
+				w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_ABORTED, StatusMessage: "Source VM shutdown aborted"})
+				return
+			}
+
+			w.sendErrorResponse(fmt.Errorf("Failed to shut down source VM: %w", err))
 			return
 		}
 
+		powerOffTime = time.Now().UTC()
 		slog.Info("Source VM shutdown complete")
 	}
 
 	slog.Info("Performing disk import")
 
-	err := w.importDisksHelper(ctx, cmd)
+	diskSyncs, diskStates, err := w.importDisksHelper(ctx, cmd)
 	if err != nil {
+		// Migration manager already knows, and sends the command to clean up once the worker reports that the import has stopped.
+		if commandAborted(ctx) {
+			slog.Warn("Disk import aborted", logger.Err(err))
+			w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_ABORTED, StatusMessage: "Disk import aborted"})
+			return
+		}
+
 		w.sendErrorResponse(err)
 		return
 	}
 
-	slog.Info("Performing dry-run of post-import steps")
-	err = w.postImportTasks(ctx, cmd, true)
-	if err != nil {
-		w.sendErrorResponse(err)
-		return
+	// The dry-run needs the instance's disks to be attached locally, which is not the case when the post-import tasks are handed off.
+	// The post-import steps are then only checked once the instance boots to run them.
+	if w.postImportHandoff != nil {
+		slog.Warn("Skipping dry-run of post-import steps, as they run once the instance is started")
+		w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, "Skipped dry-run of post-import steps, as they run once the instance is started")
+	} else {
+		slog.Info("Performing dry-run of post-import steps")
+		err = w.postImportTasks(ctx, cmd, true)
+		if err != nil {
+			w.sendErrorResponse(err)
+			return
+		}
 	}
 
 	slog.Info("Disk import completed successfully")
-	w.sendStatusResponse(api.WORKERRESPONSE_SUCCESS, "Disk import completed successfully")
+	w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_SUCCESS, StatusMessage: "Disk import completed successfully", DiskSyncs: diskSyncs, DiskStates: diskStates, SourcePowerOff: powerOffTime})
 }
 
-func (w *Worker) importDisksHelper(ctx context.Context, cmd api.WorkerCommand) error {
+// shutdownAttemptMessage returns the status message reported once a step of the source VM shutdown has finished.
+func shutdownAttemptMessage(attempt api.ShutdownAttempt) string {
+	if attempt.Error != "" {
+		return fmt.Sprintf("Source VM %s failed after %s: %s", attempt.Action, attempt.Duration.String(), attempt.Error)
+	}
+
+	return fmt.Sprintf("Source VM %s completed in %s", attempt.Action, attempt.Duration.String())
+}
+
+func (w *Worker) importDisksHelper(ctx context.Context, cmd api.WorkerCommand) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
 	// Delete any existing migration snapshot that might be left over.
 	err := w.source.DeleteVMSnapshot(ctx, cmd.Location, internal.IncusSnapshotName)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	sdkFile, imported, err := w.getArtifact(api.ARTIFACTTYPE_SDK, cmd, "")
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if imported {
 		err := os.RemoveAll(filepath.Dir(worker.VMwareSDKPath))
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		// unpack the vmware SDK.
 		err = util.UnpackTarball(filepath.Dir(worker.VMwareSDKPath), sdkFile)
 		if err != nil {
-			return fmt.Errorf("Failed to unpack SDK: %w", err)
+			return nil, nil, fmt.Errorf("Failed to unpack SDK: %w", err)
 		}
 	}
 
-	resp, err := w.doHTTPRequestV1("/1.0/instances/"+w.uuid, http.MethodGet, "secret="+w.token+"&instance="+w.uuid, nil)
+	resp, err := w.doHTTPRequestV1("/1.0/instances/"+w.uuid, http.MethodGet, "", nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var instance api.Instance
 	err = responseToStruct(resp, &instance)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	// Do the actual import.
-	return w.source.ImportDisks(ctx, cmd.Location, worker.VMwareSDKPath, instance.Disks, func(status string, isImportant bool) {
-		slog.Info(status) //nolint:sloglint
+	w.setConcurrentDisks(max(min(cmd.TransferLimits.Disks, len(instance.Disks)-len(cmd.DroppedDisks)), 1))
+	defer w.setConcurrentDisks(0)
 
-		// Only send updates back to the server if important or once every 5 seconds.
-		if isImportant || time.Since(w.lastUpdate).Seconds() >= 5 {
-			w.lastUpdate = time.Now().UTC()
-			w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, status)
-		}
+	err = w.writeBandwidthLimit(cmd.TransferLimits.Bandwidth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Do the actual import.
+	return w.source.ImportDisks(ctx, cmd.Location, source.ImportDisksOptions{
+		SDKPath:      worker.VMwareSDKPath,
+		Disks:        instance.Disks,
+		Dropped:      cmd.DroppedDisks,
+		Locator:      w.diskLocator,
+		States:       cmd.DiskStates,
+		Limits:       cmd.TransferLimits,
+		Verification: cmd.Verification,
+		StatusCallback: func(status string, isImportant bool) {
+			slog.Info(status) //nolint:sloglint
+
+			// Only send updates back to the server if important or once every 5 seconds.
+			if isImportant || time.Since(w.lastUpdate).Seconds() >= 5 {
+				w.lastUpdate = time.Now().UTC()
+				w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, status)
+			}
+		},
+		CheckpointCallback: func(state api.WorkerDiskState) {
+			slog.Info("Disk checkpoint reached", slog.String("disk", state.Name), slog.Int64("offset", state.SyncedOffset))
+
+			// Checkpoints are always sent, so that an interrupted copy can resume from the latest one.
+			w.handleUpdateResponse(w.sendResponse(api.WorkerResponse{
+				Status:        api.WORKERRESPONSE_RUNNING,
+				StatusMessage: fmt.Sprintf("Saved checkpoint for disk %q", state.Name),
+				DiskStates:    []api.WorkerDiskState{state},
+			}))
+		},
 	})
 }
 
@@ -275,7 +440,7 @@ func (w *Worker) postImportTasks(ctx context.Context, cmd api.WorkerCommand, dry
 		}
 	}
 
-	resp, err := w.doHTTPRequestV1("/1.0/instances/"+w.uuid, http.MethodGet, "secret="+w.token+"&instance="+w.uuid, nil)
+	resp, err := w.doHTTPRequestV1("/1.0/instances/"+w.uuid, http.MethodGet, "", nil)
 	if err != nil {
 		return err
 	}
@@ -293,7 +458,12 @@ func (w *Worker) postImportTasks(ctx context.Context, cmd api.WorkerCommand, dry
 			return err
 		}
 
-		err = worker.WindowsInjectDrivers(ctx, cmd.DistributionVersion, cmd.Architecture, file, dryRun)
+		scripts, err := w.getScriptArtifacts(cmd)
+		if err != nil {
+			return err
+		}
+
+		err = worker.WindowsInjectDrivers(ctx, cmd.DistributionVersion, cmd.Architecture, file, cmd.BitLockerRecoveryPassword, cmd.GuestCustomization.WindowsFirstBootScript, scripts, dryRun)
 		if err != nil {
 			return err
 		}
@@ -314,8 +484,23 @@ func (w *Worker) postImportTasks(ctx context.Context, cmd api.WorkerCommand, dry
 			return err
 		}
 
+	case api.OSTYPE_BSD:
+		err := worker.BSDDoPostMigrationConfig(dryRun)
+		if err != nil {
+			return err
+		}
+
 	case api.OSTYPE_LINUX:
-		err := worker.LinuxDoPostMigrationConfig(ctx, instance, cmd.Distribution, cmd.DistributionVersion, dryRun)
+		scripts, err := w.getScriptArtifacts(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(scripts) > 0 && !dryRun {
+			w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, fmt.Sprintf("Running %d post-migration scripts", len(scripts)))
+		}
+
+		err = worker.LinuxDoPostMigrationConfig(ctx, instance, cmd.Distribution, cmd.DistributionVersion, worker.LUKSKey{Passphrase: cmd.LUKSPassphrase, KeyFile: cmd.LUKSKeyFile}, cmd.GuestCustomization, scripts, dryRun)
 		if err != nil {
 			return err
 		}
@@ -343,6 +528,11 @@ func (w *Worker) doPostImportTasks(ctx context.Context, cmd api.WorkerCommand) (
 
 	err := w.postImportTasks(ctx, cmd, false)
 	if err != nil {
+		if commandAborted(ctx) {
+			slog.Warn("Post-import tasks aborted", logger.Err(err))
+			return false
+		}
+
 		w.sendErrorResponse(err)
 		return false
 	}
@@ -357,6 +547,22 @@ func (w *Worker) doPostImportTasks(ctx context.Context, cmd api.WorkerCommand) (
 	return true
 }
 
+// handOffPostImportTasks starts the instance so that its own worker performs the post-import tasks.
+// The tasks modify the guest file system and run programs from it, so they are only run from within the instance.
+// Returns true once the instance has been started, as there is nothing left for this worker to do.
+func (w *Worker) handOffPostImportTasks(ctx context.Context) (done bool) {
+	slog.Info("Starting instance for post-import tasks")
+	w.sendStatusResponse(api.WORKERRESPONSE_RUNNING, "Starting instance for post-import tasks")
+
+	err := w.postImportHandoff(ctx)
+	if err != nil {
+		w.sendErrorResponse(fmt.Errorf("Failed to start instance for post-import tasks: %w", err))
+		return false
+	}
+
+	return true
+}
+
 func (w *Worker) connectSource(ctx context.Context, sourceType api.SourceType, sourceRaw json.RawMessage) error {
 	var src api.Source
 
@@ -384,82 +590,234 @@ func (w *Worker) connectSource(ctx context.Context, sourceType api.SourceType, s
 }
 
 func (w *Worker) sendStatusResponse(statusVal api.WorkerResponseType, statusMessage string) {
-	resp := api.WorkerResponse{Status: statusVal, StatusMessage: statusMessage}
+	w.handleUpdateResponse(w.sendResponse(api.WorkerResponse{Status: statusVal, StatusMessage: statusMessage}))
+}
+
+// handleUpdateResponse acts on the reply to a status update of the running command sent over HTTP.
+func (w *Worker) handleUpdateResponse(resp *incusAPI.Response) {
+	if resp == nil {
+		return
+	}
+
+	var update api.WorkerUpdateResponse
+	err := responseToStruct(resp, &update)
+	if err != nil {
+		slog.Error("Failed to unmarshal status update response", logger.Err(err))
+		return
+	}
+
+	w.handleUpdate(update)
+}
+
+// handleUpdate acts on a change to the running command.
+// Migration manager asks the worker to abort the command if the migration was canceled, and sends the current transfer limits during a disk import,
+// so bandwidth schedules apply to running imports.
+func (w *Worker) handleUpdate(update api.WorkerUpdateResponse) {
+	if update.Command == api.WORKERCOMMAND_ABORT {
+		w.abortRunningCommand()
+		return
+	}
+
+	if update.TransferLimits == nil {
+		return
+	}
+
+	err := w.writeBandwidthLimit(update.TransferLimits.Bandwidth)
+	if err != nil {
+		slog.Error("Failed to update bandwidth limit", logger.Err(err))
+	}
+}
+
+// startCommand returns the context for running a command, which is canceled if migration manager asks the worker to abort it.
+// The returned function must be called once the command has finished.
+func (w *Worker) startCommand(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	w.abortLock.Lock()
+	w.abortCommand = cancel
+	w.abortLock.Unlock()
+
+	return ctx, func() {
+		w.abortLock.Lock()
+		w.abortCommand = nil
+		w.abortLock.Unlock()
+
+		cancel(nil)
+	}
+}
+
+// abortRunningCommand stops the running command, such as a disk import, by canceling its context.
+func (w *Worker) abortRunningCommand() {
+	w.abortLock.Lock()
+	defer w.abortLock.Unlock()
+
+	if w.abortCommand != nil {
+		slog.Warn("Migration was canceled, aborting the running command")
+		w.abortCommand(errAborted)
+	}
+}
+
+// commandAborted returns whether the command running with the given context was aborted by migration manager.
+func commandAborted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errAborted)
+}
+
+// abort cleans up after a canceled migration and acknowledges it to migration manager.
+// The migration snapshot is removed in case an aborted disk import left it behind. Returns true, as there is nothing left for the worker to do.
+func (w *Worker) abort(ctx context.Context, cmd api.WorkerCommand) (done bool) {
+	slog.Info("Migration was canceled, cleaning up")
+
+	var err error
+	if w.source == nil {
+		err = w.connectSource(ctx, cmd.SourceType, cmd.Source)
+	}
+
+	if err == nil {
+		err = w.source.DeleteVMSnapshot(ctx, cmd.Location, internal.IncusSnapshotName)
+	}
+
+	if err != nil {
+		slog.Error("Failed to remove migration snapshot", logger.Err(err))
+	}
+
+	w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_ABORTED, StatusMessage: "Worker stopped after the migration was canceled"})
+
+	return true
+}
+
+// sendResponse sends a status update of the running command to migration manager, and returns the reply if it was sent over HTTP.
+// Updates sent over the control channel are answered over the channel.
+func (w *Worker) sendResponse(resp api.WorkerResponse) *incusAPI.Response {
+	if w.sendOverChannel(resp) {
+		return nil
+	}
 
 	content, err := json.Marshal(resp)
 	if err != nil {
 		slog.Error("Failed to marshal status response for migration manager", logger.Err(err))
-		return
+		return nil
 	}
 
-	_, err = w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:update", http.MethodPost, "secret="+w.token, content)
+	apiResp, err := w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:update", http.MethodPost, "", content)
 	if err != nil {
 		slog.Error("Failed to send status back to migration manager", logger.Err(err))
-		return
+		return nil
+	}
+
+	return apiResp
+}
+
+// setConcurrentDisks records the number of disks being imported at once, or 0 once the import has finished.
+func (w *Worker) setConcurrentDisks(disks int) {
+	w.bandwidthLock.Lock()
+	defer w.bandwidthLock.Unlock()
+
+	w.concurrentDisks = disks
+}
+
+// writeBandwidthLimit splits the bandwidth limit in bytes per second between the disks being imported, and records the rate of each disk for nbdkit.
+// Nothing is written if no import is running. The file is replaced in one step, so that nbdkit never reads a partially written rate.
+func (w *Worker) writeBandwidthLimit(bandwidth int64) error {
+	w.bandwidthLock.Lock()
+	defer w.bandwidthLock.Unlock()
+
+	if w.concurrentDisks == 0 {
+		return nil
+	}
+
+	// nbdkit expects bits per second, and treats a rate of 0 as unlimited.
+	rate := int64(0)
+	if bandwidth > 0 {
+		rate = max(bandwidth*8/int64(w.concurrentDisks), 1)
+	}
+
+	partPath := worker.BandwidthRateFile + ".part"
+	err := os.WriteFile(partPath, []byte(strconv.FormatInt(rate, 10)), 0o644)
+	if err != nil {
+		return fmt.Errorf("Failed to write bandwidth rate file: %w", err)
+	}
+
+	err = os.Rename(partPath, worker.BandwidthRateFile)
+	if err != nil {
+		_ = os.Remove(partPath)
+		return fmt.Errorf("Failed to replace bandwidth rate file: %w", err)
 	}
+
+	return nil
 }
 
 func (w *Worker) sendErrorResponse(err error) {
 	slog.Error("worker error", logger.Err(err))
+
+	// Upload the logs before reporting the failure, as the worker may be cleaned up as soon as the error is received.
+	w.uploadLogs()
+
 	b, err2 := os.ReadFile(w.logFile)
 	if err2 != nil && !os.IsNotExist(err2) {
 		slog.Error("Failed to read log file", slog.String("file", w.logFile), slog.Any("error", err2))
 	}
 
-	resp := api.WorkerResponse{Status: api.WORKERRESPONSE_FAILED, StatusMessage: err.Error(), Metadata: b}
-	content, err := json.Marshal(resp)
+	w.sendResponse(api.WorkerResponse{Status: api.WORKERRESPONSE_FAILED, StatusMessage: err.Error(), Metadata: b})
+}
+
+// uploadLogs sends the worker log, along with the post-migration and nbdkit logs, to migration manager, so they remain available after the worker is gone.
+func (w *Worker) uploadLogs() {
+	content, err := worker.ArchiveLogs(w.logFile)
 	if err != nil {
-		slog.Error("Failed to send error back to migration manager", logger.Err(err))
+		slog.Error("Failed to archive log files", logger.Err(err))
 		return
 	}
 
-	_, err = w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:update", http.MethodPost, "secret="+w.token, content)
+	_, err = w.doHTTPRequestV1("/internal/worker/"+w.uuid+"/:logs", http.MethodPost, "", content)
 	if err != nil {
-		slog.Error("Failed to send error back to migration manager", logger.Err(err))
-		return
+		slog.Error("Failed to upload logs to migration manager", logger.Err(err))
 	}
 }
 
 func (w *Worker) makeRequest(endpoint string, method string, query string, reader io.Reader) (*http.Request, *http.Client, error) {
-	var err error
-	w.endpoint.Path = endpoint
+	// Work on a copy of the endpoint, as requests may be made from several goroutines.
+	u := *w.endpoint
+	u.Path = endpoint
+	u.RawQuery = query
 
-	w.endpoint.RawQuery = query
-
-	req, err := http.NewRequest(method, w.endpoint.String(), reader)
+	req, err := http.NewRequest(method, u.String(), reader)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-			VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-				if len(rawCerts) == 0 {
-					return &tls.CertificateVerificationError{Err: fmt.Errorf("No TLS certificates found")}
-				}
+	req.Header.Set(api.WorkerSecretHeader, w.token)
+	req.Header.Set(api.WorkerInstanceHeader, w.uuid)
 
-				if w.trustedFingerprint == "" {
-					return &tls.CertificateVerificationError{Err: fmt.Errorf("No trusted fingerprint found")}
-				}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: w.tlsConfig()}}
+	return req, client, nil
+}
 
-				fingerprint, err := incusTLS.CertFingerprintStr(api.CertEncodeToPEM(rawCerts[0]))
-				if err != nil {
-					return &tls.CertificateVerificationError{Err: err}
-				}
+// tlsConfig returns the TLS configuration for connecting to migration manager, which only trusts the certificate with the configured fingerprint.
+func (w *Worker) tlsConfig() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return &tls.CertificateVerificationError{Err: fmt.Errorf("No TLS certificates found")}
+			}
 
-				trustedFingerprint := strings.ToLower(strings.ReplaceAll(w.trustedFingerprint, ":", ""))
-				if fingerprint != strings.ToLower(strings.ReplaceAll(w.trustedFingerprint, ":", "")) {
-					return &tls.CertificateVerificationError{Err: fmt.Errorf("Fingerprints do not match. Expected (%s), Got (%s)", trustedFingerprint, fingerprint)}
-				}
+			if w.trustedFingerprint == "" {
+				return &tls.CertificateVerificationError{Err: fmt.Errorf("No trusted fingerprint found")}
+			}
+
+			fingerprint, err := incusTLS.CertFingerprintStr(api.CertEncodeToPEM(rawCerts[0]))
+			if err != nil {
+				return &tls.CertificateVerificationError{Err: err}
+			}
+
+			trustedFingerprint := strings.ToLower(strings.ReplaceAll(w.trustedFingerprint, ":", ""))
+			if fingerprint != strings.ToLower(strings.ReplaceAll(w.trustedFingerprint, ":", "")) {
+				return &tls.CertificateVerificationError{Err: fmt.Errorf("Fingerprints do not match. Expected (%s), Got (%s)", trustedFingerprint, fingerprint)}
+			}
 
-				return nil
-			},
+			return nil
 		},
 	}
-
-	client := &http.Client{Transport: transport}
-	return req, client, nil
 }
 
 func (w *Worker) doHTTPRequestV1Writer(endpoint string, method string, query string, writer io.WriteSeeker) error {
@@ -550,23 +908,12 @@ func getIncusConfig(ctx context.Context, client *http.Client, key string) (strin
 }
 
 func (w *Worker) getArtifact(artifactType api.ArtifactType, cmd api.WorkerCommand, osVersion string) (string, bool, error) {
-	reverter := revert.New()
-	defer reverter.Fail()
-
-	query := fmt.Sprintf("secret=%s&instance=%s", w.token, w.uuid)
-	resp, err := w.doHTTPRequestV1("/1.0/artifacts", http.MethodGet, query, nil)
-	if err != nil {
-		return "", false, err
-	}
-
-	var artifacts []api.Artifact
-	err = responseToStruct(resp, &artifacts)
+	artifacts, err := w.getAllArtifacts()
 	if err != nil {
 		return "", false, err
 	}
 
 	var artifact *api.Artifact
-	var dir string
 	switch artifactType {
 	case api.ARTIFACTTYPE_DRIVER:
 		artifact, err = w.matchDriverArtifact(artifacts, cmd)
@@ -582,12 +929,51 @@ func (w *Worker) getArtifact(artifactType api.ArtifactType, cmd api.WorkerComman
 		return "", false, err
 	}
 
+	return w.downloadArtifact(*artifact)
+}
+
+// getScriptArtifacts downloads the script artifacts sent by migration manager, and returns their paths in the order they should run.
+func (w *Worker) getScriptArtifacts(cmd api.WorkerCommand) ([]string, error) {
+	paths := make([]string, 0, len(cmd.ScriptArtifacts))
+	for _, a := range cmd.ScriptArtifacts {
+		slog.Info("Using script artifact", slog.String("uuid", a.UUID.String()), slog.Int("order", a.Order))
+		path, _, err := w.downloadArtifact(a)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get script artifact %q: %w", a.UUID, err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+func (w *Worker) getAllArtifacts() ([]api.Artifact, error) {
+	resp, err := w.doHTTPRequestV1("/1.0/artifacts", http.MethodGet, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []api.Artifact
+	err = responseToStruct(resp, &artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// downloadArtifact fetches the default file of the artifact, unless it is already up to date, and returns its path and whether it was downloaded.
+func (w *Worker) downloadArtifact(artifact api.Artifact) (string, bool, error) {
+	reverter := revert.New()
+	defer reverter.Fail()
+
 	requiredFile, err := artifact.DefaultArtifactFile()
 	if err != nil {
 		return "", false, err
 	}
 
-	dir = filepath.Join("/tmp", artifact.UUID.String())
+	dir := filepath.Join("/tmp", artifact.UUID.String())
 	artifactPath := filepath.Join(dir, requiredFile)
 
 	_, err = os.Stat(artifactPath)
@@ -609,29 +995,20 @@ func (w *Worker) getArtifact(artifactType api.ArtifactType, cmd api.WorkerComman
 
 		reverter.Add(func() { _ = os.RemoveAll(dir) })
 
-		var hasRequiredFile bool
-		for _, file := range artifact.Files {
-			if file != requiredFile {
-				continue
-			}
-
-			hasRequiredFile = true
-			f, err := os.Create(filepath.Join(dir, file))
-			if err != nil {
-				return "", false, err
-			}
-
-			defer func() { _ = f.Close() }() //nolint:revive
-			err = w.doHTTPRequestV1Writer("/1.0/artifacts/"+artifact.UUID.String()+"/files/"+file, http.MethodGet, query, f)
-			if err != nil {
-				return "", false, err
-			}
+		if !slices.Contains(artifact.Files, requiredFile) {
+			return "", false, fmt.Errorf("Required file %q not found", requiredFile)
+		}
 
-			break
+		f, err := os.Create(artifactPath)
+		if err != nil {
+			return "", false, err
 		}
 
-		if !hasRequiredFile {
-			return "", false, fmt.Errorf("Required file %q not found", requiredFile)
+		defer func() { _ = f.Close() }()
+
+		err = w.doHTTPRequestV1Writer("/1.0/artifacts/"+artifact.UUID.String()+"/files/"+requiredFile, http.MethodGet, "", f)
+		if err != nil {
+			return "", false, err
 		}
 	}
 
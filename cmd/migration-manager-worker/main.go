@@ -49,6 +49,10 @@ func main() {
 	app.PersistentFlags().BoolVarP(&globalCmd.flagLogDebug, "debug", "d", false, "Show all debug messages")
 	app.PersistentFlags().BoolVarP(&globalCmd.flagLogVerbose, "verbose", "v", false, "Show all information messages")
 
+	// agent sub-command
+	agentCmd := cmdAgent{global: &globalCmd}
+	app.AddCommand(agentCmd.Command())
+
 	// Version handling
 	app.SetVersionTemplate("{{.Version}}\n")
 	app.Version = version.Version
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	incus "github.com/lxc/incus/v7/client"
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+
+	"github.com/FuturFusion/migration-manager/cmd/migration-manager-worker/internal/agent"
+	"github.com/FuturFusion/migration-manager/cmd/migration-manager-worker/internal/worker"
+)
+
+type cmdAgent struct {
+	global *cmdGlobal
+
+	flagIncusDir string
+	flagLogDir   string
+	flagInterval time.Duration
+}
+
+func (c *cmdAgent) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "agent"
+	cmd.Short = "Run the import agent on an Incus host"
+	cmd.Long = `Description:
+  Run the import agent on an Incus host
+
+  The import agent imports the disks of instances in batches using the agent import mode.
+  It runs on the Incus host, and writes directly to the storage volumes of the stopped instances,
+  so no worker VM needs to be started for the import.
+`
+	cmd.RunE = c.Run
+	cmd.PersistentFlags().StringVar(&c.flagIncusDir, "incus-dir", "/var/lib/incus", "Path to the Incus directory")
+	cmd.Flags().StringVar(&c.flagLogDir, "log-dir", "/var/log/migration-manager-agent", "Directory for the log files of the imports")
+	cmd.Flags().DurationVar(&c.flagInterval, "interval", 10*time.Second, "How often to check for instances to import")
+
+	importCmd := cmdAgentImport{agent: c}
+	cmd.AddCommand(importCmd.Command())
+
+	return cmd
+}
+
+func (c *cmdAgent) Run(cmd *cobra.Command, args []string) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("This tool must be run as root")
+	}
+
+	client, err := incus.ConnectIncusUnix(filepath.Join(c.flagIncusDir, "unix.socket"), nil)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to Incus: %w", err)
+	}
+
+	err = os.MkdirAll(c.flagLogDir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	spawn := func(project string, instanceName string) (*exec.Cmd, error) {
+		logFile := filepath.Join(c.flagLogDir, project+"_"+instanceName+".log")
+
+		// Each import runs in its own mount namespace, so that the fixed paths used by the worker aren't shared between imports.
+		importCmd := exec.Command("/proc/self/exe", "agent", "import", project, instanceName, "--incus-dir", c.flagIncusDir, "--logfile", logFile)
+		importCmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNS}
+
+		err := importCmd.Start()
+		if err != nil {
+			return nil, err
+		}
+
+		return importCmd, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), unix.SIGINT, unix.SIGQUIT, unix.SIGTERM)
+	defer stop()
+
+	slog.Info("Starting import agent")
+	agent.NewAgent(client, c.flagInterval, spawn).Run(ctx)
+	slog.Info("Shutting down")
+
+	return nil
+}
+
+type cmdAgentImport struct {
+	agent *cmdAgent
+}
+
+func (c *cmdAgentImport) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "import <project> <instance>"
+	cmd.Short = "Import the disks of an instance"
+	cmd.Long = `Description:
+  Import the disks of an instance
+
+  This is run by the import agent for each instance to import, in a separate mount namespace.
+`
+	cmd.Hidden = true
+	cmd.Args = cobra.ExactArgs(2)
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdAgentImport) Run(cmd *cobra.Command, args []string) error {
+	project, instanceName := args[0], args[1]
+
+	// Keep the worker's temporary files private to this import.
+	err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, "")
+	if err != nil {
+		return fmt.Errorf("Failed to make mounts private: %w", err)
+	}
+
+	err = unix.Mount("tmpfs", "/tmp", "tmpfs", 0, "mode=1777")
+	if err != nil {
+		return fmt.Errorf("Failed to mount /tmp: %w", err)
+	}
+
+	client, err := incus.ConnectIncusUnix(filepath.Join(c.agent.flagIncusDir, "unix.socket"), nil)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to Incus: %w", err)
+	}
+
+	client = client.UseProject(project)
+	inst, _, err := client.GetInstance(instanceName)
+	if err != nil {
+		return fmt.Errorf("Failed to get instance %q in project %q: %w", instanceName, project, err)
+	}
+
+	locator := agent.NewVolumeLocator(client, c.agent.flagIncusDir, project, inst)
+	defer locator.Release()
+
+	var handedOff bool
+	handoff := func(ctx context.Context) error {
+		// Incus exposes the volumes itself when starting the instance.
+		locator.Release()
+
+		op, err := client.UpdateInstanceState(instanceName, incusAPI.InstanceStatePut{Action: "start", Timeout: -1}, "")
+		if err != nil {
+			return err
+		}
+
+		err = op.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		handedOff = true
+		return nil
+	}
+
+	w, err := worker.NewWorkerWithConfig(inst.Config, c.agent.global.flagLogFile, worker.WithDiskLocator(locator.Locate), worker.WithPostImportHandoff(handoff))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), unix.SIGINT, unix.SIGQUIT, unix.SIGTERM)
+	defer stop()
+
+	w.Run(ctx)
+
+	if !handedOff {
+		return fmt.Errorf("Import of instance %q in project %q stopped before post-import tasks", instanceName, project)
+	}
+
+	return nil
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"archive/tar"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +18,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	incusAPI "github.com/lxc/incus/v7/shared/api"
 	"github.com/lxc/incus/v7/shared/subprocess"
 	"github.com/stretchr/testify/require"
@@ -99,6 +102,49 @@ func TestNewWorker(t *testing.T) {
 	}
 }
 
+func TestNewWorkerWithConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		config map[string]string
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success",
+			config: map[string]string{
+				"user.migration.endpoint":    ts.URL,
+				"user.migration.token":       "token",
+				"user.migration.fingerprint": "fingerprint",
+				"user.migration.uuid":        uuidA,
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - missing token",
+			config: map[string]string{
+				"user.migration.endpoint":    ts.URL,
+				"user.migration.fingerprint": "fingerprint",
+				"user.migration.uuid":        uuidA,
+			},
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := worker.NewWorkerWithConfig(tc.config, "/tmp/foo")
+			tc.assertErr(t, err)
+		})
+	}
+}
+
 func TestRun(t *testing.T) {
 	tests := []struct {
 		name                       string
@@ -106,7 +152,9 @@ func TestRun(t *testing.T) {
 		instanceSpec               instanceDetails
 		sourceDeleteVMSnapshotErr  error
 		sourceImportDisksErr       error
-		sourcePowerOffVMErr        error
+		sourceShutdownVMErr        error
+		sourceShutdownAttempts     []api.ShutdownAttempt
+		updateResponse             api.WorkerUpdateResponse
 
 		wantWorkerResponses []api.WorkerResponseType
 		wantEndOfTestCause  error
@@ -143,10 +191,32 @@ func TestRun(t *testing.T) {
 			},
 
 			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_RUNNING,
 				api.WORKERRESPONSE_SUCCESS,
 			},
 			wantEndOfTestCause: errGracefulEndOfTest, // if finalize import is successful, the worker ends it self gracefully, so no cause is given.
 		},
+		{
+			name: "success - finalize import with forced power off",
+			migrationManagerdResponses: []func(instanceSpec instanceDetails, cancel context.CancelCauseFunc, w http.ResponseWriter, r *http.Request){
+				workerCommandResponse(api.WORKERCOMMAND_IDLE, false), // newWorker connectivity test
+				workerCommandResponse(api.WORKERCOMMAND_FINALIZE_IMPORT, false),
+				workerCommandResponse(api.WORKERCOMMAND_IDLE, true),
+			},
+			sourceShutdownAttempts: []api.ShutdownAttempt{
+				{Action: api.SHUTDOWNACTION_GUEST_SHUTDOWN, Duration: api.AsDuration(time.Minute), Error: "Guest OS did not shut down within 1m0s"},
+				{Action: api.SHUTDOWNACTION_POWER_OFF, Duration: api.AsDuration(time.Second)},
+			},
+
+			// Each shutdown attempt is reported as it finishes.
+			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_RUNNING,
+				api.WORKERRESPONSE_RUNNING,
+				api.WORKERRESPONSE_RUNNING,
+				api.WORKERRESPONSE_SUCCESS,
+			},
+			wantEndOfTestCause: errGracefulEndOfTest,
+		},
 		{
 			name: "success - post- import",
 			migrationManagerdResponses: []func(instanceSpec instanceDetails, cancel context.CancelCauseFunc, w http.ResponseWriter, r *http.Request){
@@ -161,6 +231,35 @@ func TestRun(t *testing.T) {
 			},
 			wantEndOfTestCause: errGracefulEndOfTest, // if finalize import is successful, the worker ends it self gracefully, so no cause is given.
 		},
+		{
+			name: "success - abort",
+			migrationManagerdResponses: []func(instanceSpec instanceDetails, cancel context.CancelCauseFunc, w http.ResponseWriter, r *http.Request){
+				workerCommandResponse(api.WORKERCOMMAND_IDLE, false), // newWorker connectivity test
+				workerCommandResponse(api.WORKERCOMMAND_ABORT, true),
+			},
+
+			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_ABORTED,
+			},
+			wantEndOfTestCause: errGracefulEndOfTest,
+		},
+		{
+			name: "success - import disks aborted while running",
+			migrationManagerdResponses: []func(instanceSpec instanceDetails, cancel context.CancelCauseFunc, w http.ResponseWriter, r *http.Request){
+				workerCommandResponse(api.WORKERCOMMAND_IDLE, false), // newWorker connectivity test
+				workerCommandResponse(api.WORKERCOMMAND_IMPORT_DISKS, false),
+				workerCommandResponse(api.WORKERCOMMAND_ABORT, true),
+			},
+			updateResponse: api.WorkerUpdateResponse{Command: api.WORKERCOMMAND_ABORT},
+
+			// The aborted import isn't reported as failed.
+			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_RUNNING,
+				api.WORKERRESPONSE_ABORTED,
+				api.WORKERRESPONSE_ABORTED,
+			},
+			wantEndOfTestCause: errGracefulEndOfTest,
+		},
 		// FIXME: currently hard to test due to the hard coded file system access for the injecting of drivers.
 		// {
 		// 	name: "success - finalize import for windows",
@@ -255,15 +354,16 @@ func TestRun(t *testing.T) {
 			wantEndOfTestCause: errGracefulEndOfTest,
 		},
 		{
-			name: "error - finalize import power off vm error",
+			name: "error - finalize import shutdown vm error",
 			migrationManagerdResponses: []func(instanceSpec instanceDetails, cancel context.CancelCauseFunc, w http.ResponseWriter, r *http.Request){
 				workerCommandResponse(api.WORKERCOMMAND_IDLE, false), // newWorker connectivity test
 				workerCommandResponse(api.WORKERCOMMAND_FINALIZE_IMPORT, false),
 				workerCommandResponse(api.WORKERCOMMAND_IDLE, true),
 			},
-			sourcePowerOffVMErr: fmt.Errorf("boom!"),
+			sourceShutdownVMErr: fmt.Errorf("boom!"),
 
 			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_RUNNING,
 				api.WORKERRESPONSE_FAILED,
 			},
 			wantEndOfTestCause: errGracefulEndOfTest,
@@ -278,6 +378,7 @@ func TestRun(t *testing.T) {
 			sourceDeleteVMSnapshotErr: fmt.Errorf("boom!"),
 
 			wantWorkerResponses: []api.WorkerResponseType{
+				api.WORKERRESPONSE_RUNNING,
 				api.WORKERRESPONSE_FAILED,
 			},
 			wantEndOfTestCause: errGracefulEndOfTest,
@@ -334,6 +435,12 @@ func TestRun(t *testing.T) {
 
 			// Create migration-managerd double.
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The worker identifies itself through headers rather than query parameters.
+				if r.RequestURI != "/1.0" && r.Header.Get(api.WorkerInstanceHeader) != uuidA {
+					cancel(fmt.Errorf("Unexpected worker instance header %q", r.Header.Get(api.WorkerInstanceHeader)))
+					return
+				}
+
 				switch r.RequestURI {
 				case "/1.0":
 					if r.Method != http.MethodGet {
@@ -342,14 +449,14 @@ func TestRun(t *testing.T) {
 					}
 
 					fallthrough
-				case fmt.Sprintf("/1.0/instances/%s?secret=&instance=%s", uuidA, uuidA):
+				case fmt.Sprintf("/1.0/instances/%s", uuidA):
 					if r.Method != http.MethodGet {
 						cancel(fmt.Errorf("Unsupported method %q", r.Method))
 						return
 					}
 
 					fallthrough
-				case fmt.Sprintf("/internal/worker/%s/:command?secret=", uuidA):
+				case fmt.Sprintf("/internal/worker/%s/:command?wait=30", uuidA):
 					if !strings.HasPrefix(r.RequestURI, "/1.0") {
 						if r.Method != http.MethodPost {
 							cancel(fmt.Errorf("Unsupported method %q", r.Method))
@@ -366,7 +473,10 @@ func TestRun(t *testing.T) {
 					tc.migrationManagerdResponses = tc.migrationManagerdResponses[1:]
 
 					respFunc(tc.instanceSpec, cancel, w, r)
-				case fmt.Sprintf("/internal/worker/%s/:update?secret=", uuidA):
+				case fmt.Sprintf("/internal/worker/%s/:channel", uuidA):
+					// Behave like a migration manager without the worker channel, so that commands are polled for.
+					_ = response.NotFound(nil).Render(w)
+				case fmt.Sprintf("/internal/worker/%s/:update", uuidA):
 					if r.Method != http.MethodPost {
 						cancel(fmt.Errorf("Unsupported method %q", r.Method))
 						return
@@ -393,8 +503,33 @@ func TestRun(t *testing.T) {
 						cancel(fmt.Errorf("expected worker response: %d, got: %d (%s)", wantResponse, resp.Status, resp.StatusMessage))
 					}
 
+					err = response.SyncResponse(true, tc.updateResponse).Render(w)
+					if err != nil {
+						cancel(fmt.Errorf("Response error: %w", err))
+						return
+					}
+				case fmt.Sprintf("/internal/worker/%s/:logs", uuidA):
+					if r.Method != http.MethodPost {
+						cancel(fmt.Errorf("Unsupported method %q", r.Method))
+						return
+					}
+
+					// The logs are uploaded as a tar archive.
+					tr := tar.NewReader(r.Body)
+					for {
+						_, err := tr.Next()
+						if err == io.EOF {
+							break
+						}
+
+						if err != nil {
+							cancel(fmt.Errorf("Log archive error: %w", err))
+							return
+						}
+					}
+
 					_, _ = w.Write([]byte(`{}`))
-				case fmt.Sprintf("/1.0/artifacts?secret=&instance=%s", uuidA):
+				case "/1.0/artifacts":
 					if r.Method != http.MethodGet {
 						cancel(fmt.Errorf("Unsupported method %q", r.Method))
 						return
@@ -413,7 +548,7 @@ func TestRun(t *testing.T) {
 						return
 					}
 
-				case fmt.Sprintf("/1.0/artifacts/%s/files/vmware-sdk.tar.gz?secret=&instance=%s", sdkArtifactUUID, uuidA):
+				case fmt.Sprintf("/1.0/artifacts/%s/files/vmware-sdk.tar.gz", sdkArtifactUUID):
 					if r.Method != http.MethodGet {
 						cancel(fmt.Errorf("Unsupported method %q", r.Method))
 						return
@@ -476,11 +611,22 @@ func TestRun(t *testing.T) {
 				DeleteVMSnapshotFunc: func(ctx context.Context, vmName string, snapshotName string) error {
 					return tc.sourceDeleteVMSnapshotErr
 				},
-				ImportDisksFunc: func(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error {
-					return tc.sourceImportDisksErr
+				ImportDisksFunc: func(ctx context.Context, vmName string, opts source.ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
+					// Keep copying until the abort in the reply to the status update cancels the import.
+					if tc.updateResponse.Command == api.WORKERCOMMAND_ABORT {
+						opts.StatusCallback("Copying disk", true)
+						<-ctx.Done()
+						return nil, nil, ctx.Err()
+					}
+
+					return nil, nil, tc.sourceImportDisksErr
 				},
-				PowerOffVMFunc: func(ctx context.Context, vmName string) error {
-					return tc.sourcePowerOffVMErr
+				ShutdownVMFunc: func(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+					for _, attempt := range tc.sourceShutdownAttempts {
+						attemptCallback(attempt)
+					}
+
+					return tc.sourceShutdownVMErr
 				},
 			}
 
@@ -558,3 +704,105 @@ func workerCommandResponse(command api.WorkerCommandType, signalEndOfTest bool)
 		}
 	}
 }
+
+func TestRunChannel(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	var statuses []api.WorkerResponseType
+	serverDone := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+
+	// Create migration-managerd double, which pushes commands over the worker channel.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1.0":
+			_ = response.SyncResponse(true, nil).Render(w)
+		case fmt.Sprintf("/internal/worker/%s/:channel", uuidA):
+			if r.Method != http.MethodGet || r.Header.Get(api.WorkerInstanceHeader) != uuidA {
+				cancel(fmt.Errorf("Unexpected channel request %s %q", r.Method, r.Header.Get(api.WorkerInstanceHeader)))
+				return
+			}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				cancel(fmt.Errorf("Upgrade error: %w", err))
+				return
+			}
+
+			defer close(serverDone)
+			defer func() { _ = conn.Close() }()
+
+			push := func(msg api.WorkerChannelMessage) {
+				err := conn.WriteJSON(msg)
+				if err != nil {
+					cancel(fmt.Errorf("Push error: %w", err))
+				}
+			}
+
+			push(api.WorkerChannelMessage{Command: &api.WorkerCommand{Command: api.WORKERCOMMAND_FINALIZE_IMPORT, SourceType: api.SOURCETYPE_VMWARE, Location: "/some/instance"}})
+			for {
+				var resp api.WorkerResponse
+				err := conn.ReadJSON(&resp)
+				if err != nil {
+					return
+				}
+
+				statuses = append(statuses, resp.Status)
+				switch {
+				case resp.Status == api.WORKERRESPONSE_RUNNING:
+					// Cancel the migration while the source VM is shut down.
+					push(api.WorkerChannelMessage{Update: &api.WorkerUpdateResponse{Command: api.WORKERCOMMAND_ABORT}})
+				case resp.Status == api.WORKERRESPONSE_ABORTED && len(statuses) == 2:
+					// The command has stopped, so tell the worker to clean up.
+					push(api.WorkerChannelMessage{Command: &api.WorkerCommand{Command: api.WORKERCOMMAND_ABORT, SourceType: api.SOURCETYPE_VMWARE, Location: "/some/instance"}})
+				}
+			}
+
+		case fmt.Sprintf("/internal/worker/%s/:logs", uuidA):
+			_, _ = io.Copy(io.Discard, r.Body)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			cancel(fmt.Errorf("Unsupported request %q", r.RequestURI))
+		}
+	}))
+	defer ts.Close()
+
+	src := &source.SourceMock{
+		DeleteVMSnapshotFunc: func(ctx context.Context, vmName string, snapshotName string) error {
+			return nil
+		},
+		ShutdownVMFunc: func(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+			// Keep waiting for the shutdown until the pushed abort cancels the command.
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	w, err := worker.NewWorkerWithConfig(map[string]string{
+		"user.migration.endpoint":    ts.URL,
+		"user.migration.token":       uuid.NewString(),
+		"user.migration.fingerprint": "unused",
+		"user.migration.uuid":        uuidA,
+	}, filepath.Join(t.TempDir(), "worker.log"), worker.WithSource(src), worker.WithIdleSleep(1*time.Microsecond))
+	require.NoError(t, err)
+
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		w.Run(ctx)
+	}()
+
+	select {
+	case <-runDone:
+	case <-time.After(5 * time.Second):
+		cancel(fmt.Errorf("test case timed out"))
+		<-runDone
+	}
+
+	require.NoError(t, context.Cause(ctx))
+	<-serverDone
+
+	// The command reports progress and its abort, and the clean up is acknowledged.
+	require.Equal(t, []api.WorkerResponseType{api.WORKERRESPONSE_RUNNING, api.WORKERRESPONSE_ABORTED, api.WORKERRESPONSE_ABORTED}, statuses)
+}
@@ -189,7 +189,7 @@ func (c *cmdArtifactExport) Run(cmd *cobra.Command, args []string) error {
 		Format: fmt.Sprintf("Downloading artifact to %q: %%s", filePath),
 	}
 
-	err = c.global.doHTTPRequestV1Writer("/artifacts/"+artUUID+"/files/"+fileName, http.MethodGet, outFile, nil, progress.UpdateProgress)
+	err = c.global.doHTTPRequestV1Writer("/artifacts/"+artUUID+"/files/"+fileName, http.MethodGet, "", outFile, nil, progress.UpdateProgress)
 	if err != nil {
 		return err
 	}
@@ -266,6 +266,9 @@ func (c *cmdArtifactRemoveFile) Run(cmd *cobra.Command, args []string) error {
 
 type cmdArtifactUpload struct {
 	global *CmdGlobal
+
+	flagDistributions string
+	flagOrder         int
 }
 
 func (c *cmdArtifactUpload) Command() *cobra.Command {
@@ -276,12 +279,23 @@ func (c *cmdArtifactUpload) Command() *cobra.Command {
 
 	Upload an artifact file with the given set of properties.
 
-	Supported types: sdk, os-image, driver
+	Supported types: sdk, os-image, driver, script
+
+	- 'architectures' is a comma-delimited list applying to 'driver', 'os-image', and 'script'.
+	  Scripts apply to all architectures if it is empty.
+	- 'versions' is a comma-delimited list applying to 'os-image' and 'script'.
+
+	Scripts are run during post-migration configuration of matching VMs, in increasing order.
+	Linux scripts are shell scripts run within a chroot of the VM's root file system.
+	Windows scripts are PowerShell scripts run when the VM first boots.
 
-	- 'architectures' is a comma-delimited list applying to 'driver' and 'os-image'.
-	- 'versions' is a comma-delimited list applying to 'os-image'.
+	Example:
+	  migration-manager artifact upload script linux ./install-agent.sh "" 22.04,24.04 --distributions ubuntu --order 10
 	`
 
+	cmd.Flags().StringVar(&c.flagDistributions, "distributions", "", "Comma-delimited list of distributions a script applies to")
+	cmd.Flags().IntVar(&c.flagOrder, "order", 0, "Order in which a script runs, lowest first")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -312,6 +326,22 @@ func (c *cmdArtifactUpload) Run(cmd *cobra.Command, args []string) error {
 		if len(args) == 5 {
 			data.Versions = strings.Split(args[4], ",")
 		}
+	} else if data.Type == api.ARTIFACTTYPE_SCRIPT {
+		data.OS = api.OSType(args[1])
+		data.Order = c.flagOrder
+		if len(args) > 3 && args[3] != "" {
+			data.Architectures = strings.Split(args[3], ",")
+		}
+
+		if len(args) == 5 && args[4] != "" {
+			data.Versions = strings.Split(args[4], ",")
+		}
+
+		if c.flagDistributions != "" {
+			for _, d := range strings.Split(c.flagDistributions, ",") {
+				data.Distributions = append(data.Distributions, api.Distro(d))
+			}
+		}
 	} else {
 		exit, err := c.global.CheckArgs(cmd, args, 3, 3)
 		if exit {
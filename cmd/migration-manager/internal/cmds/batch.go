@@ -69,6 +69,14 @@ func (c *CmdBatch) Command() *cobra.Command {
 	batchEditCmd := cmdBatchEdit{global: c.Global}
 	cmd.AddCommand(batchEditCmd.Command())
 
+	// Report
+	batchReportCmd := cmdBatchReport{global: c.Global}
+	cmd.AddCommand(batchReportCmd.Command())
+
+	// Source cleanup
+	batchSourceCleanupCmd := cmdBatchSourceCleanup{global: c.Global}
+	cmd.AddCommand(batchSourceCleanupCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -485,6 +493,151 @@ func (c *cmdBatchReset) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Report for the batch.
+type cmdBatchReport struct {
+	global *CmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdBatchReport) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "report <name> [<file-path>]"
+	cmd.Short = "Export the migration report of a batch"
+	cmd.Long = `Description:
+  Export the migration report of a batch, covering the source location, target placement, final status, phase timings,
+  bytes transferred, downtime, warnings and override comments of every instance queued by the batch.
+
+  The report is written to the given file, or to standard output if no file is given.
+`
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", string(api.BATCHREPORTFORMAT_JSON), "Format (csv|json|html)")
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdBatchReport) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	name := args[0]
+
+	switch api.BatchReportFormat(c.flagFormat) {
+	case api.BATCHREPORTFORMAT_CSV, api.BATCHREPORTFORMAT_JSON, api.BATCHREPORTFORMAT_HTML:
+	default:
+		return fmt.Errorf("Invalid format %q", c.flagFormat)
+	}
+
+	out := os.Stdout
+	if len(args) > 1 {
+		out, err = os.Create(args[1])
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = out.Close() }()
+	}
+
+	// The JSON report is returned as a sync response, so render only its metadata.
+	if api.BatchReportFormat(c.flagFormat) == api.BATCHREPORTFORMAT_JSON {
+		resp, _, err := c.global.doHTTPRequestV1("/batches/"+name+"/report", http.MethodGet, "", nil)
+		if err != nil {
+			return err
+		}
+
+		report := api.BatchReport{}
+		err = responseToStruct(resp, &report)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	return c.global.doHTTPRequestV1Writer("/batches/"+name+"/report", http.MethodGet, "format="+c.flagFormat, out, nil, nil)
+}
+
+// Show the source cleanup plan of the batch.
+type cmdBatchSourceCleanup struct {
+	global *CmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdBatchSourceCleanup) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "source-cleanup <name>"
+	cmd.Short = "Show the source cleanup plan of a batch"
+	cmd.Long = `Description:
+  Show the cleanup that the source cleanup policy of a batch applies to the source VMs of its finished instances,
+  without applying it.
+
+  Pending actions are applied by the next periodic cleanup run.
+`
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", `Format (csv|json|table|yaml|compact), use suffix ",noheader" to disable headers and ",header" to enable if demanded, e.g. csv,header`)
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return validateFlagFormat(cmd.Flag("format").Value.String())
+	}
+
+	return cmd
+}
+
+func (c *cmdBatchSourceCleanup) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	name := args[0]
+
+	resp, _, err := c.global.doHTTPRequestV1("/batches/"+name+"/source-cleanup", http.MethodGet, "", nil)
+	if err != nil {
+		return err
+	}
+
+	plans := []api.SourceCleanupPlan{}
+	err = responseToStruct(resp, &plans)
+	if err != nil {
+		return err
+	}
+
+	// Render the table.
+	header := []string{"UUID", "Name", "Completed", "Pending", "Delete Time", "Last Error"}
+	data := [][]string{}
+
+	join := func(actions []api.SourceCleanupAction) string {
+		names := make([]string, 0, len(actions))
+		for _, action := range actions {
+			names = append(names, string(action))
+		}
+
+		return strings.Join(names, ", ")
+	}
+
+	for _, p := range plans {
+		deleteTime := ""
+		if !p.DeleteTime.IsZero() {
+			deleteTime = p.DeleteTime.String()
+		}
+
+		data = append(data, []string{p.InstanceUUID.String(), p.InstanceName, join(p.Completed), join(p.Pending), deleteTime, p.LastError})
+	}
+
+	sort.Sort(util.SortColumnsNaturally(data))
+
+	return util.RenderTable(cmd.OutOrStdout(), c.flagFormat, header, data, plans)
+}
+
 // Edit the batch.
 type cmdBatchEdit struct {
 	global *CmdGlobal
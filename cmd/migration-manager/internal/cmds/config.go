@@ -545,7 +545,7 @@ func (c *cmdConfigBackup) Run(cmd *cobra.Command, args []string) error {
 		Format: fmt.Sprintf("Downloading backup file to %q: %%s", filePath),
 	}
 
-	err = c.global.doHTTPRequestV1Writer("/system/:backup", http.MethodPost, outFile, b, progress.UpdateProgress)
+	err = c.global.doHTTPRequestV1Writer("/system/:backup", http.MethodPost, "", outFile, b, progress.UpdateProgress)
 	if err != nil {
 		return err
 	}
@@ -340,8 +340,8 @@ func (c *CmdGlobal) doHTTPRequestV1Reader(endpoint string, method string, query
 	return c.makeHTTPRequest(endpoint, method, query, reader)
 }
 
-func (c *CmdGlobal) doHTTPRequestV1Writer(endpoint string, method string, writer io.WriteSeeker, content []byte, progress func(ioprogress.ProgressData)) error {
-	req, client, err := c.buildRequest(endpoint, method, "", bytes.NewBuffer(content))
+func (c *CmdGlobal) doHTTPRequestV1Writer(endpoint string, method string, query string, writer io.WriteSeeker, content []byte, progress func(ioprogress.ProgressData)) error {
+	req, client, err := c.buildRequest(endpoint, method, query, bytes.NewBuffer(content))
 	if err != nil {
 		return err
 	}
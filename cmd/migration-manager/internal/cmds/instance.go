@@ -41,6 +41,10 @@ func (c *CmdInstance) Command() *cobra.Command {
 	instanceOverrideCmd := CmdInstanceOverride{Global: c.Global}
 	cmd.AddCommand(instanceOverrideCmd.Command())
 
+	// Secret
+	instanceSecretCmd := CmdInstanceSecret{Global: c.Global}
+	cmd.AddCommand(instanceSecretCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -97,7 +101,7 @@ func (c *cmdInstanceList) Run(cmd *cobra.Command, args []string) error {
 	// Render the table.
 	header := []string{"UUID", "Source", "Location", "OS Type", "Distro", "Version", "CPUs", "Memory", "Background Import", "Migration Disabled", "Running", "Last Update from Source"}
 	if c.flagVerbose {
-		header = []string{"UUID", "Location", "Description", "Source", "Last Update from Source", "Architecture", "OS Type", "Distro", "Version", "OS Name", "OS Description", "Disks", "NICs", "CPUs", "Memory", "Legacy Boot", "Secure Boot", "TPM", "Background Import", "Migration Disabled", "Running"}
+		header = []string{"UUID", "Location", "Description", "Source", "Last Update from Source", "Architecture", "OS Type", "Distro", "Version", "OS Name", "OS Description", "Disks", "NICs", "CPUs", "Memory", "Legacy Boot", "Secure Boot", "TPM", "Background Import", "Migration Disabled", "Running", "Recommended CPUs", "Recommended Memory"}
 	}
 
 	data := [][]string{}
@@ -119,7 +123,13 @@ func (c *cmdInstanceList) Run(cmd *cobra.Command, args []string) error {
 				nics = append(nics, nic.HardwareAddress+" ("+nic.Location+", "+nic.SourceSpecificID+")")
 			}
 
-			row = []string{props.UUID.String(), props.Location, props.Description, i.Source, i.LastUpdateFromSource.String(), props.Architecture, string(i.OSType), string(i.Distribution), i.DistributionVersion, props.OS, props.OSDescription, strings.Join(disks, "\n"), strings.Join(nics, "\n"), strconv.Itoa(int(props.CPUs)), units.GetByteSizeStringIEC(props.Memory, 2), strconv.FormatBool(props.LegacyBoot), strconv.FormatBool(props.SecureBoot), strconv.FormatBool(props.TPM), strconv.FormatBool(props.BackgroundImport), strconv.FormatBool(i.Overrides.DisableMigration), strconv.FormatBool(props.Running)}
+			var recCPUs, recMemory string
+			if i.Overrides.Recommendation != nil {
+				recCPUs = strconv.Itoa(int(i.Overrides.Recommendation.CPUs))
+				recMemory = units.GetByteSizeStringIEC(i.Overrides.Recommendation.Memory, 2)
+			}
+
+			row = []string{props.UUID.String(), props.Location, props.Description, i.Source, i.LastUpdateFromSource.String(), props.Architecture, string(i.OSType), string(i.Distribution), i.DistributionVersion, props.OS, props.OSDescription, strings.Join(disks, "\n"), strings.Join(nics, "\n"), strconv.Itoa(int(props.CPUs)), units.GetByteSizeStringIEC(props.Memory, 2), strconv.FormatBool(props.LegacyBoot), strconv.FormatBool(props.SecureBoot), strconv.FormatBool(props.TPM), strconv.FormatBool(props.BackgroundImport), strconv.FormatBool(i.Overrides.DisableMigration), strconv.FormatBool(props.Running), recCPUs, recMemory}
 		}
 
 		data = append(data, row)
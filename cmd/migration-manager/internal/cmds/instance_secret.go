@@ -0,0 +1,508 @@
+package cmds
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/FuturFusion/migration-manager/internal/util"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+type CmdInstanceSecret struct {
+	Global *CmdGlobal
+}
+
+func (c *CmdInstanceSecret) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "secret"
+	cmd.Short = "Manage instance secrets"
+	cmd.Long = `Description:
+  Manage the secrets stored for instances, such as BitLocker recovery passwords
+
+  Secrets are encrypted at rest and their values can't be read back.
+`
+
+	// Import
+	instanceSecretImportCmd := cmdInstanceSecretImport{global: c.Global}
+	cmd.AddCommand(instanceSecretImportCmd.Command())
+
+	// List
+	instanceSecretListCmd := cmdInstanceSecretList{global: c.Global}
+	cmd.AddCommand(instanceSecretListCmd.Command())
+
+	// Remove
+	instanceSecretRemoveCmd := cmdInstanceSecretRemove{global: c.Global}
+	cmd.AddCommand(instanceSecretRemoveCmd.Command())
+
+	// Set
+	instanceSecretSetCmd := cmdInstanceSecretSet{global: c.Global}
+	cmd.AddCommand(instanceSecretSetCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+
+	return cmd
+}
+
+// List the secrets of an instance.
+type cmdInstanceSecretList struct {
+	global *CmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdInstanceSecretList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "list <uuid>"
+	cmd.Short = "List the secrets of an instance"
+	cmd.Long = `Description:
+  List the secrets stored for an instance
+`
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", `Format (csv|json|table|yaml|compact), use suffix ",noheader" to disable headers and ",header" to enable if demanded, e.g. csv,header`)
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return validateFlagFormat(cmd.Flag("format").Value.String())
+	}
+
+	return cmd
+}
+
+func (c *cmdInstanceSecretList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resp, _, err := c.global.doHTTPRequestV1("/instances/"+args[0]+"/secrets", http.MethodGet, "", nil)
+	if err != nil {
+		return err
+	}
+
+	secrets := []api.InstanceSecret{}
+	err = responseToStruct(resp, &secrets)
+	if err != nil {
+		return err
+	}
+
+	// Render the table.
+	header := []string{"Type", "Last Updated"}
+	data := [][]string{}
+
+	for _, s := range secrets {
+		data = append(data, []string{string(s.Type), s.LastUpdated.String()})
+	}
+
+	sort.Sort(util.SortColumnsNaturally(data))
+
+	return util.RenderTable(cmd.OutOrStdout(), c.flagFormat, header, data, secrets)
+}
+
+// Set a secret of an instance.
+type cmdInstanceSecretSet struct {
+	global *CmdGlobal
+
+	flagType string
+	flagFile string
+}
+
+func (c *cmdInstanceSecretSet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "set <uuid> [<value>]"
+	cmd.Short = "Set a secret of an instance"
+	cmd.Long = `Description:
+  Set a secret of an instance, replacing any existing secret of the same type
+
+  If no value is given, it is prompted for. With --file, the value is read
+  from the given file instead. LUKS keyfiles are base64 encoded before being
+  sent to the server.
+
+Example:
+  migration-manager instance secret set <uuid> --type luks-passphrase
+  migration-manager instance secret set <uuid> --type luks-keyfile --file root.key
+`
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagType, "type", string(api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD), "Type of the secret")
+	cmd.Flags().StringVar(&c.flagFile, "file", "", "Read the secret value from a file")
+
+	return cmd
+}
+
+func (c *cmdInstanceSecretSet) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	UUIDString := args[0]
+
+	var value string
+	switch {
+	case c.flagFile != "":
+		if len(args) == 2 {
+			return fmt.Errorf("A value can't be given together with --file")
+		}
+
+		data, err := os.ReadFile(c.flagFile)
+		if err != nil {
+			return fmt.Errorf("Failed to read secret from %q: %w", c.flagFile, err)
+		}
+
+		if api.InstanceSecretType(c.flagType) == api.INSTANCESECRETTYPE_LUKS_KEYFILE {
+			value = base64.StdEncoding.EncodeToString(data)
+		} else {
+			value = string(data)
+		}
+
+	case len(args) == 2:
+		value = args[1]
+	default:
+		value = c.global.Asker.AskPasswordOnce("Please enter the secret value: ")
+	}
+
+	content, err := json.Marshal(api.InstanceSecretPut{Type: api.InstanceSecretType(c.flagType), Value: strings.TrimSpace(value)})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.global.doHTTPRequestV1("/instances/"+UUIDString+"/secrets", http.MethodPost, "", content)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Successfully set %s secret for instance %q.\n", c.flagType, UUIDString)
+	return nil
+}
+
+// Remove the secrets of an instance.
+type cmdInstanceSecretRemove struct {
+	global *CmdGlobal
+}
+
+func (c *cmdInstanceSecretRemove) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "remove <uuid>"
+	cmd.Short = "Remove the secrets of an instance"
+	cmd.Long = `Description:
+  Remove all secrets stored for an instance
+`
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdInstanceSecretRemove) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	UUIDString := args[0]
+
+	_, _, err = c.global.doHTTPRequestV1("/instances/"+UUIDString+"/secrets", http.MethodDelete, "", nil)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Successfully removed secrets for instance %q.\n", UUIDString)
+	return nil
+}
+
+// Import BitLocker recovery passwords from a CSV file.
+type cmdInstanceSecretImport struct {
+	global *CmdGlobal
+
+	flagNameColumn string
+	flagKeyColumn  string
+}
+
+// csvNameColumns are the normalized headers that hold the computer name in common AD and Intune exports.
+var csvNameColumns = []string{"computername", "computer", "devicename", "hostname", "name", "distinguishedname"}
+
+// csvKeyColumns are the normalized headers that hold the recovery password in common AD and Intune exports.
+var csvKeyColumns = []string{"recoverypassword", "msfverecoverypassword", "bitlockerrecoverykey", "bitlockerrecoverypassword", "recoverykey"}
+
+func (c *cmdInstanceSecretImport) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "import <file>"
+	cmd.Short = "Import BitLocker recovery passwords"
+	cmd.Long = `Description:
+  Import BitLocker recovery passwords from a CSV export from Active Directory or Intune
+
+  The columns holding the computer name and recovery password are detected from the header row,
+  or can be given with --name-column and --key-column. Computer names are matched against
+  instance names without regard to case. A computer name without a domain also matches instance
+  names with a domain, as long as it matches only one of them.
+
+  Only one recovery password can be stored for an instance, so the import fails if a computer has
+  rows with different recovery passwords. Remove the rows of outdated recovery passwords, or those
+  of volumes other than the operating system volume, before importing.
+`
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVar(&c.flagNameColumn, "name-column", "", "Header of the column holding the computer name")
+	cmd.Flags().StringVar(&c.flagKeyColumn, "key-column", "", "Header of the column holding the recovery password")
+
+	return cmd
+}
+
+func (c *cmdInstanceSecretImport) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	passwords, err := parseRecoveryPasswordCSV(f, c.flagNameColumn, c.flagKeyColumn)
+	if err != nil {
+		return fmt.Errorf("Failed to parse %q: %w", args[0], err)
+	}
+
+	resp, _, err := c.global.doHTTPRequestV1("/instances", http.MethodGet, "recursion=1", nil)
+	if err != nil {
+		return err
+	}
+
+	instances := []api.Instance{}
+	err = responseToStruct(resp, &instances)
+	if err != nil {
+		return err
+	}
+
+	matched, unmatched, err := matchRecoveryPasswords(passwords, instances)
+	if err != nil {
+		return err
+	}
+
+	UUIDs := make([]string, 0, len(matched))
+	for UUIDString := range matched {
+		UUIDs = append(UUIDs, UUIDString)
+	}
+
+	sort.Strings(UUIDs)
+
+	var imported int
+	for _, UUIDString := range UUIDs {
+		content, err := json.Marshal(api.InstanceSecretPut{Type: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, Value: matched[UUIDString]})
+		if err != nil {
+			return err
+		}
+
+		_, _, err = c.global.doHTTPRequestV1("/instances/"+UUIDString+"/secrets", http.MethodPost, "", content)
+		if err != nil {
+			return fmt.Errorf("Failed to set recovery password for instance %q: %w", UUIDString, err)
+		}
+
+		imported++
+	}
+
+	cmd.Printf("Successfully imported %d recovery passwords.\n", imported)
+	if len(unmatched) > 0 {
+		cmd.Printf("No matching instance for %d computers: %s\n", len(unmatched), strings.Join(unmatched, ", "))
+	}
+
+	return nil
+}
+
+// matchRecoveryPasswords returns the recovery passwords by instance UUID, and the computer names that match no instance.
+// A computer name matches the instance with the same name, ignoring case. Otherwise, it matches by short name, which
+// must then be unique among the instances. It is an error for an instance to be matched by computers with different passwords.
+func matchRecoveryPasswords(passwords map[string]string, instances []api.Instance) (map[string]string, []string, error) {
+	byName := map[string][]api.Instance{}
+	byShortName := map[string][]api.Instance{}
+	for _, inst := range instances {
+		byName[strings.ToLower(inst.Name)] = append(byName[strings.ToLower(inst.Name)], inst)
+		byShortName[normalizeComputerName(inst.Name)] = append(byShortName[normalizeComputerName(inst.Name)], inst)
+	}
+
+	names := make([]string, 0, len(passwords))
+	for name := range passwords {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	matched := map[string]string{}
+	matchedBy := map[string]string{}
+	var unmatched []string
+	for _, name := range names {
+		candidates := byName[name]
+		if len(candidates) == 0 {
+			candidates = byShortName[normalizeComputerName(name)]
+		}
+
+		if len(candidates) == 0 {
+			unmatched = append(unmatched, name)
+			continue
+		}
+
+		if len(candidates) > 1 {
+			locations := make([]string, 0, len(candidates))
+			for _, inst := range candidates {
+				locations = append(locations, inst.Location)
+			}
+
+			return nil, nil, fmt.Errorf("Computer %q matches several instances (%s), use its full name", name, strings.Join(locations, ", "))
+		}
+
+		UUIDString := candidates[0].UUID.String()
+		existing, ok := matched[UUIDString]
+		if ok && existing != passwords[name] {
+			return nil, nil, fmt.Errorf("Computers %q and %q both match instance %q with different recovery passwords", matchedBy[UUIDString], name, candidates[0].Location)
+		}
+
+		matched[UUIDString] = passwords[name]
+		matchedBy[UUIDString] = name
+	}
+
+	return matched, unmatched, nil
+}
+
+// normalizeCSVHeader lowercases the header and drops separators, so "Device name", "DeviceName" and "device_name" match.
+func normalizeCSVHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' || r == '-' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(strings.TrimSpace(header)))
+}
+
+// normalizeComputerName returns the lowercase short name of the computer.
+func normalizeComputerName(name string) string {
+	name, _, _ = strings.Cut(strings.TrimSpace(name), ".")
+	return strings.ToLower(name)
+}
+
+// computerNameFromDN returns the computer name from the distinguished name of a computer or of its BitLocker recovery information,
+// e.g. "CN=2025-01-01T01:00:00-00:00{GUID},CN=PC01,OU=Computers,DC=example,DC=com".
+func computerNameFromDN(dn string) string {
+	for _, part := range strings.Split(dn, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !strings.EqualFold(key, "CN") || strings.Contains(value, "{") {
+			continue
+		}
+
+		return value
+	}
+
+	return ""
+}
+
+// findCSVColumn returns the index of the column with the given header, or of the first column matching one of the candidates.
+func findCSVColumn(headers []string, flag string, candidates []string) (int, error) {
+	normalized := make([]string, 0, len(headers))
+	for _, h := range headers {
+		normalized = append(normalized, normalizeCSVHeader(h))
+	}
+
+	if flag != "" {
+		idx := slices.Index(normalized, normalizeCSVHeader(flag))
+		if idx < 0 {
+			return -1, fmt.Errorf("Column %q not found", flag)
+		}
+
+		return idx, nil
+	}
+
+	for _, candidate := range candidates {
+		idx := slices.Index(normalized, candidate)
+		if idx >= 0 {
+			return idx, nil
+		}
+	}
+
+	return -1, fmt.Errorf("Unable to detect column, expected one of %v", candidates)
+}
+
+// parseRecoveryPasswordCSV returns the recovery passwords in the CSV by lowercase computer name.
+// Only one recovery password can be stored for an instance, so computers with several different recovery passwords are rejected.
+func parseRecoveryPasswordCSV(r io.Reader, nameColumn string, keyColumn string) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read header row: %w", err)
+	}
+
+	nameIdx, err := findCSVColumn(headers, nameColumn, csvNameColumns)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find computer name column: %w", err)
+	}
+
+	keyIdx, err := findCSVColumn(headers, keyColumn, csvKeyColumns)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find recovery password column: %w", err)
+	}
+
+	isDN := normalizeCSVHeader(headers[nameIdx]) == "distinguishedname"
+
+	passwords := map[string]string{}
+	var conflicts []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if nameIdx >= len(record) || keyIdx >= len(record) {
+			continue
+		}
+
+		name := record[nameIdx]
+		if isDN {
+			name = computerNameFromDN(name)
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		key := strings.TrimSpace(record[keyIdx])
+		if name == "" || key == "" {
+			continue
+		}
+
+		existing, ok := passwords[name]
+		if ok && existing != key {
+			conflicts = append(conflicts, name)
+			continue
+		}
+
+		passwords[name] = key
+	}
+
+	if len(conflicts) > 0 {
+		slices.Sort(conflicts)
+		return nil, fmt.Errorf("Several recovery passwords found for computers: %s", strings.Join(slices.Compact(conflicts), ", "))
+	}
+
+	return passwords, nil
+}
@@ -0,0 +1,157 @@
+package cmds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestParseRecoveryPasswordCSV(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		nameColumn string
+		keyColumn  string
+
+		want      map[string]string
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - Intune export",
+			csv: `Device name,BitLocker recovery key,Key ID
+PC01.example.com,111111-111111-111111-111111-111111-111111-111111-111111,abc
+pc02,222222-222222-222222-222222-222222-222222-222222-222222,def
+`,
+
+			want: map[string]string{
+				"pc01.example.com": "111111-111111-111111-111111-111111-111111-111111-111111",
+				"pc02":             "222222-222222-222222-222222-222222-222222-222222-222222",
+			},
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - AD export, repeated row",
+			csv: `DistinguishedName,msFVE-RecoveryPassword
+"CN=2024-01-01T01:00:00-00:00{A},CN=PC01,OU=Computers,DC=example,DC=com",111111-111111-111111-111111-111111-111111-111111-111111
+"CN=2024-01-01T01:00:00-00:00{A},CN=PC01,OU=Computers,DC=example,DC=com",111111-111111-111111-111111-111111-111111-111111-111111
+`,
+
+			want: map[string]string{
+				"pc01": "111111-111111-111111-111111-111111-111111-111111-111111",
+			},
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - AD export, several recovery passwords for a computer",
+			csv: `DistinguishedName,msFVE-RecoveryPassword
+"CN=2024-01-01T01:00:00-00:00{A},CN=PC01,OU=Computers,DC=example,DC=com",111111-111111-111111-111111-111111-111111-111111-111111
+"CN=2025-01-01T01:00:00-00:00{B},CN=PC01,OU=Computers,DC=example,DC=com",333333-333333-333333-333333-333333-333333-333333-333333
+`,
+
+			assertErr: require.Error,
+		},
+		{
+			name: "success - explicit columns",
+			csv: `Host,Secret,Name
+PC01,111111-111111-111111-111111-111111-111111-111111-111111,ignored
+`,
+			nameColumn: "host",
+			keyColumn:  "Secret",
+
+			want: map[string]string{
+				"pc01": "111111-111111-111111-111111-111111-111111-111111-111111",
+			},
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - no key column",
+			csv: `ComputerName,Description
+PC01,desc
+`,
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - unknown name column",
+			csv: `ComputerName,RecoveryPassword
+PC01,111111-111111-111111-111111-111111-111111-111111-111111
+`,
+			nameColumn: "Host",
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseRecoveryPasswordCSV(strings.NewReader(tc.csv), tc.nameColumn, tc.keyColumn)
+			tc.assertErr(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestMatchRecoveryPasswords(t *testing.T) {
+	newInstance := func(name string) api.Instance {
+		inst := api.Instance{}
+		inst.UUID = uuid.New()
+		inst.Location = "/dc/vm/" + name
+		inst.Name = name
+		return inst
+	}
+
+	pc01 := newInstance("PC01")
+	pc02a := newInstance("pc02.a.com")
+	pc02b := newInstance("pc02.b.com")
+	instances := []api.Instance{pc01, pc02a, pc02b}
+
+	tests := []struct {
+		name      string
+		passwords map[string]string
+
+		wantMatched   map[string]string
+		wantUnmatched []string
+		assertErr     require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - short and full names",
+			passwords: map[string]string{
+				"pc01.example.com": "1",
+				"pc02.b.com":       "2",
+				"pc03":             "3",
+			},
+
+			wantMatched:   map[string]string{pc01.UUID.String(): "1", pc02b.UUID.String(): "2"},
+			wantUnmatched: []string{"pc03"},
+			assertErr:     require.NoError,
+		},
+		{
+			name:      "error - short name matches several instances",
+			passwords: map[string]string{"pc02": "2"},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - instance matched with different passwords",
+			passwords: map[string]string{
+				"pc01":             "1",
+				"pc01.example.com": "2",
+			},
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, unmatched, err := matchRecoveryPasswords(tc.passwords, instances)
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantMatched, matched)
+			require.Equal(t, tc.wantUnmatched, unmatched)
+		})
+	}
+}
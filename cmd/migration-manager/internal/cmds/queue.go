@@ -3,7 +3,10 @@ package cmds
 import (
 	"fmt"
 	"net/http"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -46,6 +49,10 @@ func (c *CmdQueue) Command() *cobra.Command {
 	queueResolveCmd := cmdQueueResolve{global: c.Global}
 	cmd.AddCommand(queueResolveCmd.Command())
 
+	// Logs
+	queueLogsCmd := cmdQueueLogs{global: c.Global}
+	cmd.AddCommand(queueLogsCmd.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -103,7 +110,7 @@ func (c *cmdQueueList) Run(cmd *cobra.Command, args []string) error {
 	batchesByName := map[string]api.Batch{}
 	header := []string{"UUID", "Name", "Batch", "Last Update", "Status", "Status Message", "Migration Window"}
 	if c.flagVerbose {
-		header = append(header, "Batch Status", "Batch Status Message", "Target", "Target Project`")
+		header = append(header, "Batch Status", "Batch Status Message", "Target", "Target Project`", "Final Import Forecast", "Downtime")
 
 		// Get the current migration queue.
 		resp, _, err := c.global.doHTTPRequestV1("/batches", http.MethodGet, "recursion=1", nil)
@@ -145,7 +152,17 @@ func (c *cmdQueueList) Run(cmd *cobra.Command, args []string) error {
 
 		row := []string{q.InstanceUUID.String(), q.InstanceName, q.BatchName, lastUpdate, string(q.MigrationStatus), q.MigrationStatusMessage, window}
 		if c.flagVerbose {
-			row = append(row, string(batchesByName[q.BatchName].Status), batchesByName[q.BatchName].StatusMessage, q.Placement.TargetName, q.Placement.TargetProject)
+			forecast := "unknown"
+			if q.FinalImportForecast.Duration > 0 {
+				forecast = q.FinalImportForecast.Truncate(time.Second).String()
+			}
+
+			downtime := "unknown"
+			if q.Cutover.Downtime.Duration > 0 {
+				downtime = q.Cutover.Downtime.Truncate(time.Second).String()
+			}
+
+			row = append(row, string(batchesByName[q.BatchName].Status), batchesByName[q.BatchName].StatusMessage, q.Placement.TargetName, q.Placement.TargetProject, forecast, downtime)
 		}
 
 		data = append(data, row)
@@ -312,3 +329,68 @@ func (c *cmdQueueResolve) Run(cmd *cobra.Command, args []string) error {
 	cmd.Printf("Successfully resolved queue entry %q.\n", instanceUUID)
 	return nil
 }
+
+// Show the worker logs of the queue entry.
+type cmdQueueLogs struct {
+	global *CmdGlobal
+
+	flagFormat string
+}
+
+func (c *cmdQueueLogs) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "logs <instance UUID> [<attempt> <file>]"
+	cmd.Short = "Show the worker logs of the queue entry"
+	cmd.Long = `Description:
+  Show the worker logs of the queue entry.
+
+  Without further arguments, lists the log files uploaded by the migration worker for each attempt.
+  Given an attempt and file name, prints the content of that log file.
+`
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", `Format (csv|json|table|yaml|compact), use suffix ",noheader" to disable headers and ",header" to enable if demanded, e.g. csv,header`)
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return validateFlagFormat(cmd.Flag("format").Value.String())
+	}
+
+	return cmd
+}
+
+func (c *cmdQueueLogs) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 3)
+	if exit {
+		return err
+	}
+
+	if len(args) == 2 {
+		_ = cmd.Help()
+		return fmt.Errorf("Invalid number of arguments")
+	}
+
+	instanceUUID := args[0]
+
+	if len(args) == 3 {
+		return c.global.doHTTPRequestV1Writer("/queue/"+instanceUUID+"/logs/"+args[1]+"/"+args[2], http.MethodGet, "", os.Stdout, nil, nil)
+	}
+
+	resp, _, err := c.global.doHTTPRequestV1("/queue/"+instanceUUID+"/logs", http.MethodGet, "", nil)
+	if err != nil {
+		return err
+	}
+
+	attempts := []api.QueueLogAttempt{}
+	err = responseToStruct(resp, &attempts)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Attempt", "Time", "Files"}
+	data := [][]string{}
+	for _, a := range attempts {
+		data = append(data, []string{strconv.Itoa(a.Attempt), a.Time.String(), strings.Join(a.Files, ", ")})
+	}
+
+	return util.RenderTable(cmd.OutOrStdout(), c.flagFormat, header, data, attempts)
+}
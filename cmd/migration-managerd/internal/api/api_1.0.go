@@ -21,12 +21,16 @@ var api10 = []APIEndpoint{
 	artifactFileCmd,
 	batchCmd,
 	batchInstancesCmd,
+	batchReportCmd,
 	batchResetCmd,
+	batchSourceCleanupCmd,
 	batchStartCmd,
 	batchStopCmd,
 	batchesCmd,
 	instanceCmd,
+	instanceHistoryCmd,
 	instanceOverrideCmd,
+	instanceSecretsCmd,
 	instanceResetBackgroundImportCmd,
 	instanceEnableBackgroundImportCmd,
 	instancePowerCmd,
@@ -36,6 +40,9 @@ var api10 = []APIEndpoint{
 	networkOverrideCmd,
 	networksCmd,
 	queueCancelCmd,
+	queueHistoryCmd,
+	queueLogCmd,
+	queueLogsCmd,
 	queueResolveCmd,
 	queueRetryCmd,
 	queueRootCmd,
@@ -59,6 +66,8 @@ var api10 = []APIEndpoint{
 var apiInternal = []APIEndpoint{
 	workerUpdateCmd,
 	workerCommandCmd,
+	workerLogsCmd,
+	workerChannelCmd,
 	sqlCmd,
 }
 
@@ -2,11 +2,15 @@ package api
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -45,6 +49,18 @@ var batchInstancesCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: batchInstancesGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
 }
 
+var batchReportCmd = APIEndpoint{
+	Path: "batches/{name}/report",
+
+	Get: APIEndpointAction{Handler: batchReportGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
+var batchSourceCleanupCmd = APIEndpoint{
+	Path: "batches/{name}/source-cleanup",
+
+	Get: APIEndpointAction{Handler: batchSourceCleanupGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
 var batchStartCmd = APIEndpoint{
 	Path: "batches/{name}/:start",
 
@@ -321,6 +337,7 @@ func batchDelete(d *Daemon, r *http.Request) response.Response {
 	name := r.PathValue("name")
 
 	var batch api.Batch
+	var entries migration.QueueEntries
 	err := transaction.Do(r.Context(), func(ctx context.Context) error {
 		b, err := d.batch.GetByName(ctx, name)
 		if err != nil {
@@ -334,12 +351,25 @@ func batchDelete(d *Daemon, r *http.Request) response.Response {
 
 		batch = b.ToAPI(windows)
 
+		// The queue entries are removed along with the batch.
+		entries, err = d.queue.GetAllByBatch(ctx, name)
+		if err != nil {
+			return err
+		}
+
 		return d.batch.DeleteByName(ctx, name)
 	})
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	for _, q := range entries {
+		err := d.workerLog.DeleteByInstanceUUID(q.InstanceUUID)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	d.logHandler.SendLifecycle(r.Context(), event.NewBatchEvent(event.BatchRemoved, r, batch, batch.Name))
 	return response.EmptySyncResponse
 }
@@ -509,6 +539,9 @@ func batchPut(d *Daemon, r *http.Request) response.Response {
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewBatchEvent(event.BatchModified, r, newBatch.ToAPI(windows), newBatch.Name))
 
+	// Changes to migration windows or limits may let waiting workers proceed.
+	d.workerSignal.Broadcast()
+
 	return response.SyncResponseLocation(true, nil, "/"+api.APIVersion+"/batches/"+batch.Name)
 }
 
@@ -637,6 +670,274 @@ func batchInstancesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, result)
 }
 
+// swagger:operation GET /1.0/batches/{name}/report batches batch_report_get
+//
+//	Get the migration report for the batch
+//
+//	Returns the migration report for every instance queued by the batch, including its source location, target placement,
+//	final status, the time spent in each migration phase, bytes transferred, downtime, warnings and override comments.
+//
+//	The report is returned as a sync response by default. If `format` is `csv` or `html`, the report is returned as a file instead.
+//
+//	---
+//	produces:
+//	  - application/json
+//	  - text/csv
+//	  - text/html
+//	parameters:
+//	  - in: query
+//	    name: format
+//	    description: Output format of the report, one of `json` (default), `csv` or `html`.
+//	    type: string
+//	    example: csv
+//	responses:
+//	  "200":
+//	    description: Batch report
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/BatchReport"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func batchReportGet(d *Daemon, r *http.Request) response.Response {
+	name := r.PathValue("name")
+
+	format := api.BatchReportFormat(r.FormValue("format"))
+	switch format {
+	case "":
+		format = api.BATCHREPORTFORMAT_JSON
+	case api.BATCHREPORTFORMAT_JSON, api.BATCHREPORTFORMAT_CSV, api.BATCHREPORTFORMAT_HTML:
+	default:
+		return response.BadRequest(fmt.Errorf("Invalid report format %q", format))
+	}
+
+	var report api.BatchReport
+	err := transaction.Do(r.Context(), func(ctx context.Context) error {
+		batch, err := d.batch.GetByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		entries, err := d.queue.GetAllByBatch(ctx, batch.Name)
+		if err != nil {
+			return fmt.Errorf("Failed to get queue entries for batch %q: %w", batch.Name, err)
+		}
+
+		instances, err := d.instance.GetAllQueued(ctx, entries)
+		if err != nil {
+			return fmt.Errorf("Failed to get instances for batch %q: %w", batch.Name, err)
+		}
+
+		history, err := d.queue.GetHistoryByBatch(ctx, batch.Name)
+		if err != nil {
+			return fmt.Errorf("Failed to get history for batch %q: %w", batch.Name, err)
+		}
+
+		warnings, err := d.warning.GetAll(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to get warnings: %w", err)
+		}
+
+		report = migration.NewBatchReport(*batch, entries, instances, history, warnings, time.Now().UTC())
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	switch format {
+	case api.BATCHREPORTFORMAT_CSV:
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Batch+".csv"))
+
+			return writeBatchReportCSV(w, report)
+		})
+
+	case api.BATCHREPORTFORMAT_HTML:
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+			return batchReportTemplate.Execute(w, report)
+		})
+	}
+
+	return response.SyncResponse(true, report)
+}
+
+// batchReportHeader lists the columns of each row returned by batchReportRows.
+var batchReportHeader = []string{"UUID", "Name", "Source", "Location", "Target", "Project", "Status", "Status Message", "Phases", "Bytes Transferred", "Downtime", "Warnings", "Comment"}
+
+// batchReportRows flattens the batch report into one row per instance.
+func batchReportRows(report api.BatchReport) [][]string {
+	rows := make([][]string, 0, len(report.Instances))
+	for _, inst := range report.Instances {
+		phases := make([]string, 0, len(inst.Phases))
+		for _, p := range inst.Phases {
+			end := ""
+			if !p.End.IsZero() {
+				end = p.End.Format(time.RFC3339)
+			}
+
+			phases = append(phases, fmt.Sprintf("%s (%s - %s)", p.Status, p.Start.Format(time.RFC3339), end))
+		}
+
+		rows = append(rows, []string{
+			inst.UUID.String(),
+			inst.Name,
+			inst.Source,
+			inst.Location,
+			inst.Placement.TargetName,
+			inst.Placement.TargetProject,
+			string(inst.MigrationStatus),
+			inst.MigrationStatusMessage,
+			strings.Join(phases, "; "),
+			strconv.FormatInt(inst.BytesTransferred, 10),
+			inst.Downtime.String(),
+			strings.Join(inst.Warnings, "; "),
+			inst.Comment,
+		})
+	}
+
+	return rows
+}
+
+func writeBatchReportCSV(w io.Writer, report api.BatchReport) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(batchReportHeader)
+	if err != nil {
+		return err
+	}
+
+	return cw.WriteAll(batchReportRows(report))
+}
+
+var batchReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"header": func() []string { return batchReportHeader }, "rows": batchReportRows}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Migration report: {{ .Batch }}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>Migration report: {{ .Batch }}</h1>
+<p>Status: {{ .Status }}<br>Generated: {{ .GeneratedAt.Format "2006-01-02 15:04:05 MST" }}</p>
+<table>
+<tr>{{ range header }}<th>{{ . }}</th>{{ end }}</tr>
+{{- range rows . }}
+<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// swagger:operation GET /1.0/batches/{name}/source-cleanup batches batch_source_cleanup_get
+//
+//	Get the source cleanup plan for the batch
+//
+//	Returns the cleanup that the source cleanup policy of the batch applies to the source VM of each finished queue entry,
+//	without applying it. Pending actions are applied by the next periodic cleanup run.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Source cleanup plan
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: Source cleanup of each finished instance
+//	          items:
+//	            $ref: "#/definitions/SourceCleanupPlan"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func batchSourceCleanupGet(d *Daemon, r *http.Request) response.Response {
+	name := r.PathValue("name")
+
+	plans := []api.SourceCleanupPlan{}
+	err := transaction.Do(r.Context(), func(ctx context.Context) error {
+		batch, err := d.batch.GetByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		entries, err := d.queue.GetAllByBatch(ctx, batch.Name)
+		if err != nil {
+			return fmt.Errorf("Failed to get queue entries for batch %q: %w", batch.Name, err)
+		}
+
+		instances, err := d.instance.GetAllQueued(ctx, entries)
+		if err != nil {
+			return fmt.Errorf("Failed to get instances for batch %q: %w", batch.Name, err)
+		}
+
+		names := make(map[uuid.UUID]string, len(instances))
+		for _, inst := range instances {
+			names[inst.UUID] = inst.GetName()
+		}
+
+		now := time.Now().UTC()
+		for _, q := range entries {
+			if q.MigrationStatus != api.MIGRATIONSTATUS_FINISHED {
+				continue
+			}
+
+			plans = append(plans, q.SourceCleanupPlan(batch.Config.SourceCleanup, names[q.InstanceUUID], now))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, plans)
+}
+
 // swagger:operation POST /1.0/batches/{name}/start batches batches_start_post
 //
 //	Start a batch
@@ -699,6 +1000,7 @@ func batchStartPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewBatchEvent(event.BatchStarted, r, batch, batch.Name))
+	d.workerSignal.Broadcast()
 
 	return response.SyncResponse(true, nil)
 }
@@ -749,6 +1051,7 @@ func batchStopPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewBatchEvent(event.BatchStopped, r, batch, batch.Name))
+	d.workerSignal.Broadcast()
 
 	return response.SyncResponse(true, nil)
 }
@@ -3,9 +3,12 @@ package api
 import (
 	"context"
 	"crypto/x509"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -366,3 +369,201 @@ func TestBatchAPI_reset(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchAPI_report(t *testing.T) {
+	instUUID := uuid.New()
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, []APIEndpoint{batchReportCmd}, nil)
+
+	batch := migration.Batch{
+		Name:              "b1",
+		Status:            api.BATCHSTATUS_DEFINED,
+		IncludeExpression: "true",
+		Defaults: api.BatchDefaults{
+			Placement: api.BatchPlacement{Target: "default", TargetProject: "default", StoragePool: "default"},
+		},
+		Config: api.BatchConfig{
+			BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
+			FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
+		},
+	}
+
+	_, err := d.batch.Create(t.Context(), batch)
+	require.NoError(t, err)
+
+	src := migration.Source{Name: "src", SourceType: api.SOURCETYPE_VMWARE, Properties: json.RawMessage(`{"endpoint": "bar", "username":"u", "password":"p"}`), EndpointFunc: func(api.Source) (migration.SourceEndpoint, error) {
+		return &mock.SourceEndpointMock{
+			ConnectFunc: func(ctx context.Context) error { return nil },
+			DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+				return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+			},
+		}, nil
+	}}
+
+	_, err = d.source.Create(t.Context(), src)
+	require.NoError(t, err)
+
+	_, err = d.instance.Create(t.Context(), migration.Instance{
+		UUID:                 instUUID,
+		Source:               src.Name,
+		SourceType:           src.SourceType,
+		LastUpdateFromSource: time.Now(),
+		Overrides:            api.InstanceOverride{Comment: "Approved by ops"},
+		Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm"}, Location: "/dc/vm"},
+	})
+	require.NoError(t, err)
+
+	_, err = d.queue.CreateEntry(t.Context(), migration.QueueEntry{
+		InstanceUUID:    instUUID,
+		BatchName:       batch.Name,
+		MigrationStatus: api.MIGRATIONSTATUS_WAITING,
+		SecretToken:     uuid.New(),
+		ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+		Placement:       api.Placement{TargetName: "tgt", TargetProject: "default", StoragePools: map[string]string{"root": "default"}, Networks: map[string]api.NetworkPlacement{}},
+	})
+	require.NoError(t, err)
+
+	_, err = d.queue.UpdateStatusByUUID(t.Context(), instUUID, api.MIGRATIONSTATUS_FINAL_IMPORT, "Importing", migration.IMPORTSTAGE_FINAL, nil)
+	require.NoError(t, err)
+
+	_, err = d.queue.UpdateStatusByUUID(t.Context(), instUUID, api.MIGRATIONSTATUS_FINISHED, "Finished", migration.IMPORTSTAGE_COMPLETE, nil)
+	require.NoError(t, err)
+
+	_, err = d.warning.Emit(t.Context(), migration.NewSyncWarning(api.InstanceIncomplete, src.Name, `"/dc/vm" has incomplete properties. Ensure VM is powered on and guest agent is running`))
+	require.NoError(t, err)
+
+	// Warnings only quoting a longer location that starts with the instance's must not be attributed to it.
+	_, err = d.warning.Emit(t.Context(), migration.NewSyncWarning(api.InstanceMissingNetworkSource, src.Name, `No NSX source for network "/dc/vm/net"`))
+	require.NoError(t, err)
+
+	_, err = d.warning.Emit(t.Context(), migration.NewSyncWarning(api.InstanceIncomplete, src.Name, `"/dc/vm2" has incomplete properties. Ensure VM is powered on and guest agent is running`))
+	require.NoError(t, err)
+
+	statusCode, body := probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/b1/report", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+
+	var resp struct {
+		Metadata api.BatchReport `json:"metadata"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Equal(t, batch.Name, resp.Metadata.Batch)
+	require.Len(t, resp.Metadata.Instances, 1)
+
+	inst := resp.Metadata.Instances[0]
+	require.Equal(t, "vm", inst.Name)
+	require.Equal(t, "/dc/vm", inst.Location)
+	require.Equal(t, "tgt", inst.Placement.TargetName)
+	require.Equal(t, api.MIGRATIONSTATUS_FINISHED, inst.MigrationStatus)
+	require.Len(t, inst.Phases, 3)
+	require.Equal(t, api.MIGRATIONSTATUS_WAITING, inst.Phases[0].Status)
+	require.Equal(t, api.MIGRATIONSTATUS_FINISHED, inst.Phases[2].Status)
+	require.True(t, inst.Phases[2].End.IsZero())
+	require.Equal(t, []string{`"/dc/vm" has incomplete properties. Ensure VM is powered on and guest agent is running`}, inst.Warnings)
+	require.Equal(t, "Approved by ops", inst.Comment)
+
+	statusCode, body = probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/b1/report?format=csv", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+
+	rows, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "UUID", rows[0][0])
+	require.Equal(t, instUUID.String(), rows[1][0])
+	require.Equal(t, "Approved by ops", rows[1][len(rows[1])-1])
+
+	statusCode, body = probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/b1/report?format=html", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+	require.Contains(t, body, "<td>/dc/vm</td>")
+	require.Contains(t, body, "&#34;/dc/vm&#34; has incomplete properties")
+	require.NotContains(t, body, "/dc/vm/net")
+
+	statusCode, _ = probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/b1/report?format=pdf", nil, nil)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+
+	statusCode, _ = probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/missing/report", nil, nil)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func TestBatchAPI_sourceCleanup(t *testing.T) {
+	finishedUUID := uuid.New()
+	waitingUUID := uuid.New()
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, []APIEndpoint{batchSourceCleanupCmd}, nil)
+
+	batch := migration.Batch{
+		Name:              "b1",
+		Status:            api.BATCHSTATUS_DEFINED,
+		IncludeExpression: "true",
+		Defaults: api.BatchDefaults{
+			Placement: api.BatchPlacement{Target: "default", TargetProject: "default", StoragePool: "default"},
+		},
+		Config: api.BatchConfig{
+			BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
+			FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
+			SourceCleanup:            api.SourceCleanupPolicy{RenameSuffix: "-migrated", Folder: "/dc/vm/quarantine"},
+		},
+	}
+
+	_, err := d.batch.Create(t.Context(), batch)
+	require.NoError(t, err)
+
+	src := migration.Source{Name: "src", SourceType: api.SOURCETYPE_VMWARE, Properties: json.RawMessage(`{"endpoint": "bar", "username":"u", "password":"p"}`), EndpointFunc: func(api.Source) (migration.SourceEndpoint, error) {
+		return &mock.SourceEndpointMock{
+			ConnectFunc: func(ctx context.Context) error { return nil },
+			DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+				return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+			},
+		}, nil
+	}}
+
+	_, err = d.source.Create(t.Context(), src)
+	require.NoError(t, err)
+
+	for _, instUUID := range []uuid.UUID{finishedUUID, waitingUUID} {
+		_, err = d.instance.Create(t.Context(), migration.Instance{
+			UUID:                 instUUID,
+			Source:               src.Name,
+			SourceType:           src.SourceType,
+			LastUpdateFromSource: time.Now(),
+			Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm-" + instUUID.String()}, Location: "/dc/vm/" + instUUID.String()},
+		})
+		require.NoError(t, err)
+
+		_, err = d.queue.CreateEntry(t.Context(), migration.QueueEntry{
+			InstanceUUID:    instUUID,
+			BatchName:       batch.Name,
+			MigrationStatus: api.MIGRATIONSTATUS_WAITING,
+			SecretToken:     uuid.New(),
+			ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+			Placement:       api.Placement{TargetName: "tgt", TargetProject: "default", StoragePools: map[string]string{"root": "default"}, Networks: map[string]api.NetworkPlacement{}},
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = d.queue.UpdateStatusByUUID(t.Context(), finishedUUID, api.MIGRATIONSTATUS_FINISHED, "Finished", migration.IMPORTSTAGE_COMPLETE, nil)
+	require.NoError(t, err)
+
+	_, err = d.queue.RecordSourceCleanupByUUID(t.Context(), finishedUUID, []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME}, errors.New("Folder not found"))
+	require.NoError(t, err)
+
+	statusCode, body := probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/b1/source-cleanup", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+
+	var resp struct {
+		Metadata []api.SourceCleanupPlan `json:"metadata"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Len(t, resp.Metadata, 1)
+
+	plan := resp.Metadata[0]
+	require.Equal(t, finishedUUID, plan.InstanceUUID)
+	require.Equal(t, "vm-"+finishedUUID.String(), plan.InstanceName)
+	require.Equal(t, []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME}, plan.Completed)
+	require.Equal(t, []api.SourceCleanupAction{api.SOURCECLEANUPACTION_MOVE}, plan.Pending)
+	require.Equal(t, "Folder not found", plan.LastError)
+
+	statusCode, _ = probeAPI(t, client, http.MethodGet, srvURL+"/1.0/batches/missing/source-cleanup", nil, nil)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/FuturFusion/migration-manager/internal/migration"
 	"github.com/FuturFusion/migration-manager/internal/server/auth"
 	"github.com/FuturFusion/migration-manager/internal/server/response"
 	"github.com/FuturFusion/migration-manager/internal/server/util"
@@ -31,6 +32,12 @@ var instanceCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: instanceGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView), Authenticator: TokenAuthenticate},
 }
 
+var instanceHistoryCmd = APIEndpoint{
+	Path: "instances/{uuid}/history",
+
+	Get: APIEndpointAction{Handler: instanceHistoryGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
 var instanceOverrideCmd = APIEndpoint{
 	Path: "instances/{uuid}/override",
 
@@ -39,6 +46,14 @@ var instanceOverrideCmd = APIEndpoint{
 	Put:    APIEndpointAction{Handler: instanceOverridePut, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
 }
 
+var instanceSecretsCmd = APIEndpoint{
+	Path: "instances/{uuid}/secrets",
+
+	Delete: APIEndpointAction{Handler: instanceSecretsDelete, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanDelete)},
+	Get:    APIEndpointAction{Handler: instanceSecretsGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+	Post:   APIEndpointAction{Handler: instanceSecretsPost, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
+}
+
 // swagger:operation GET /1.0/instances instances instances_get
 //
 //	Get the instances
@@ -304,13 +319,81 @@ func instanceOverrideGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(fmt.Errorf("Failed to get override for instance %q: %w", UUID, err))
 	}
 
+	override := instance.Overrides
+	override.Recommendation = instance.SizingRecommendation()
+
 	return response.SyncResponseETag(
 		true,
-		instance.Overrides,
+		override,
 		instance.Overrides,
 	)
 }
 
+// swagger:operation GET /1.0/instances/{uuid}/history instances instance_history_get
+//
+//	Get the migration history of an instance
+//
+//	Returns all recorded migration status changes for the instance across all of its queue entries, oldest first.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Instance migration history
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of migration status changes
+//	          items:
+//	            $ref: "#/definitions/QueueHistoryEntry"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceHistoryGet(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var history migration.QueueHistoryEntries
+	err = transaction.Do(r.Context(), func(ctx context.Context) error {
+		_, err := d.instance.GetByUUID(ctx, UUID)
+		if err != nil {
+			return err
+		}
+
+		history, err = d.queue.GetHistoryByInstanceUUID(ctx, UUID)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to get history for instance %q: %w", UUID, err))
+	}
+
+	result := make([]api.QueueHistoryEntry, 0, len(history))
+	for _, h := range history {
+		result = append(result, h.ToAPI())
+	}
+
+	return response.SyncResponse(true, result)
+}
+
 // swagger:operation PUT /1.0/instances/{uuid}/override instances instance_override_put
 //
 //	Update the instance override
@@ -379,6 +462,8 @@ func instanceOverridePut(d *Daemon, r *http.Request) response.Response {
 		return response.PreconditionFailed(err)
 	}
 
+	// The sizing recommendation is derived from the instance properties and never stored.
+	override.Recommendation = nil
 	override.LastUpdate = time.Now().UTC()
 	currentInstance.Overrides = override
 
@@ -449,3 +534,163 @@ func instanceOverrideDelete(d *Daemon, r *http.Request) response.Response {
 
 	return response.EmptySyncResponse
 }
+
+// swagger:operation GET /1.0/instances/{uuid}/secrets instances instance_secrets_get
+//
+//	Get the instance secrets
+//
+//	Returns the secrets stored for the instance. Secret values are never returned.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Instance secrets
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of instance secrets
+//	          items:
+//	            $ref: "#/definitions/InstanceSecret"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceSecretsGet(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var secrets migration.InstanceSecrets
+	err = transaction.Do(r.Context(), func(ctx context.Context) error {
+		_, err := d.instance.GetByUUID(ctx, UUID)
+		if err != nil {
+			return err
+		}
+
+		secrets, err = d.instanceSecret.GetAllByInstanceUUID(ctx, UUID)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to get secrets for instance %q: %w", UUID, err))
+	}
+
+	result := make([]api.InstanceSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		result = append(result, secret.ToAPI())
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// swagger:operation POST /1.0/instances/{uuid}/secrets instances instance_secrets_post
+//
+//	Set an instance secret
+//
+//	Stores the secret for the instance, replacing any existing secret of the same type.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: body
+//	    name: secret
+//	    description: Instance secret
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceSecretPut"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceSecretsPost(d *Daemon, r *http.Request) response.Response {
+	UUIDString := r.PathValue("uuid")
+
+	UUID, err := uuid.Parse(UUIDString)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var secret api.InstanceSecretPut
+	err = json.NewDecoder(r.Body).Decode(&secret)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = transaction.Do(r.Context(), func(ctx context.Context) error {
+		_, err := d.instance.GetByUUID(ctx, UUID)
+		if err != nil {
+			return err
+		}
+
+		return d.instanceSecret.Set(ctx, UUID, secret.Type, secret.Value)
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to set secret for instance %q: %w", UUID, err))
+	}
+
+	return response.SyncResponseLocation(true, nil, "/"+api.APIVersion+"/instances/"+UUIDString+"/secrets")
+}
+
+// swagger:operation DELETE /1.0/instances/{uuid}/secrets instances instance_secrets_delete
+//
+//	Delete the instance secrets
+//
+//	Removes all secrets stored for the instance.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceSecretsDelete(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = transaction.Do(r.Context(), func(ctx context.Context) error {
+		_, err := d.instance.GetByUUID(ctx, UUID)
+		if err != nil {
+			return err
+		}
+
+		return d.instanceSecret.DeleteAllByInstanceUUID(ctx, UUID)
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to delete secrets for instance %q: %w", UUID, err))
+	}
+
+	return response.EmptySyncResponse
+}
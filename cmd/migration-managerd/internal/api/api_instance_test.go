@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/migration/endpoint/mock"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestInstanceAPI_secrets(t *testing.T) {
+	instUUID := uuid.New()
+	recoveryPassword := "123456-123456-123456-123456-123456-123456-123456-123456"
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, []APIEndpoint{instanceSecretsCmd}, nil)
+
+	src := migration.Source{Name: "src", SourceType: api.SOURCETYPE_VMWARE, Properties: json.RawMessage(`{"endpoint": "bar", "username":"u", "password":"p"}`), EndpointFunc: func(api.Source) (migration.SourceEndpoint, error) {
+		return &mock.SourceEndpointMock{
+			ConnectFunc: func(ctx context.Context) error { return nil },
+			DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+				return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+			},
+		}, nil
+	}}
+
+	_, err := d.source.Create(t.Context(), src)
+	require.NoError(t, err)
+
+	_, err = d.instance.Create(t.Context(), migration.Instance{
+		UUID:                 instUUID,
+		Source:               src.Name,
+		SourceType:           src.SourceType,
+		LastUpdateFromSource: time.Now(),
+		Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm"}, Location: "/dc/vm"},
+	})
+	require.NoError(t, err)
+
+	secretsURL := srvURL + "/1.0/instances/" + instUUID.String() + "/secrets"
+
+	// Invalid recovery passwords are rejected.
+	statusCode, body := probeAPI(t, client, http.MethodPost, secretsURL, strings.NewReader(`{"type": "bitlocker-recovery-password", "value": "123456"}`), nil)
+	require.Equal(t, http.StatusBadRequest, statusCode, body)
+
+	// Secrets can't be set for unknown instances.
+	statusCode, body = probeAPI(t, client, http.MethodPost, srvURL+"/1.0/instances/"+uuid.NewString()+"/secrets", strings.NewReader(`{"type": "bitlocker-recovery-password", "value": "`+recoveryPassword+`"}`), nil)
+	require.Equal(t, http.StatusBadRequest, statusCode, body)
+
+	statusCode, body = probeAPI(t, client, http.MethodPost, secretsURL, strings.NewReader(`{"type": "bitlocker-recovery-password", "value": "`+recoveryPassword+`"}`), nil)
+	require.Equal(t, http.StatusCreated, statusCode, body)
+
+	// The value is never returned.
+	statusCode, body = probeAPI(t, client, http.MethodGet, secretsURL, nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+	require.NotContains(t, body, recoveryPassword)
+
+	var resp struct {
+		Metadata []api.InstanceSecret `json:"metadata"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Len(t, resp.Metadata, 1)
+	require.Equal(t, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, resp.Metadata[0].Type)
+
+	value, err := d.instanceSecret.GetValueByInstanceUUID(t.Context(), instUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.NoError(t, err)
+	require.Equal(t, recoveryPassword, value)
+
+	statusCode, body = probeAPI(t, client, http.MethodDelete, secretsURL, nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+
+	_, err = d.instanceSecret.GetValueByInstanceUUID(t.Context(), instUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.ErrorIs(t, err, migration.ErrNotFound)
+}
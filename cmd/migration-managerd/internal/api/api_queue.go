@@ -33,6 +33,24 @@ var queueCmd = APIEndpoint{
 	Delete: APIEndpointAction{Handler: queueDelete, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanDelete)},
 }
 
+var queueHistoryCmd = APIEndpoint{
+	Path: "queue/{uuid}/history",
+
+	Get: APIEndpointAction{Handler: queueHistoryGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
+var queueLogsCmd = APIEndpoint{
+	Path: "queue/{uuid}/logs",
+
+	Get: APIEndpointAction{Handler: queueLogsGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
+var queueLogCmd = APIEndpoint{
+	Path: "queue/{uuid}/logs/{attempt}/{name}",
+
+	Get: APIEndpointAction{Handler: queueLogGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanView)},
+}
+
 var queueCancelCmd = APIEndpoint{
 	Path: "queue/{uuid}/:cancel",
 	Post: APIEndpointAction{Handler: queueCancel, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
@@ -288,6 +306,168 @@ func queueGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponseETag(true, queueItem.ToAPI(instanceName, d.queueHandler.LastWorkerUpdate(queueItem.InstanceUUID), *migrationWindow), queueItem)
 }
 
+// swagger:operation GET /1.0/queue/{uuid}/history queue queue_history_get
+//
+//	Get the migration history of a queue entry
+//
+//	Returns all recorded migration status changes for the instance, oldest first.
+//	The history is kept after the queue entry has been retried, reset, or deleted.
+//	It is removed along with the instance or the batch it was recorded for.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Queue entry history
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of migration status changes
+//	          items:
+//	            $ref: "#/definitions/QueueHistoryEntry"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func queueHistoryGet(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// History outlives the queue entry, so only reject instances that are unknown altogether.
+	var history migration.QueueHistoryEntries
+	err = transaction.Do(r.Context(), func(ctx context.Context) error {
+		_, err := d.instance.GetByUUID(ctx, UUID)
+		if err != nil {
+			return err
+		}
+
+		history, err = d.queue.GetHistoryByInstanceUUID(ctx, UUID)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to get history for queue entry %q: %w", UUID, err))
+	}
+
+	result := make([]api.QueueHistoryEntry, 0, len(history))
+	for _, h := range history {
+		result = append(result, h.ToAPI())
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// swagger:operation GET /1.0/queue/{uuid}/logs queue queue_logs_get
+//
+//	Get the worker logs of a queue entry
+//
+//	Returns the log files uploaded by the migration worker, grouped by attempt, oldest first.
+//	The worker uploads its logs when a migration step fails, and once the migration is complete.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Worker log attempts
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of log uploads
+//	          items:
+//	            $ref: "#/definitions/QueueLogAttempt"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func queueLogsGet(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	attempts, err := d.workerLog.GetAllByInstanceUUID(UUID)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to get worker logs for queue entry %q: %w", UUID, err))
+	}
+
+	result := make([]api.QueueLogAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		result = append(result, a.ToAPI())
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// swagger:operation GET /1.0/queue/{uuid}/logs/{attempt}/{name} queue queue_log_get
+//
+//	Get a worker log file
+//
+//	Download a log file uploaded by the migration worker during the given attempt.
+//
+//	---
+//	produces:
+//	  - application/octet-stream
+//	responses:
+//	  "200":
+//	    description: Raw log file
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func queueLogGet(d *Daemon, r *http.Request) response.Response {
+	UUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	attempt, err := strconv.Atoi(r.PathValue("attempt"))
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid attempt %q: %w", r.PathValue("attempt"), err))
+	}
+
+	filePath, err := d.workerLog.FilePath(UUID, attempt, r.PathValue("name"))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.FileResponse(r, []response.FileResponseEntry{{Path: filePath}}, nil)
+}
+
 // swagger:operation DELETE /1.0/queue/{uuid} queue queue_delete
 //
 //	Delete the queue
@@ -342,6 +522,11 @@ func queueDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	err = d.workerLog.DeleteByInstanceUUID(queueUUID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	d.logHandler.SendLifecycle(r.Context(), event.NewQueueEntryEvent(event.QueueEntryRemoved, r, apiQueue, apiQueue.InstanceUUID))
 
 	return response.EmptySyncResponse
@@ -467,6 +652,9 @@ func queueCancel(d *Daemon, r *http.Request) response.Response {
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewQueueEntryEvent(event.QueueEntryCanceled, r, apiQueue, apiQueue.InstanceUUID))
 
+	// Let a waiting worker receive the abort command right away.
+	d.workerSignal.Broadcast()
+
 	return response.EmptySyncResponse
 }
 
@@ -529,6 +717,7 @@ func queueRetry(d *Daemon, r *http.Request) response.Response {
 	}
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewQueueEntryEvent(event.QueueEntryRetried, r, apiQueue, apiQueue.InstanceUUID))
+	d.workerSignal.Broadcast()
 
 	return response.EmptySyncResponse
 }
@@ -608,6 +797,7 @@ func queueResolve(d *Daemon, r *http.Request) response.Response {
 	}
 
 	d.logHandler.SendLifecycle(r.Context(), event.NewQueueEntryEvent(event.QueueEntryResolved, r, apiQueue, apiQueue.InstanceUUID))
+	d.workerSignal.Broadcast()
 
 	return response.EmptySyncResponse
 }
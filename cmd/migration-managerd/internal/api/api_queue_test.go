@@ -279,3 +279,132 @@ func TestQueueAPI_cancel(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueAPI_history(t *testing.T) {
+	instUUID := uuid.New()
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, []APIEndpoint{queueHistoryCmd, instanceHistoryCmd}, nil)
+
+	batch := migration.Batch{
+		Name:              "b1",
+		Status:            api.BATCHSTATUS_DEFINED,
+		IncludeExpression: "true",
+		Defaults: api.BatchDefaults{
+			Placement: api.BatchPlacement{Target: "default", TargetProject: "default", StoragePool: "default"},
+		},
+		Config: api.BatchConfig{
+			BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
+			FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
+		},
+	}
+
+	_, err := d.batch.Create(t.Context(), batch)
+	require.NoError(t, err)
+
+	src := migration.Source{Name: "src", SourceType: api.SOURCETYPE_VMWARE, Properties: json.RawMessage(`{"endpoint": "bar", "username":"u", "password":"p"}`), EndpointFunc: func(api.Source) (migration.SourceEndpoint, error) {
+		return &mock.SourceEndpointMock{
+			ConnectFunc: func(ctx context.Context) error { return nil },
+			DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+				return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+			},
+		}, nil
+	}}
+
+	_, err = d.source.Create(t.Context(), src)
+	require.NoError(t, err)
+
+	_, err = d.instance.Create(t.Context(), migration.Instance{
+		UUID:                 instUUID,
+		Source:               src.Name,
+		SourceType:           src.SourceType,
+		LastUpdateFromSource: time.Now(),
+		Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm"}, Location: "vm"},
+	})
+	require.NoError(t, err)
+
+	_, err = d.queue.CreateEntry(t.Context(), migration.QueueEntry{
+		InstanceUUID:    instUUID,
+		BatchName:       batch.Name,
+		MigrationStatus: api.MIGRATIONSTATUS_WAITING,
+		SecretToken:     uuid.New(),
+		ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+		Placement:       api.Placement{TargetName: "tgt", TargetProject: "default", StoragePools: map[string]string{"root": "default"}, Networks: map[string]api.NetworkPlacement{}},
+	})
+	require.NoError(t, err)
+
+	// Status message changes without a status change are not recorded.
+	_, err = d.queue.UpdateStatusByUUID(t.Context(), instUUID, api.MIGRATIONSTATUS_WAITING, "Still waiting", migration.IMPORTSTAGE_BACKGROUND, nil)
+	require.NoError(t, err)
+
+	_, err = d.queue.UpdateStatusByUUID(t.Context(), instUUID, api.MIGRATIONSTATUS_ERROR, "Something failed", migration.IMPORTSTAGE_BACKGROUND, nil)
+	require.NoError(t, err)
+
+	// History survives deletion of the queue entry.
+	require.NoError(t, d.queue.DeleteByUUID(t.Context(), instUUID))
+
+	for _, path := range []string{"/1.0/queue/" + instUUID.String() + "/history", "/1.0/instances/" + instUUID.String() + "/history"} {
+		statusCode, body := probeAPI(t, client, http.MethodGet, srvURL+path, nil, nil)
+		require.Equal(t, http.StatusOK, statusCode, body)
+
+		var resp struct {
+			Metadata []api.QueueHistoryEntry `json:"metadata"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		require.Len(t, resp.Metadata, 2)
+		require.Equal(t, api.MIGRATIONSTATUS_WAITING, resp.Metadata[0].MigrationStatus)
+		require.Empty(t, resp.Metadata[0].Error)
+		require.Equal(t, api.MIGRATIONSTATUS_ERROR, resp.Metadata[1].MigrationStatus)
+		require.Equal(t, "Something failed", resp.Metadata[1].Error)
+		require.Equal(t, batch.Name, resp.Metadata[1].BatchName)
+		require.Equal(t, "tgt", resp.Metadata[1].Placement.TargetName)
+	}
+
+	unknownUUID := uuid.New().String()
+	for _, path := range []string{"/1.0/queue/" + unknownUUID + "/history", "/1.0/instances/" + unknownUUID + "/history"} {
+		statusCode, _ := probeAPI(t, client, http.MethodGet, srvURL+path, nil, nil)
+		require.Equal(t, http.StatusBadRequest, statusCode)
+	}
+
+	// History is removed along with its instance.
+	require.NoError(t, d.instance.DeleteByUUID(t.Context(), instUUID))
+
+	history, err := d.queue.GetHistoryByInstanceUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	history, err = d.queue.GetHistoryByBatch(t.Context(), batch.Name)
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	// History is removed along with its batch.
+	_, err = d.instance.Create(t.Context(), migration.Instance{
+		UUID:                 instUUID,
+		Source:               src.Name,
+		SourceType:           src.SourceType,
+		LastUpdateFromSource: time.Now(),
+		Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm"}, Location: "vm"},
+	})
+	require.NoError(t, err)
+
+	_, err = d.queue.CreateEntry(t.Context(), migration.QueueEntry{
+		InstanceUUID:    instUUID,
+		BatchName:       batch.Name,
+		MigrationStatus: api.MIGRATIONSTATUS_WAITING,
+		SecretToken:     uuid.New(),
+		ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+		Placement:       api.Placement{TargetName: "tgt", TargetProject: "default", StoragePools: map[string]string{"root": "default"}, Networks: map[string]api.NetworkPlacement{}},
+	})
+	require.NoError(t, err)
+
+	history, err = d.queue.GetHistoryByInstanceUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	require.NoError(t, d.queue.DeleteByUUID(t.Context(), instUUID))
+	require.NoError(t, d.batch.DeleteByName(t.Context(), batch.Name))
+
+	history, err = d.queue.GetHistoryByInstanceUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/google/uuid"
 	incusTLS "github.com/lxc/incus/v7/shared/tls"
 
 	"github.com/FuturFusion/migration-manager/internal/migration"
@@ -281,6 +282,7 @@ func sourceDelete(d *Daemon, r *http.Request) response.Response {
 	name := r.PathValue("name")
 
 	var apiSrc api.Source
+	var instanceUUIDs []uuid.UUID
 	err := transaction.Do(r.Context(), func(ctx context.Context) error {
 		src, err := d.source.GetByName(ctx, name)
 		if err != nil {
@@ -289,12 +291,25 @@ func sourceDelete(d *Daemon, r *http.Request) response.Response {
 
 		apiSrc = src.ToAPI()
 
+		// The instances of the source are removed along with it.
+		instanceUUIDs, err = d.instance.GetAllUUIDsBySource(ctx, name)
+		if err != nil {
+			return err
+		}
+
 		return d.source.DeleteByName(ctx, name, d.instance)
 	})
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	for _, instUUID := range instanceUUIDs {
+		err := d.workerLog.DeleteByInstanceUUID(instUUID)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	d.logHandler.SendLifecycle(r.Context(), event.NewSourceEvent(event.SourceRemoved, r, apiSrc, apiSrc.Name))
 
 	return response.EmptySyncResponse
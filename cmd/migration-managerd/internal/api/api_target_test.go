@@ -400,6 +400,12 @@ func daemonSetup(t *testing.T) *Daemon {
 	daemon.window = migration.NewWindowService(sqlite.NewMigrationWindow(tx))
 	daemon.queue = migration.NewQueueService(sqlite.NewQueue(tx), daemon.batch, daemon.instance, daemon.source, daemon.target, daemon.window)
 	daemon.network = migration.NewNetworkService(sqlite.NewNetwork(tx))
+	daemon.warning = migration.NewWarningService(sqlite.NewWarning(tx))
+	daemon.workerLog = migration.NewWorkerLogService(daemon.os)
+	secretsKey, err := daemon.os.LoadSecretsKey()
+	require.NoError(t, err)
+	daemon.instanceSecret, err = migration.NewInstanceSecretService(sqlite.NewInstanceSecret(tx), secretsKey)
+	require.NoError(t, err)
 	daemon.queueHandler = queue.NewMigrationHandler(daemon.batch, daemon.instance, daemon.network, daemon.source, daemon.target, daemon.queue, daemon.window)
 	daemon.errgroup = &errgroup.Group{}
 
@@ -421,7 +427,7 @@ func daemonSetup(t *testing.T) *Daemon {
 func startTestDaemon(t *testing.T, daemon *Daemon, endpoints []APIEndpoint, internalEndpoints []APIEndpoint) (*http.Client, string) {
 	t.Helper()
 
-	for _, dir := range []string{daemon.os.CacheDir, daemon.os.LogDir, daemon.os.RunDir, daemon.os.VarDir, daemon.os.UsrDir, daemon.os.DatabaseDir, daemon.os.ArtifactDir} {
+	for _, dir := range []string{daemon.os.CacheDir, daemon.os.LogDir, daemon.os.RunDir, daemon.os.VarDir, daemon.os.UsrDir, daemon.os.DatabaseDir, daemon.os.ArtifactDir, daemon.os.WorkerLogDir} {
 		if !incusUtil.PathExists(dir) {
 			require.NoError(t, os.MkdirAll(dir, 0o755))
 		}
@@ -2,19 +2,29 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"reflect"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	incusAPI "github.com/lxc/incus/v7/shared/api"
 
+	"github.com/FuturFusion/migration-manager/internal/logger"
 	"github.com/FuturFusion/migration-manager/internal/migration"
 	"github.com/FuturFusion/migration-manager/internal/server/auth"
 	"github.com/FuturFusion/migration-manager/internal/server/response"
 	"github.com/FuturFusion/migration-manager/internal/source"
+	"github.com/FuturFusion/migration-manager/internal/target"
 	"github.com/FuturFusion/migration-manager/internal/transaction"
 	"github.com/FuturFusion/migration-manager/shared/api"
 	"github.com/FuturFusion/migration-manager/shared/api/event"
@@ -32,12 +42,19 @@ var workerCommandCmd = APIEndpoint{
 	Post: APIEndpointAction{Handler: workerCommandPost, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit), Authenticator: TokenAuthenticate},
 }
 
-func instanceUUIDFromRequestURL(r *http.Request) (uuid.UUID, error) {
-	// Only allow GET and POST methods.
-	if r.Method != http.MethodPost {
-		return uuid.Nil, fmt.Errorf("Expected method %q, but received method %q", http.MethodPost, r.Method)
-	}
+var workerLogsCmd = APIEndpoint{
+	Path: "worker/{uuid}/:logs",
+
+	Post: APIEndpointAction{Handler: workerLogsPost, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit), Authenticator: TokenAuthenticate},
+}
+
+var workerChannelCmd = APIEndpoint{
+	Path: "worker/{uuid}/:channel",
+
+	Get: APIEndpointAction{Handler: workerChannelGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit), Authenticator: TokenAuthenticate},
+}
 
+func instanceUUIDFromRequestURL(r *http.Request) (uuid.UUID, error) {
 	// Limit to just worker status updates
 	// /internal/worker/{uuid}/{:action}
 	pathParts := strings.Split(r.URL.Path, "/")
@@ -45,10 +62,20 @@ func instanceUUIDFromRequestURL(r *http.Request) (uuid.UUID, error) {
 		return uuid.Nil, fmt.Errorf("Invalid request URL path: %q", r.URL.Path)
 	}
 
-	if pathParts[1] != "internal" && pathParts[2] != "worker" && !slices.Contains([]string{":command", ":update"}, pathParts[4]) {
+	if pathParts[1] != "internal" || pathParts[2] != "worker" || !slices.Contains([]string{":channel", ":command", ":update", ":logs"}, pathParts[4]) {
 		return uuid.Nil, fmt.Errorf("Request to API path %q is not valid", r.URL.Path)
 	}
 
+	// Only the control channel is opened with GET, all other actions use POST.
+	expectedMethod := http.MethodPost
+	if pathParts[4] == ":channel" {
+		expectedMethod = http.MethodGet
+	}
+
+	if r.Method != expectedMethod {
+		return uuid.Nil, fmt.Errorf("Expected method %q, but received method %q", expectedMethod, r.Method)
+	}
+
 	queueUUID, err := uuid.Parse(pathParts[3])
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("Invalid UUID in request URL %q: %w", r.URL.Path, err)
@@ -57,15 +84,47 @@ func instanceUUIDFromRequestURL(r *http.Request) (uuid.UUID, error) {
 	return queueUUID, nil
 }
 
+// maxWorkerCommandWait limits how long a worker's request for its next command is held open.
+const maxWorkerCommandWait = time.Minute
+
+// workerCommandRecheckInterval is how often a held request for the next command is re-evaluated without being woken, so that the start of a migration window is noticed.
+const workerCommandRecheckInterval = 10 * time.Second
+
+// workerSignal wakes the requests of workers waiting on their next command whenever something that may change it has happened.
+type workerSignal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newWorkerSignal() *workerSignal {
+	return &workerSignal{ch: make(chan struct{})}
+}
+
+// Wait returns a channel that is closed on the next call to Broadcast.
+func (s *workerSignal) Wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ch
+}
+
+// Broadcast wakes all waiting requests.
+func (s *workerSignal) Broadcast() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(s.ch)
+	s.ch = make(chan struct{})
+}
+
+// workerCommandPost returns the next command for the worker.
+// If the 'wait' query parameter is set, the request is held for up to that many seconds until there is something for the worker to do.
 func workerCommandPost(d *Daemon, r *http.Request) response.Response {
 	err := d.WaitForSchemaUpdate(r.Context())
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	// Share this lock with running worker tasks.
-	workerLock.RLock()
-	defer workerLock.RUnlock()
 	uuidString := r.PathValue("uuid")
 
 	instanceUUID, err := uuid.Parse(uuidString)
@@ -73,71 +132,123 @@ func workerCommandPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	var wait time.Duration
+	waitStr := r.URL.Query().Get("wait")
+	if waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds < 0 {
+			return response.BadRequest(fmt.Errorf("Invalid 'wait' value %q", waitStr))
+		}
+
+		wait = min(time.Duration(seconds)*time.Second, maxWorkerCommandWait)
+	}
+
+	deadline := time.Now().Add(wait)
 	var workerCommand migration.WorkerCommand
-	err = transaction.Do(r.Context(), func(ctx context.Context) error {
-		var err error
-		workerCommand, err = d.queue.NewWorkerCommandByInstanceUUID(r.Context(), instanceUUID)
+	for {
+		// Fetch the signal channel before evaluating the command, so that no wake up is missed in between.
+		wake := d.workerSignal.Wait()
+		workerCommand, err = d.startWorkerCommand(r.Context(), instanceUUID)
 		if err != nil {
-			return err
+			return response.SmartError(err)
 		}
 
-		// If we are moving into final import to shut down the VM, fetch the power state one last time.
-		if workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
-			inst, err := d.instance.GetByUUID(ctx, instanceUUID)
-			if err != nil {
-				return err
-			}
+		remaining := time.Until(deadline)
+		if workerCommand.Command != api.WORKERCOMMAND_IDLE || remaining <= 0 {
+			break
+		}
 
-			// If the instance has overridden power state, we can skip checking power state.
-			if inst.Overrides.StartedAfterMigration || inst.Overrides.StoppedAfterMigration {
-				return nil
-			}
+		timer := time.NewTimer(min(remaining, workerCommandRecheckInterval))
+		select {
+		case <-wake:
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return response.SmartError(r.Context().Err())
+		}
 
-			q, err := d.queue.GetByInstanceUUID(ctx, instanceUUID)
-			if err != nil {
-				return err
-			}
+		timer.Stop()
+	}
 
-			s, err := source.NewVMSource(workerCommand.Source.ToAPI())
+	cmd, err := d.workerCommandToAPI(r.Context(), instanceUUID, workerCommand)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, cmd, workerCommand)
+}
+
+// startWorkerCommand determines the next command for the worker of the instance, and records that the worker has been heard from.
+func (d *Daemon) startWorkerCommand(ctx context.Context, instanceUUID uuid.UUID) (migration.WorkerCommand, error) {
+	workerCommand, err := d.nextWorkerCommand(ctx, instanceUUID)
+	if err != nil {
+		return migration.WorkerCommand{}, err
+	}
+
+	d.queueHandler.RecordWorkerUpdate(instanceUUID)
+
+	// A starting import takes a share of the transfer limits of the running ones.
+	if workerCommand.Command == api.WORKERCOMMAND_IMPORT_DISKS || workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
+		d.queueHandler.ResetTransferLimits()
+	}
+
+	return workerCommand, nil
+}
+
+// workerCommandToAPI prepares a command for sending to the worker, adding the instance secrets it needs, and sends the lifecycle event of the step it starts.
+func (d *Daemon) workerCommandToAPI(ctx context.Context, instanceUUID uuid.UUID, workerCommand migration.WorkerCommand) (api.WorkerCommand, error) {
+	apiSourceJSON, err := json.Marshal(workerCommand.Source.ToAPI())
+	if err != nil {
+		return api.WorkerCommand{}, err
+	}
+
+	// Instance secrets are only needed to open encrypted volumes for the post-import tasks, and to run the pre-shutdown command of the final import, so they aren't sent with any other command.
+	var recoveryPassword, luksPassphrase, guestPassword string
+	var luksKeyFile []byte
+	var scripts []api.Artifact
+	if workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT && workerCommand.ShutdownPolicy.PreShutdownCommand != "" {
+		guestPassword, err = d.getInstanceSecretValue(ctx, instanceUUID, api.INSTANCESECRETTYPE_GUEST_PASSWORD)
+		if err != nil {
+			return api.WorkerCommand{}, err
+		}
+	}
+
+	if workerCommand.Command == api.WORKERCOMMAND_POST_IMPORT {
+		scripts, err = d.getScriptArtifacts(ctx, instanceUUID)
+		if err != nil {
+			return api.WorkerCommand{}, err
+		}
+
+		switch workerCommand.OSType {
+		case api.OSTYPE_WINDOWS:
+			recoveryPassword, err = d.getInstanceSecretValue(ctx, instanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
 			if err != nil {
-				return err
+				return api.WorkerCommand{}, err
 			}
 
-			ctx, cancel := context.WithTimeout(ctx, s.Timeout())
-			defer cancel()
-			err = s.Connect(ctx)
+		case api.OSTYPE_LINUX:
+			luksPassphrase, err = d.getInstanceSecretValue(ctx, instanceUUID, api.INSTANCESECRETTYPE_LUKS_PASSPHRASE)
 			if err != nil {
-				return err
+				return api.WorkerCommand{}, err
 			}
 
-			running, err := s.IsRunning(ctx, inst.Properties.Location)
+			keyFile, err := d.getInstanceSecretValue(ctx, instanceUUID, api.INSTANCESECRETTYPE_LUKS_KEYFILE)
 			if err != nil {
-				return err
+				return api.WorkerCommand{}, err
 			}
 
-			if running && !q.Placement.Running {
-				q.Placement.Running = running
-				_, err = d.queue.UpdatePlacementByUUID(ctx, instanceUUID, q.Placement)
+			if keyFile != "" {
+				luksKeyFile, err = base64.StdEncoding.DecodeString(keyFile)
 				if err != nil {
-					return err
+					return api.WorkerCommand{}, fmt.Errorf("Failed to decode LUKS keyfile of instance %q: %w", instanceUUID, err)
 				}
 			}
 		}
-
-		return nil
-	})
-	if err != nil {
-		return response.SmartError(err)
-	}
-
-	apiSourceJSON, err := json.Marshal(workerCommand.Source.ToAPI())
-	if err != nil {
-		return response.SmartError(err)
 	}
 
 	getLifecycleData := func(action api.LifecycleAction) (*api.EventLifecycle, error) {
 		var eventResp api.EventLifecycle
-		err := transaction.Do(r.Context(), func(ctx context.Context) error {
+		err := transaction.Do(ctx, func(ctx context.Context) error {
 			q, err := d.queue.GetByInstanceUUID(ctx, instanceUUID)
 			if err != nil {
 				return err
@@ -172,21 +283,20 @@ func workerCommandPost(d *Daemon, r *http.Request) response.Response {
 	case api.WORKERCOMMAND_IMPORT_DISKS:
 		msg, err := getLifecycleData(event.MigrationSyncStarted)
 		if err != nil {
-			return response.SmartError(err)
+			return api.WorkerCommand{}, err
 		}
 
-		d.logHandler.SendLifecycle(r.Context(), *msg)
+		d.logHandler.SendLifecycle(ctx, *msg)
 	case api.WORKERCOMMAND_FINALIZE_IMPORT:
 		msg, err := getLifecycleData(event.MigrationFinalStarted)
 		if err != nil {
-			return response.SmartError(err)
+			return api.WorkerCommand{}, err
 		}
 
-		d.logHandler.SendLifecycle(r.Context(), *msg)
+		d.logHandler.SendLifecycle(ctx, *msg)
 	}
 
-	d.queueHandler.RecordWorkerUpdate(instanceUUID)
-	return response.SyncResponseETag(true, api.WorkerCommand{
+	return api.WorkerCommand{
 		Command:             workerCommand.Command,
 		Location:            workerCommand.Location,
 		SourceType:          workerCommand.SourceType,
@@ -195,18 +305,280 @@ func workerCommandPost(d *Daemon, r *http.Request) response.Response {
 		DistributionVersion: workerCommand.DistroVersion,
 		OSType:              workerCommand.OSType,
 		Architecture:        workerCommand.Architecture,
-	}, workerCommand)
+		TransferLimits:      workerCommand.TransferLimits,
+		DiskStates:          workerCommand.DiskStates,
+		Verification:        workerCommand.Verification,
+		DroppedDisks:        workerCommand.DroppedDisks,
+
+		BitLockerRecoveryPassword: recoveryPassword,
+		LUKSPassphrase:            luksPassphrase,
+		LUKSKeyFile:               luksKeyFile,
+		GuestCustomization:        workerCommand.GuestCustomization,
+		ShutdownPolicy:            workerCommand.ShutdownPolicy,
+		GuestPassword:             guestPassword,
+		ScriptArtifacts:           scripts,
+	}, nil
 }
 
-func workerUpdatePost(d *Daemon, r *http.Request) response.Response {
+// getScriptArtifacts returns the script artifacts that apply to the instance, in the order the worker runs them.
+func (d *Daemon) getScriptArtifacts(ctx context.Context, instanceUUID uuid.UUID) ([]api.Artifact, error) {
+	var scripts []api.Artifact
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		inst, err := d.instance.GetByUUID(ctx, instanceUUID)
+		if err != nil {
+			return err
+		}
+
+		arts, err := d.artifact.GetAllByType(ctx, api.ARTIFACTTYPE_SCRIPT)
+		if err != nil {
+			return err
+		}
+
+		for _, art := range arts.ScriptsForInstance(*inst) {
+			scripts = append(scripts, art.ToAPI())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get script artifacts for instance %q: %w", instanceUUID, err)
+	}
+
+	return scripts, nil
+}
+
+// workerChannelUpgrader upgrades the request of a worker for its control channel to a websocket.
+var workerChannelUpgrader = websocket.Upgrader{}
+
+// workerChannelGet opens the control channel of a worker.
+// Commands, and changes to the running command such as new transfer limits or a request to abort, are pushed to the worker as soon as they are known,
+// and the worker streams the status of its commands back over the same connection.
+func workerChannelGet(d *Daemon, r *http.Request) response.Response {
 	err := d.WaitForSchemaUpdate(r.Context())
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	uuidString := r.PathValue("uuid")
+
+	instanceUUID, err := uuid.Parse(uuidString)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		conn, err := workerChannelUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has already replied with the error.
+			slog.Warn("Failed to open worker channel", slog.String("instance", instanceUUID.String()), logger.Err(err))
+			return nil
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		err = d.serveWorkerChannel(r.Context(), conn, instanceUUID)
+		if err != nil {
+			slog.Warn("Worker channel closed", slog.String("instance", instanceUUID.String()), logger.Err(err))
+		}
+
+		return nil
+	})
+}
+
+// serveWorkerChannel pushes commands and changes to the running command to the worker, and processes the status updates it sends back, until the connection is closed.
+// The worker only opens the channel while it is idle, and is only sent its next command once it has reported the end of the previous one.
+func (d *Daemon) serveWorkerChannel(ctx context.Context, conn *websocket.Conn, instanceUUID uuid.UUID) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updates := make(chan api.WorkerResponse)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var resp api.WorkerResponse
+			err := conn.ReadJSON(&resp)
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			select {
+			case updates <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Whether the worker is running a command, and the last change pushed for it.
+	var running bool
+	var lastUpdate *api.WorkerUpdateResponse
+	push := func(update *api.WorkerUpdateResponse) error {
+		if update == nil || reflect.DeepEqual(update, lastUpdate) {
+			return nil
+		}
+
+		lastUpdate = update
+		return conn.WriteJSON(api.WorkerChannelMessage{Update: update})
+	}
+
+	for {
+		// Fetch the signal channel before evaluating the command, so that no wake up is missed in between.
+		wake := d.workerSignal.Wait()
+		if !running {
+			workerCommand, err := d.startWorkerCommand(ctx, instanceUUID)
+			if err != nil {
+				slog.Warn("Failed to determine next worker command", slog.String("instance", instanceUUID.String()), logger.Err(err))
+			} else if workerCommand.Command != api.WORKERCOMMAND_IDLE {
+				cmd, err := d.workerCommandToAPI(ctx, instanceUUID, workerCommand)
+				if err != nil {
+					return err
+				}
+
+				err = conn.WriteJSON(api.WorkerChannelMessage{Command: &cmd})
+				if err != nil {
+					return err
+				}
+
+				running = true
+				lastUpdate = nil
+			}
+		} else {
+			update, err := d.runningCommandUpdate(ctx, instanceUUID)
+			if err != nil {
+				slog.Warn("Failed to determine update for running worker command", slog.String("instance", instanceUUID.String()), logger.Err(err))
+			}
+
+			err = push(update)
+			if err != nil {
+				return err
+			}
+		}
+
+		timer := time.NewTimer(workerCommandRecheckInterval)
+		select {
+		case resp := <-updates:
+			reply, err := d.processWorkerUpdate(ctx, instanceUUID, resp)
+			if err != nil {
+				slog.Warn("Failed to process worker update", slog.String("instance", instanceUUID.String()), logger.Err(err))
+			}
+
+			// A worker that reports anything but progress is done with its command, and waits for the next one.
+			if resp.Status != api.WORKERRESPONSE_RUNNING {
+				running = false
+			}
+
+			err = push(reply)
+			if err != nil {
+				timer.Stop()
+				return err
+			}
+
+		case <-wake:
+		case <-timer.C:
+		case err := <-readErr:
+			timer.Stop()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+
+			return err
+		case <-d.ShutdownCtx.Done():
+			timer.Stop()
+			return conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "Shutting down"))
+		}
+
+		timer.Stop()
+	}
+}
+
+// nextWorkerCommand determines the next command for the worker of the instance, updating the queue entry accordingly.
+func (d *Daemon) nextWorkerCommand(ctx context.Context, instanceUUID uuid.UUID) (migration.WorkerCommand, error) {
+	// Don't hand out the next import or the post-import tasks while the target instance is being snapshotted.
+	if d.queueHandler.TargetSnapshotPending(instanceUUID) {
+		return migration.WorkerCommand{Command: api.WORKERCOMMAND_IDLE}, nil
+	}
+
 	// Share this lock with running worker tasks.
 	workerLock.RLock()
 	defer workerLock.RUnlock()
+
+	var workerCommand migration.WorkerCommand
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		var err error
+		workerCommand, err = d.queue.NewWorkerCommandByInstanceUUID(ctx, instanceUUID)
+		if err != nil {
+			return err
+		}
+
+		// If we are moving into final import to shut down the VM, fetch the power state one last time.
+		if workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
+			inst, err := d.instance.GetByUUID(ctx, instanceUUID)
+			if err != nil {
+				return err
+			}
+
+			// If the instance has overridden power state, we can skip checking power state.
+			if inst.Overrides.StartedAfterMigration || inst.Overrides.StoppedAfterMigration {
+				return nil
+			}
+
+			q, err := d.queue.GetByInstanceUUID(ctx, instanceUUID)
+			if err != nil {
+				return err
+			}
+
+			s, err := source.NewVMSource(workerCommand.Source.ToAPI())
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, s.Timeout())
+			defer cancel()
+			err = s.Connect(ctx)
+			if err != nil {
+				return err
+			}
+
+			running, err := s.IsRunning(ctx, inst.Properties.Location)
+			if err != nil {
+				return err
+			}
+
+			if running && !q.Placement.Running {
+				q.Placement.Running = running
+				_, err = d.queue.UpdatePlacementByUUID(ctx, instanceUUID, q.Placement)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return migration.WorkerCommand{}, err
+	}
+
+	return workerCommand, nil
+}
+
+// getInstanceSecretValue returns the value of the instance's secret of the given type, or an empty string if it isn't set.
+func (d *Daemon) getInstanceSecretValue(ctx context.Context, instanceUUID uuid.UUID, secretType api.InstanceSecretType) (string, error) {
+	value, err := d.instanceSecret.GetValueByInstanceUUID(ctx, instanceUUID, secretType)
+	if err != nil && !errors.Is(err, migration.ErrNotFound) {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func workerUpdatePost(d *Daemon, r *http.Request) response.Response {
+	err := d.WaitForSchemaUpdate(r.Context())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	uuidString := r.PathValue("uuid")
 
 	instanceUUID, err := uuid.Parse(uuidString)
@@ -221,14 +593,54 @@ func workerUpdatePost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	updatedEntry, err := d.queue.ProcessWorkerUpdate(r.Context(), instanceUUID, resp)
+	reply, err := d.processWorkerUpdate(r.Context(), instanceUUID, resp)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	if reply == nil {
+		return response.SyncResponse(true, nil)
+	}
+
+	return response.SyncResponse(true, *reply)
+}
+
+// processWorkerUpdate records a status update of the worker of the instance, and returns the reply for the worker, if any.
+func (d *Daemon) processWorkerUpdate(ctx context.Context, instanceUUID uuid.UUID, resp api.WorkerResponse) (*api.WorkerUpdateResponse, error) {
+	// Share this lock with running worker tasks.
+	workerLock.RLock()
+	defer workerLock.RUnlock()
+
+	updatedEntry, err := d.queue.ProcessWorkerUpdate(ctx, instanceUUID, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tell a worker that is still running a command of a canceled migration to stop.
+	if updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_CANCELED {
+		d.queueHandler.RecordWorkerUpdate(instanceUUID)
+		if resp.Status == api.WORKERRESPONSE_RUNNING {
+			return &api.WorkerUpdateResponse{Command: api.WORKERCOMMAND_ABORT}, nil
+		}
+
+		return nil, nil
+	}
+
+	// Snapshot the target instance once a disk import has completed, if the batch asks for it.
+	// The snapshot is taken in the background, and the worker is held idle until it has finished.
+	if resp.Status == api.WORKERRESPONSE_SUCCESS && updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_IDLE {
+		snapshotName := api.TargetSnapshotBackgroundImport
+		if updatedEntry.ImportStage == migration.IMPORTSTAGE_COMPLETE {
+			snapshotName = api.TargetSnapshotFinalImport
+		}
+
+		d.queueHandler.StartTargetSnapshot(instanceUUID, snapshotName)
+		go d.runTargetSnapshot(updatedEntry, snapshotName)
+	}
+
 	getLifecycleData := func(action api.LifecycleAction) (*api.EventLifecycle, error) {
 		var eventResp api.EventLifecycle
-		err := transaction.Do(r.Context(), func(ctx context.Context) error {
+		err := transaction.Do(ctx, func(ctx context.Context) error {
 			inst, err := d.instance.GetByUUID(ctx, instanceUUID)
 			if err != nil {
 				return err
@@ -257,23 +669,16 @@ func workerUpdatePost(d *Daemon, r *http.Request) response.Response {
 	if updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_IDLE && updatedEntry.ImportStage == migration.IMPORTSTAGE_FINAL {
 		msg, err := getLifecycleData(event.MigrationSyncCompleted)
 		if err != nil {
-			return response.SmartError(err)
-		}
-
-		d.logHandler.SendLifecycle(r.Context(), *msg)
-	} else if updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_WORKER_DONE {
-		msg, err := getLifecycleData(event.MigrationFinalCompleted)
-		if err != nil {
-			return response.SmartError(err)
+			return nil, err
 		}
 
-		d.logHandler.SendLifecycle(r.Context(), *msg)
+		d.logHandler.SendLifecycle(ctx, *msg)
 	}
 
 	if updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_ERROR {
 		var src *migration.Source
 		var inst *migration.Instance
-		err := transaction.Do(r.Context(), func(ctx context.Context) error {
+		err := transaction.Do(ctx, func(ctx context.Context) error {
 			var err error
 			inst, err = d.instance.GetByUUID(ctx, instanceUUID)
 			if err != nil {
@@ -288,28 +693,194 @@ func workerUpdatePost(d *Daemon, r *http.Request) response.Response {
 			return nil
 		})
 		if err != nil {
-			return response.SmartError(err)
+			return nil, err
 		}
 
 		// Power on the source VM if it was initially running.
 		if updatedEntry.Placement.Running {
 			is, err := source.NewVMSource(src.ToAPI())
 			if err != nil {
-				return response.SmartError(err)
+				return nil, err
 			}
 
-			err = is.Connect(r.Context())
+			err = is.Connect(ctx)
 			if err != nil {
-				return response.SmartError(err)
+				return nil, err
 			}
 
-			err = is.PowerOnVM(r.Context(), inst.Properties.Location)
+			err = is.PowerOnVM(ctx, inst.Properties.Location)
 			if err != nil {
-				return response.SmartError(err)
+				return nil, err
 			}
 		}
 	}
 
 	d.queueHandler.RecordWorkerUpdate(instanceUUID)
-	return response.SyncResponse(true, nil)
+
+	// A finished import frees up import limits, and may let the worker continue with the next step, so re-evaluate waiting workers.
+	if resp.Status != api.WORKERRESPONSE_RUNNING {
+		d.queueHandler.ResetTransferLimits()
+		d.workerSignal.Broadcast()
+	}
+
+	// Reply to progress updates of a running disk import with the current transfer limits, so that bandwidth schedules apply without restarting the import.
+	if resp.Status == api.WORKERRESPONSE_RUNNING && (updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_BACKGROUND_IMPORT || updatedEntry.MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT) {
+		limits, err := d.currentTransferLimits(ctx, instanceUUID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &api.WorkerUpdateResponse{TransferLimits: &limits}, nil
+	}
+
+	return nil, nil
+}
+
+// currentTransferLimits returns the transfer limits of the running disk import of the instance.
+// The limits are only recomputed once a minute, or when another import starts or ends.
+func (d *Daemon) currentTransferLimits(ctx context.Context, instanceUUID uuid.UUID) (api.WorkerTransferLimits, error) {
+	limits, ok := d.queueHandler.CachedTransferLimits(instanceUUID)
+	if ok {
+		return limits, nil
+	}
+
+	limits, err := d.queue.GetTransferLimitsByUUID(ctx, instanceUUID)
+	if err != nil {
+		return api.WorkerTransferLimits{}, err
+	}
+
+	d.queueHandler.CacheTransferLimits(instanceUUID, limits)
+
+	return limits, nil
+}
+
+// runningCommandUpdate returns the change to push to a worker that is running a command, without waiting for its next status update.
+// A worker of a canceled migration is told to abort, and a worker running a disk import is given its current transfer limits.
+func (d *Daemon) runningCommandUpdate(ctx context.Context, instanceUUID uuid.UUID) (*api.WorkerUpdateResponse, error) {
+	q, err := d.queue.GetByInstanceUUID(ctx, instanceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch q.MigrationStatus {
+	case api.MIGRATIONSTATUS_CANCELED:
+		return &api.WorkerUpdateResponse{Command: api.WORKERCOMMAND_ABORT}, nil
+	case api.MIGRATIONSTATUS_BACKGROUND_IMPORT, api.MIGRATIONSTATUS_FINAL_IMPORT:
+		limits, err := d.currentTransferLimits(ctx, instanceUUID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &api.WorkerUpdateResponse{TransferLimits: &limits}, nil
+	}
+
+	return nil, nil
+}
+
+// workerLogsPost stores the tar archive of log files uploaded by the worker as a new attempt for the instance.
+func workerLogsPost(d *Daemon, r *http.Request) response.Response {
+	err := d.WaitForSchemaUpdate(r.Context())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	instanceUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, err = d.queue.GetByInstanceUUID(r.Context(), instanceUUID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	attempt, err := d.workerLog.WriteLogs(instanceUUID, r.Body)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to store worker logs for instance %q: %w", instanceUUID, err))
+	}
+
+	slog.Info("Received worker logs", slog.String("instance", instanceUUID.String()), slog.Int("attempt", attempt.Attempt), slog.Any("files", attempt.Files))
+
+	return response.EmptySyncResponse
+}
+
+// runTargetSnapshot snapshots the target instance of the queue entry, and sets the queue entry to error if the snapshot fails.
+// Once done, waiting workers are woken to fetch their next command.
+func (d *Daemon) runTargetSnapshot(q migration.QueueEntry, snapshotName string) {
+	defer d.workerSignal.Broadcast()
+	defer d.queueHandler.FinishTargetSnapshot(q.InstanceUUID)
+
+	log := slog.With(slog.String("instance", q.InstanceUUID.String()), slog.String("snapshot", snapshotName))
+	err := d.snapshotTargetInstance(d.ShutdownCtx, q, snapshotName)
+	if err == nil {
+		return
+	}
+
+	log.Error("Failed to create target snapshot", logger.Err(err))
+
+	// Share this lock with running worker tasks.
+	workerLock.RLock()
+	defer workerLock.RUnlock()
+
+	_, err = d.queue.UpdateStatusByUUID(d.ShutdownCtx, q.InstanceUUID, api.MIGRATIONSTATUS_ERROR, fmt.Sprintf("Failed to create target snapshot %q: %v", snapshotName, err), q.ImportStage, q.GetWindowName())
+	if err != nil {
+		log.Error("Failed to record target snapshot failure", logger.Err(err))
+	}
+}
+
+// snapshotTargetInstance snapshots the target instance of the queue entry, if its batch has target snapshots enabled.
+func (d *Daemon) snapshotTargetInstance(ctx context.Context, q migration.QueueEntry, snapshotName string) error {
+	var inst *migration.Instance
+	var tgt *migration.Target
+	var enabled bool
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		batch, err := d.batch.GetByName(ctx, q.BatchName)
+		if err != nil {
+			return err
+		}
+
+		enabled = batch.Config.TargetSnapshots
+		if !enabled {
+			return nil
+		}
+
+		inst, err = d.instance.GetByUUID(ctx, q.InstanceUUID)
+		if err != nil {
+			return err
+		}
+
+		tgt, err = d.target.GetByName(ctx, q.Placement.TargetName)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	it, err := target.NewTarget(tgt.ToAPI())
+	if err != nil {
+		return fmt.Errorf("Failed to construct target %q: %w", tgt.Name, err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, it.Timeout())
+	defer cancel()
+
+	err = it.Connect(timeoutCtx)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to target %q: %w", it.GetName(), err)
+	}
+
+	err = it.SetProject(q.Placement.TargetProject)
+	if err != nil {
+		return fmt.Errorf("Failed to set target %q project %q: %w", it.GetName(), q.Placement.TargetProject, err)
+	}
+
+	return it.CreateVMSnapshot(timeoutCtx, inst.GetName(), snapshotName)
 }
@@ -0,0 +1,504 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/migration/endpoint/mock"
+	"github.com/FuturFusion/migration-manager/internal/source"
+	"github.com/FuturFusion/migration-manager/internal/target"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestWorkerSignal(t *testing.T) {
+	s := newWorkerSignal()
+
+	first := s.Wait()
+	select {
+	case <-first:
+		t.Fatal("Signal fired before broadcast")
+	default:
+	}
+
+	s.Broadcast()
+
+	select {
+	case <-first:
+	case <-time.After(time.Second):
+		t.Fatal("Signal didn't fire after broadcast")
+	}
+
+	// Waiting again only fires on the next broadcast.
+	select {
+	case <-s.Wait():
+		t.Fatal("Signal fired before broadcast")
+	default:
+	}
+}
+
+func TestCheckQueueToken(t *testing.T) {
+	instUUID := uuid.New()
+	secret := uuid.New()
+	d := daemonSetup(t)
+
+	createWorkerTestQueueEntry(t, d, instUUID, secret, api.MIGRATIONSTATUS_IDLE)
+
+	cases := []struct {
+		name    string
+		method  string
+		url     string
+		headers map[string]string
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name:    "success - headers",
+			url:     "/internal/worker/" + instUUID.String() + "/:update",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String()},
+
+			assertErr: require.NoError,
+		},
+		{
+			name:    "success - headers with instance",
+			url:     "/1.0/artifacts",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String(), api.WorkerInstanceHeader: instUUID.String()},
+
+			assertErr: require.NoError,
+		},
+		{
+			name:    "success - channel",
+			method:  http.MethodGet,
+			url:     "/internal/worker/" + instUUID.String() + "/:channel",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String()},
+
+			assertErr: require.NoError,
+		},
+		{
+			name:    "error - channel with wrong method",
+			url:     "/internal/worker/" + instUUID.String() + "/:channel",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String()},
+
+			assertErr: require.Error,
+		},
+		{
+			name:    "error - not a worker path",
+			url:     "/internal/sql/" + instUUID.String() + "/:update",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String()},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - query parameters are ignored",
+			url:  "/1.0/artifacts?secret=" + secret.String() + "&instance=" + instUUID.String(),
+
+			assertErr: require.Error,
+		},
+		{
+			name:    "error - wrong secret",
+			url:     "/internal/worker/" + instUUID.String() + "/:update",
+			headers: map[string]string{api.WorkerSecretHeader: uuid.New().String()},
+
+			assertErr: require.Error,
+		},
+		{
+			name:    "error - no instance",
+			url:     "/1.0/artifacts",
+			headers: map[string]string{api.WorkerSecretHeader: secret.String()},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - no secret",
+			url:  "/internal/worker/" + instUUID.String() + "/:update",
+
+			assertErr: require.Error,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Logf("\n\nTEST %02d: %s\n\n", i, tc.name)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			r := httptest.NewRequestWithContext(t.Context(), method, tc.url, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+
+			tc.assertErr(t, d.checkQueueToken(r))
+		})
+	}
+}
+
+func TestWorkerCanceledMigration(t *testing.T) {
+	instUUID := uuid.New()
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, nil, []APIEndpoint{workerUpdateCmd, workerCommandCmd})
+
+	// Worker endpoints wait for the schema update, which the test database doesn't need.
+	close(d.migrationCh)
+
+	createWorkerTestQueueEntry(t, d, instUUID, uuid.New(), api.MIGRATIONSTATUS_BACKGROUND_IMPORT)
+
+	_, _, err := d.queue.CancelByUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+
+	postUpdate := func(resp api.WorkerResponse) api.WorkerUpdateResponse {
+		content, err := json.Marshal(resp)
+		require.NoError(t, err)
+
+		statusCode, body := probeAPI(t, client, http.MethodPost, srvURL+"/internal/worker/"+instUUID.String()+"/:update", bytes.NewReader(content), nil)
+		require.Equal(t, http.StatusOK, statusCode, body)
+
+		var update struct {
+			Metadata api.WorkerUpdateResponse `json:"metadata"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(body), &update))
+		return update.Metadata
+	}
+
+	// Progress of the running import is answered with the request to abort.
+	update := postUpdate(api.WorkerResponse{Status: api.WORKERRESPONSE_RUNNING, StatusMessage: "Copying disk"})
+	require.Equal(t, api.WORKERCOMMAND_ABORT, update.Command)
+
+	// A worker asking for its next command is told to abort as well.
+	statusCode, body := probeAPI(t, client, http.MethodPost, srvURL+"/internal/worker/"+instUUID.String()+"/:command", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, body)
+
+	var cmd struct {
+		Metadata api.WorkerCommand `json:"metadata"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(body), &cmd))
+	require.Equal(t, api.WORKERCOMMAND_ABORT, cmd.Metadata.Command)
+
+	// The acknowledgement is recorded without leaving the canceled state.
+	update = postUpdate(api.WorkerResponse{Status: api.WORKERRESPONSE_ABORTED, StatusMessage: "Worker stopped"})
+	require.Empty(t, update)
+
+	q, err := d.queue.GetByInstanceUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	require.Equal(t, api.MIGRATIONSTATUS_CANCELED, q.MigrationStatus)
+	require.Equal(t, "Worker stopped", q.MigrationStatusMessage)
+	require.Equal(t, api.WORKERRESPONSE_ABORTED, q.LastWorkerStatus)
+}
+
+func TestWorkerTargetSnapshot(t *testing.T) {
+	cases := []struct {
+		name        string
+		snapshotErr error
+
+		wantStatus api.MigrationStatusType
+	}{
+		{
+			name:       "success - worker held idle until the snapshot is taken",
+			wantStatus: api.MIGRATIONSTATUS_IDLE,
+		},
+		{
+			name:        "error - failed snapshot is recorded on the queue entry",
+			snapshotErr: errors.New("boom!"),
+			wantStatus:  api.MIGRATIONSTATUS_ERROR,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instUUID := uuid.New()
+			d := daemonSetup(t)
+			client, srvURL := startTestDaemon(t, d, nil, []APIEndpoint{workerUpdateCmd, workerCommandCmd})
+
+			// Worker endpoints wait for the schema update, which the test database doesn't need.
+			close(d.migrationCh)
+
+			createWorkerTestQueueEntry(t, d, instUUID, uuid.New(), api.MIGRATIONSTATUS_BACKGROUND_IMPORT)
+
+			batch, err := d.batch.GetByName(t.Context(), "b1")
+			require.NoError(t, err)
+
+			batch.Config.TargetSnapshots = true
+			require.NoError(t, d.batch.Update(t.Context(), d.queue, batch.Name, batch))
+
+			_, err = d.target.Create(t.Context(), migration.Target{Name: "tgt", TargetType: api.TARGETTYPE_INCUS, Properties: json.RawMessage(`{"endpoint": "bar", "create_limit": 5, "connection_timeout": "30s"}`), EndpointFunc: func(api.Target) (migration.TargetEndpoint, error) {
+				return &mock.TargetEndpointMock{
+					ConnectFunc:                func(ctx context.Context) error { return nil },
+					IsWaitingForOIDCTokensFunc: func() bool { return false },
+					DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+						return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+					},
+				}, nil
+			}})
+			require.NoError(t, err)
+
+			release := make(chan struct{})
+			var snapshots []string
+			origTarget := target.NewTarget
+			defer func() { target.NewTarget = origTarget }()
+
+			target.NewTarget = func(tgt api.Target) (target.Target, error) {
+				return &target.TargetMock{
+					TimeoutFunc:    func() time.Duration { return time.Minute },
+					GetNameFunc:    func() string { return tgt.Name },
+					ConnectFunc:    func(ctx context.Context) error { return nil },
+					SetProjectFunc: func(project string) error { return nil },
+					CreateVMSnapshotFunc: func(ctx context.Context, name string, snapshotName string) error {
+						<-release
+						snapshots = append(snapshots, snapshotName)
+						return tc.snapshotErr
+					},
+				}, nil
+			}
+
+			content, err := json.Marshal(api.WorkerResponse{Status: api.WORKERRESPONSE_SUCCESS, StatusMessage: "Import done"})
+			require.NoError(t, err)
+
+			// The update is answered without waiting for the snapshot.
+			statusCode, body := probeAPI(t, client, http.MethodPost, srvURL+"/internal/worker/"+instUUID.String()+"/:update", bytes.NewReader(content), nil)
+			require.Equal(t, http.StatusOK, statusCode, body)
+			require.True(t, d.queueHandler.TargetSnapshotPending(instUUID))
+
+			// The worker gets no new command while the snapshot is being taken.
+			statusCode, body = probeAPI(t, client, http.MethodPost, srvURL+"/internal/worker/"+instUUID.String()+"/:command", nil, nil)
+			require.Equal(t, http.StatusOK, statusCode, body)
+
+			var cmd struct {
+				Metadata api.WorkerCommand `json:"metadata"`
+			}
+
+			require.NoError(t, json.Unmarshal([]byte(body), &cmd))
+			require.Equal(t, api.WORKERCOMMAND_IDLE, cmd.Metadata.Command)
+
+			close(release)
+			require.Eventually(t, func() bool { return !d.queueHandler.TargetSnapshotPending(instUUID) }, 5*time.Second, 10*time.Millisecond)
+			require.Equal(t, []string{api.TargetSnapshotBackgroundImport}, snapshots)
+
+			q, err := d.queue.GetByInstanceUUID(t.Context(), instUUID)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantStatus, q.MigrationStatus)
+			if tc.snapshotErr != nil {
+				require.Contains(t, q.MigrationStatusMessage, "Failed to create target snapshot")
+			}
+		})
+	}
+}
+
+func TestWorkerVerificationFailure(t *testing.T) {
+	cases := []struct {
+		name    string
+		running bool
+
+		wantPowerOn bool
+	}{
+		{
+			name:        "source VM that was running is powered back on",
+			running:     true,
+			wantPowerOn: true,
+		},
+		{
+			name:        "source VM that was stopped stays off",
+			running:     false,
+			wantPowerOn: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instUUID := uuid.New()
+			d := daemonSetup(t)
+			client, srvURL := startTestDaemon(t, d, nil, []APIEndpoint{workerUpdateCmd, workerCommandCmd})
+
+			// Worker endpoints wait for the schema update, which the test database doesn't need.
+			close(d.migrationCh)
+
+			createWorkerTestQueueEntry(t, d, instUUID, uuid.New(), api.MIGRATIONSTATUS_FINAL_IMPORT)
+
+			batch, err := d.batch.GetByName(t.Context(), "b1")
+			require.NoError(t, err)
+
+			batch.Config.Verification = api.VERIFICATIONMODE_SAMPLED
+			require.NoError(t, d.batch.Update(t.Context(), d.queue, batch.Name, batch))
+
+			q, err := d.queue.GetByInstanceUUID(t.Context(), instUUID)
+			require.NoError(t, err)
+
+			q.ImportStage = migration.IMPORTSTAGE_FINAL
+			q.Placement.Running = tc.running
+			require.NoError(t, d.queue.Update(t.Context(), q))
+
+			origSource := source.NewVMSource
+			defer func() { source.NewVMSource = origSource }()
+
+			var poweredOn []string
+			source.NewVMSource = func(s api.Source) (source.Source, error) {
+				return &source.SourceMock{
+					TimeoutFunc: func() time.Duration { return time.Second },
+					GetNameFunc: func() string { return s.Name },
+					ConnectFunc: func(ctx context.Context) error { return nil },
+					PowerOnVMFunc: func(ctx context.Context, name string) error {
+						poweredOn = append(poweredOn, name)
+						return nil
+					},
+				}, nil
+			}
+
+			content, err := json.Marshal(api.WorkerResponse{
+				Status:        api.WORKERRESPONSE_SUCCESS,
+				StatusMessage: "Import done",
+				DiskSyncs: []api.WorkerDiskSync{{
+					Name:         "disk",
+					Verification: &api.WorkerDiskVerification{Mode: api.VERIFICATIONMODE_SAMPLED, VerifiedBytes: 1024, MismatchedBlocks: 1},
+				}},
+			})
+			require.NoError(t, err)
+
+			statusCode, body := probeAPI(t, client, http.MethodPost, srvURL+"/internal/worker/"+instUUID.String()+"/:update", bytes.NewReader(content), nil)
+			require.Equal(t, http.StatusOK, statusCode, body)
+
+			// The migration stops before cutover, and the source VM is left as it was found so that the instance is not kept offline.
+			q, err = d.queue.GetByInstanceUUID(t.Context(), instUUID)
+			require.NoError(t, err)
+			require.Equal(t, api.MIGRATIONSTATUS_ERROR, q.MigrationStatus)
+			require.Contains(t, q.MigrationStatusMessage, "blocks differ from the source")
+
+			if tc.wantPowerOn {
+				require.Equal(t, []string{"vm"}, poweredOn)
+			} else {
+				require.Empty(t, poweredOn)
+			}
+		})
+	}
+}
+
+func TestWorkerChannel(t *testing.T) {
+	instUUID := uuid.New()
+	secret := uuid.New()
+	d := daemonSetup(t)
+	client, srvURL := startTestDaemon(t, d, nil, []APIEndpoint{workerChannelCmd})
+
+	// Worker endpoints wait for the schema update, which the test database doesn't need.
+	close(d.migrationCh)
+
+	// The worker last received the import command, but never reported on it.
+	createWorkerTestQueueEntry(t, d, instUUID, secret, api.MIGRATIONSTATUS_BACKGROUND_IMPORT)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+
+	dialer := websocket.Dialer{TLSClientConfig: transport.TLSClientConfig}
+	header := http.Header{}
+	header.Set(api.WorkerSecretHeader, secret.String())
+
+	conn, resp, err := dialer.DialContext(t.Context(), "wss"+strings.TrimPrefix(srvURL, "https")+"/internal/worker/"+instUUID.String()+"/:channel", header)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	defer func() { _ = conn.Close() }()
+
+	read := func() api.WorkerChannelMessage {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		var msg api.WorkerChannelMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+
+	// The lost command is sent again once the worker connects.
+	msg := read()
+	require.NotNil(t, msg.Command)
+	require.Equal(t, api.WORKERCOMMAND_IMPORT_DISKS, msg.Command.Command)
+
+	// Progress of the import is answered with the transfer limits.
+	require.NoError(t, conn.WriteJSON(api.WorkerResponse{Status: api.WORKERRESPONSE_RUNNING, StatusMessage: "Copying disk"}))
+	msg = read()
+	require.Nil(t, msg.Command)
+	require.NotNil(t, msg.Update)
+	require.NotNil(t, msg.Update.TransferLimits)
+
+	// Canceling the migration pushes the request to abort without waiting for the next progress update.
+	_, _, err = d.queue.CancelByUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	d.workerSignal.Broadcast()
+
+	msg = read()
+	require.NotNil(t, msg.Update)
+	require.Equal(t, api.WORKERCOMMAND_ABORT, msg.Update.Command)
+
+	// Once the worker has stopped, it is given the abort command for cleaning up.
+	require.NoError(t, conn.WriteJSON(api.WorkerResponse{Status: api.WORKERRESPONSE_ABORTED, StatusMessage: "Worker stopped"}))
+	msg = read()
+	require.NotNil(t, msg.Command)
+	require.Equal(t, api.WORKERCOMMAND_ABORT, msg.Command.Command)
+
+	q, err := d.queue.GetByInstanceUUID(t.Context(), instUUID)
+	require.NoError(t, err)
+	require.Equal(t, api.MIGRATIONSTATUS_CANCELED, q.MigrationStatus)
+	require.Equal(t, api.WORKERRESPONSE_ABORTED, q.LastWorkerStatus)
+}
+
+// createWorkerTestQueueEntry adds a queue entry with the given status and secret token for a new instance.
+func createWorkerTestQueueEntry(t *testing.T, d *Daemon, instUUID uuid.UUID, secret uuid.UUID, status api.MigrationStatusType) {
+	t.Helper()
+
+	batch := migration.Batch{
+		Name:              "b1",
+		Status:            api.BATCHSTATUS_DEFINED,
+		IncludeExpression: "true",
+		Defaults: api.BatchDefaults{
+			Placement: api.BatchPlacement{Target: "default", TargetProject: "default", StoragePool: "default"},
+		},
+		Config: api.BatchConfig{
+			BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
+			FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
+		},
+	}
+
+	_, err := d.batch.Create(t.Context(), batch)
+	require.NoError(t, err)
+
+	src := migration.Source{Name: "src", SourceType: api.SOURCETYPE_VMWARE, Properties: json.RawMessage(`{"endpoint": "bar", "username":"u", "password":"p"}`), EndpointFunc: func(api.Source) (migration.SourceEndpoint, error) {
+		return &mock.SourceEndpointMock{
+			ConnectFunc: func(ctx context.Context) error { return nil },
+			DoBasicConnectivityCheckFunc: func() (api.ExternalConnectivityStatus, *x509.Certificate) {
+				return api.EXTERNALCONNECTIVITYSTATUS_OK, nil
+			},
+		}, nil
+	}}
+
+	_, err = d.source.Create(t.Context(), src)
+	require.NoError(t, err)
+
+	_, err = d.instance.Create(t.Context(), migration.Instance{
+		UUID:                 instUUID,
+		Source:               src.Name,
+		SourceType:           src.SourceType,
+		LastUpdateFromSource: time.Now(),
+		Properties:           api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Name: "vm"}, Location: "vm"},
+	})
+	require.NoError(t, err)
+
+	_, err = d.queue.CreateEntry(t.Context(), migration.QueueEntry{
+		InstanceUUID:    instUUID,
+		BatchName:       batch.Name,
+		MigrationStatus: status,
+		SecretToken:     secret,
+		ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+		Placement:       api.Placement{TargetName: "tgt", TargetProject: "default", StoragePools: map[string]string{"root": "default"}, Networks: map[string]api.NetworkPlacement{}},
+	})
+	require.NoError(t, err)
+}
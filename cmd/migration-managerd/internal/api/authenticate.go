@@ -10,6 +10,7 @@ import (
 
 	"github.com/FuturFusion/migration-manager/internal/server/auth/oidc"
 	tlsutil "github.com/FuturFusion/migration-manager/internal/server/util"
+	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
 type authenticatorResponse struct {
@@ -105,31 +106,28 @@ func UnixAuthenticate(d *Daemon, w http.ResponseWriter, r *http.Request) (*authe
 	return nil, fmt.Errorf("Request from %q is not a unix socket request", r.RemoteAddr)
 }
 
-// checkQueueToken first checks for the 'secret' and 'instance' query parameters to find a token matching an existing queue entry.
-// If no 'instance' parameter is given, an attempt is made to parse the URL for the instance UUID.
+// checkQueueToken looks for a secret token and instance UUID to find a matching queue entry.
+// The token and UUID are read from the worker headers, so that the token never appears in a URL.
+// If no instance UUID is given, an attempt is made to parse the URL for it.
 func (d *Daemon) checkQueueToken(r *http.Request) error {
-	// Get the secret token.
-	err := r.ParseForm()
-	if err != nil {
-		return fmt.Errorf("Failed to parse required query parameters: %w", err)
-	}
+	secret := r.Header.Get(api.WorkerSecretHeader)
+	instKey := r.Header.Get(api.WorkerInstanceHeader)
 
-	secretUUID, err := uuid.Parse(r.Form.Get("secret"))
+	secretUUID, err := uuid.Parse(secret)
 	if err != nil {
-		return fmt.Errorf("Failed to parse required 'secret' query paremeter: %w", err)
+		return fmt.Errorf("Failed to parse required secret token: %w", err)
 	}
 
 	var instanceUUID uuid.UUID
-	instKey := r.Form.Get("instance")
 	if instKey != "" {
 		instanceUUID, err = uuid.Parse(instKey)
 		if err != nil {
-			return fmt.Errorf("Failed to parse instance UUID from query parameter %q: %w", instKey, err)
+			return fmt.Errorf("Failed to parse instance UUID %q: %w", instKey, err)
 		}
 	} else {
 		instanceUUID, err = instanceUUIDFromRequestURL(r)
 		if err != nil {
-			return fmt.Errorf("Missing required 'instance' query parameter: %w", err)
+			return fmt.Errorf("Missing required instance UUID: %w", err)
 		}
 	}
 
@@ -50,6 +50,9 @@ type Daemon struct {
 	logHandler  *logger.Handler
 	migrationCh chan struct{}
 
+	// workerSignal wakes workers waiting on their next command.
+	workerSignal *workerSignal
+
 	queueHandler *queue.Handler
 	batch        migration.BatchService
 	instance     migration.InstanceService
@@ -60,6 +63,9 @@ type Daemon struct {
 	warning      migration.WarningService
 	artifact     migration.ArtifactService
 	window       migration.WindowService
+	workerLog    migration.WorkerLogService
+
+	instanceSecret migration.InstanceSecretService
 
 	errgroup *errgroup.Group
 
@@ -85,6 +91,7 @@ func NewDaemon(logHandler *logger.Handler) *Daemon {
 	d := &Daemon{
 		db:             &db.Node{},
 		migrationCh:    make(chan struct{}),
+		workerSignal:   newWorkerSignal(),
 		os:             sys.DefaultOS(),
 		logHandler:     logHandler,
 		batchLock:      util.NewIDLock[string](),
@@ -240,6 +247,17 @@ func (d *Daemon) Start() error {
 	d.batch = migration.NewBatchService(sqlite.NewBatch(d.DBTX()), d.instance)
 	d.window = migration.NewWindowService(sqlite.NewMigrationWindow(d.DBTX()))
 	d.queue = migration.NewQueueService(sqlite.NewQueue(d.DBTX()), d.batch, d.instance, d.source, d.target, d.window)
+	d.workerLog = migration.NewWorkerLogService(d.os)
+
+	secretsKey, err := d.os.LoadSecretsKey()
+	if err != nil {
+		return err
+	}
+
+	d.instanceSecret, err = migration.NewInstanceSecretService(sqlite.NewInstanceSecret(d.DBTX()), secretsKey)
+	if err != nil {
+		return err
+	}
 
 	d.queueHandler = queue.NewMigrationHandler(d.batch, d.instance, d.network, d.source, d.target, d.queue, d.window)
 
@@ -320,6 +338,7 @@ func (d *Daemon) Start() error {
 
 	d.runPeriodicTask(d.ShutdownCtx, PostImportTask, d.finalizeCompleteInstances, 10*time.Second)
 	d.runPeriodicTask(d.ShutdownCtx, CacheCleanupTask, d.cleanupCacheDir, 24*time.Hour)
+	d.runPeriodicTask(d.ShutdownCtx, SourceCleanupTask, d.cleanupSources, time.Hour)
 
 	select {
 	case <-errgroupCtx.Done():
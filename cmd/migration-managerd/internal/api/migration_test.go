@@ -145,6 +145,7 @@ func TestMigration_beginImports(t *testing.T) {
 		targetDetails []target.IncusDetails
 
 		rerunScriptlet bool
+		importMode     api.ImportMode
 
 		scriptlet string
 
@@ -478,6 +479,32 @@ def placement(instance, batch):
 			resultBatchState:     api.BATCHSTATUS_RUNNING,
 			assertErr:            require.NoError,
 		},
+		{
+			name: "import agent with unsupported storage pool driver -- only unsupported pool blocked",
+			instances: migration.Instances{
+				uuids.newTestInstance("vm1", map[int]bool{1: true}, map[int]string{1: "10.0.0.10"}, api.OSTYPE_LINUX, false),
+				uuids.newTestInstance("vm2", map[int]bool{1: true}, map[int]string{1: "10.0.0.11"}, api.OSTYPE_LINUX, false),
+			},
+
+			initialPlacements: map[uuid.UUID]api.Placement{
+				uuids["vm1"]: {TargetName: "tgt", TargetProject: "project1", StoragePools: map[string]string{"vm1_disk_1": "pool1"}, Networks: map[string]api.NetworkPlacement{"00:00:00:00:00:01": {Network: "net1", NICType: api.INCUSNICTYPE_MANAGED}}},
+				uuids["vm2"]: {TargetName: "tgt", TargetProject: "project1", StoragePools: map[string]string{"vm2_disk_1": "pool2"}, Networks: map[string]api.NetworkPlacement{"00:00:00:00:00:01": {Network: "net1", NICType: api.INCUSNICTYPE_MANAGED}}},
+			},
+
+			targetDetails: []target.IncusDetails{
+				{Name: "tgt", Projects: []string{"project1"}, StoragePools: []string{"pool1", "pool2"}, StoragePoolDrivers: map[string]string{"pool1": "zfs", "pool2": "lvm"}, NetworksByProject: netMap(setMap{"project1": {"net1"}}), InstancesByProject: setMap{"project1": {}}},
+			},
+
+			hasVMwareSDK:    true,
+			hasWorker:       true,
+			hasWorkerVolume: false,
+			rerunScriptlet:  false,
+			importMode:      api.IMPORTMODE_AGENT,
+
+			resultMigrationState: map[uuid.UUID]api.MigrationStatusType{uuids["vm1"]: api.MIGRATIONSTATUS_IDLE, uuids["vm2"]: api.MIGRATIONSTATUS_BLOCKED},
+			resultBatchState:     api.BATCHSTATUS_RUNNING,
+			assertErr:            require.NoError,
+		},
 		{
 			name: "missing worker binary -- all blocked, batch errored",
 			instances: migration.Instances{
@@ -738,6 +765,7 @@ def placement(instance, batch):
 					BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
 					FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
 					PlacementScriptlet:       tc.scriptlet,
+					ImportMode:               tc.importMode,
 				},
 			}
 
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/internal/logger"
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/source"
+	"github.com/FuturFusion/migration-manager/internal/transaction"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// sourceCleanup is the cleanup due for the source VM of a finished queue entry.
+type sourceCleanup struct {
+	instanceUUID uuid.UUID
+	policy       api.SourceCleanupPolicy
+	actions      []api.SourceCleanupAction
+}
+
+// cleanupSources applies the source cleanup policies of all batches to the source VMs of their finished queue entries.
+func (d *Daemon) cleanupSources(ctx context.Context) error {
+	now := time.Now().UTC()
+	sourcesByName := map[string]migration.Source{}
+	cleanupsBySource := map[string][]sourceCleanup{}
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		batches, err := d.batch.GetAll(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to get batches: %w", err)
+		}
+
+		for _, b := range batches {
+			if !b.Config.SourceCleanup.Enabled() {
+				continue
+			}
+
+			entries, err := d.queue.GetAllByBatch(ctx, b.Name)
+			if err != nil {
+				return fmt.Errorf("Failed to get queue entries for batch %q: %w", b.Name, err)
+			}
+
+			for _, q := range entries {
+				actions := q.PendingSourceCleanup(b.Config.SourceCleanup, now)
+				if len(actions) == 0 {
+					continue
+				}
+
+				inst, err := d.instance.GetByUUID(ctx, q.InstanceUUID)
+				if err != nil {
+					return fmt.Errorf("Failed to get instance %q: %w", q.InstanceUUID, err)
+				}
+
+				cleanupsBySource[inst.Source] = append(cleanupsBySource[inst.Source], sourceCleanup{instanceUUID: q.InstanceUUID, policy: b.Config.SourceCleanup, actions: actions})
+			}
+		}
+
+		sources, err := d.source.GetAll(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to get sources: %w", err)
+		}
+
+		for _, src := range sources {
+			sourcesByName[src.Name] = src
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for srcName, cleanups := range cleanupsBySource {
+		log := slog.With(slog.String("source", srcName))
+		src, ok := sourcesByName[srcName]
+		if !ok {
+			log.Warn("Skipping cleanup of source VMs, source no longer exists")
+			continue
+		}
+
+		err := d.cleanupSourceVMs(ctx, src, cleanups)
+		if err != nil {
+			log.Error("Failed to clean up source VMs", logger.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// cleanupSourceVMs applies the given cleanups to VMs on the source, and records the outcome of each on its queue entry.
+func (d *Daemon) cleanupSourceVMs(ctx context.Context, src migration.Source, cleanups []sourceCleanup) error {
+	is, err := source.NewVMSource(src.ToAPI())
+	if err != nil {
+		return err
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, is.Timeout())
+	err = is.Connect(connectCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Failed to connect to source: %w", err)
+	}
+
+	defer func() { _ = is.Disconnect(ctx) }()
+
+	for _, c := range cleanups {
+		log := slog.With(slog.String("source", src.Name), slog.String("instance", c.instanceUUID.String()))
+		log.Info("Cleaning up source VM", slog.Any("actions", c.actions))
+
+		completed, cleanupErr := is.CleanupVM(ctx, c.instanceUUID, c.policy, c.actions)
+		if cleanupErr != nil {
+			log.Error("Failed to clean up source VM", logger.Err(cleanupErr))
+		}
+
+		_, err := d.queue.RecordSourceCleanupByUUID(ctx, c.instanceUUID, completed, cleanupErr)
+		if err != nil {
+			return fmt.Errorf("Failed to record cleanup of source VM %q: %w", c.instanceUUID, err)
+		}
+	}
+
+	return nil
+}
@@ -43,7 +43,7 @@ func (d *Daemon) syncActiveBatches(ctx context.Context) error {
 		return err
 	}
 
-	importingFromSource := map[string]int{}
+	importingFromSource := migration.Instances{}
 	importingToTarget := map[string]int{}
 	creatingOnTarget := map[string]int{}
 	workerUpdates := map[uuid.UUID]time.Time{}
@@ -64,12 +64,12 @@ func (d *Daemon) syncActiveBatches(ctx context.Context) error {
 				workerUpdates[instUUID] = now
 
 			case api.MIGRATIONSTATUS_BACKGROUND_IMPORT:
-				importingFromSource[state.Sources[instUUID].Name] = importingFromSource[state.Sources[instUUID].Name] + 1
+				importingFromSource = append(importingFromSource, state.Instances[instUUID])
 				importingToTarget[state.Targets[instUUID].Name] = importingToTarget[state.Targets[instUUID].Name] + 1
 				workerUpdates[instUUID] = now
 
 			case api.MIGRATIONSTATUS_FINAL_IMPORT:
-				importingFromSource[state.Sources[instUUID].Name] = importingFromSource[state.Sources[instUUID].Name] + 1
+				importingFromSource = append(importingFromSource, state.Instances[instUUID])
 				importingToTarget[state.Targets[instUUID].Name] = importingToTarget[state.Targets[instUUID].Name] + 1
 				workerUpdates[instUUID] = now
 
@@ -729,6 +729,11 @@ func (d *Daemon) syncInstancesFromSource(ctx context.Context, sourceName string,
 			if err != nil {
 				log.Error("Failed to delete instance", slog.Any("error", err))
 			} else {
+				err := d.workerLog.DeleteByInstanceUUID(instUUID)
+				if err != nil {
+					log.Error("Failed to delete worker logs", slog.Any("error", err))
+				}
+
 				d.logHandler.SendLifecycle(ctx, event.NewInstanceEvent(event.InstanceRemoved, nil, inst.ToAPI(), inst.UUID))
 			}
 
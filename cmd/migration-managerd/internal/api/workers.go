@@ -30,11 +30,12 @@ import (
 type Task string
 
 const (
-	SyncTask         Task = "sync"
-	ImportTask       Task = "import"
-	PostImportTask   Task = "post-import"
-	ACMEUpdateTask   Task = "acme-update"
-	CacheCleanupTask Task = "cache-cleanup"
+	SyncTask          Task = "sync"
+	ImportTask        Task = "import"
+	PostImportTask    Task = "post-import"
+	ACMEUpdateTask    Task = "acme-update"
+	CacheCleanupTask  Task = "cache-cleanup"
+	SourceCleanupTask Task = "source-cleanup"
 )
 
 func (d *Daemon) runPeriodicTask(ctx context.Context, task Task, f func(context.Context) error, interval time.Duration) {
@@ -668,6 +669,11 @@ func (d *Daemon) createTargetVM(ctx context.Context, b migration.Batch, inst mig
 		return fmt.Errorf("Failed to create instance definition: %w", err)
 	}
 
+	// Mark the instance for the import agent on the target host, which imports its disks while the instance is stopped.
+	if b.Config.ImportMode == api.IMPORTMODE_AGENT {
+		instanceDef.Config[target.ImportAgentConfigKey] = uuid.NewString()
+	}
+
 	// Add a lock for this particular target, so each instance create operation on it is processed serially.
 	vmCreateLock.Lock(t.Name)
 	op, cleanup, err := it.CreateNewVM(timeoutCtx, inst, instanceDef, q.Placement, util.WorkerVolume(inst.GetArchitecture()))
@@ -737,9 +743,12 @@ func (d *Daemon) createTargetVM(ctx context.Context, b migration.Batch, inst mig
 	// Unblock the concurrency limits for the target so that the Incus agent doesn't block other creations.
 	d.target.RemoveCreation(t.Name)
 
-	err = it.CheckIncusAgent(timeoutCtx, instanceDef.Name)
-	if err != nil {
-		return err
+	// In agent mode the instance is only started for post-import steps, once its disks have been imported.
+	if b.Config.ImportMode != api.IMPORTMODE_AGENT {
+		err = it.CheckIncusAgent(timeoutCtx, instanceDef.Name)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Set the instance state to IDLE before triggering the worker.
@@ -816,6 +825,12 @@ func (d *Daemon) resetQueueEntry(ctx context.Context, instUUID uuid.UUID, state
 		if err != nil {
 			return fmt.Errorf("Failed to clean up instance %q due to migration window deadline: %w", state.Instances[instUUID].Properties.Location, err)
 		}
+	} else if state.Batch.Config.ImportMode == api.IMPORTMODE_AGENT {
+		// Stop the import agent's worker so it doesn't interfere with our state cleanup.
+		err = setImportAgentConfig(timeoutCtx, it, state.Instances[instUUID].GetName(), "")
+		if err != nil {
+			return fmt.Errorf("Failed to stop import agent for instance %q: %w", state.Instances[instUUID].Properties.Location, err)
+		}
 	} else {
 		// Stop the migration worker so it doesn't interfere with our state cleanup.
 		err = it.Exec(timeoutCtx, state.Instances[instUUID].GetName(), []string{"systemctl", "stop", "migration-manager-worker.service"})
@@ -832,7 +847,13 @@ func (d *Daemon) resetQueueEntry(ctx context.Context, instUUID uuid.UUID, state
 	}
 
 	// Restart the migration worker if the instance is still running.
-	if state.QueueEntries[instUUID].MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT {
+	if state.QueueEntries[instUUID].MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT && state.Batch.Config.ImportMode == api.IMPORTMODE_AGENT {
+		log.Warn("Restarting import agent due to migration window deadline")
+		err := setImportAgentConfig(timeoutCtx, it, state.Instances[instUUID].GetName(), uuid.NewString())
+		if err != nil {
+			return fmt.Errorf("Failed to restart import agent for instance %q: %w", state.Instances[instUUID].Properties.Location, err)
+		}
+	} else if state.QueueEntries[instUUID].MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT {
 		log.Warn("Restarting migration worker due to migration window deadline")
 		err := it.Exec(timeoutCtx, state.Instances[instUUID].GetName(), []string{"systemctl", "restart", "migration-manager-worker.service"})
 		if err != nil {
@@ -843,6 +864,28 @@ func (d *Daemon) resetQueueEntry(ctx context.Context, instUUID uuid.UUID, state
 	return nil
 }
 
+// setImportAgentConfig sets the import agent config key of the instance, or clears it if the value is empty.
+func setImportAgentConfig(ctx context.Context, it target.Target, instanceName string, value string) error {
+	inst, etag, err := it.GetInstance(instanceName)
+	if err != nil {
+		return err
+	}
+
+	instPut := inst.Writable()
+	if value == "" {
+		delete(instPut.Config, target.ImportAgentConfigKey)
+	} else {
+		instPut.Config[target.ImportAgentConfigKey] = value
+	}
+
+	op, err := it.UpdateInstance(instanceName, instPut, etag)
+	if err != nil {
+		return err
+	}
+
+	return op.WaitContext(ctx)
+}
+
 // finalizeCompleteInstances fetches all instances in RUNNING batches whose status is WORKER DONE, and for each batch, runs configureMigratedInstances.
 func (d *Daemon) finalizeCompleteInstances(ctx context.Context) (_err error) {
 	workerLock.RLock()
@@ -1405,18 +1448,67 @@ func (d *Daemon) configureMigratedInstances(ctx context.Context, q migration.Que
 		return fmt.Errorf("Failed to set target %q project %q: %w", it.GetName(), q.Placement.TargetProject, err)
 	}
 
-	err = it.SetPostMigrationVMConfig(timeoutCtx, i, q)
+	err = it.SetPostMigrationVMConfig(timeoutCtx, i, q, batch.Config.GuestCustomization.Apply(i.Overrides.GuestCustomization))
 	if err != nil {
 		return fmt.Errorf("Failed to update post-migration config for instance %q in %q: %w", i.GetName(), it.GetName(), err)
 	}
 
+	// The target instance is only started if the source VM was initially running, so downtime is only measured in that case.
+	var targetStart time.Time
+	if q.Placement.Running {
+		targetStart = time.Now().UTC()
+	}
+
+	_, err = d.queue.RecordCutoverByUUID(ctx, i.UUID, targetStart, time.Time{})
+	if err != nil {
+		return fmt.Errorf("Failed to record cutover for instance %q: %w", i.GetName(), err)
+	}
+
 	// Update the instance status to finished, and remove its migration window.
-	_, err = d.queue.UpdateStatusByUUID(ctx, i.UUID, api.MIGRATIONSTATUS_FINISHED, string(api.MIGRATIONSTATUS_FINISHED), q.ImportStage, nil)
+	finished, err := d.queue.UpdateStatusByUUID(ctx, i.UUID, api.MIGRATIONSTATUS_FINISHED, string(api.MIGRATIONSTATUS_FINISHED), q.ImportStage, nil)
 	if err != nil {
 		return fmt.Errorf("Failed to update instance status to %q: %w", api.MIGRATIONSTATUS_FINISHED, err)
 	}
 
 	reverter.Success()
 
+	// The guest may take a while to boot, so wait for its agent in the background rather than holding up the other instances.
+	// The completion event is sent from there, once the downtime has been measured.
+	if q.Placement.Running {
+		go d.recordAgentReady(it, i, w, *finished, targetStart)
+		return nil
+	}
+
+	d.logHandler.SendLifecycle(ctx, event.NewMigrationEvent(event.MigrationFinalCompleted, i.ToAPI(), finished.ToAPI(i.GetName(), d.queueHandler.LastWorkerUpdate(i.UUID), w)))
+
 	return nil
 }
+
+// recordAgentReady waits for the guest agent of the migrated instance to become ready, and records when it did in the cutover of the queue entry.
+// If the agent doesn't become ready in time, the downtime stays measured until the target instance started.
+// Either way, the final completion event is sent afterwards, so that it carries the measured downtime.
+func (d *Daemon) recordAgentReady(it target.Target, i migration.Instance, w migration.Window, finished migration.QueueEntry, targetStart time.Time) {
+	log := slog.With(
+		slog.String("method", "recordAgentReady"),
+		slog.String("target", it.GetName()),
+		slog.String("instance", i.Properties.Location),
+	)
+
+	ctx, cancel := context.WithTimeout(d.ShutdownCtx, it.Timeout())
+	defer cancel()
+
+	entry := finished
+	err := it.CheckIncusAgent(ctx, i.GetName())
+	if err != nil {
+		log.Warn("Guest agent did not become ready, measuring downtime until target instance start", logger.Err(err))
+	} else {
+		updated, err := d.queue.RecordCutoverByUUID(d.ShutdownCtx, i.UUID, targetStart, time.Now().UTC())
+		if err != nil {
+			log.Warn("Failed to record guest agent readiness", logger.Err(err))
+		} else {
+			entry = *updated
+		}
+	}
+
+	d.logHandler.SendLifecycle(d.ShutdownCtx, event.NewMigrationEvent(event.MigrationFinalCompleted, i.ToAPI(), entry.ToAPI(i.GetName(), d.queueHandler.LastWorkerUpdate(i.UUID), w)))
+}
@@ -26,6 +26,15 @@ CREATE TABLE "batches" (
     config             TEXT NOT NULL,
     UNIQUE (name)
 );
+CREATE TABLE instance_secrets (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_uuid TEXT NOT NULL,
+    type          TEXT NOT NULL,
+    value         TEXT NOT NULL,
+    last_updated  DATETIME NOT NULL,
+    UNIQUE (instance_uuid, type),
+    FOREIGN KEY(instance_uuid) REFERENCES instances(uuid) ON DELETE CASCADE
+);
 CREATE TABLE "instances" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     uuid TEXT NOT NULL,
@@ -80,11 +89,31 @@ CREATE TABLE "queue" (
     migration_window_id              INTEGER,
     placement                        TEXT NOT NULL,
     last_background_sync             DATETIME NOT NULL,
+    sync_history                     TEXT NOT NULL,
+    cutover                          TEXT NOT NULL,
+    disk_states                      TEXT NOT NULL,
+    source_cleanup                   TEXT NOT NULL,
     FOREIGN KEY(migration_window_id) REFERENCES migration_windows(id),
     FOREIGN KEY(instance_id)         REFERENCES instances(id) ON DELETE CASCADE,
     FOREIGN KEY(batch_id)            REFERENCES batches(id) ON DELETE CASCADE,
     UNIQUE (instance_id)
 );
+CREATE TABLE queue_history (
+    id                       INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_uuid            TEXT NOT NULL,
+    batch_name               TEXT NOT NULL,
+    time                     DATETIME NOT NULL,
+    migration_status         TEXT NOT NULL,
+    migration_status_message TEXT NOT NULL,
+    worker_status            INTEGER NOT NULL,
+    worker_status_message    TEXT NOT NULL,
+    placement                TEXT NOT NULL,
+    error                    TEXT NOT NULL,
+    FOREIGN KEY(instance_uuid) REFERENCES instances(uuid) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(batch_name)    REFERENCES batches(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX queue_history_instance_uuid_idx ON queue_history (instance_uuid);
+CREATE INDEX queue_history_batch_name_idx ON queue_history (batch_name);
 CREATE TABLE sources (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name VARCHAR(255) NOT NULL,
@@ -116,5 +145,5 @@ CREATE TABLE warnings (
     UNIQUE (type, scope, entity_type, entity)
 	);
 
-INSERT INTO schema (version, updated_at) VALUES (18, strftime("%s"))
+INSERT INTO schema (version, updated_at) VALUES (24, strftime("%s"))
 `
@@ -79,6 +79,172 @@ var updates = map[int]schema.Update{
 	16: updateFromV15,
 	17: updateFromV16,
 	18: updateFromV17,
+	19: updateFromV18,
+	20: updateFromV19,
+	21: updateFromV20,
+	22: updateFromV21,
+	23: updateFromV22,
+	24: updateFromV23,
+}
+
+func updateFromV23(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE instance_secrets (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_uuid TEXT NOT NULL,
+    type          TEXT NOT NULL,
+    value         TEXT NOT NULL,
+    last_updated  DATETIME NOT NULL,
+    UNIQUE (instance_uuid, type),
+    FOREIGN KEY(instance_uuid) REFERENCES instances(uuid) ON DELETE CASCADE
+);
+`)
+
+	return err
+}
+
+func updateFromV22(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE queue_new (
+    id                               INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id                      INTEGER NOT NULL,
+    batch_id                         INTEGER NOT NULL,
+    migration_status                 TEXT NOT NULL,
+    migration_status_message         TEXT NOT NULL,
+    import_stage                     TEXT NOT NULL,
+    secret_token                     TEXT NOT NULL,
+    last_worker_status               INTEGER NOT NULL,
+    migration_window_id              INTEGER,
+    placement                        TEXT NOT NULL,
+    last_background_sync             DATETIME NOT NULL,
+    sync_history                     TEXT NOT NULL,
+    cutover                          TEXT NOT NULL,
+    disk_states                      TEXT NOT NULL,
+    source_cleanup                   TEXT NOT NULL,
+    FOREIGN KEY(migration_window_id) REFERENCES migration_windows(id),
+    FOREIGN KEY(instance_id)         REFERENCES instances(id) ON DELETE CASCADE,
+    FOREIGN KEY(batch_id)            REFERENCES batches(id) ON DELETE CASCADE,
+    UNIQUE (instance_id)
+);
+
+    INSERT INTO queue_new (id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, cutover, disk_states, source_cleanup)
+    SELECT id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, cutover, disk_states, '{}' FROM queue;
+DROP TABLE queue;
+ALTER TABLE queue_new RENAME TO queue;
+`)
+
+	return err
+}
+
+func updateFromV21(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE queue_new (
+    id                               INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id                      INTEGER NOT NULL,
+    batch_id                         INTEGER NOT NULL,
+    migration_status                 TEXT NOT NULL,
+    migration_status_message         TEXT NOT NULL,
+    import_stage                     TEXT NOT NULL,
+    secret_token                     TEXT NOT NULL,
+    last_worker_status               INTEGER NOT NULL,
+    migration_window_id              INTEGER,
+    placement                        TEXT NOT NULL,
+    last_background_sync             DATETIME NOT NULL,
+    sync_history                     TEXT NOT NULL,
+    cutover                          TEXT NOT NULL,
+    disk_states                      TEXT NOT NULL,
+    FOREIGN KEY(migration_window_id) REFERENCES migration_windows(id),
+    FOREIGN KEY(instance_id)         REFERENCES instances(id) ON DELETE CASCADE,
+    FOREIGN KEY(batch_id)            REFERENCES batches(id) ON DELETE CASCADE,
+    UNIQUE (instance_id)
+);
+
+    INSERT INTO queue_new (id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, cutover, disk_states)
+    SELECT id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, cutover, '[]' FROM queue;
+DROP TABLE queue;
+ALTER TABLE queue_new RENAME TO queue;
+`)
+
+	return err
+}
+
+func updateFromV20(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE queue_new (
+    id                               INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id                      INTEGER NOT NULL,
+    batch_id                         INTEGER NOT NULL,
+    migration_status                 TEXT NOT NULL,
+    migration_status_message         TEXT NOT NULL,
+    import_stage                     TEXT NOT NULL,
+    secret_token                     TEXT NOT NULL,
+    last_worker_status               INTEGER NOT NULL,
+    migration_window_id              INTEGER,
+    placement                        TEXT NOT NULL,
+    last_background_sync             DATETIME NOT NULL,
+    sync_history                     TEXT NOT NULL,
+    cutover                          TEXT NOT NULL,
+    FOREIGN KEY(migration_window_id) REFERENCES migration_windows(id),
+    FOREIGN KEY(instance_id)         REFERENCES instances(id) ON DELETE CASCADE,
+    FOREIGN KEY(batch_id)            REFERENCES batches(id) ON DELETE CASCADE,
+    UNIQUE (instance_id)
+);
+
+    INSERT INTO queue_new (id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, cutover)
+    SELECT id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history, '{}' FROM queue;
+DROP TABLE queue;
+ALTER TABLE queue_new RENAME TO queue;
+`)
+
+	return err
+}
+
+func updateFromV19(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE queue_history (
+    id                       INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_uuid            TEXT NOT NULL,
+    batch_name               TEXT NOT NULL,
+    time                     DATETIME NOT NULL,
+    migration_status         TEXT NOT NULL,
+    migration_status_message TEXT NOT NULL,
+    worker_status            INTEGER NOT NULL,
+    worker_status_message    TEXT NOT NULL,
+    placement                TEXT NOT NULL,
+    error                    TEXT NOT NULL,
+    FOREIGN KEY(instance_uuid) REFERENCES instances(uuid) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(batch_name)    REFERENCES batches(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX queue_history_instance_uuid_idx ON queue_history (instance_uuid);
+CREATE INDEX queue_history_batch_name_idx ON queue_history (batch_name);
+`)
+
+	return err
+}
+
+func updateFromV18(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE TABLE queue_new (
+    id                               INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instance_id                      INTEGER NOT NULL,
+    batch_id                         INTEGER NOT NULL,
+    migration_status                 TEXT NOT NULL,
+    migration_status_message         TEXT NOT NULL,
+    import_stage                     TEXT NOT NULL,
+    secret_token                     TEXT NOT NULL,
+    last_worker_status               INTEGER NOT NULL,
+    migration_window_id              INTEGER,
+    placement                        TEXT NOT NULL,
+    last_background_sync             DATETIME NOT NULL,
+    sync_history                     TEXT NOT NULL,
+    FOREIGN KEY(migration_window_id) REFERENCES migration_windows(id),
+    FOREIGN KEY(instance_id)         REFERENCES instances(id) ON DELETE CASCADE,
+    FOREIGN KEY(batch_id)            REFERENCES batches(id) ON DELETE CASCADE,
+    UNIQUE (instance_id)
+);
+
+    INSERT INTO queue_new (id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, sync_history)
+    SELECT id, instance_id, batch_id, migration_status, migration_status_message, import_stage, secret_token, last_worker_status, migration_window_id, placement, last_background_sync, '[]' FROM queue;
+DROP TABLE queue;
+ALTER TABLE queue_new RENAME TO queue;
+`)
+
+	return err
 }
 
 func updateFromV17(ctx context.Context, tx *sql.Tx) error {
@@ -26,6 +26,8 @@ type NbdkitBuilder struct {
 	filename    string
 	compression CompressionMethod
 	sdk         string
+	rateFile    string
+	logFile     string
 }
 
 func NewNbdkitBuilder() *NbdkitBuilder {
@@ -77,6 +79,18 @@ func (b *NbdkitBuilder) SDK(filename string) *NbdkitBuilder {
 	return b
 }
 
+// RateFile limits the transfer rate to the bits per second read from the given file, which nbdkit re-reads periodically.
+func (b *NbdkitBuilder) RateFile(filename string) *NbdkitBuilder {
+	b.rateFile = filename
+	return b
+}
+
+// LogFile appends the output of nbdkit to the given file for as long as it runs.
+func (b *NbdkitBuilder) LogFile(filename string) *NbdkitBuilder {
+	b.logFile = filename
+	return b
+}
+
 func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 	tmp, err := os.MkdirTemp("", "migratekit-")
 	if err != nil {
@@ -92,13 +106,19 @@ func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 		return nil, err
 	}
 
-	cmd := exec.Command(
-		"nbdkit",
+	args := []string{
 		"--exit-with-parent",
 		"--readonly",
 		"--foreground",
 		fmt.Sprintf("--unix=%s", socket),
 		fmt.Sprintf("--pidfile=%s", pidFile),
+	}
+
+	if b.rateFile != "" {
+		args = append(args, "--filter=rate")
+	}
+
+	args = append(args,
 		"vddk",
 		fmt.Sprintf("server=%s", b.server),
 		fmt.Sprintf("user=%s", b.username),
@@ -109,13 +129,19 @@ func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 		fmt.Sprintf("snapshot=%s", b.snapshot),
 		fmt.Sprintf("libdir=%s", b.sdk),
 		"transports=file:nbdssl:nbd",
-		b.filename,
 	)
 
+	if b.rateFile != "" {
+		args = append(args, fmt.Sprintf("rate-file=%s", b.rateFile))
+	}
+
+	cmd := exec.Command("nbdkit", append(args, b.filename)...)
+
 	return &NbdkitServer{
 		cmd:      cmd,
 		socket:   socket,
 		pidFile:  pidFile,
 		cacheDir: tmp,
+		logFile:  b.logFile,
 	}, nil
 }
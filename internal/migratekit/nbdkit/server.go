@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 )
 
@@ -14,22 +15,42 @@ type NbdkitServer struct {
 	cmd      *exec.Cmd
 	socket   string
 	pidFile  string
+	logFile  string
 }
 
 func (s *NbdkitServer) Start() error {
 	log := slog.With(slog.String("command", "nbdkit"))
-	stdout := bytes.Buffer{}
-	stderr := bytes.Buffer{}
-	s.cmd.Stdout = &stdout
-	s.cmd.Stderr = &stderr
+	if s.logFile != "" {
+		err := os.MkdirAll(filepath.Dir(s.logFile), 0o755)
+		if err != nil {
+			return fmt.Errorf("failed to create nbdkit log directory: %w", err)
+		}
 
-	log.Info("Running command", slog.Any("args", s.cmd))
-	defer func() {
-		log.Debug("Command ended", slog.Any("stdout", stdout.String()))
-		if len(stderr.String()) > 0 {
-			log.Error("Command errored", slog.Any("stderr", stderr.String()))
+		f, err := os.OpenFile(s.logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open nbdkit log file: %w", err)
 		}
-	}()
+
+		// nbdkit inherits its own copy of the file descriptor.
+		defer f.Close()
+
+		s.cmd.Stdout = f
+		s.cmd.Stderr = f
+	} else {
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		s.cmd.Stdout = &stdout
+		s.cmd.Stderr = &stderr
+
+		defer func() {
+			log.Debug("Command ended", slog.Any("stdout", stdout.String()))
+			if len(stderr.String()) > 0 {
+				log.Error("Command errored", slog.Any("stderr", stderr.String()))
+			}
+		}()
+	}
+
+	log.Info("Running command", slog.Any("args", s.cmd), slog.String("log", s.logFile))
 
 	if err := s.cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start nbdkit server: %w", err)
@@ -2,11 +2,7 @@ package target
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"io/fs"
 	"os"
-	"strings"
 
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
@@ -18,13 +14,17 @@ type DiskTarget struct {
 	VirtualMachine *object.VirtualMachine
 	Disk           *types.VirtualDisk
 	DeviceTarget   string
+
+	// ChangeID is the change ID of the last sync to the target, as recorded by the migration manager.
+	ChangeID string
 }
 
-func NewDiskTarget(vm *object.VirtualMachine, disk *types.VirtualDisk, deviceTarget string) (*DiskTarget, error) {
+func NewDiskTarget(vm *object.VirtualMachine, disk *types.VirtualDisk, deviceTarget string, changeID string) (*DiskTarget, error) {
 	return &DiskTarget{
 		VirtualMachine: vm,
 		Disk:           disk,
 		DeviceTarget:   deviceTarget,
+		ChangeID:       changeID,
 	}, nil
 }
 
@@ -53,16 +53,10 @@ func (t *DiskTarget) Exists(ctx context.Context) (bool, error) {
 }
 
 func (t *DiskTarget) GetCurrentChangeID(ctx context.Context) (*vmware.ChangeID, error) {
-	diskParts := strings.Split(t.DeviceTarget, "/")
-	data, err := os.ReadFile(fmt.Sprintf("/tmp/migration-manager_%s.cid", diskParts[len(diskParts)-1]))
-	if err != nil && !errors.Is(err, fs.ErrNotExist) {
-		return nil, err
-	}
-
-	return vmware.ParseChangeID(string(data))
+	return vmware.ParseChangeID(t.ChangeID)
 }
 
 func (t *DiskTarget) WriteChangeID(ctx context.Context, changeID *vmware.ChangeID) error {
-	diskParts := strings.Split(t.DeviceTarget, "/")
-	return os.WriteFile(fmt.Sprintf("/tmp/migration-manager_%s.cid", diskParts[len(diskParts)-1]), []byte(changeID.Value), 0o644)
+	t.ChangeID = changeID.Value
+	return nil
 }
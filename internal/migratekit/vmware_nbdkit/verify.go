@@ -0,0 +1,157 @@
+package vmware_nbdkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/FuturFusion/migration-manager/internal/migratekit/progress"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// VerifyBlockSize is the size of the blocks compared when verifying a disk.
+const VerifyBlockSize = 1024 * 1024
+
+// VerifySamples is the number of blocks compared when verifying a disk in sampled mode.
+const VerifySamples = 1024
+
+// verifyBlocks returns the offsets of the blocks to compare in the given mode.
+// In sampled mode, the disk is split into VerifySamples equal ranges, and one block is picked at random from each.
+func verifyBlocks(size int64, mode api.VerificationMode) []int64 {
+	blocks := (size + VerifyBlockSize - 1) / VerifyBlockSize
+	if mode == api.VERIFICATIONMODE_FULL || blocks <= VerifySamples {
+		offsets := make([]int64, 0, blocks)
+		for i := range blocks {
+			offsets = append(offsets, i*VerifyBlockSize)
+		}
+
+		return offsets
+	}
+
+	offsets := make([]int64, 0, VerifySamples)
+	for i := range int64(VerifySamples) {
+		start := i * blocks / VerifySamples
+		end := (i + 1) * blocks / VerifySamples
+		offsets = append(offsets, (start+rand.Int64N(end-start))*VerifyBlockSize)
+	}
+
+	return offsets
+}
+
+// VerifyTarget compares blocks of the target disk with the NBD export of the source snapshot, and returns the result of the comparison.
+func (s *NbdkitServer) VerifyTarget(ctx context.Context, path string, mode api.VerificationMode, statusCallback func(string, bool)) (*api.WorkerDiskVerification, error) {
+	diskName, index, err := s.diskInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	log := slog.With(
+		slog.String("vm", s.Servers.VirtualMachine.Name()),
+		slog.String("disk", diskName),
+		slog.String("mode", string(mode)),
+	)
+
+	log.Info("Starting disk verification")
+
+	handles, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeHandles(handles)
+
+	// Read the target directly, so that it is compared as written to the disk rather than from the page cache.
+	fd, err := os.OpenFile(path, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	offsets := verifyBlocks(s.Disk.CapacityInBytes, mode)
+	digests := make([][sha256.Size]byte, len(offsets))
+	bar := progress.DataProgressBar("Verification", int64(len(offsets)))
+
+	var progressLock sync.Mutex
+	verified := 0
+	result := &api.WorkerDiskVerification{Mode: mode}
+	indexes := make(chan int)
+
+	grp, grpCtx := errgroup.WithContext(ctx)
+	for _, handle := range handles {
+		grp.Go(func() error {
+			sourceBuf := make([]byte, VerifyBlockSize)
+			targetBuf := make([]byte, VerifyBlockSize)
+			for i := range indexes {
+				offset := offsets[i]
+				size := min(VerifyBlockSize, s.Disk.CapacityInBytes-offset)
+
+				err := handle.Pread(sourceBuf[:size], uint64(offset), nil)
+				if err != nil {
+					return fmt.Errorf("Failed to read source disk %q at offset %d: %w", diskName, offset, err)
+				}
+
+				_, err = fd.ReadAt(targetBuf[:size], offset)
+				if err != nil {
+					return fmt.Errorf("Failed to read target disk %q at offset %d: %w", diskName, offset, err)
+				}
+
+				digests[i] = sha256.Sum256(sourceBuf[:size])
+				match := bytes.Equal(sourceBuf[:size], targetBuf[:size])
+
+				progressLock.Lock()
+				result.VerifiedBytes += size
+				if !match {
+					result.MismatchedBlocks++
+					log.Warn("Disk block mismatch", slog.Int64("offset", offset), slog.Int64("size", size))
+				}
+
+				verified++
+				bar.Add(1)
+				statusCallback(fmt.Sprintf("Verifying disk (%d/%d) %q: %02.2f%% complete", index, len(s.Servers.Servers), diskName, float64(verified)/float64(len(offsets))*100.0), false)
+				progressLock.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	grp.Go(func() error {
+		defer close(indexes)
+		for i := range offsets {
+			select {
+			case indexes <- i:
+			case <-grpCtx.Done():
+				return grpCtx.Err()
+			}
+		}
+
+		return nil
+	})
+
+	err = grp.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	// Combine the block digests in disk order, so the checksum does not depend on the order blocks were read in.
+	h := sha256.New()
+	for _, digest := range digests {
+		h.Write(digest[:])
+	}
+
+	result.Checksum = hex.EncodeToString(h.Sum(nil))
+
+	log.Info("Disk verification completed", slog.Int64("verifiedBytes", result.VerifiedBytes), slog.Int64("mismatchedBlocks", result.MismatchedBlocks))
+
+	return result, nil
+}
@@ -1,8 +1,10 @@
 package vmware_nbdkit
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,26 +14,34 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/schollz/progressbar/v3"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sys/unix"
 	"libguestfs.org/libnbd"
 
 	"github.com/FuturFusion/migration-manager/internal"
-	"github.com/FuturFusion/migration-manager/internal/migratekit/nbdcopy"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/nbdkit"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/progress"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/target"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/vmware"
 	"github.com/FuturFusion/migration-manager/internal/util"
+	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
 const MaxChunkSize = 64 * 1024 * 1024
 
+// CheckpointSize is the amount of data a full copy writes between checkpoints.
+const CheckpointSize = 1024 * 1024 * 1024
+
 type VddkConfig struct {
 	Debug       bool
 	Endpoint    *url.URL
@@ -46,6 +56,29 @@ type NbdkitServers struct {
 	Servers        []*NbdkitServer
 	StatusCallback func(string, bool)
 	SDKPath        string
+	RateFile       string
+	Limits         api.WorkerTransferLimits
+
+	// LogDir is where the output of the nbdkit server of each disk is written, if set.
+	LogDir string
+
+	// States holds the sync state of each disk, keyed by disk name. It is updated as disks are synced and checkpointed.
+	States map[string]api.WorkerDiskState
+
+	// Verification is how disks are compared with the source snapshot once they have been synced.
+	Verification api.VerificationMode
+
+	// CheckpointCallback is called with the state of a disk whenever a full copy of it reaches a checkpoint.
+	CheckpointCallback func(api.WorkerDiskState)
+
+	// Dropped lists the names of the source disks that are not synced to the target.
+	Dropped []string
+
+	// DiskLocator returns the path of the target device for a disk, and whether it is the root disk.
+	// If nil, the disks are looked up among the devices of the VM the worker is running in.
+	DiskLocator func(ctx context.Context, diskName string) (string, bool, error)
+
+	statesLock sync.Mutex
 }
 
 type NbdkitServer struct {
@@ -54,13 +87,43 @@ type NbdkitServer struct {
 	Nbdkit  *nbdkit.NbdkitServer
 }
 
-func NewNbdkitServers(vddk *VddkConfig, vm *object.VirtualMachine, sdkPath string, statusCallback func(string, bool)) *NbdkitServers {
+func NewNbdkitServers(vddk *VddkConfig, vm *object.VirtualMachine, sdkPath string, limits api.WorkerTransferLimits, statusCallback func(string, bool)) *NbdkitServers {
 	return &NbdkitServers{
 		VddkConfig:     vddk,
 		VirtualMachine: vm,
 		Servers:        []*NbdkitServer{},
 		StatusCallback: statusCallback,
 		SDKPath:        sdkPath,
+		States:         map[string]api.WorkerDiskState{},
+		Limits: api.WorkerTransferLimits{
+			Disks:       max(limits.Disks, 1),
+			Connections: max(limits.Connections, 1),
+		},
+	}
+}
+
+func (s *NbdkitServers) state(diskName string) (api.WorkerDiskState, bool) {
+	s.statesLock.Lock()
+	defer s.statesLock.Unlock()
+
+	state, ok := s.States[diskName]
+	return state, ok
+}
+
+func (s *NbdkitServers) setState(state api.WorkerDiskState) {
+	s.statesLock.Lock()
+	defer s.statesLock.Unlock()
+
+	s.States[state.Name] = state
+}
+
+// checkpoint records the state of a partially copied disk, and reports it to the checkpoint callback.
+// The callback is called without holding the state lock, so that a slow report doesn't hold up the other disks.
+func (s *NbdkitServers) checkpoint(state api.WorkerDiskState) {
+	s.setState(state)
+
+	if s.CheckpointCallback != nil {
+		s.CheckpointCallback(state)
 	}
 }
 
@@ -89,6 +152,9 @@ func (s *NbdkitServers) createSnapshot(ctx context.Context) error {
 }
 
 func (s *NbdkitServers) Start(ctx context.Context, validate func(d []*types.VirtualDisk) error) error {
+	// Servers of a previous attempt were stopped along with it, so only track the ones started now.
+	s.Servers = nil
+
 	err := s.createSnapshot(ctx)
 	if err != nil {
 		return err
@@ -121,11 +187,21 @@ func (s *NbdkitServers) Start(ctx context.Context, validate func(d []*types.Virt
 			return err
 		}
 
+		if slices.Contains(s.Dropped, diskName) {
+			slog.Info("Skipping dropped disk", slog.String("disk", diskName))
+			continue
+		}
+
 		// Use the latest snapshot vmdk as the disk source so we traverse all snapshots.
 		if len(snapshotTree) > 0 {
 			diskName = snapshotTree[0]
 		}
 
+		logFile := ""
+		if s.LogDir != "" {
+			logFile = filepath.Join(s.LogDir, fmt.Sprintf("nbdkit-%d.log", disk.Key))
+		}
+
 		password, _ := s.VddkConfig.Endpoint.User.Password()
 		server, err := nbdkit.NewNbdkitBuilder().
 			Server(s.VddkConfig.Endpoint.Host).
@@ -137,6 +213,8 @@ func (s *NbdkitServers) Start(ctx context.Context, validate func(d []*types.Virt
 			Filename(diskName).
 			Compression(s.VddkConfig.Compression).
 			SDK(s.SDKPath).
+			RateFile(s.RateFile).
+			LogFile(logFile).
 			Build()
 		if err != nil {
 			return err
@@ -153,20 +231,6 @@ func (s *NbdkitServers) Start(ctx context.Context, validate func(d []*types.Virt
 		})
 	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		slog.Warn("Received interrupt signal, cleaning up...")
-
-		err := s.Stop(ctx)
-		if err != nil {
-			slog.Error("Failed to stop nbdkit servers", slog.Any("error", err))
-		}
-
-		os.Exit(1)
-	}()
-
 	return nil
 }
 
@@ -269,66 +333,173 @@ func getIncusDisk(ctx context.Context, client *http.Client, diskName string) (st
 	return "", false, fmt.Errorf("Failed to find disk with ID %q", diskID)
 }
 
-func (s *NbdkitServers) MigrationCycle(ctx context.Context, diskValidator func([]*types.VirtualDisk) error, runV2V bool) error {
+// MigrationCycle syncs all disks of the VM to their targets, and returns statistics about the data copied for each disk
+// along with the resulting sync state of each disk. Up to Limits.Disks disks are synced concurrently.
+func (s *NbdkitServers) MigrationCycle(ctx context.Context, diskValidator func([]*types.VirtualDisk) error, runV2V bool) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
 	err := s.Start(ctx, diskValidator)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	defer func() {
-		err := s.Stop(ctx)
+		// Remove the snapshot even if the import was aborted by canceling the context.
+		err := s.Stop(context.WithoutCancel(ctx))
 		if err != nil {
 			slog.Error("Failed to stop nbdkit servers", slog.Any("error", err))
 		}
 	}()
 
-	devIncus := util.UnixHTTPClient("/dev/incus/sock")
+	type diskSync struct {
+		server *NbdkitServer
+		target target.Target
+		runV2V bool
+	}
+
+	locator := s.DiskLocator
+	if locator == nil {
+		devIncus := util.UnixHTTPClient("/dev/incus/sock")
+		locator = func(ctx context.Context, diskName string) (string, bool, error) {
+			return getIncusDisk(ctx, devIncus, diskName)
+		}
+	}
+
+	disks := make([]diskSync, 0, len(s.Servers))
 	for _, server := range s.Servers {
 		diskName, _, err := vmware.IsSupportedDisk(server.Disk)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		diskID, isRoot, err := getIncusDisk(ctx, devIncus, diskName)
+		diskID, isRoot, err := locator(ctx, diskName)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		if isRoot {
 			runV2V = false
 		}
 
-		t, err := target.NewDiskTarget(s.VirtualMachine, server.Disk, diskID)
+		state, _ := s.state(diskName)
+		t, err := target.NewDiskTarget(s.VirtualMachine, server.Disk, diskID, state.ChangeID)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		err = server.SyncToTarget(ctx, t, runV2V, s.StatusCallback)
+		disks = append(disks, diskSync{server: server, target: t, runV2V: runV2V})
+	}
+
+	// A single interrupt handler for the whole cycle cleans up all disks and servers before exiting.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	defer func() {
+		signal.Stop(signals)
+		close(done)
+	}()
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		slog.Warn("Received interrupt signal, cleaning up...")
+		for _, disk := range disks {
+			err := disk.target.Disconnect(ctx)
+			if err != nil {
+				slog.Error("Failed to disconnect from target", slog.Any("error", err))
+			}
+		}
+
+		err := s.Stop(context.WithoutCancel(ctx))
 		if err != nil {
-			return err
+			slog.Error("Failed to stop nbdkit servers", slog.Any("error", err))
 		}
+
+		os.Exit(1)
+	}()
+
+	// Serialize status updates from disks being copied at the same time.
+	var statusLock sync.Mutex
+	statusCallback := func(status string, isImportant bool) {
+		statusLock.Lock()
+		defer statusLock.Unlock()
+
+		s.StatusCallback(status, isImportant)
 	}
 
-	return nil
-}
+	syncs := make([]api.WorkerDiskSync, len(disks))
+	states := make([]api.WorkerDiskState, len(disks))
+	grp, grpCtx := errgroup.WithContext(ctx)
+	grp.SetLimit(s.Limits.Disks)
+	for i, disk := range disks {
+		grp.Go(func() error {
+			result, state, err := disk.server.SyncToTarget(grpCtx, disk.target, disk.runV2V, statusCallback)
+			if err != nil {
+				return err
+			}
 
-func (s *NbdkitServer) FullCopyToTarget(t target.Target, path string, targetIsClean bool, statusCallback func(string, bool)) error {
-	diskName, _, err := vmware.IsSupportedDisk(s.Disk)
+			syncs[i] = *result
+			states[i] = *state
+			return nil
+		})
+	}
+
+	err = grp.Wait()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	log := slog.With(
-		slog.String("vm", s.Servers.VirtualMachine.Name()),
-		slog.String("disk", diskName),
-	)
+	return syncs, states, nil
+}
+
+// chunk is a range of the disk that is copied with a single NBD request.
+type chunk struct {
+	offset int64
+	size   int64
+}
+
+// copyProgress tracks the data written to a target, and reports it through the status callback.
+type copyProgress struct {
+	bar            *progressbar.ProgressBar
+	message        string
+	diskName       string
+	size           int64
+	statusCallback func(string, bool)
+
+	// byOffset reports progress as the highest offset written rather than the amount of data written,
+	// for copies whose total amount of data isn't known up front.
+	byOffset bool
+
+	copied        int64
+	highestOffset int64
+}
+
+func (p *copyProgress) add(c chunk) {
+	p.copied += c.size
+	p.highestOffset = max(p.highestOffset, c.offset+c.size)
+
+	done := p.copied
+	if p.byOffset {
+		done = p.highestOffset
+	}
+
+	p.bar.Set64(done)
+	p.statusCallback(fmt.Sprintf("%s %q: %02.2f%% complete", p.message, p.diskName, float64(done)/float64(max(p.size, 1))*100.0), false)
+}
 
-	log.Info("Starting full copy")
+// diskInfo returns the name of the disk, and its position among the disks being imported.
+func (s *NbdkitServer) diskInfo() (string, int, error) {
+	diskName, _, err := vmware.IsSupportedDisk(s.Disk)
+	if err != nil {
+		return "", 0, err
+	}
 
 	index := 1
 	for i, server := range s.Servers.Servers {
 		serverDiskName, _, err := vmware.IsSupportedDisk(server.Disk)
 		if err != nil {
-			return err
+			return "", 0, err
 		}
 
 		if serverDiskName == diskName {
@@ -337,42 +508,147 @@ func (s *NbdkitServer) FullCopyToTarget(t target.Target, path string, targetIsCl
 		}
 	}
 
-	msg := fmt.Sprintf("Importing disk (%d/%d)", index, len(s.Servers.Servers))
-	err = nbdcopy.Run(
-		msg,
-		s.Nbdkit.LibNBDExportName(),
-		path,
-		s.Disk.CapacityInBytes,
-		targetIsClean,
-		diskName,
-		statusCallback,
-	)
-	if err != nil {
-		return err
+	return diskName, index, nil
+}
+
+// connect opens one NBD connection to the export per concurrent request.
+func (s *NbdkitServer) connect() ([]*libnbd.Libnbd, error) {
+	handles := make([]*libnbd.Libnbd, 0, s.Servers.Limits.Connections)
+	for range s.Servers.Limits.Connections {
+		handle, err := libnbd.Create()
+		if err != nil {
+			closeHandles(handles)
+			return nil, err
+		}
+
+		handles = append(handles, handle)
+		err = handle.ConnectUri(s.Nbdkit.LibNBDExportName())
+		if err != nil {
+			closeHandles(handles)
+			return nil, err
+		}
 	}
 
-	log.Info("Full copy completed")
+	return handles, nil
+}
 
-	return nil
+func closeHandles(handles []*libnbd.Libnbd) {
+	for _, handle := range handles {
+		_ = handle.Close()
+	}
 }
 
-func (s *NbdkitServer) IncrementalCopyToTarget(ctx context.Context, t target.Target, path string, statusCallback func(string, bool)) error {
-	diskName, _, err := vmware.IsSupportedDisk(s.Disk)
-	if err != nil {
-		return err
+// openDevice opens the raw disk device of the target for writing.
+func openDevice(path string) (*os.File, error) {
+	// We have removed os.O_EXCL, as it was causing some weird failure when attempting to perform followup incremental disk syncs.
+	// For our use, we know nothing else in the migration environment will be doing anything with the raw disk device.
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_DIRECT, 0o644)
+}
+
+// nbdReader reads from an NBD export. Pread either fills the whole buffer or fails, so a short read surfaces as an error.
+type nbdReader interface {
+	Pread(buf []byte, offset uint64, optargs *libnbd.PreadOptargs) error
+}
+
+// copyChunks copies the chunks sent by produce from the NBD export to the same offsets of fd, with one worker per NBD connection.
+// Chunks that only contain zeroes are not written if skipZeroes is set.
+//
+// This replaces nbdcopy, which copies a whole export in a single run. Copying in-process lets the full copy be split into
+// extents that are flushed and checkpointed one at a time, and lets the incremental and resumed copies share the same workers.
+func copyChunks[R nbdReader](ctx context.Context, handles []R, fd *os.File, skipZeroes bool, produce func(context.Context, chan<- chunk) error, p *copyProgress) error {
+	chunks := make(chan chunk)
+	var progressLock sync.Mutex
+
+	grp, grpCtx := errgroup.WithContext(ctx)
+	for _, handle := range handles {
+		grp.Go(func() error {
+			buf := make([]byte, MaxChunkSize)
+			for c := range chunks {
+				err := handle.Pread(buf[:c.size], uint64(c.offset), nil)
+				if err != nil {
+					return err
+				}
+
+				if !skipZeroes || slices.ContainsFunc(buf[:c.size], func(b byte) bool { return b != 0 }) {
+					_, err = fd.WriteAt(buf[:c.size], c.offset)
+					if err != nil {
+						return err
+					}
+				}
+
+				progressLock.Lock()
+				p.add(c)
+				progressLock.Unlock()
+			}
+
+			return nil
+		})
 	}
 
-	index := 1
-	for i, server := range s.Servers.Servers {
-		serverDiskName, _, err := vmware.IsSupportedDisk(server.Disk)
+	grp.Go(func() error {
+		defer close(chunks)
+		return produce(grpCtx, chunks)
+	})
+
+	return grp.Wait()
+}
+
+// sendRange splits the range of the disk between start and end into chunks.
+func sendRange(ctx context.Context, chunks chan<- chunk, start int64, end int64) error {
+	for offset := start; offset < end; offset += MaxChunkSize {
+		select {
+		case chunks <- chunk{offset: offset, size: min(MaxChunkSize, end-offset)}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// sendChangedAreas sends the areas of the disk below end that changed since the given change ID.
+func (s *NbdkitServer) sendChangedAreas(ctx context.Context, chunks chan<- chunk, changeID string, end int64) error {
+	startOffset := int64(0)
+	for startOffset < end {
+		req := types.QueryChangedDiskAreas{
+			This:        s.Servers.VirtualMachine.Reference(),
+			Snapshot:    &s.Servers.SnapshotRef,
+			DeviceKey:   s.Disk.Key,
+			StartOffset: startOffset,
+			ChangeId:    changeID,
+		}
+
+		res, err := methods.QueryChangedDiskAreas(ctx, s.Servers.VirtualMachine.Client(), &req)
 		if err != nil {
-			return err
+			return fmt.Errorf("Failed to query disk changes: %w", err)
 		}
 
-		if serverDiskName == diskName {
-			index = i + 1
-			break
+		diskChangeInfo := res.Returnval
+		for _, area := range diskChangeInfo.ChangedArea {
+			err := sendRange(ctx, chunks, area.Start, min(area.Start+area.Length, end))
+			if err != nil {
+				return err
+			}
 		}
+
+		startOffset = diskChangeInfo.StartOffset + diskChangeInfo.Length
+	}
+
+	return nil
+}
+
+// FullCopyToTarget copies the whole disk to the target in extents of CheckpointSize, and calls checkpoint with the end of each
+// extent once it has been flushed to the target. The end of the disk is not checkpointed, as the caller records the completed sync.
+//
+// Only the areas of the disk that are allocated on the source are copied. Unless the target is known to be clean, the rest of
+// each extent is zeroed on the target.
+//
+// If resumeFrom is set, the target already matches the disk as of its change ID up to its synced offset. Only the areas that
+// changed since then are copied below that offset, and the copy continues from there.
+func (s *NbdkitServer) FullCopyToTarget(ctx context.Context, path string, targetIsClean bool, resumeFrom *api.WorkerDiskState, statusCallback func(string, bool), checkpoint func(int64)) (int64, error) {
+	diskName, index, err := s.diskInfo()
+	if err != nil {
+		return 0, err
 	}
 
 	log := slog.With(
@@ -380,86 +656,312 @@ func (s *NbdkitServer) IncrementalCopyToTarget(ctx context.Context, t target.Tar
 		slog.String("disk", diskName),
 	)
 
-	log.Info("Starting incremental copy")
-
-	currentChangeId, err := t.GetCurrentChangeID(ctx)
+	handles, err := s.connect()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	handle, err := libnbd.Create()
+	defer closeHandles(handles)
+
+	fd, err := openDevice(path)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = handle.ConnectUri(s.Nbdkit.LibNBDExportName())
+	defer fd.Close()
+
+	message := fmt.Sprintf("Importing disk (%d/%d)", index, len(s.Servers.Servers))
+	start := int64(0)
+	resumedBytes := int64(0)
+	if resumeFrom != nil {
+		start = resumeFrom.SyncedOffset
+		log.Info("Resuming full copy", slog.Int64("offset", start), slog.String("changeID", resumeFrom.ChangeID))
+
+		// Changes below the checkpoint are spread across that part of the disk, so report how far through it the copy is.
+		resumed := &copyProgress{
+			bar:            progress.DataProgressBar("Resumed changes", start),
+			message:        message,
+			diskName:       diskName,
+			size:           start,
+			byOffset:       true,
+			statusCallback: statusCallback,
+		}
+
+		err = copyChunks(ctx, handles, fd, false, func(ctx context.Context, chunks chan<- chunk) error {
+			return s.sendChangedAreas(ctx, chunks, resumeFrom.ChangeID, start)
+		}, resumed)
+		if err != nil {
+			return 0, err
+		}
+
+		resumedBytes = resumed.copied
+	} else {
+		log.Info("Starting full copy")
+	}
+
+	allocated, err := s.allocatedAreas(ctx, start)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("Failed to get allocated areas of disk %q: %w", diskName, err)
 	}
 
-	// We have removed os.O_EXCL, as it was causing some weird failure when attempting to perform followup incremental disk syncs.
-	// For our use, we know nothing else in the migration environment will be doing anything with the raw disk device.
-	fd, err := os.OpenFile(path, os.O_WRONLY|syscall.O_DIRECT, 0o644)
+	allocatedBytes := int64(0)
+	for _, area := range allocated {
+		allocatedBytes += area.Length
+	}
+
+	log.Info("Copying allocated areas", slog.Int64("allocatedBytes", allocatedBytes), slog.Int64("capacity", s.Disk.CapacityInBytes))
+
+	p := &copyProgress{
+		bar:            progress.DataProgressBar("Full copy", allocatedBytes),
+		message:        message,
+		diskName:       diskName,
+		size:           allocatedBytes,
+		statusCallback: statusCallback,
+	}
+
+	for offset := start; offset < s.Disk.CapacityInBytes; offset += CheckpointSize {
+		end := min(offset+CheckpointSize, s.Disk.CapacityInBytes)
+		areas := clipAreas(allocated, offset, end)
+		err := copyChunks(ctx, handles, fd, targetIsClean, func(ctx context.Context, chunks chan<- chunk) error {
+			for _, area := range areas {
+				err := sendRange(ctx, chunks, area.Start, area.Start+area.Length)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}, p)
+		if err != nil {
+			return 0, err
+		}
+
+		if !targetIsClean {
+			err = zeroUnallocated(fd, areas, offset, end)
+			if err != nil {
+				return 0, fmt.Errorf("Failed to zero unallocated areas of disk %q: %w", diskName, err)
+			}
+		}
+
+		if end == s.Disk.CapacityInBytes {
+			break
+		}
+
+		// Only checkpoint data that has reached the disk.
+		err = fd.Sync()
+		if err != nil {
+			return 0, fmt.Errorf("Failed to flush disk %q: %w", diskName, err)
+		}
+
+		checkpoint(end)
+	}
+
+	err = fd.Sync()
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("Failed to flush disk %q: %w", diskName, err)
 	}
-	defer fd.Close()
 
-	startOffset := int64(0)
-	bar := progress.DataProgressBar("Incremental copy", s.Disk.CapacityInBytes)
+	p.bar.Set64(allocatedBytes)
+	log.Info("Full copy completed")
+
+	return resumedBytes + p.copied, nil
+}
 
-	for {
+// allocatedAreas returns the areas of the disk from start onwards that are allocated on the source, using the special change ID "*".
+// If the source can't report allocated areas, for example because CBT is disabled, the whole range is treated as allocated.
+func (s *NbdkitServer) allocatedAreas(ctx context.Context, start int64) ([]types.DiskChangeExtent, error) {
+	var queryErr error
+	areas, err := collectAllocatedAreas(s.Disk.CapacityInBytes, func(startOffset int64) (types.DiskChangeInfo, error) {
 		req := types.QueryChangedDiskAreas{
 			This:        s.Servers.VirtualMachine.Reference(),
 			Snapshot:    &s.Servers.SnapshotRef,
 			DeviceKey:   s.Disk.Key,
 			StartOffset: startOffset,
-			ChangeId:    currentChangeId.Value,
+			ChangeId:    "*",
 		}
 
 		res, err := methods.QueryChangedDiskAreas(ctx, s.Servers.VirtualMachine.Client(), &req)
 		if err != nil {
-			return fmt.Errorf("Failed to query disk changes: %w", err)
+			queryErr = err
+			return types.DiskChangeInfo{}, err
 		}
 
-		diskChangeInfo := res.Returnval
+		return res.Returnval, nil
+	})
+	if queryErr != nil {
+		slog.Warn("Failed to query allocated disk areas, copying the whole disk", slog.String("vm", s.Servers.VirtualMachine.Name()), slog.Any("error", queryErr))
+		return []types.DiskChangeExtent{{Start: start, Length: s.Disk.CapacityInBytes - start}}, nil
+	}
 
-		for _, area := range diskChangeInfo.ChangedArea {
-			for offset := area.Start; offset < area.Start+area.Length; {
-				chunkSize := area.Length - (offset - area.Start)
-				if chunkSize > MaxChunkSize {
-					chunkSize = MaxChunkSize
-				}
+	if err != nil {
+		return nil, err
+	}
 
-				buf := make([]byte, chunkSize)
-				err = handle.Pread(buf, uint64(offset), nil)
-				if err != nil {
-					return err
-				}
+	return clipAreas(areas, start, s.Disk.CapacityInBytes), nil
+}
 
-				_, err = fd.WriteAt(buf, offset)
-				if err != nil {
-					return err
-				}
+// collectAllocatedAreas pages through the allocated areas of a disk with the given capacity.
+// Each reply must move past the offset it was queried with, otherwise an error is returned rather than querying the same offset forever.
+func collectAllocatedAreas(capacity int64, query func(startOffset int64) (types.DiskChangeInfo, error)) ([]types.DiskChangeExtent, error) {
+	var areas []types.DiskChangeExtent
+	startOffset := int64(0)
+	for startOffset < capacity {
+		info, err := query(startOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		next := info.StartOffset + info.Length
+		if next <= startOffset {
+			return nil, fmt.Errorf("Allocated disk areas query made no progress at offset %d", startOffset)
+		}
+
+		areas = append(areas, info.ChangedArea...)
+		startOffset = next
+	}
 
-				bar.Set64(offset + chunkSize)
-				statusCallback(fmt.Sprintf("Importing disk (%d/%d) %q: %02.2f%% complete", index, len(s.Servers.Servers), diskName, float64(offset+chunkSize)/float64(s.Disk.CapacityInBytes)*100.0), false)
-				offset += chunkSize
+	return areas, nil
+}
+
+// clipAreas returns the parts of the given areas that lie between start and end, sorted by offset with overlapping areas merged.
+func clipAreas(areas []types.DiskChangeExtent, start int64, end int64) []types.DiskChangeExtent {
+	sorted := slices.SortedFunc(slices.Values(areas), func(a types.DiskChangeExtent, b types.DiskChangeExtent) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	clipped := []types.DiskChangeExtent{}
+	for _, area := range sorted {
+		areaStart := max(area.Start, start)
+		areaEnd := min(area.Start+area.Length, end)
+		if areaStart >= areaEnd {
+			continue
+		}
+
+		last := len(clipped) - 1
+		if last >= 0 && areaStart <= clipped[last].Start+clipped[last].Length {
+			clipped[last].Length = max(clipped[last].Length, areaEnd-clipped[last].Start)
+			continue
+		}
+
+		clipped = append(clipped, types.DiskChangeExtent{Start: areaStart, Length: areaEnd - areaStart})
+	}
+
+	return clipped
+}
+
+// zeroUnallocated zeroes the parts of the range between start and end of the target that are not covered by the allocated areas.
+func zeroUnallocated(fd *os.File, allocated []types.DiskChangeExtent, start int64, end int64) error {
+	offset := start
+	for _, area := range clipAreas(allocated, start, end) {
+		if area.Start > offset {
+			err := zeroRange(fd, offset, area.Start-offset)
+			if err != nil {
+				return err
 			}
 		}
 
-		startOffset = diskChangeInfo.StartOffset + diskChangeInfo.Length
-		bar.Set64(startOffset)
+		offset = area.Start + area.Length
+	}
 
-		if startOffset == s.Disk.CapacityInBytes {
-			break
+	if offset < end {
+		return zeroRange(fd, offset, end-offset)
+	}
+
+	return nil
+}
+
+// zeroRange discards a range of the target, which reads back as zeroes afterwards.
+// If discarding isn't supported, zeroes are written instead.
+func zeroRange(fd *os.File, offset int64, length int64) error {
+	err := unix.Fallocate(int(fd.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, length)
+	if err == nil || !errors.Is(err, unix.EOPNOTSUPP) {
+		return err
+	}
+
+	// Allocate a full chunk, so that the buffer is aligned for direct I/O.
+	buf := make([]byte, MaxChunkSize)
+	for written := int64(0); written < length; {
+		n, err := fd.WriteAt(buf[:min(int64(len(buf)), length-written)], offset+written)
+		if err != nil {
+			return err
 		}
+
+		written += int64(n)
 	}
 
 	return nil
 }
 
-func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V bool, statusCallback func(string, bool)) error {
+func (s *NbdkitServer) IncrementalCopyToTarget(ctx context.Context, t target.Target, path string, statusCallback func(string, bool)) (int64, error) {
+	diskName, index, err := s.diskInfo()
+	if err != nil {
+		return 0, err
+	}
+
+	log := slog.With(
+		slog.String("vm", s.Servers.VirtualMachine.Name()),
+		slog.String("disk", diskName),
+	)
+
+	log.Info("Starting incremental copy")
+
+	currentChangeId, err := t.GetCurrentChangeID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	handles, err := s.connect()
+	if err != nil {
+		return 0, err
+	}
+
+	defer closeHandles(handles)
+
+	fd, err := openDevice(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer fd.Close()
+
+	p := &copyProgress{
+		bar:            progress.DataProgressBar("Incremental copy", s.Disk.CapacityInBytes),
+		message:        fmt.Sprintf("Importing disk (%d/%d)", index, len(s.Servers.Servers)),
+		diskName:       diskName,
+		size:           s.Disk.CapacityInBytes,
+		byOffset:       true,
+		statusCallback: statusCallback,
+	}
+
+	err = copyChunks(ctx, handles, fd, false, func(ctx context.Context, chunks chan<- chunk) error {
+		return s.sendChangedAreas(ctx, chunks, currentChangeId.Value, s.Disk.CapacityInBytes)
+	}, p)
+	if err != nil {
+		return 0, err
+	}
+
+	p.bar.Set64(s.Disk.CapacityInBytes)
+
+	return p.copied, nil
+}
+
+// interruptedCopy returns whether the last full copy of the disk was interrupted, and the checkpoint to resume it from if possible.
+// Resuming relies on CBT to find what changed below the checkpoint, so the checkpoint's change ID must still be valid for the disk.
+func (s *NbdkitServer) interruptedCopy(diskName string, snapshotChangeID *vmware.ChangeID) (bool, *api.WorkerDiskState) {
+	state, ok := s.Servers.state(diskName)
+	if !ok || state.SyncedOffset <= 0 || state.SyncedOffset >= s.Disk.CapacityInBytes {
+		return false, nil
+	}
+
+	changeID, err := vmware.ParseChangeID(state.ChangeID)
+	if err != nil || changeID.UUID != snapshotChangeID.UUID {
+		return true, nil
+	}
+
+	return true, &state
+}
+
+func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V bool, statusCallback func(string, bool)) (*api.WorkerDiskSync, *api.WorkerDiskState, error) {
 	snapshotChangeId, err := vmware.GetChangeID(s.Disk)
 	if err != nil {
 		// Rather than returning an error when CBT isn't enabled, just proceed with a dummy change ID.
@@ -473,43 +975,67 @@ func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V
 
 	needFullCopy, targetIsClean, err := target.NeedsFullCopy(ctx, t)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	err = t.Connect(ctx)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	defer t.Disconnect(ctx)
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		slog.Warn("Received interrupt signal, cleaning up...")
+	path, err := t.GetPath(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		err := t.Disconnect(ctx)
-		if err != nil {
-			slog.Error("Failed to disconnect from target", slog.Any("error", err))
-		}
+	diskName, _, err := vmware.IsSupportedDisk(s.Disk)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		os.Exit(1)
-	}()
+	// The change ID of an interrupted full copy only covers the data up to its checkpoint, so it can't be synced incrementally.
+	interrupted, resumeFrom := s.interruptedCopy(diskName, snapshotChangeId)
+	if interrupted {
+		needFullCopy = true
+	}
 
-	path, err := t.GetPath(ctx)
-	if err != nil {
-		return err
+	if targetIsClean {
+		resumeFrom = nil
 	}
 
+	checkpoint := func(offset int64) {
+		state := api.WorkerDiskState{
+			Name:         diskName,
+			ChangeID:     snapshotChangeId.Value,
+			SnapshotRef:  s.Servers.SnapshotRef.Value,
+			SyncedOffset: offset,
+		}
+
+		s.Servers.checkpoint(state)
+	}
+
+	diskSync := &api.WorkerDiskSync{Name: diskName, FullCopy: needFullCopy}
+	start := time.Now()
 	if needFullCopy {
-		err = s.FullCopyToTarget(t, path, targetIsClean, statusCallback)
+		diskSync.CopiedBytes, err = s.FullCopyToTarget(ctx, path, targetIsClean, resumeFrom, statusCallback, checkpoint)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	} else {
-		err = s.IncrementalCopyToTarget(ctx, t, path, statusCallback)
+		diskSync.CopiedBytes, err = s.IncrementalCopyToTarget(ctx, t, path, statusCallback)
 		if err != nil {
-			return err
+			return nil, nil, err
+		}
+	}
+
+	diskSync.Duration = api.AsDuration(time.Since(start))
+
+	// Verify the disk before virt-v2v modifies it.
+	if s.Servers.Verification.Enabled() {
+		diskSync.Verification, err = s.VerifyTarget(ctx, path, s.Servers.Verification, statusCallback)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -530,19 +1056,33 @@ func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V
 
 		err := cmd.Run()
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		err = t.WriteChangeID(ctx, &vmware.ChangeID{})
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	} else {
 		err = t.WriteChangeID(ctx, snapshotChangeId)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
-	return nil
+	state := &api.WorkerDiskState{
+		Name:         diskName,
+		ChangeID:     snapshotChangeId.Value,
+		SnapshotRef:  s.Servers.SnapshotRef.Value,
+		SyncedOffset: s.Disk.CapacityInBytes,
+	}
+
+	// Running virt-v2v modifies the disk, so the next sync must not be incremental.
+	if runV2V {
+		state.ChangeID = ""
+	}
+
+	s.Servers.setState(*state)
+
+	return diskSync, state, nil
 }
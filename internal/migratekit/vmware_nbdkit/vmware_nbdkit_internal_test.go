@@ -0,0 +1,350 @@
+package vmware_nbdkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi/vim25/types"
+	"libguestfs.org/libnbd"
+
+	"github.com/FuturFusion/migration-manager/internal/migratekit/progress"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestClipAreas(t *testing.T) {
+	tests := []struct {
+		name  string
+		areas []types.DiskChangeExtent
+		start int64
+		end   int64
+
+		wantAreas []types.DiskChangeExtent
+	}{
+		{
+			name:      "empty",
+			start:     0,
+			end:       100,
+			wantAreas: []types.DiskChangeExtent{},
+		},
+		{
+			name:      "inside range",
+			areas:     []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 30, Length: 10}},
+			start:     0,
+			end:       100,
+			wantAreas: []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 30, Length: 10}},
+		},
+		{
+			name:      "clipped at both ends",
+			areas:     []types.DiskChangeExtent{{Start: 0, Length: 20}, {Start: 40, Length: 20}},
+			start:     10,
+			end:       50,
+			wantAreas: []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 40, Length: 10}},
+		},
+		{
+			name:      "outside range",
+			areas:     []types.DiskChangeExtent{{Start: 0, Length: 10}, {Start: 50, Length: 10}},
+			start:     10,
+			end:       50,
+			wantAreas: []types.DiskChangeExtent{},
+		},
+		{
+			name:      "unsorted",
+			areas:     []types.DiskChangeExtent{{Start: 50, Length: 10}, {Start: 10, Length: 10}},
+			start:     0,
+			end:       100,
+			wantAreas: []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 50, Length: 10}},
+		},
+		{
+			name:      "overlapping and adjacent are merged",
+			areas:     []types.DiskChangeExtent{{Start: 10, Length: 20}, {Start: 20, Length: 5}, {Start: 25, Length: 10}, {Start: 35, Length: 5}},
+			start:     0,
+			end:       100,
+			wantAreas: []types.DiskChangeExtent{{Start: 10, Length: 30}},
+		},
+		{
+			name:      "zero-length areas are dropped",
+			areas:     []types.DiskChangeExtent{{Start: 10, Length: 0}, {Start: 20, Length: 10}},
+			start:     0,
+			end:       100,
+			wantAreas: []types.DiskChangeExtent{{Start: 20, Length: 10}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.wantAreas, clipAreas(tc.areas, tc.start, tc.end))
+		})
+	}
+}
+
+func TestZeroUnallocated(t *testing.T) {
+	tests := []struct {
+		name      string
+		allocated []types.DiskChangeExtent
+		start     int64
+		end       int64
+
+		wantZeroed []types.DiskChangeExtent
+	}{
+		{
+			name:       "empty",
+			start:      0,
+			end:        100,
+			wantZeroed: []types.DiskChangeExtent{{Start: 0, Length: 100}},
+		},
+		{
+			name:       "fully allocated",
+			allocated:  []types.DiskChangeExtent{{Start: 0, Length: 100}},
+			start:      0,
+			end:        100,
+			wantZeroed: nil,
+		},
+		{
+			name:       "gaps between areas",
+			allocated:  []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 50, Length: 10}},
+			start:      0,
+			end:        100,
+			wantZeroed: []types.DiskChangeExtent{{Start: 0, Length: 10}, {Start: 20, Length: 30}, {Start: 60, Length: 40}},
+		},
+		{
+			name:       "unsorted",
+			allocated:  []types.DiskChangeExtent{{Start: 50, Length: 10}, {Start: 10, Length: 10}},
+			start:      0,
+			end:        100,
+			wantZeroed: []types.DiskChangeExtent{{Start: 0, Length: 10}, {Start: 20, Length: 30}, {Start: 60, Length: 40}},
+		},
+		{
+			name:       "overlapping",
+			allocated:  []types.DiskChangeExtent{{Start: 10, Length: 30}, {Start: 20, Length: 5}},
+			start:      0,
+			end:        100,
+			wantZeroed: []types.DiskChangeExtent{{Start: 0, Length: 10}, {Start: 40, Length: 60}},
+		},
+		{
+			name:       "only within range",
+			allocated:  []types.DiskChangeExtent{{Start: 0, Length: 30}, {Start: 80, Length: 40}},
+			start:      20,
+			end:        90,
+			wantZeroed: []types.DiskChangeExtent{{Start: 30, Length: 50}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "disk")
+			data := bytes.Repeat([]byte{0xff}, 120)
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+
+			fd, err := os.OpenFile(path, os.O_RDWR, 0)
+			require.NoError(t, err)
+			defer func() { _ = fd.Close() }()
+
+			require.NoError(t, zeroUnallocated(fd, tc.allocated, tc.start, tc.end))
+
+			for _, area := range tc.wantZeroed {
+				copy(data[area.Start:area.Start+area.Length], make([]byte, area.Length))
+			}
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		})
+	}
+}
+
+func TestCollectAllocatedAreas(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies []types.DiskChangeInfo
+
+		wantAreas []types.DiskChangeExtent
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - several pages",
+			replies: []types.DiskChangeInfo{
+				{StartOffset: 0, Length: 50, ChangedArea: []types.DiskChangeExtent{{Start: 10, Length: 10}}},
+				{StartOffset: 50, Length: 50, ChangedArea: []types.DiskChangeExtent{{Start: 60, Length: 10}}},
+			},
+			wantAreas: []types.DiskChangeExtent{{Start: 10, Length: 10}, {Start: 60, Length: 10}},
+			assertErr: require.NoError,
+		},
+		{
+			name:      "error - zero-length reply",
+			replies:   []types.DiskChangeInfo{{StartOffset: 0, Length: 0}},
+			assertErr: require.Error,
+		},
+		{
+			name: "error - reply moves backwards",
+			replies: []types.DiskChangeInfo{
+				{StartOffset: 0, Length: 50},
+				{StartOffset: 0, Length: 40},
+			},
+			assertErr: require.Error,
+		},
+		{
+			name:      "error - query fails",
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			areas, err := collectAllocatedAreas(100, func(startOffset int64) (types.DiskChangeInfo, error) {
+				if calls >= len(tc.replies) {
+					return types.DiskChangeInfo{}, errors.New("boom!")
+				}
+
+				calls++
+				return tc.replies[calls-1], nil
+			})
+
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantAreas, areas)
+		})
+	}
+}
+
+// fakeNBDReader serves reads from an in-memory export, failing reads that extend past its end like libnbd does.
+type fakeNBDReader struct {
+	data []byte
+	err  error
+}
+
+func (r fakeNBDReader) Pread(buf []byte, offset uint64, _ *libnbd.PreadOptargs) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if offset+uint64(len(buf)) > uint64(len(r.data)) {
+		return io.ErrUnexpectedEOF
+	}
+
+	copy(buf, r.data[offset:])
+	return nil
+}
+
+func TestCopyChunks(t *testing.T) {
+	export := append(bytes.Repeat([]byte{0xaa}, 40), make([]byte, 20)...)
+	export = append(export, bytes.Repeat([]byte{0xbb}, 40)...)
+
+	tests := []struct {
+		name       string
+		chunks     []chunk
+		readErr    error
+		produceErr error
+		skipZeroes bool
+		readOnly   bool
+
+		wantData   []byte
+		wantCopied int64
+		assertErr  require.ErrorAssertionFunc
+	}{
+		{
+			name:       "success - chunks of different sizes",
+			chunks:     []chunk{{offset: 0, size: 64}, {offset: 64, size: 36}},
+			wantData:   export,
+			wantCopied: 100,
+			assertErr:  require.NoError,
+		},
+		{
+			name:       "success - zero chunks are skipped",
+			chunks:     []chunk{{offset: 0, size: 40}, {offset: 40, size: 20}, {offset: 60, size: 40}},
+			skipZeroes: true,
+			wantData:   append(append(bytes.Repeat([]byte{0xaa}, 40), bytes.Repeat([]byte{0xff}, 20)...), bytes.Repeat([]byte{0xbb}, 40)...),
+			wantCopied: 100,
+			assertErr:  require.NoError,
+		},
+		{
+			name:      "error - short read past the end of the export",
+			chunks:    []chunk{{offset: 80, size: 40}},
+			assertErr: func(tt require.TestingT, err error, a ...any) { require.ErrorIs(tt, err, io.ErrUnexpectedEOF, a...) },
+		},
+		{
+			name:      "error - read fails",
+			chunks:    []chunk{{offset: 0, size: 10}},
+			readErr:   errors.New("boom!"),
+			assertErr: require.Error,
+		},
+		{
+			name:      "error - write fails",
+			chunks:    []chunk{{offset: 0, size: 10}},
+			readOnly:  true,
+			assertErr: require.Error,
+		},
+		{
+			name:       "error - producer fails",
+			produceErr: errors.New("boom!"),
+			assertErr:  require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "disk")
+			require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0xff}, len(export)), 0o600))
+
+			flag := os.O_RDWR
+			if tc.readOnly {
+				flag = os.O_RDONLY
+			}
+
+			fd, err := os.OpenFile(path, flag, 0)
+			require.NoError(t, err)
+			defer func() { _ = fd.Close() }()
+
+			handles := []fakeNBDReader{{data: export, err: tc.readErr}, {data: export, err: tc.readErr}}
+			p := &copyProgress{bar: progress.DataProgressBar("Test", int64(len(export))), size: int64(len(export)), statusCallback: func(string, bool) {}}
+
+			err = copyChunks(context.Background(), handles, fd, tc.skipZeroes, func(ctx context.Context, chunks chan<- chunk) error {
+				for _, c := range tc.chunks {
+					select {
+					case chunks <- c:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+
+				return tc.produceErr
+			}, p)
+			tc.assertErr(t, err)
+			if err != nil {
+				return
+			}
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantData, got)
+			require.Equal(t, tc.wantCopied, p.copied)
+		})
+	}
+}
+
+func TestNbdkitServers_checkpoint(t *testing.T) {
+	s := &NbdkitServers{States: map[string]api.WorkerDiskState{}}
+
+	var reported []api.WorkerDiskState
+	s.CheckpointCallback = func(state api.WorkerDiskState) {
+		// Other disks must be able to record their state while a checkpoint is being reported.
+		s.setState(api.WorkerDiskState{Name: "other", SyncedOffset: state.SyncedOffset})
+
+		recorded, ok := s.state(state.Name)
+		require.True(t, ok)
+		require.Equal(t, state, recorded)
+
+		reported = append(reported, state)
+	}
+
+	state := api.WorkerDiskState{Name: "[ds] disk.vmdk", ChangeID: "52 d1/4", SyncedOffset: 1024}
+	s.checkpoint(state)
+
+	require.Equal(t, []api.WorkerDiskState{state}, reported)
+	require.Equal(t, api.WorkerDiskState{Name: "other", SyncedOffset: 1024}, s.States["other"])
+}
@@ -2,6 +2,8 @@ package migration
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +33,10 @@ func (a Artifact) Validate() error {
 		return NewValidationErrf("Artifact has invalid UUID %q", a.UUID)
 	}
 
+	if a.Type != api.ARTIFACTTYPE_SCRIPT && (len(a.Properties.Distributions) > 0 || a.Properties.Order != 0) {
+		return NewValidationErrf("Artifact of type %q does not support distributions or order", a.Type)
+	}
+
 	switch a.Type {
 	case api.ARTIFACTTYPE_DRIVER:
 		if a.Properties.SourceType != "" {
@@ -97,6 +103,48 @@ func (a Artifact) Validate() error {
 			return NewValidationErrf("Artifact has invalid OS %q", a.Properties.OS)
 		}
 
+	case api.ARTIFACTTYPE_SCRIPT:
+		if a.Properties.SourceType != "" {
+			return NewValidationErrf("Artifact does not support a source type")
+		}
+
+		if a.Properties.Order < 0 {
+			return NewValidationErrf("Artifact order must not be negative")
+		}
+
+		// Scripts without architectures apply to all architectures.
+		for _, arch := range a.Properties.Architectures {
+			_, err := osarch.ArchitectureID(arch)
+			if err != nil {
+				return NewValidationErrf("Architecture %q is not supported", arch)
+			}
+		}
+
+		switch a.Properties.OS {
+		case api.OSTYPE_LINUX:
+			for _, d := range a.Properties.Distributions {
+				err := api.ValidateDistribution(a.Properties.OS, string(d))
+				if err != nil {
+					return NewValidationErrf("Artifact distribution is invalid: %v", err)
+				}
+			}
+
+		case api.OSTYPE_WINDOWS:
+			if len(a.Properties.Distributions) > 0 {
+				return NewValidationErrf("Artifact for OS %q does not support distributions", a.Properties.OS)
+			}
+
+			for _, v := range a.Properties.Versions {
+				err := util.ValidateWindowsVersion(v)
+				if err != nil {
+					return NewValidationErrf("Artifact version is invalid for OS %q: %v", a.Properties.OS, err)
+				}
+			}
+
+		default:
+			return NewValidationErrf("Artifact has invalid OS %q", a.Properties.OS)
+		}
+
 	case api.ARTIFACTTYPE_SDK:
 		if a.Properties.SourceType != api.SOURCETYPE_VMWARE {
 			return NewValidationErrf("Artifact source type %q is not supported", a.Properties.SourceType)
@@ -122,6 +170,11 @@ func (a Artifact) Validate() error {
 }
 
 func (a Artifact) CollidesWith(arts Artifacts) error {
+	// Any number of scripts can apply to the same VM, so they never collide.
+	if a.Type == api.ARTIFACTTYPE_SCRIPT {
+		return nil
+	}
+
 	archMap := map[string]bool{}
 	verMap := map[string]bool{}
 
@@ -162,6 +215,34 @@ func (a Artifact) CollidesWith(arts Artifacts) error {
 	return nil
 }
 
+// MatchesInstance returns whether the script artifact applies to the instance, after applying overrides.
+func (a Artifact) MatchesInstance(inst Instance) bool {
+	distro, version := inst.GetDistribution(true)
+
+	return util.MatchScriptArtifact(a.ToAPI(), inst.GetOSType(true), distro, version, inst.GetArchitecture())
+}
+
+// ScriptsForInstance returns the script artifacts that apply to the instance, sorted by the order they run in.
+func (a Artifacts) ScriptsForInstance(inst Instance) Artifacts {
+	scripts := Artifacts{}
+	for _, art := range a {
+		if art.MatchesInstance(inst) {
+			scripts = append(scripts, art)
+		}
+	}
+
+	// Scripts with the same order run in a stable, if arbitrary, order.
+	slices.SortFunc(scripts, func(a Artifact, b Artifact) int {
+		if a.Properties.Order != b.Properties.Order {
+			return a.Properties.Order - b.Properties.Order
+		}
+
+		return strings.Compare(a.UUID.String(), b.UUID.String())
+	})
+
+	return scripts
+}
+
 func (a Artifact) ToAPI() api.Artifact {
 	return api.Artifact{
 		ArtifactPost: api.ArtifactPost{
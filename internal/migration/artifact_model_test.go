@@ -0,0 +1,69 @@
+package migration_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestArtifacts_ScriptsForInstance(t *testing.T) {
+	script := func(id string, osType api.OSType, order int, archs ...string) migration.Artifact {
+		return migration.Artifact{
+			UUID:       uuid.MustParse(id),
+			Type:       api.ARTIFACTTYPE_SCRIPT,
+			Properties: api.ArtifactPut{OS: osType, Order: order, Architectures: archs},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		artifacts migration.Artifacts
+		instance  migration.Instance
+
+		wantUUIDs []string
+	}{
+		{
+			name: "success - no scripts",
+			artifacts: migration.Artifacts{
+				{UUID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Type: api.ARTIFACTTYPE_DRIVER, Properties: api.ArtifactPut{OS: api.OSTYPE_WINDOWS}},
+			},
+			instance: migration.Instance{Properties: api.InstanceProperties{OS: "Windows"}},
+
+			wantUUIDs: []string{},
+		},
+		{
+			name: "success - matching scripts sorted by order, then UUID",
+			artifacts: migration.Artifacts{
+				script("00000000-0000-0000-0000-000000000003", api.OSTYPE_LINUX, 10),
+				script("00000000-0000-0000-0000-000000000004", api.OSTYPE_LINUX, 0),
+				script("00000000-0000-0000-0000-000000000002", api.OSTYPE_LINUX, 10),
+				script("00000000-0000-0000-0000-000000000005", api.OSTYPE_WINDOWS, 0),
+				script("00000000-0000-0000-0000-000000000006", api.OSTYPE_LINUX, 0, "aarch64"),
+			},
+			instance: migration.Instance{Properties: api.InstanceProperties{OS: "Ubuntu", InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Architecture: "x86_64"}}},
+
+			wantUUIDs: []string{
+				"00000000-0000-0000-0000-000000000004",
+				"00000000-0000-0000-0000-000000000002",
+				"00000000-0000-0000-0000-000000000003",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scripts := tc.artifacts.ScriptsForInstance(tc.instance)
+
+			uuids := []string{}
+			for _, s := range scripts {
+				uuids = append(uuids, s.UUID.String())
+			}
+
+			require.Equal(t, tc.wantUUIDs, uuids)
+		})
+	}
+}
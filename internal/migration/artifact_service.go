@@ -174,6 +174,12 @@ func (a artifactService) HasRequiredArtifactsForInstance(artifacts Artifacts, in
 				osArtifactExists = true
 			}
 
+		case api.ARTIFACTTYPE_SCRIPT:
+			// Scripts are optional, but any that match must have content or the worker will fail to run them.
+			if art.MatchesInstance(inst) && !slices.Contains(art.Files, requiredFile) {
+				return fmt.Errorf("Failed to find content for %q artifact %q", art.Type, art.UUID)
+			}
+
 		case api.ARTIFACTTYPE_SDK:
 			if !sdkArtifactExists && art.Properties.SourceType == inst.SourceType {
 				if !slices.Contains(art.Files, requiredFile) {
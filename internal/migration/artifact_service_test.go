@@ -48,6 +48,24 @@ func TestArtifact_Create(t *testing.T) {
 			},
 			assertErr: require.NoError,
 		},
+		{
+			name: "success - linux script",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_LINUX, Distributions: []api.Distro{api.DISTRO_UBUNTU}, Versions: []string{"24.04"}, Order: 10},
+			},
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - windows script without architecture",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_WINDOWS},
+			},
+			assertErr: require.NoError,
+		},
 		{
 			name: "error - vmware sdk with version",
 			artifact: migration.Artifact{
@@ -154,6 +172,51 @@ func TestArtifact_Create(t *testing.T) {
 			},
 			assertErr: require.Error,
 		},
+		{
+			name: "error - script with invalid distribution",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_LINUX, Distributions: []api.Distro{"plan9"}},
+			},
+			assertErr: require.Error,
+		},
+		{
+			name: "error - windows script with distribution",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_WINDOWS, Distributions: []api.Distro{api.DISTRO_UBUNTU}},
+			},
+			assertErr: require.Error,
+		},
+		{
+			name: "error - fortigate script",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_FORTIGATE},
+			},
+			assertErr: require.Error,
+		},
+		{
+			name: "error - script with negative order",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_SCRIPT,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_LINUX, Order: -1},
+			},
+			assertErr: require.Error,
+		},
+		{
+			name: "error - driver with order",
+			artifact: migration.Artifact{
+				UUID:       uuid.New(),
+				Type:       api.ARTIFACTTYPE_DRIVER,
+				Properties: api.ArtifactPut{OS: api.OSTYPE_WINDOWS, Architectures: []string{"x86_64"}, Order: 1},
+			},
+			assertErr: require.Error,
+		},
 	}
 
 	for i, tc := range cases {
@@ -243,6 +306,17 @@ func TestArtifact_HasRequiredArtifactsForInstance(t *testing.T) {
 				InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Architecture: "x86_64"},
 			}},
 		},
+		{
+			name:      "success - linux with script for other OS missing content",
+			assertErr: require.NoError,
+			artifacts: []api.Artifact{
+				{ArtifactPost: api.ArtifactPost{Type: api.ARTIFACTTYPE_SCRIPT, ArtifactPut: api.ArtifactPut{OS: api.OSTYPE_WINDOWS}}},
+				{
+					ArtifactPost: api.ArtifactPost{Type: api.ARTIFACTTYPE_SCRIPT, ArtifactPut: api.ArtifactPut{OS: api.OSTYPE_LINUX}},
+					Files:        []string{"script.sh"},
+				},
+			},
+		},
 		{
 			name:      "error - fortigate from vmware (missing sdk)",
 			assertErr: require.Error,
@@ -328,6 +402,13 @@ func TestArtifact_HasRequiredArtifactsForInstance(t *testing.T) {
 			},
 			instance: migration.Instance{SourceType: api.SOURCETYPE_VMWARE, Properties: api.InstanceProperties{OS: "Windows", InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{Architecture: "x86_64"}}},
 		},
+		{
+			name:      "error - linux with matching script missing content",
+			assertErr: require.Error,
+			artifacts: []api.Artifact{
+				{ArtifactPost: api.ArtifactPost{Type: api.ARTIFACTTYPE_SCRIPT, ArtifactPut: api.ArtifactPut{OS: api.OSTYPE_LINUX}}},
+			},
+		},
 		{
 			name:      "error - linux from vmware (no sdk)",
 			assertErr: require.Error,
@@ -453,6 +534,15 @@ func TestArtifact_collidesWith(t *testing.T) {
 			artifact:  art([]string{"v1"}, []string{"a1"}, api.ARTIFACTTYPE_DRIVER, api.OSTYPE_WINDOWS, ""),
 			assertErr: require.NoError,
 		},
+		{
+			name: "success - scripts never collide",
+			existing: migration.Artifacts{
+				art(nil, nil, api.ARTIFACTTYPE_SCRIPT, api.OSTYPE_LINUX, ""),
+			},
+
+			artifact:  art(nil, nil, api.ARTIFACTTYPE_SCRIPT, api.OSTYPE_LINUX, ""),
+			assertErr: require.NoError,
+		},
 		//
 		// Errors
 		//
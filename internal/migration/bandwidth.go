@@ -0,0 +1,135 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lxc/incus/v7/shared/units"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+var bandwidthDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func validateBandwidthPolicy(policy api.BandwidthPolicy) error {
+	_, err := parseBandwidthLimit(policy.Limit)
+	if err != nil {
+		return err
+	}
+
+	_, err = time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return fmt.Errorf("Invalid timezone %q: %w", policy.Timezone, err)
+	}
+
+	for _, schedule := range policy.Schedules {
+		_, err := parseBandwidthLimit(schedule.Limit)
+		if err != nil {
+			return err
+		}
+
+		start, err := parseTimeOfDay(schedule.Start)
+		if err != nil {
+			return err
+		}
+
+		end, err := parseTimeOfDay(schedule.End)
+		if err != nil {
+			return err
+		}
+
+		if start == end {
+			return fmt.Errorf("Invalid bandwidth schedule %s-%s: Start and end must differ", schedule.Start, schedule.End)
+		}
+
+		for _, day := range schedule.Days {
+			if !slices.Contains(bandwidthDays, strings.ToLower(day)) {
+				return fmt.Errorf("Invalid day %q, must be one of %v", day, bandwidthDays)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseBandwidthLimit returns the number of bytes per second of a bandwidth limit, or 0 if unlimited.
+func parseBandwidthLimit(limit string) (int64, error) {
+	if limit == "" {
+		return 0, nil
+	}
+
+	bytes, err := units.ParseByteSizeString(limit)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid bandwidth limit %q: %w", limit, err)
+	}
+
+	if bytes < 0 {
+		return 0, fmt.Errorf("Invalid bandwidth limit %q: Must not be negative", limit)
+	}
+
+	return bytes, nil
+}
+
+// parseTimeOfDay returns the time since midnight of a time in HH:MM format.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time of day %q: %w", value, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// BandwidthLimitAt returns the bandwidth limit in bytes per second that the policy sets at the given time, or 0 if it is unlimited.
+func BandwidthLimitAt(policy api.BandwidthPolicy, now time.Time) (int64, error) {
+	loc, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid timezone %q: %w", policy.Timezone, err)
+	}
+
+	now = now.In(loc)
+	sinceMidnight := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
+	today := bandwidthDays[now.Weekday()]
+	yesterday := bandwidthDays[(now.Weekday()+6)%7]
+
+	for _, schedule := range policy.Schedules {
+		start, err := parseTimeOfDay(schedule.Start)
+		if err != nil {
+			return 0, err
+		}
+
+		end, err := parseTimeOfDay(schedule.End)
+		if err != nil {
+			return 0, err
+		}
+
+		appliesOn := func(day string) bool {
+			return len(schedule.Days) == 0 || slices.ContainsFunc(schedule.Days, func(d string) bool { return strings.EqualFold(d, day) })
+		}
+
+		var active bool
+		if start <= end {
+			active = appliesOn(today) && sinceMidnight >= start && sinceMidnight < end
+		} else {
+			// The range wraps past midnight, so the early hours belong to the previous day's schedule.
+			active = (appliesOn(today) && sinceMidnight >= start) || (appliesOn(yesterday) && sinceMidnight < end)
+		}
+
+		if active {
+			return parseBandwidthLimit(schedule.Limit)
+		}
+	}
+
+	return parseBandwidthLimit(policy.Limit)
+}
+
+// shareBandwidth divides a bandwidth limit between the given number of concurrent transfers.
+func shareBandwidth(limit int64, transfers int) int64 {
+	if limit <= 0 {
+		return 0
+	}
+
+	return max(limit/int64(max(transfers, 1)), 1)
+}
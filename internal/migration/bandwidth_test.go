@@ -0,0 +1,141 @@
+package migration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestBandwidthLimitAt(t *testing.T) {
+	businessHours := api.BandwidthPolicy{
+		Schedules: []api.BandwidthSchedule{
+			{Start: "08:00", End: "18:00", Days: []string{"mon", "tue", "wed", "thu", "fri"}, Limit: "50MB"},
+		},
+	}
+
+	overnight := api.BandwidthPolicy{
+		Limit: "10MB",
+		Schedules: []api.BandwidthSchedule{
+			{Start: "22:00", End: "06:00", Days: []string{"Fri"}},
+		},
+	}
+
+	// 2025-01-06 is a Monday.
+	monday := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy api.BandwidthPolicy
+		now    time.Time
+
+		assertErr require.ErrorAssertionFunc
+		wantLimit int64
+	}{
+		{
+			name:   "success - no policy",
+			policy: api.BandwidthPolicy{},
+			now:    monday,
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - default limit",
+			policy: api.BandwidthPolicy{Limit: "1MiB"},
+			now:    monday,
+
+			assertErr: require.NoError,
+			wantLimit: 1024 * 1024,
+		},
+		{
+			name:   "success - within business hours",
+			policy: businessHours,
+			now:    monday.Add(9 * time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 50_000_000,
+		},
+		{
+			name:   "success - end of business hours",
+			policy: businessHours,
+			now:    monday.Add(18 * time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - business hours on the weekend",
+			policy: businessHours,
+			now:    monday.Add(-2*24*time.Hour + 9*time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - business hours in another timezone",
+			policy: api.BandwidthPolicy{Timezone: "America/New_York", Schedules: businessHours.Schedules},
+			now:    monday.Add(9 * time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - overnight schedule before midnight",
+			policy: overnight,
+			now:    monday.Add(-3*24*time.Hour + 23*time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - overnight schedule after midnight",
+			policy: overnight,
+			now:    monday.Add(-2*24*time.Hour + 5*time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 0,
+		},
+		{
+			name:   "success - outside overnight schedule",
+			policy: overnight,
+			now:    monday.Add(-2*24*time.Hour + 23*time.Hour),
+
+			assertErr: require.NoError,
+			wantLimit: 10_000_000,
+		},
+		{
+			name:   "error - invalid limit",
+			policy: api.BandwidthPolicy{Limit: "fast"},
+			now:    monday,
+
+			assertErr: require.Error,
+		},
+		{
+			name:   "error - invalid schedule time",
+			policy: api.BandwidthPolicy{Schedules: []api.BandwidthSchedule{{Start: "8am", End: "18:00"}}},
+			now:    monday,
+
+			assertErr: require.Error,
+		},
+		{
+			name:   "error - invalid timezone",
+			policy: api.BandwidthPolicy{Timezone: "Mars/Olympus_Mons"},
+			now:    monday,
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, err := migration.BandwidthLimitAt(tc.policy, tc.now)
+
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantLimit, limit)
+		})
+	}
+}
@@ -93,6 +93,53 @@ func (b *Batch) GetIncusPlacement(instance Instance, usedNetworks Networks, plac
 		resp.StoragePools[disk] = pool
 	}
 
+	// Disk policies from the instance overrides take precedence over the placement.
+	for disk, policy := range placement.Disks {
+		if resp.Disks == nil {
+			resp.Disks = map[string]api.DiskPolicy{}
+		}
+
+		resp.Disks[disk] = policy
+	}
+
+	for disk, policy := range instance.Overrides.Disks {
+		if resp.Disks == nil {
+			resp.Disks = map[string]api.DiskPolicy{}
+		}
+
+		resp.Disks[disk] = policy
+	}
+
+	// Grow any disks without their own growth policy by the batch default.
+	if b.Config.DiskGrowth != "" {
+		for _, d := range instance.Properties.Disks {
+			if !d.Supported {
+				continue
+			}
+
+			if resp.Disks == nil {
+				resp.Disks = map[string]api.DiskPolicy{}
+			}
+
+			policy := resp.Disks[d.Name]
+			if policy.Grow == "" {
+				policy.Grow = b.Config.DiskGrowth
+				resp.Disks[d.Name] = policy
+			}
+		}
+	}
+
+	for disk, policy := range resp.Disks {
+		err := validateDiskPolicy(disk, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(instance.Properties.Disks) > 0 && instance.Properties.Disks[0].Name == disk && (policy.Drop || policy.Detach) {
+			return nil, fmt.Errorf("Root disk %q of instance %q can not be dropped or detached", disk, instance.GetName())
+		}
+	}
+
 	return resp, nil
 }
 
@@ -230,6 +277,41 @@ func (b Batch) Validate() error {
 		return NewValidationErrf("Final background sync limit %q cannot be greater than the background sync interval %q", b.Config.FinalBackgroundSyncLimit, b.Config.BackgroundSyncInterval)
 	}
 
+	err = validateBandwidthPolicy(b.Config.Bandwidth)
+	if err != nil {
+		return NewValidationErrf("Invalid batch bandwidth: %v", err)
+	}
+
+	err = b.Config.Verification.Validate()
+	if err != nil {
+		return NewValidationErrf("Invalid batch verification: %v", err)
+	}
+
+	err = b.Config.ImportMode.Validate()
+	if err != nil {
+		return NewValidationErrf("Invalid batch import mode: %v", err)
+	}
+
+	err = validateSourceCleanupPolicy(b.Config.SourceCleanup)
+	if err != nil {
+		return NewValidationErrf("Invalid batch source cleanup: %v", err)
+	}
+
+	err = validateGuestCustomization(b.Config.GuestCustomization)
+	if err != nil {
+		return NewValidationErrf("Invalid batch guest customization: %v", err)
+	}
+
+	err = validateShutdownPolicy(b.Config.Shutdown)
+	if err != nil {
+		return NewValidationErrf("Invalid batch shutdown policy: %v", err)
+	}
+
+	_, err = DiskCapacity(api.DiskPolicy{Grow: b.Config.DiskGrowth}, 0)
+	if err != nil {
+		return NewValidationErrf("Invalid batch disk growth: %v", err)
+	}
+
 	return nil
 }
 
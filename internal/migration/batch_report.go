@@ -0,0 +1,105 @@
+package migration
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// NewBatchReport compiles the migration report of a batch from its queue entries, their recorded history, and any warnings raised for their sources.
+// Queue entries without a matching instance are skipped.
+func NewBatchReport(b Batch, queue QueueEntries, instances Instances, history QueueHistoryEntries, warnings Warnings, now time.Time) api.BatchReport {
+	instancesByUUID := make(map[uuid.UUID]Instance, len(instances))
+	for _, inst := range instances {
+		instancesByUUID[inst.UUID] = inst
+	}
+
+	historyByUUID := map[uuid.UUID]QueueHistoryEntries{}
+	for _, h := range history {
+		// An instance may have been queued by an earlier batch, so only consider the history of this one.
+		if h.BatchName != b.Name {
+			continue
+		}
+
+		historyByUUID[h.InstanceUUID] = append(historyByUUID[h.InstanceUUID], h)
+	}
+
+	report := api.BatchReport{
+		Batch:       b.Name,
+		Status:      b.Status,
+		GeneratedAt: now,
+		Instances:   make([]api.BatchReportInstance, 0, len(queue)),
+	}
+
+	for _, q := range queue {
+		inst, ok := instancesByUUID[q.InstanceUUID]
+		if !ok {
+			continue
+		}
+
+		// Prefer the measured downtime, falling back to the time between status changes for migrations that did not record it.
+		instHistory := historyByUUID[q.InstanceUUID]
+		downtime := q.Cutover.Downtime
+		if downtime.Duration == 0 {
+			downtime = api.AsDuration(instHistory.Downtime())
+		}
+
+		instWarnings := []string{}
+		for _, w := range warnings {
+			if w.Entity != inst.Source {
+				continue
+			}
+
+			for _, msg := range w.Messages {
+				if warningMentionsInstance(msg, inst) {
+					instWarnings = append(instWarnings, msg)
+				}
+			}
+		}
+
+		report.Instances = append(report.Instances, api.BatchReportInstance{
+			UUID:                   inst.UUID,
+			Name:                   inst.GetName(),
+			Source:                 inst.Source,
+			Location:               inst.Properties.Location,
+			Placement:              q.Placement,
+			MigrationStatus:        q.MigrationStatus,
+			MigrationStatusMessage: q.MigrationStatusMessage,
+			Phases:                 instHistory.Phases(),
+			BytesTransferred:       q.BytesTransferred(),
+			Downtime:               downtime,
+			Warnings:               instWarnings,
+			Comment:                inst.Overrides.Comment,
+		})
+	}
+
+	return report
+}
+
+// warningMentionsInstance reports whether any quoted value in the warning message is exactly the instance's location or UUID.
+// Sync warnings are recorded per source and quote the identifiers of the instances they concern.
+func warningMentionsInstance(msg string, inst Instance) bool {
+	for {
+		_, rest, ok := strings.Cut(msg, `"`)
+		if !ok {
+			return false
+		}
+
+		quoted, err := strconv.QuotedPrefix(`"` + rest)
+		if err != nil {
+			msg = rest
+			continue
+		}
+
+		value, err := strconv.Unquote(quoted)
+		if err == nil && (value == inst.Properties.Location || value == inst.UUID.String()) {
+			return true
+		}
+
+		msg = rest[len(quoted)-1:]
+	}
+}
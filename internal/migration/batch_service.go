@@ -591,7 +591,7 @@ func (s batchService) ResetBatchByName(ctx context.Context, name string, queueSv
 
 		for _, q := range entries {
 			if q.MigrationStatus == api.MIGRATIONSTATUS_BACKGROUND_IMPORT || q.MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT {
-				sourceSvc.RemoveActiveImport(instMap[q.InstanceUUID].Source)
+				sourceSvc.RemoveActiveImport(instMap[q.InstanceUUID])
 				targetSvc.RemoveActiveImport(q.Placement.TargetName)
 			}
 		}
@@ -125,6 +125,28 @@ func TestBatchService_Create(t *testing.T) {
 				require.ErrorAs(tt, err, &verr, a...)
 			},
 		},
+		{
+			name: "error - bandwidth schedule with equal start and end",
+			batch: migration.Batch{
+				ID:                1,
+				Name:              "one",
+				Defaults:          defaultPlacement,
+				IncludeExpression: "true",
+				Status:            api.BATCHSTATUS_DEFINED,
+				Config: api.BatchConfig{
+					BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
+					FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
+					Bandwidth: api.BandwidthPolicy{
+						Schedules: []api.BandwidthSchedule{{Start: "08:00", End: "08:00", Limit: "10MB"}}, // empty window
+					},
+				},
+			},
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
 		{
 			name: "error - repo",
 			batch: migration.Batch{
@@ -1805,10 +1827,12 @@ func TestBatchService_DeterminePlacement(t *testing.T) {
 	type netMap map[string]api.NetworkPlacement
 	netProps := []byte(`{"vlan_id": 1}`)
 	cases := []struct {
-		name      string
-		scriptlet string
-		instance  api.InstanceProperties
-		networks  migration.Networks
+		name       string
+		scriptlet  string
+		diskGrowth string
+		instance   api.InstanceProperties
+		overrides  api.InstanceOverride
+		networks   migration.Networks
 
 		batchCreateAssertErr require.ErrorAssertionFunc
 		placementAssertErr   require.ErrorAssertionFunc
@@ -1946,6 +1970,130 @@ def placement(instance, batch):
 			batchCreateAssertErr: require.NoError,
 			placementAssertErr:   require.NoError,
 		},
+		{
+			name:     "success - with scriptlet disk policies",
+			instance: api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}, {Name: "disk2", Supported: true}, {Name: "disk3", Supported: true}}},
+			networks: migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("disk1", grow="20%", config={"zfs.blocksize": "64KiB"})
+			set_disk("disk2", drop=True)
+			set_disk("disk3", detach=True)
+			`,
+
+			placement: api.Placement{
+				TargetName:    "default",
+				TargetProject: "default",
+				StoragePools:  strMap{"disk1": "default", "disk2": "default", "disk3": "default"},
+				Networks:      netMap{},
+				Disks: map[string]api.DiskPolicy{
+					"disk1": {Grow: "20%", Config: strMap{"zfs.blocksize": "64KiB"}},
+					"disk2": {Drop: true},
+					"disk3": {Detach: true},
+				},
+			},
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.NoError,
+		},
+		{
+			name:      "success - instance override disk policy takes precedence",
+			instance:  api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}, {Name: "disk2", Supported: true}}},
+			overrides: api.InstanceOverride{Disks: map[string]api.DiskPolicy{"disk2": {Grow: "10GiB"}}},
+			networks:  migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("disk1", grow="20%")
+			set_disk("disk2", drop=True)
+			`,
+
+			placement: api.Placement{
+				TargetName:    "default",
+				TargetProject: "default",
+				StoragePools:  strMap{"disk1": "default", "disk2": "default"},
+				Networks:      netMap{},
+				Disks: map[string]api.DiskPolicy{
+					"disk1": {Grow: "20%"},
+					"disk2": {Grow: "10GiB"},
+				},
+			},
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.NoError,
+		},
+		{
+			name:       "success - batch disk growth for disks without their own growth",
+			instance:   api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}, {Name: "disk2", Supported: true}, {Name: "disk3", Supported: true}, {Name: "disk4"}}},
+			overrides:  api.InstanceOverride{Disks: map[string]api.DiskPolicy{"disk3": {Grow: "0"}}},
+			diskGrowth: "10%",
+			networks:   migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("disk2", grow="5GiB")
+			`,
+
+			placement: api.Placement{
+				TargetName:    "default",
+				TargetProject: "default",
+				StoragePools:  strMap{"disk1": "default", "disk2": "default", "disk3": "default"},
+				Networks:      netMap{},
+				Disks: map[string]api.DiskPolicy{
+					"disk1": {Grow: "10%"},
+					"disk2": {Grow: "5GiB"},
+					"disk3": {Grow: "0"},
+				},
+			},
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.NoError,
+		},
+		{
+			name:       "error - invalid batch disk growth",
+			instance:   api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}}},
+			diskGrowth: "NaN%",
+			networks:   migration.Networks{},
+
+			batchCreateAssertErr: require.Error,
+		},
+		{
+			name:     "error - drop root disk",
+			instance: api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}, {Name: "disk2", Supported: true}}},
+			networks: migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("disk1", drop=True)
+			`,
+
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.Error,
+		},
+		{
+			name:     "error - invalid disk growth",
+			instance: api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}}},
+			networks: migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("disk1", grow="lots")
+			`,
+
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.Error,
+		},
+		{
+			name:     "error - set disk policy for unknown disk",
+			instance: api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}}},
+			networks: migration.Networks{},
+
+			scriptlet: `
+def placement(instance, batch):
+			set_disk("some_disk", grow="20%")
+			`,
+
+			batchCreateAssertErr: require.NoError,
+			placementAssertErr:   require.Error,
+		},
 		{
 			name:     "error - scriptlet syntax",
 			instance: api.InstanceProperties{Disks: []api.InstancePropertiesDisk{{Name: "disk1", Supported: true}}, NICs: []api.InstancePropertiesNIC{{SourceSpecificID: "srcnet1"}}},
@@ -2109,12 +2257,13 @@ def placement(instance, batch):
 					BackgroundSyncInterval:   api.AsDuration(10 * time.Minute),
 					FinalBackgroundSyncLimit: api.AsDuration(10 * time.Minute),
 					PlacementScriptlet:       tc.scriptlet,
+					DiskGrowth:               tc.diskGrowth,
 				},
 			})
 			tc.batchCreateAssertErr(t, err)
 
 			if err == nil {
-				placement, err := batchSvc.DeterminePlacement(ctx, migration.Instance{Properties: tc.instance}, tc.networks, batch, migration.Windows{})
+				placement, err := batchSvc.DeterminePlacement(ctx, migration.Instance{Properties: tc.instance, Overrides: tc.overrides}, tc.networks, batch, migration.Windows{})
 				tc.placementAssertErr(t, err)
 
 				if err == nil {
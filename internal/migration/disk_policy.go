@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v7/shared/units"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// diskAlignment is the size that grown disks are rounded up to, so that volume sizes stay aligned to the block size of the storage driver.
+const diskAlignment = 1024 * 1024
+
+// maxDiskGrowthPercent is the largest percentage a disk can be grown by, to catch policies that would create unreasonably large volumes.
+const maxDiskGrowthPercent = 1000
+
+func validateDiskPolicy(diskName string, policy api.DiskPolicy) error {
+	if policy.Drop && policy.Detach {
+		return fmt.Errorf("Disk %q can not be both dropped and detached", diskName)
+	}
+
+	_, err := DiskCapacity(policy, 0)
+	if err != nil {
+		return err
+	}
+
+	for key := range policy.Config {
+		if key == "" {
+			return fmt.Errorf("Disk %q has an empty volume config key", diskName)
+		}
+
+		// The volume size is derived from the source disk, so it can only be changed through grow.
+		if key == "size" {
+			return fmt.Errorf("Disk %q can not set the volume size directly, use grow instead", diskName)
+		}
+	}
+
+	return nil
+}
+
+// DiskCapacity returns the size in bytes of the target volume for a source disk of the given capacity, after growing it according to the policy.
+func DiskCapacity(policy api.DiskPolicy, capacity int64) (int64, error) {
+	grow := strings.TrimPrefix(policy.Grow, "+")
+	if grow == "" {
+		return capacity, nil
+	}
+
+	var growBy int64
+	percent, isPercent := strings.CutSuffix(grow, "%")
+	if isPercent {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return 0, fmt.Errorf("Invalid disk growth %q: Must be a positive percentage or byte size", policy.Grow)
+		}
+
+		if value > maxDiskGrowthPercent {
+			return 0, fmt.Errorf("Invalid disk growth %q: Must be at most %d%%", policy.Grow, maxDiskGrowthPercent)
+		}
+
+		growBy = int64(float64(capacity) * value / 100)
+	} else {
+		value, err := units.ParseByteSizeString(grow)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("Invalid disk growth %q: Must be a positive percentage or byte size", policy.Grow)
+		}
+
+		growBy = value
+	}
+
+	if growBy == 0 {
+		return capacity, nil
+	}
+
+	if growBy > math.MaxInt64-capacity-diskAlignment {
+		return 0, fmt.Errorf("Invalid disk growth %q: Disk size is too large", policy.Grow)
+	}
+
+	size := capacity + growBy
+	if size%diskAlignment != 0 {
+		size += diskAlignment - size%diskAlignment
+	}
+
+	return size, nil
+}
+
+// DroppedDisks returns the sorted names of the disks that the placement excludes from the migration.
+func DroppedDisks(placement api.Placement) []string {
+	var dropped []string
+	for name, policy := range placement.Disks {
+		if policy.Drop {
+			dropped = append(dropped, name)
+		}
+	}
+
+	slices.Sort(dropped)
+
+	return dropped
+}
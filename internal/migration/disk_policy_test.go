@@ -0,0 +1,130 @@
+package migration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestDiskCapacity(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+
+	tests := []struct {
+		name     string
+		policy   api.DiskPolicy
+		capacity int64
+
+		assertErr    require.ErrorAssertionFunc
+		wantCapacity int64
+	}{
+		{
+			name:     "success - no growth",
+			policy:   api.DiskPolicy{},
+			capacity: 10 * gib,
+
+			assertErr:    require.NoError,
+			wantCapacity: 10 * gib,
+		},
+		{
+			name:     "success - percentage",
+			policy:   api.DiskPolicy{Grow: "20%"},
+			capacity: 10 * gib,
+
+			assertErr:    require.NoError,
+			wantCapacity: 12 * gib,
+		},
+		{
+			name:     "success - percentage with leading plus",
+			policy:   api.DiskPolicy{Grow: "+50%"},
+			capacity: 10 * gib,
+
+			assertErr:    require.NoError,
+			wantCapacity: 15 * gib,
+		},
+		{
+			name:     "success - zero growth",
+			policy:   api.DiskPolicy{Grow: "0"},
+			capacity: 10 * gib,
+
+			assertErr:    require.NoError,
+			wantCapacity: 10 * gib,
+		},
+		{
+			name:     "success - byte size",
+			policy:   api.DiskPolicy{Grow: "5GiB"},
+			capacity: 10 * gib,
+
+			assertErr:    require.NoError,
+			wantCapacity: 15 * gib,
+		},
+		{
+			name:     "success - rounded up to alignment",
+			policy:   api.DiskPolicy{Grow: "1%"},
+			capacity: 1000,
+
+			assertErr:    require.NoError,
+			wantCapacity: 1024 * 1024,
+		},
+		{
+			name:     "error - invalid percentage",
+			policy:   api.DiskPolicy{Grow: "a lot%"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - negative percentage",
+			policy:   api.DiskPolicy{Grow: "-20%"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - NaN percentage",
+			policy:   api.DiskPolicy{Grow: "NaN%"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - infinite percentage",
+			policy:   api.DiskPolicy{Grow: "Inf%"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - percentage too large",
+			policy:   api.DiskPolicy{Grow: "1001%"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - byte size overflows",
+			policy:   api.DiskPolicy{Grow: "7EiB"},
+			capacity: 2 * 1024 * 1024 * gib * 1024,
+
+			assertErr: require.Error,
+		},
+		{
+			name:     "error - invalid byte size",
+			policy:   api.DiskPolicy{Grow: "big"},
+			capacity: 10 * gib,
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			capacity, err := migration.DiskCapacity(tc.policy, tc.capacity)
+
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantCapacity, capacity)
+		})
+	}
+}
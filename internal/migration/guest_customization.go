@@ -0,0 +1,69 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func validateGuestCustomization(g api.GuestCustomization) error {
+	err := validateCloudInitData("user-data", g.CloudInitUserData)
+	if err != nil {
+		return err
+	}
+
+	err = validateCloudInitData("vendor-data", g.CloudInitVendorData)
+	if err != nil {
+		return err
+	}
+
+	if g.CloudInitNetworkConfig != "" {
+		var cfg struct {
+			Network *struct {
+				Version int `yaml:"version"`
+			} `yaml:"network"`
+			Version int `yaml:"version"`
+		}
+
+		err := yaml.Unmarshal([]byte(g.CloudInitNetworkConfig), &cfg)
+		if err != nil {
+			return fmt.Errorf("Invalid cloud-init network-config: %w", err)
+		}
+
+		// The configuration may be nested under a top-level network key.
+		version := cfg.Version
+		if cfg.Network != nil {
+			version = cfg.Network.Version
+		}
+
+		if version != 1 && version != 2 {
+			return fmt.Errorf("Invalid cloud-init network-config: Version must be 1 or 2")
+		}
+	}
+
+	return nil
+}
+
+// validateCloudInitData checks that the user-data or vendor-data has a header cloud-init can identify, and that cloud-config is valid YAML.
+func validateCloudInitData(name string, data string) error {
+	if data == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(data, "#") && !strings.HasPrefix(data, "Content-Type:") {
+		return fmt.Errorf("Invalid cloud-init %s: Must begin with a header such as #cloud-config or #!", name)
+	}
+
+	if strings.HasPrefix(data, "#cloud-config\n") {
+		var cfg map[string]any
+		err := yaml.Unmarshal([]byte(data), &cfg)
+		if err != nil {
+			return fmt.Errorf("Invalid cloud-init %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"maps"
+	"math"
 	"regexp"
 	"slices"
 	"strconv"
@@ -60,6 +61,30 @@ func (i Instance) Validate() error {
 		return NewValidationErrf("Invalid instance override, ambiguous post-migration power state")
 	}
 
+	for name, policy := range i.Overrides.Disks {
+		err := validateDiskPolicy(name, policy)
+		if err != nil {
+			return NewValidationErrf("Invalid instance override: %v", err)
+		}
+	}
+
+	for hwaddr, cfg := range i.Overrides.NICs {
+		err := validateNICConfig(hwaddr, cfg)
+		if err != nil {
+			return NewValidationErrf("Invalid instance override: %v", err)
+		}
+	}
+
+	err := validateGuestCustomization(i.Overrides.GuestCustomization)
+	if err != nil {
+		return NewValidationErrf("Invalid instance override: %v", err)
+	}
+
+	err = validateShutdownPolicy(i.Overrides.Shutdown)
+	if err != nil {
+		return NewValidationErrf("Invalid instance override: %v", err)
+	}
+
 	for _, nic := range i.Properties.NICs {
 		if nic.UUID == uuid.Nil {
 			return NewValidationErrf("Instance NIC %q has empty UUID", nic.Location)
@@ -67,7 +92,7 @@ func (i Instance) Validate() error {
 	}
 
 	osType := i.GetOSType(true)
-	err := api.ValidateOSType(string(osType))
+	err = api.ValidateOSType(string(osType))
 	if err != nil {
 		return NewValidationErrf("Invalid instance OS type %q: %v", osType, err)
 	}
@@ -201,6 +226,29 @@ func (i Instance) GetArchitecture() string {
 	return props.Architecture
 }
 
+// Datastores returns the names of the datastores holding the supported disks of the instance, in the order they are first found.
+func (i Instance) Datastores() []string {
+	var datastores []string
+	for _, disk := range i.Properties.Disks {
+		if !disk.Supported {
+			continue
+		}
+
+		// VMware disk names are of the form "[datastore] path/to/disk.vmdk".
+		name, ok := strings.CutPrefix(disk.Name, "[")
+		if !ok {
+			continue
+		}
+
+		ds, _, ok := strings.Cut(name, "]")
+		if ok && !slices.Contains(datastores, ds) {
+			datastores = append(datastores, ds)
+		}
+	}
+
+	return datastores
+}
+
 func (i Instance) NeedsBackgroundImportVerification() bool {
 	if i.Properties.BackgroundImport {
 		for _, disk := range i.Properties.Disks {
@@ -529,6 +577,13 @@ func (i Instance) ApplyUpdates(srcInst Instance) (Instance, bool) {
 		instanceUpdated = true
 	}
 
+	// Preserve performance statistics from the previous sync if none were collected, such as when the VM has turned off.
+	if !srcInst.Properties.Performance.CollectedAt.IsZero() && inst.Properties.Performance != srcInst.Properties.Performance {
+		log.Debug("Instance performance statistics changed")
+		inst.Properties.Performance = srcInst.Properties.Performance
+		instanceUpdated = true
+	}
+
 	return inst, instanceUpdated
 }
 
@@ -652,5 +707,57 @@ func (i Instance) ToAPI() api.Instance {
 		DistributionVersion:  distroVersion,
 	}
 
+	apiInst.Overrides.Recommendation = i.SizingRecommendation()
+
 	return apiInst
 }
+
+// sizingHeadroom is the fraction of additional capacity added on top of the observed peak usage when recommending resource limits.
+const sizingHeadroom = 0.25
+
+// minRecommendedMemory is the smallest memory limit that will be recommended.
+const minRecommendedMemory = 512 * 1024 * 1024
+
+// SizingRecommendation returns the CPU and memory limits recommended from the peak usage recorded in the performance statistics.
+// Recommendations never exceed the configured source limits. Returns nil if no statistics have been collected.
+func (i Instance) SizingRecommendation() *api.InstanceSizingRecommendation {
+	perf := i.Properties.Performance
+	if perf.CollectedAt.IsZero() {
+		return nil
+	}
+
+	rec := &api.InstanceSizingRecommendation{
+		CPUs:   i.Properties.CPUs,
+		Memory: i.Properties.Memory,
+	}
+
+	cpus := int64(math.Ceil(float64(i.Properties.CPUs) * perf.CPUUsagePeak / 100 * (1 + sizingHeadroom)))
+	if cpus < rec.CPUs {
+		rec.CPUs = max(cpus, 1)
+	}
+
+	// Round memory up to the nearest MiB.
+	memory := int64(math.Ceil(float64(perf.MemoryActivePeak)*(1+sizingHeadroom)/(1024*1024))) * 1024 * 1024
+	if memory < rec.Memory {
+		rec.Memory = min(max(memory, minRecommendedMemory), rec.Memory)
+	}
+
+	return rec
+}
+
+// EffectiveProperties returns the instance properties with all overrides applied.
+// If enabled, the recommended sizing is used for CPU and memory limits that are not explicitly overridden.
+func (i Instance) EffectiveProperties() api.InstanceProperties {
+	props := i.Properties
+	if i.Overrides.UseRecommendedSizing {
+		rec := i.SizingRecommendation()
+		if rec != nil {
+			props.CPUs = rec.CPUs
+			props.Memory = rec.Memory
+		}
+	}
+
+	props.Apply(i.Overrides.InstancePropertiesConfigurable)
+
+	return props
+}
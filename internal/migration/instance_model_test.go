@@ -2,10 +2,13 @@ package migration_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/ptr"
 	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
@@ -42,3 +45,486 @@ func TestInstance_GetOSType(t *testing.T) {
 		})
 	}
 }
+
+func TestInstance_Datastores(t *testing.T) {
+	instance := migration.Instance{
+		Properties: api.InstanceProperties{
+			Disks: []api.InstancePropertiesDisk{
+				{Name: "[ds1] vm/vm.vmdk", Supported: true},
+				{Name: "[ds2] vm/vm_1.vmdk", Supported: true},
+				{Name: "[ds1] vm/vm_2.vmdk", Supported: true},
+				{Name: "[ds3] vm/vm_3.vmdk", Supported: false},
+				{Name: "disk-without-datastore", Supported: true},
+			},
+		},
+	}
+
+	require.Equal(t, []string{"ds1", "ds2"}, instance.Datastores())
+}
+
+func TestInstance_SizingRecommendation(t *testing.T) {
+	collected := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		instance migration.Instance
+
+		want *api.InstanceSizingRecommendation
+	}{
+		{
+			name: "no statistics",
+			instance: migration.Instance{
+				Properties: api.InstanceProperties{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 8, Memory: 16 * 1024 * 1024 * 1024}},
+			},
+
+			want: nil,
+		},
+		{
+			name: "over-provisioned",
+			instance: migration.Instance{
+				Properties: api.InstanceProperties{
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 8, Memory: 16 * 1024 * 1024 * 1024},
+					Performance: api.InstancePropertiesPerformance{
+						CollectedAt:      collected,
+						CPUUsagePeak:     25,
+						MemoryActivePeak: 2 * 1024 * 1024 * 1024,
+					},
+				},
+			},
+
+			want: &api.InstanceSizingRecommendation{CPUs: 3, Memory: 2560 * 1024 * 1024},
+		},
+		{
+			name: "idle",
+			instance: migration.Instance{
+				Properties: api.InstanceProperties{
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 4, Memory: 4 * 1024 * 1024 * 1024},
+					Performance: api.InstancePropertiesPerformance{
+						CollectedAt: collected,
+					},
+				},
+			},
+
+			want: &api.InstanceSizingRecommendation{CPUs: 1, Memory: 512 * 1024 * 1024},
+		},
+		{
+			name: "fully utilized",
+			instance: migration.Instance{
+				Properties: api.InstanceProperties{
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 4, Memory: 4 * 1024 * 1024 * 1024},
+					Performance: api.InstancePropertiesPerformance{
+						CollectedAt:      collected,
+						CPUUsagePeak:     95,
+						MemoryActivePeak: 4 * 1024 * 1024 * 1024,
+					},
+				},
+			},
+
+			want: &api.InstanceSizingRecommendation{CPUs: 4, Memory: 4 * 1024 * 1024 * 1024},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.instance.SizingRecommendation()
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestInstance_EffectiveProperties(t *testing.T) {
+	inst := migration.Instance{
+		Properties: api.InstanceProperties{
+			InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 8, Memory: 16 * 1024 * 1024 * 1024},
+			Performance: api.InstancePropertiesPerformance{
+				CollectedAt:      time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC),
+				CPUUsagePeak:     25,
+				MemoryActivePeak: 2 * 1024 * 1024 * 1024,
+			},
+		},
+	}
+
+	// Recommendations are ignored unless enabled.
+	props := inst.EffectiveProperties()
+	require.Equal(t, int64(8), props.CPUs)
+	require.Equal(t, int64(16*1024*1024*1024), props.Memory)
+
+	// Recommendations are used for limits without explicit overrides.
+	inst.Overrides.UseRecommendedSizing = true
+	inst.Overrides.Memory = 4 * 1024 * 1024 * 1024
+	props = inst.EffectiveProperties()
+	require.Equal(t, int64(3), props.CPUs)
+	require.Equal(t, int64(4*1024*1024*1024), props.Memory)
+}
+
+func TestInstance_ValidateNICOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		nics map[string]api.NICConfig
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - re-IP",
+			nics: map[string]api.NICConfig{
+				"00:0c:29:a1:76:30": {
+					Name:          "eth0",
+					Addresses:     []string{"10.0.1.10/24", "fd42::10/64"},
+					Gateway4:      "10.0.1.1",
+					Gateway6:      "fd42::1",
+					Nameservers:   []string{"10.0.1.2", "fd42::2"},
+					SearchDomains: []string{"example.com"},
+				},
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - invalid hardware address",
+			nics: map[string]api.NICConfig{"eth0": {}},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - invalid interface name",
+			nics: map[string]api.NICConfig{"00:0c:29:a1:76:30": {Name: "eth0 eth1"}},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - address without prefix",
+			nics: map[string]api.NICConfig{"00:0c:29:a1:76:30": {Addresses: []string{"10.0.1.10"}}},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - IPv6 address as IPv4 gateway",
+			nics: map[string]api.NICConfig{"00:0c:29:a1:76:30": {Gateway4: "fd42::1"}},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - invalid nameserver",
+			nics: map[string]api.NICConfig{"00:0c:29:a1:76:30": {Nameservers: []string{"dns.example.com"}}},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - invalid search domain",
+			nics: map[string]api.NICConfig{"00:0c:29:a1:76:30": {SearchDomains: []string{"example..com"}}},
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := migration.Instance{
+				UUID:      uuid.MustParse("a2095069-a527-4b2a-ab23-1739325dcac7"),
+				Source:    "src",
+				Overrides: api.InstanceOverride{NICs: tc.nics},
+				Properties: api.InstanceProperties{
+					Location: "/path/to/vm",
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{
+						Name: "vm",
+					},
+					OS: "ubuntu64Guest",
+				},
+			}
+
+			err := instance.Validate()
+			tc.assertErr(t, err)
+			if err != nil {
+				var verr migration.ErrValidation
+				require.ErrorAs(t, err, &verr)
+			}
+		})
+	}
+}
+
+func TestInstance_ValidateGuestCustomization(t *testing.T) {
+	tests := []struct {
+		name          string
+		customization api.GuestCustomization
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - cloud-config and network-config",
+			customization: api.GuestCustomization{
+				CloudInitUserData:      "#cloud-config\nhostname: vm01\nssh_authorized_keys:\n  - ssh-ed25519 AAAA\n",
+				CloudInitVendorData:    "#!/bin/sh\necho hello\n",
+				CloudInitNetworkConfig: "network:\n  version: 2\n  ethernets:\n    eth0:\n      dhcp4: true\n",
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - unwrapped network-config",
+			customization: api.GuestCustomization{
+				CloudInitNetworkConfig: "version: 1\nconfig:\n  - type: physical\n    name: eth0\n",
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - windows payload only",
+			customization: api.GuestCustomization{
+				WindowsFirstBootScript: "Rename-Computer -NewName vm01",
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - user-data without header",
+			customization: api.GuestCustomization{
+				CloudInitUserData: "hostname: vm01\n",
+			},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - invalid cloud-config",
+			customization: api.GuestCustomization{
+				CloudInitVendorData: "#cloud-config\nhostname: [vm01\n",
+			},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - network-config without version",
+			customization: api.GuestCustomization{
+				CloudInitNetworkConfig: "ethernets:\n  eth0:\n    dhcp4: true\n",
+			},
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := migration.Instance{
+				UUID:      uuid.MustParse("a2095069-a527-4b2a-ab23-1739325dcac7"),
+				Source:    "src",
+				Overrides: api.InstanceOverride{GuestCustomization: tc.customization},
+				Properties: api.InstanceProperties{
+					Location: "/path/to/vm",
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{
+						Name: "vm",
+					},
+					OS: "ubuntu64Guest",
+				},
+			}
+
+			err := instance.Validate()
+			tc.assertErr(t, err)
+			if err != nil {
+				var verr migration.ErrValidation
+				require.ErrorAs(t, err, &verr)
+			}
+		})
+	}
+}
+
+func TestShutdownPolicy_Apply(t *testing.T) {
+	batchPolicy := api.ShutdownPolicy{
+		GuestTimeout:         api.AsDuration(10 * time.Minute),
+		ForcePowerOff:        ptr.To(true),
+		PreShutdownCommand:   "/usr/bin/systemctl",
+		PreShutdownArguments: "stop postgresql",
+		GuestUsername:        "root",
+	}
+
+	tests := []struct {
+		name     string
+		override api.ShutdownPolicy
+
+		want api.ShutdownPolicy
+	}{
+		{
+			name: "no override",
+			want: batchPolicy,
+		},
+		{
+			name:     "forced power off turned off",
+			override: api.ShutdownPolicy{ForcePowerOff: ptr.To(false)},
+			want: api.ShutdownPolicy{
+				GuestTimeout:         api.AsDuration(10 * time.Minute),
+				ForcePowerOff:        ptr.To(false),
+				PreShutdownCommand:   "/usr/bin/systemctl",
+				PreShutdownArguments: "stop postgresql",
+				GuestUsername:        "root",
+			},
+		},
+		{
+			name:     "arguments without command",
+			override: api.ShutdownPolicy{PreShutdownArguments: "stop mysql"},
+			want: api.ShutdownPolicy{
+				GuestTimeout:         api.AsDuration(10 * time.Minute),
+				ForcePowerOff:        ptr.To(true),
+				PreShutdownCommand:   "/usr/bin/systemctl",
+				PreShutdownArguments: "stop mysql",
+				GuestUsername:        "root",
+			},
+		},
+		{
+			name:     "command without arguments",
+			override: api.ShutdownPolicy{PreShutdownCommand: "/usr/local/bin/quiesce"},
+			want: api.ShutdownPolicy{
+				GuestTimeout:         api.AsDuration(10 * time.Minute),
+				ForcePowerOff:        ptr.To(true),
+				PreShutdownCommand:   "/usr/local/bin/quiesce",
+				PreShutdownArguments: "stop postgresql",
+				GuestUsername:        "root",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := batchPolicy.Apply(tc.override)
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.want.ForcesPowerOff(), got.ForcesPowerOff())
+		})
+	}
+}
+
+func TestShutdownPolicy_GuestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy api.ShutdownPolicy
+
+		want time.Duration
+	}{
+		{
+			name: "no timeout",
+			want: 0,
+		},
+		{
+			name:   "timeout without forced power off",
+			policy: api.ShutdownPolicy{GuestTimeout: api.AsDuration(5 * time.Minute)},
+			want:   5 * time.Minute,
+		},
+		{
+			name:   "timeout with forced power off",
+			policy: api.ShutdownPolicy{GuestTimeout: api.AsDuration(5 * time.Minute), ForcePowerOff: ptr.To(true)},
+			want:   5 * time.Minute,
+		},
+		{
+			name:   "forced power off without timeout",
+			policy: api.ShutdownPolicy{ForcePowerOff: ptr.To(true)},
+			want:   api.DefaultForcedGuestTimeout,
+		},
+		{
+			name:   "forced power off turned off without timeout",
+			policy: api.ShutdownPolicy{ForcePowerOff: ptr.To(false)},
+			want:   0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.policy.GuestShutdownTimeout())
+		})
+	}
+}
+
+func TestInstance_ValidateShutdownPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy api.ShutdownPolicy
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "success - timeout and forced power off",
+			policy: api.ShutdownPolicy{
+				GuestTimeout:  api.AsDuration(10 * time.Minute),
+				ForcePowerOff: ptr.To(true),
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - linux pre-shutdown command",
+			policy: api.ShutdownPolicy{
+				PreShutdownCommand:   "/usr/bin/systemctl",
+				PreShutdownArguments: "stop postgresql",
+				GuestUsername:        "root",
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "success - windows pre-shutdown command",
+			policy: api.ShutdownPolicy{
+				PreShutdownCommand:   `C:\Windows\System32\net.exe`,
+				PreShutdownArguments: "stop MSSQLSERVER",
+				GuestUsername:        "Administrator",
+			},
+
+			assertErr: require.NoError,
+		},
+		{
+			name: "error - relative pre-shutdown command",
+			policy: api.ShutdownPolicy{
+				PreShutdownCommand: "systemctl",
+				GuestUsername:      "root",
+			},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - arguments without pre-shutdown command",
+			policy: api.ShutdownPolicy{
+				PreShutdownArguments: "stop postgresql",
+			},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - negative guest timeout",
+			policy: api.ShutdownPolicy{
+				GuestTimeout:  api.AsDuration(-time.Minute),
+				ForcePowerOff: ptr.To(true),
+			},
+
+			assertErr: require.Error,
+		},
+		{
+			name: "error - negative pre-shutdown timeout",
+			policy: api.ShutdownPolicy{
+				PreShutdownCommand: "/usr/bin/systemctl",
+				PreShutdownTimeout: api.AsDuration(-time.Minute),
+				GuestUsername:      "root",
+			},
+
+			assertErr: require.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := migration.Instance{
+				UUID:      uuid.MustParse("a2095069-a527-4b2a-ab23-1739325dcac7"),
+				Source:    "src",
+				Overrides: api.InstanceOverride{Shutdown: tc.policy},
+				Properties: api.InstanceProperties{
+					Location: "/path/to/vm",
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{
+						Name: "vm",
+					},
+					OS: "ubuntu64Guest",
+				},
+			}
+
+			err := instance.Validate()
+			tc.assertErr(t, err)
+			if err != nil {
+				var verr migration.ErrValidation
+				require.ErrorAs(t, err, &verr)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package migration
+
+import (
+	"encoding/base64"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// InstanceSecret is a secret stored for an instance. The value is encrypted at rest.
+type InstanceSecret struct {
+	ID           int64
+	InstanceUUID uuid.UUID              `db:"primary=yes"`
+	Type         api.InstanceSecretType `db:"primary=yes"`
+	Value        string
+	LastUpdated  time.Time
+}
+
+type InstanceSecrets []InstanceSecret
+
+// bitLockerRecoveryPasswordRegex matches the 48-digit BitLocker recovery password, in 8 groups of 6 digits.
+var bitLockerRecoveryPasswordRegex = regexp.MustCompile(`^[0-9]{6}(-[0-9]{6}){7}$`)
+
+// The limits cryptsetup applies to passphrases and keyfiles by default.
+const (
+	maxLUKSPassphraseLength = 512
+	maxLUKSKeyFileSize      = 8 * 1024 * 1024
+)
+
+// ValidateInstanceSecret validates the plaintext value of a secret of the given type.
+func ValidateInstanceSecret(secretType api.InstanceSecretType, value string) error {
+	err := secretType.Validate()
+	if err != nil {
+		return NewValidationErrf("Invalid instance secret: %v", err)
+	}
+
+	switch secretType {
+	case api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD:
+		if !bitLockerRecoveryPasswordRegex.MatchString(value) {
+			return NewValidationErrf("Invalid instance secret, BitLocker recovery password must be 8 groups of 6 digits separated by '-'")
+		}
+
+	case api.INSTANCESECRETTYPE_LUKS_PASSPHRASE:
+		if value == "" || len(value) > maxLUKSPassphraseLength {
+			return NewValidationErrf("Invalid instance secret, LUKS passphrase must be between 1 and %d characters", maxLUKSPassphraseLength)
+		}
+
+	case api.INSTANCESECRETTYPE_LUKS_KEYFILE:
+		keyFile, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return NewValidationErrf("Invalid instance secret, LUKS keyfile must be base64 encoded: %v", err)
+		}
+
+		if len(keyFile) == 0 || len(keyFile) > maxLUKSKeyFileSize {
+			return NewValidationErrf("Invalid instance secret, LUKS keyfile must be between 1 and %d bytes", maxLUKSKeyFileSize)
+		}
+
+	case api.INSTANCESECRETTYPE_GUEST_PASSWORD:
+		if value == "" {
+			return NewValidationErrf("Invalid instance secret, guest password can not be empty")
+		}
+	}
+
+	return nil
+}
+
+func (s InstanceSecret) Validate() error {
+	if s.InstanceUUID == uuid.Nil {
+		return NewValidationErrf("Invalid instance secret, instance UUID can not be empty")
+	}
+
+	err := s.Type.Validate()
+	if err != nil {
+		return NewValidationErrf("Invalid instance secret: %v", err)
+	}
+
+	if s.Value == "" {
+		return NewValidationErrf("Invalid instance secret, value can not be empty")
+	}
+
+	return nil
+}
+
+func (s InstanceSecret) ToAPI() api.InstanceSecret {
+	return api.InstanceSecret{
+		Type:        s.Type,
+		LastUpdated: s.LastUpdated,
+	}
+}
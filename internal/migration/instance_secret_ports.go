@@ -0,0 +1,28 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+//go:generate go run github.com/matryer/moq -fmt goimports -pkg migration_test -out instance_secret_service_mock_gen_test.go -rm . InstanceSecretService
+
+type InstanceSecretService interface {
+	GetAllByInstanceUUID(ctx context.Context, id uuid.UUID) (InstanceSecrets, error)
+	GetValueByInstanceUUID(ctx context.Context, id uuid.UUID, secretType api.InstanceSecretType) (string, error)
+	Set(ctx context.Context, id uuid.UUID, secretType api.InstanceSecretType, value string) error
+	DeleteAllByInstanceUUID(ctx context.Context, id uuid.UUID) error
+}
+
+//go:generate go run github.com/matryer/moq -fmt goimports -pkg mock -out repo/mock/instance_secret_repo_mock_gen.go -rm . InstanceSecretRepo
+//go:generate go run github.com/hexdigest/gowrap/cmd/gowrap gen -g -i InstanceSecretRepo -t ../logger/slog.gotmpl -o ./repo/middleware/instance_secret_slog_gen.go
+// disabled go:generate go run github.com/hexdigest/gowrap/cmd/gowrap gen -g -i InstanceSecretRepo -t prometheus -o ./repo/middleware/instance_secret_prometheus_gen.go
+
+type InstanceSecretRepo interface {
+	GetAllByInstanceUUID(ctx context.Context, id uuid.UUID) (InstanceSecrets, error)
+	Upsert(ctx context.Context, secret InstanceSecret) (int64, error)
+	DeleteAllByInstanceUUID(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,133 @@
+package migration
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+type instanceSecretService struct {
+	repo InstanceSecretRepo
+	aead cipher.AEAD
+}
+
+var _ InstanceSecretService = &instanceSecretService{}
+
+// NewInstanceSecretService returns a service that stores instance secrets encrypted with AES-GCM using the given 32 byte key.
+func NewInstanceSecretService(repo InstanceSecretRepo, key []byte) (instanceSecretService, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return instanceSecretService{}, fmt.Errorf("Invalid secrets key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return instanceSecretService{}, fmt.Errorf("Invalid secrets key: %w", err)
+	}
+
+	return instanceSecretService{repo: repo, aead: aead}, nil
+}
+
+// GetAllByInstanceUUID returns the secrets stored for the instance, with their values still encrypted.
+func (s instanceSecretService) GetAllByInstanceUUID(ctx context.Context, id uuid.UUID) (InstanceSecrets, error) {
+	return s.repo.GetAllByInstanceUUID(ctx, id)
+}
+
+// GetValueByInstanceUUID returns the decrypted value of the instance's secret of the given type.
+func (s instanceSecretService) GetValueByInstanceUUID(ctx context.Context, id uuid.UUID, secretType api.InstanceSecretType) (string, error) {
+	secrets, err := s.repo.GetAllByInstanceUUID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	for _, secret := range secrets {
+		if secret.Type != secretType {
+			continue
+		}
+
+		value, err := s.decrypt(secret)
+		if err != nil {
+			return "", fmt.Errorf("Failed to decrypt %q secret of instance %q: %w", secretType, id, err)
+		}
+
+		return value, nil
+	}
+
+	return "", fmt.Errorf("No %q secret for instance %q: %w", secretType, id, ErrNotFound)
+}
+
+// Set encrypts and stores the secret for the instance, replacing any existing secret of the same type.
+func (s instanceSecretService) Set(ctx context.Context, id uuid.UUID, secretType api.InstanceSecretType, value string) error {
+	err := ValidateInstanceSecret(secretType, value)
+	if err != nil {
+		return err
+	}
+
+	secret := InstanceSecret{
+		InstanceUUID: id,
+		Type:         secretType,
+		LastUpdated:  time.Now().UTC(),
+	}
+
+	secret.Value, err = s.encrypt(secret, value)
+	if err != nil {
+		return fmt.Errorf("Failed to encrypt %q secret of instance %q: %w", secretType, id, err)
+	}
+
+	err = secret.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.Upsert(ctx, secret)
+	return err
+}
+
+// DeleteAllByInstanceUUID removes all secrets stored for the instance.
+func (s instanceSecretService) DeleteAllByInstanceUUID(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteAllByInstanceUUID(ctx, id)
+}
+
+// additionalData binds the ciphertext to the instance and secret type, so a stored value can't be swapped onto another instance.
+func (s instanceSecretService) additionalData(secret InstanceSecret) []byte {
+	return []byte(secret.InstanceUUID.String() + "/" + string(secret.Type))
+}
+
+func (s instanceSecretService) encrypt(secret InstanceSecret, value string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, []byte(value), s.additionalData(secret))
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s instanceSecretService) decrypt(secret InstanceSecret) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(secret.Value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < s.aead.NonceSize() {
+		return "", fmt.Errorf("Ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	value, err := s.aead.Open(nil, nonce, ciphertext, s.additionalData(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
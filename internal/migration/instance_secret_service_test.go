@@ -0,0 +1,173 @@
+package migration_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/migration/repo/mock"
+	"github.com/FuturFusion/migration-manager/internal/testing/boom"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+var testSecretsKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestNewInstanceSecretService(t *testing.T) {
+	_, err := migration.NewInstanceSecretService(&mock.InstanceSecretRepoMock{}, []byte("short"))
+	require.Error(t, err)
+
+	_, err = migration.NewInstanceSecretService(&mock.InstanceSecretRepoMock{}, testSecretsKey)
+	require.NoError(t, err)
+}
+
+func TestInstanceSecretService_Set(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretType api.InstanceSecretType
+		value      string
+		repoErr    error
+
+		assertErr require.ErrorAssertionFunc
+	}{
+		{
+			name:       "success",
+			secretType: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD,
+			value:      "123456-123456-123456-123456-123456-123456-123456-123456",
+
+			assertErr: require.NoError,
+		},
+		{
+			name:       "error - unknown type",
+			secretType: "password",
+			value:      "123456-123456-123456-123456-123456-123456-123456-123456",
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+		{
+			name:       "error - invalid recovery password",
+			secretType: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD,
+			value:      "123456-123456",
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+		{
+			name:       "success - LUKS passphrase",
+			secretType: api.INSTANCESECRETTYPE_LUKS_PASSPHRASE,
+			value:      "correct horse battery staple",
+
+			assertErr: require.NoError,
+		},
+		{
+			name:       "success - LUKS keyfile",
+			secretType: api.INSTANCESECRETTYPE_LUKS_KEYFILE,
+			value:      "c2VjcmV0IGtleWZpbGU=",
+
+			assertErr: require.NoError,
+		},
+		{
+			name:       "error - LUKS keyfile not base64",
+			secretType: api.INSTANCESECRETTYPE_LUKS_KEYFILE,
+			value:      "not base64!",
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+		{
+			name:       "success - guest password",
+			secretType: api.INSTANCESECRETTYPE_GUEST_PASSWORD,
+			value:      "guest secret",
+
+			assertErr: require.NoError,
+		},
+		{
+			name:       "error - LUKS passphrase too long",
+			secretType: api.INSTANCESECRETTYPE_LUKS_PASSPHRASE,
+			value:      strings.Repeat("a", 513),
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+		{
+			name:       "error - repo",
+			secretType: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD,
+			value:      "123456-123456-123456-123456-123456-123456-123456-123456",
+			repoErr:    boom.Error,
+
+			assertErr: boom.ErrorIs,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mock.InstanceSecretRepoMock{
+				UpsertFunc: func(ctx context.Context, secret migration.InstanceSecret) (int64, error) {
+					// The value is never stored in plain text.
+					require.NotContains(t, secret.Value, tc.value)
+					return 1, tc.repoErr
+				},
+			}
+
+			svc, err := migration.NewInstanceSecretService(repo, testSecretsKey)
+			require.NoError(t, err)
+
+			err = svc.Set(context.Background(), uuid.New(), tc.secretType, tc.value)
+			tc.assertErr(t, err)
+		})
+	}
+}
+
+func TestInstanceSecretService_GetValueByInstanceUUID(t *testing.T) {
+	instanceUUID := uuid.New()
+	recoveryPassword := "123456-123456-123456-123456-123456-123456-123456-123456"
+
+	var stored migration.InstanceSecrets
+	repo := &mock.InstanceSecretRepoMock{
+		UpsertFunc: func(ctx context.Context, secret migration.InstanceSecret) (int64, error) {
+			stored = migration.InstanceSecrets{secret}
+			return 1, nil
+		},
+		GetAllByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (migration.InstanceSecrets, error) {
+			return stored, nil
+		},
+	}
+
+	svc, err := migration.NewInstanceSecretService(repo, testSecretsKey)
+	require.NoError(t, err)
+
+	// Nothing stored yet.
+	_, err = svc.GetValueByInstanceUUID(context.Background(), instanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.ErrorIs(t, err, migration.ErrNotFound)
+
+	err = svc.Set(context.Background(), instanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, recoveryPassword)
+	require.NoError(t, err)
+
+	value, err := svc.GetValueByInstanceUUID(context.Background(), instanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.NoError(t, err)
+	require.Equal(t, recoveryPassword, value)
+
+	// A different key can't decrypt the stored value.
+	otherSvc, err := migration.NewInstanceSecretService(repo, []byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	_, err = otherSvc.GetValueByInstanceUUID(context.Background(), instanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.Error(t, err)
+
+	// A value can't be moved onto another instance.
+	stored[0].InstanceUUID = uuid.New()
+	_, err = svc.GetValueByInstanceUUID(context.Background(), stored[0].InstanceUUID, api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, migration.ErrNotFound)
+}
@@ -0,0 +1,64 @@
+package migration
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/lxc/incus/v7/shared/validate"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func validateNICConfig(hwaddr string, cfg api.NICConfig) error {
+	err := validate.IsNetworkMAC(hwaddr)
+	if err != nil {
+		return fmt.Errorf("Invalid NIC hardware address %q: %w", hwaddr, err)
+	}
+
+	if cfg.Name != "" {
+		err := validate.IsInterfaceName(cfg.Name)
+		if err != nil {
+			return fmt.Errorf("Invalid interface name %q for NIC %q: %w", cfg.Name, hwaddr, err)
+		}
+	}
+
+	for _, addr := range cfg.Addresses {
+		_, err := netip.ParsePrefix(addr)
+		if err != nil {
+			return fmt.Errorf("Invalid address %q for NIC %q: Must be in CIDR notation", addr, hwaddr)
+		}
+	}
+
+	if cfg.Gateway4 != "" {
+		gateway, err := netip.ParseAddr(cfg.Gateway4)
+		if err != nil || !gateway.Is4() {
+			return fmt.Errorf("Invalid IPv4 gateway %q for NIC %q", cfg.Gateway4, hwaddr)
+		}
+	}
+
+	if cfg.Gateway6 != "" {
+		gateway, err := netip.ParseAddr(cfg.Gateway6)
+		if err != nil || !gateway.Is6() {
+			return fmt.Errorf("Invalid IPv6 gateway %q for NIC %q", cfg.Gateway6, hwaddr)
+		}
+	}
+
+	for _, server := range cfg.Nameservers {
+		_, err := netip.ParseAddr(server)
+		if err != nil {
+			return fmt.Errorf("Invalid DNS server %q for NIC %q", server, hwaddr)
+		}
+	}
+
+	for _, domain := range cfg.SearchDomains {
+		for _, label := range strings.Split(strings.TrimSuffix(domain, "."), ".") {
+			err := validate.IsHostname(label)
+			if err != nil {
+				return fmt.Errorf("Invalid DNS search domain %q for NIC %q: %w", domain, hwaddr, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package migration
 import (
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,19 +25,34 @@ type QueueEntry struct {
 	MigrationWindowName sql.NullString `db:"leftjoin=migration_windows.name"`
 
 	Placement api.Placement `db:"marshal=json"`
+
+	SyncHistory []api.QueueSyncRecord `db:"marshal=json"`
+
+	Cutover api.QueueCutover `db:"marshal=json"`
+
+	DiskStates []api.WorkerDiskState `db:"marshal=json"`
+
+	SourceCleanup api.QueueSourceCleanup `db:"marshal=json"`
 }
 
 type QueueEntries []QueueEntry
 
 type WorkerCommand struct {
-	Command       api.WorkerCommandType
-	Location      string
-	SourceType    api.SourceType
-	Source        Source
-	Distro        api.Distro
-	DistroVersion string
-	OSType        api.OSType
-	Architecture  string
+	Command        api.WorkerCommandType
+	Location       string
+	SourceType     api.SourceType
+	Source         Source
+	Distro         api.Distro
+	DistroVersion  string
+	OSType         api.OSType
+	Architecture   string
+	TransferLimits api.WorkerTransferLimits
+	DiskStates     []api.WorkerDiskState
+	Verification   api.VerificationMode
+	DroppedDisks   []string
+
+	GuestCustomization api.GuestCustomization
+	ShutdownPolicy     api.ShutdownPolicy
 }
 
 func (q QueueEntry) IsMigrating() bool {
@@ -174,7 +190,125 @@ func (q QueueEntry) GetWindowName() *string {
 	return nil
 }
 
+// maxSyncHistory is the maximum number of disk import records kept for each queue entry.
+const maxSyncHistory = 100
+
+// RecordSync appends the transfer statistics of a completed disk import to the queue entry's sync history.
+func (q *QueueEntry) RecordSync(disks []api.WorkerDiskSync, final bool, now time.Time) {
+	if len(disks) == 0 {
+		return
+	}
+
+	record := api.QueueSyncRecord{Time: now, Final: final, Disks: disks}
+	if len(q.SyncHistory) > 0 {
+		record.Interval = api.AsDuration(now.Sub(q.SyncHistory[len(q.SyncHistory)-1].Time))
+	}
+
+	q.SyncHistory = append(q.SyncHistory, record)
+	if len(q.SyncHistory) > maxSyncHistory {
+		q.SyncHistory = q.SyncHistory[len(q.SyncHistory)-maxSyncHistory:]
+	}
+}
+
+// CheckpointDisks records the sync state of disks whose full copy has reached a checkpoint, replacing any previous state of the same disks.
+func (q *QueueEntry) CheckpointDisks(states []api.WorkerDiskState) {
+	for _, state := range states {
+		idx := slices.IndexFunc(q.DiskStates, func(s api.WorkerDiskState) bool { return s.Name == state.Name })
+		if idx < 0 {
+			q.DiskStates = append(q.DiskStates, state)
+			continue
+		}
+
+		q.DiskStates[idx] = state
+	}
+}
+
+// ChangeRate returns the average rate in bytes per second at which the instance's disks changed between disk imports.
+// Full disk copies are ignored as they do not reflect the amount of changed data.
+func (q QueueEntry) ChangeRate() float64 {
+	var changed int64
+	var elapsed time.Duration
+	for _, record := range q.SyncHistory {
+		if record.Interval.Duration <= 0 {
+			continue
+		}
+
+		var incremental bool
+		for _, disk := range record.Disks {
+			if !disk.FullCopy {
+				incremental = true
+				changed += disk.CopiedBytes
+			}
+		}
+
+		if incremental {
+			elapsed += record.Interval.Duration
+		}
+	}
+
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(changed) / elapsed.Seconds()
+}
+
+// CopyThroughput returns the average rate in bytes per second at which disk data was copied to the target.
+func (q QueueEntry) CopyThroughput() float64 {
+	var copied int64
+	var elapsed time.Duration
+	for _, record := range q.SyncHistory {
+		for _, disk := range record.Disks {
+			if disk.Duration.Duration <= 0 {
+				continue
+			}
+
+			copied += disk.CopiedBytes
+			elapsed += disk.Duration.Duration
+		}
+	}
+
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(copied) / elapsed.Seconds()
+}
+
+// BytesTransferred returns the total number of bytes copied to the target across all recorded disk imports.
+func (q QueueEntry) BytesTransferred() int64 {
+	var copied int64
+	for _, record := range q.SyncHistory {
+		for _, disk := range record.Disks {
+			copied += disk.CopiedBytes
+		}
+	}
+
+	return copied
+}
+
+// ForecastFinalImport estimates how long the final import will take, given the time since the last disk import.
+// Returns 0 if the final import has already completed, or if not enough data has been recorded to make a forecast.
+func (q QueueEntry) ForecastFinalImport(sinceLastSync time.Duration) time.Duration {
+	if q.ImportStage == IMPORTSTAGE_COMPLETE {
+		return 0
+	}
+
+	rate := q.ChangeRate()
+	throughput := q.CopyThroughput()
+	if rate <= 0 || throughput <= 0 {
+		return 0
+	}
+
+	return time.Duration(rate * sinceLastSync.Seconds() / throughput * float64(time.Second))
+}
+
 func (q QueueEntry) ToAPI(instanceName string, lastWorkerUpdate time.Time, migrationWindow Window) api.QueueEntry {
+	var forecast time.Duration
+	if len(q.SyncHistory) > 0 {
+		forecast = q.ForecastFinalImport(time.Since(q.SyncHistory[len(q.SyncHistory)-1].Time))
+	}
+
 	return api.QueueEntry{
 		InstanceUUID:           q.InstanceUUID,
 		MigrationStatus:        q.MigrationStatus,
@@ -185,5 +319,100 @@ func (q QueueEntry) ToAPI(instanceName string, lastWorkerUpdate time.Time, migra
 		MigrationWindow:        migrationWindow.ToAPI(),
 
 		Placement: q.Placement,
+
+		SyncHistory:         q.SyncHistory,
+		FinalImportForecast: api.AsDuration(forecast),
+		Cutover:             q.Cutover,
+		SourceCleanup:       q.SourceCleanup,
 	}
 }
+
+// QueueHistoryEntry records a change to the migration status of a queue entry.
+// History entries are kept after the queue entry itself has been deleted, and are removed
+// along with their instance or batch.
+type QueueHistoryEntry struct {
+	ID                     int64
+	InstanceUUID           uuid.UUID
+	BatchName              string
+	Time                   time.Time
+	MigrationStatus        api.MigrationStatusType
+	MigrationStatusMessage string
+	WorkerStatus           api.WorkerResponseType
+	WorkerStatusMessage    string
+	Placement              api.Placement `db:"marshal=json"`
+	Error                  string
+}
+
+type QueueHistoryEntries []QueueHistoryEntry
+
+// NewQueueHistoryEntry records the current state of the queue entry, along with the worker response that caused the change, if any.
+func NewQueueHistoryEntry(q QueueEntry, workerResp *api.WorkerResponse) QueueHistoryEntry {
+	h := QueueHistoryEntry{
+		InstanceUUID:           q.InstanceUUID,
+		BatchName:              q.BatchName,
+		Time:                   time.Now().UTC(),
+		MigrationStatus:        q.MigrationStatus,
+		MigrationStatusMessage: q.MigrationStatusMessage,
+		Placement:              q.Placement,
+	}
+
+	if workerResp != nil {
+		h.WorkerStatus = workerResp.Status
+		h.WorkerStatusMessage = workerResp.StatusMessage
+	}
+
+	if q.MigrationStatus == api.MIGRATIONSTATUS_ERROR {
+		h.Error = q.MigrationStatusMessage
+	}
+
+	return h
+}
+
+func (h QueueHistoryEntry) ToAPI() api.QueueHistoryEntry {
+	return api.QueueHistoryEntry{
+		InstanceUUID:           h.InstanceUUID,
+		BatchName:              h.BatchName,
+		Time:                   h.Time,
+		MigrationStatus:        h.MigrationStatus,
+		MigrationStatusMessage: h.MigrationStatusMessage,
+		WorkerStatus:           h.WorkerStatus,
+		WorkerStatusMessage:    h.WorkerStatusMessage,
+		Placement:              h.Placement,
+		Error:                  h.Error,
+	}
+}
+
+// Phases returns the migration statuses recorded in the history, along with the time spent in each.
+// The last phase has no end time.
+func (h QueueHistoryEntries) Phases() []api.BatchReportPhase {
+	phases := make([]api.BatchReportPhase, 0, len(h))
+	for i, entry := range h {
+		phase := api.BatchReportPhase{Status: entry.MigrationStatus, Start: entry.Time}
+		if i+1 < len(h) {
+			phase.End = h[i+1].Time
+		}
+
+		phases = append(phases, phase)
+	}
+
+	return phases
+}
+
+// Downtime returns the time between the start of the final import, when the source instance is powered off,
+// and the migration finishing, when the target instance is started.
+// Returns 0 if the migration has not finished.
+func (h QueueHistoryEntries) Downtime() time.Duration {
+	var finalImport time.Time
+	for _, entry := range h {
+		switch entry.MigrationStatus {
+		case api.MIGRATIONSTATUS_FINAL_IMPORT:
+			finalImport = entry.Time
+		case api.MIGRATIONSTATUS_FINISHED:
+			if !finalImport.IsZero() {
+				return entry.Time.Sub(finalImport)
+			}
+		}
+	}
+
+	return 0
+}
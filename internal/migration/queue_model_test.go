@@ -0,0 +1,154 @@
+package migration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestQueueEntry_RecordSync(t *testing.T) {
+	first := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	second := first.Add(10 * time.Minute)
+
+	q := migration.QueueEntry{}
+
+	q.RecordSync(nil, false, first)
+	require.Empty(t, q.SyncHistory)
+
+	q.RecordSync([]api.WorkerDiskSync{{Name: "disk1", FullCopy: true, CopiedBytes: 1000}}, false, first)
+	q.RecordSync([]api.WorkerDiskSync{{Name: "disk1", CopiedBytes: 100}}, true, second)
+
+	require.Len(t, q.SyncHistory, 2)
+	require.Equal(t, time.Duration(0), q.SyncHistory[0].Interval.Duration)
+	require.False(t, q.SyncHistory[0].Final)
+	require.Equal(t, 10*time.Minute, q.SyncHistory[1].Interval.Duration)
+	require.True(t, q.SyncHistory[1].Final)
+
+	for i := range 200 {
+		q.RecordSync([]api.WorkerDiskSync{{Name: "disk1", CopiedBytes: 100}}, false, second.Add(time.Duration(i)*time.Minute))
+	}
+
+	require.Len(t, q.SyncHistory, 100)
+}
+
+func TestQueueEntry_CheckpointDisks(t *testing.T) {
+	q := migration.QueueEntry{
+		DiskStates: []api.WorkerDiskState{
+			{Name: "disk1", ChangeID: "52 d1/4", SyncedOffset: 2048},
+			{Name: "disk2", ChangeID: "52 e7/9", SyncedOffset: 4096},
+		},
+	}
+
+	q.CheckpointDisks(nil)
+	require.Len(t, q.DiskStates, 2)
+
+	q.CheckpointDisks([]api.WorkerDiskState{{Name: "disk2", ChangeID: "52 e7/12", SnapshotRef: "snapshot-2", SyncedOffset: 1024}})
+	q.CheckpointDisks([]api.WorkerDiskState{{Name: "disk3", ChangeID: "52 a0/1", SnapshotRef: "snapshot-2", SyncedOffset: 512}})
+
+	require.Equal(t, []api.WorkerDiskState{
+		{Name: "disk1", ChangeID: "52 d1/4", SyncedOffset: 2048},
+		{Name: "disk2", ChangeID: "52 e7/12", SnapshotRef: "snapshot-2", SyncedOffset: 1024},
+		{Name: "disk3", ChangeID: "52 a0/1", SnapshotRef: "snapshot-2", SyncedOffset: 512},
+	}, q.DiskStates)
+}
+
+func TestQueueEntry_ForecastFinalImport(t *testing.T) {
+	const mb = 1000 * 1000
+
+	start := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		importStage   migration.ImportStage
+		syncHistory   []api.QueueSyncRecord
+		sinceLastSync time.Duration
+
+		wantChangeRate float64
+		wantThroughput float64
+		want           time.Duration
+	}{
+		{
+			name:          "no history",
+			importStage:   migration.IMPORTSTAGE_BACKGROUND,
+			sinceLastSync: time.Hour,
+		},
+		{
+			name:        "full copy only",
+			importStage: migration.IMPORTSTAGE_BACKGROUND,
+			syncHistory: []api.QueueSyncRecord{
+				{Time: start, Disks: []api.WorkerDiskSync{{Name: "disk1", FullCopy: true, CopiedBytes: 1000 * mb, Duration: api.AsDuration(10 * time.Second)}}},
+			},
+			sinceLastSync: time.Hour,
+
+			wantThroughput: 100 * mb,
+		},
+		{
+			name:        "full copy and incremental syncs",
+			importStage: migration.IMPORTSTAGE_BACKGROUND,
+			syncHistory: []api.QueueSyncRecord{
+				{Time: start, Disks: []api.WorkerDiskSync{{Name: "disk1", FullCopy: true, CopiedBytes: 1000 * mb, Duration: api.AsDuration(10 * time.Second)}}},
+				{Time: start.Add(100 * time.Second), Interval: api.AsDuration(100 * time.Second), Disks: []api.WorkerDiskSync{
+					{Name: "disk1", CopiedBytes: 60 * mb, Duration: api.AsDuration(600 * time.Millisecond)},
+					{Name: "disk2", CopiedBytes: 40 * mb, Duration: api.AsDuration(400 * time.Millisecond)},
+				}},
+			},
+			sinceLastSync: 1000 * time.Second,
+
+			wantChangeRate: 1 * mb,
+			wantThroughput: 100 * mb,
+			want:           10 * time.Second,
+		},
+		{
+			name:        "import already complete",
+			importStage: migration.IMPORTSTAGE_COMPLETE,
+			syncHistory: []api.QueueSyncRecord{
+				{Time: start, Disks: []api.WorkerDiskSync{{Name: "disk1", FullCopy: true, CopiedBytes: 1000 * mb, Duration: api.AsDuration(10 * time.Second)}}},
+				{Time: start.Add(100 * time.Second), Interval: api.AsDuration(100 * time.Second), Final: true, Disks: []api.WorkerDiskSync{{Name: "disk1", CopiedBytes: 100 * mb, Duration: api.AsDuration(time.Second)}}},
+			},
+			sinceLastSync: 1000 * time.Second,
+
+			wantChangeRate: 1 * mb,
+			wantThroughput: 100 * mb,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := migration.QueueEntry{ImportStage: tc.importStage, SyncHistory: tc.syncHistory}
+
+			require.InDelta(t, tc.wantChangeRate, q.ChangeRate(), 0.001)
+			require.InDelta(t, tc.wantThroughput, q.CopyThroughput(), 0.001)
+			require.InDelta(t, tc.want, q.ForecastFinalImport(tc.sinceLastSync), float64(time.Millisecond))
+		})
+	}
+}
+
+func TestQueueHistoryEntries_Downtime(t *testing.T) {
+	start := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	history := migration.QueueHistoryEntries{
+		{MigrationStatus: api.MIGRATIONSTATUS_WAITING, Time: start},
+		{MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT, Time: start.Add(time.Hour)},
+		{MigrationStatus: api.MIGRATIONSTATUS_ERROR, Time: start.Add(2 * time.Hour)},
+	}
+
+	require.Equal(t, time.Duration(0), history.Downtime())
+
+	phases := history.Phases()
+	require.Len(t, phases, 3)
+	require.Equal(t, start.Add(time.Hour), phases[0].End)
+	require.True(t, phases[2].End.IsZero())
+
+	// A retried final import restarts the downtime.
+	history = append(history,
+		migration.QueueHistoryEntry{MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT, Time: start.Add(3 * time.Hour)},
+		migration.QueueHistoryEntry{MigrationStatus: api.MIGRATIONSTATUS_POST_IMPORT, Time: start.Add(3*time.Hour + 10*time.Minute)},
+		migration.QueueHistoryEntry{MigrationStatus: api.MIGRATIONSTATUS_FINISHED, Time: start.Add(3*time.Hour + 15*time.Minute)},
+	)
+
+	require.Equal(t, 15*time.Minute, history.Downtime())
+}
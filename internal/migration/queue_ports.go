@@ -2,6 +2,7 @@ package migration
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -26,10 +27,16 @@ type QueueService interface {
 
 	UpdateStatusByUUID(ctx context.Context, id uuid.UUID, status api.MigrationStatusType, statusMessage string, importStage ImportStage, windowID *string) (*QueueEntry, error)
 	UpdatePlacementByUUID(ctx context.Context, id uuid.UUID, placement api.Placement) (*QueueEntry, error)
+	RecordCutoverByUUID(ctx context.Context, id uuid.UUID, targetStart time.Time, agentReady time.Time) (*QueueEntry, error)
+	RecordSourceCleanupByUUID(ctx context.Context, id uuid.UUID, completed []api.SourceCleanupAction, cleanupErr error) (*QueueEntry, error)
 
 	NewWorkerCommandByInstanceUUID(ctx context.Context, id uuid.UUID) (WorkerCommand, error)
 	ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, workerResp api.WorkerResponse) (QueueEntry, error)
+	GetTransferLimitsByUUID(ctx context.Context, id uuid.UUID) (api.WorkerTransferLimits, error)
 	GetNextWindow(ctx context.Context, q QueueEntry) (*Window, error)
+
+	GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (QueueHistoryEntries, error)
+	GetHistoryByBatch(ctx context.Context, batch string) (QueueHistoryEntries, error)
 }
 
 //go:generate go run github.com/matryer/moq -fmt goimports -pkg mock -out repo/mock/queue_repo_mock_gen.go -rm . QueueRepo
@@ -47,4 +54,8 @@ type QueueRepo interface {
 	Update(ctx context.Context, entry QueueEntry) error
 	DeleteByUUID(ctx context.Context, id uuid.UUID) error
 	DeleteAllByBatch(ctx context.Context, batch string) error
+
+	CreateHistory(ctx context.Context, entry QueueHistoryEntry) (int64, error)
+	GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (QueueHistoryEntries, error)
+	GetHistoryByBatch(ctx context.Context, batch string) (QueueHistoryEntries, error)
 }
@@ -54,7 +54,14 @@ func (s queueService) CreateEntry(ctx context.Context, queue QueueEntry) (QueueE
 		return QueueEntry{}, err
 	}
 
-	queue.ID, err = s.repo.Create(ctx, queue)
+	err = transaction.Do(ctx, func(ctx context.Context) error {
+		queue.ID, err = s.repo.Create(ctx, queue)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, "", queue, nil)
+	})
 	if err != nil {
 		return QueueEntry{}, err
 	}
@@ -101,6 +108,7 @@ func (s queueService) UpdateStatusByUUID(ctx context.Context, id uuid.UUID, stat
 			return fmt.Errorf("Failed to get instance '%s': %w", id, err)
 		}
 
+		prevStatus := q.MigrationStatus
 		q.MigrationStatus = status
 		q.MigrationStatusMessage = statusMessage
 		q.ImportStage = importStage
@@ -111,7 +119,12 @@ func (s queueService) UpdateStatusByUUID(ctx context.Context, id uuid.UUID, stat
 			q.MigrationWindowName = sql.NullString{Valid: true, String: *windowID}
 		}
 
-		return s.repo.Update(ctx, *q)
+		err = s.repo.Update(ctx, *q)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, prevStatus, *q, nil)
 	})
 	if err != nil {
 		return nil, err
@@ -145,10 +158,88 @@ func (s queueService) UpdatePlacementByUUID(ctx context.Context, id uuid.UUID, p
 	return q, nil
 }
 
+// RecordCutoverByUUID records the times the target instance started and its guest agent became ready, and updates the measured downtime of the queue entry.
+func (s queueService) RecordCutoverByUUID(ctx context.Context, id uuid.UUID, targetStart time.Time, agentReady time.Time) (*QueueEntry, error) {
+	var q *QueueEntry
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		var err error
+		q, err = s.repo.GetByInstanceUUID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("Failed to get instance '%s': %w", id, err)
+		}
+
+		q.Cutover.TargetStart = targetStart
+		q.Cutover.AgentReady = agentReady
+		q.Cutover.Downtime = api.AsDuration(q.Cutover.MeasuredDowntime())
+
+		return s.repo.Update(ctx, *q)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// RecordSourceCleanupByUUID records the cleanup actions that were applied to the source VM of the queue entry, and the outcome of the cleanup attempt.
+func (s queueService) RecordSourceCleanupByUUID(ctx context.Context, id uuid.UUID, completed []api.SourceCleanupAction, cleanupErr error) (*QueueEntry, error) {
+	var q *QueueEntry
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		var err error
+		q, err = s.repo.GetByInstanceUUID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("Failed to get instance '%s': %w", id, err)
+		}
+
+		for _, action := range completed {
+			if !slices.Contains(q.SourceCleanup.Completed, action) {
+				q.SourceCleanup.Completed = append(q.SourceCleanup.Completed, action)
+			}
+		}
+
+		q.SourceCleanup.LastAttempt = time.Now().UTC()
+		q.SourceCleanup.LastError = ""
+		if cleanupErr != nil {
+			q.SourceCleanup.LastError = cleanupErr.Error()
+		}
+
+		return s.repo.Update(ctx, *q)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
 func (s queueService) Update(ctx context.Context, entry *QueueEntry) error {
 	return s.repo.Update(ctx, *entry)
 }
 
+// recordHistory adds a history entry for the queue entry if its migration status differs from the given previous status.
+func (s queueService) recordHistory(ctx context.Context, prevStatus api.MigrationStatusType, entry QueueEntry, workerResp *api.WorkerResponse) error {
+	if prevStatus == entry.MigrationStatus {
+		return nil
+	}
+
+	_, err := s.repo.CreateHistory(ctx, NewQueueHistoryEntry(entry, workerResp))
+	if err != nil {
+		return fmt.Errorf("Failed to record history for queue entry %q: %w", entry.InstanceUUID, err)
+	}
+
+	return nil
+}
+
+// GetHistoryByInstanceUUID returns the recorded migration status changes of the instance, oldest first.
+func (s queueService) GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (QueueHistoryEntries, error) {
+	return s.repo.GetHistoryByInstanceUUID(ctx, id)
+}
+
+// GetHistoryByBatch returns the recorded migration status changes of all instances queued by the batch, oldest first.
+func (s queueService) GetHistoryByBatch(ctx context.Context, batch string) (QueueHistoryEntries, error) {
+	return s.repo.GetHistoryByBatch(ctx, batch)
+}
+
 func (s queueService) DeleteByUUID(ctx context.Context, id uuid.UUID) error {
 	return transaction.Do(ctx, func(ctx context.Context) error {
 		entry, err := s.repo.GetByInstanceUUID(ctx, id)
@@ -174,11 +265,21 @@ func (s queueService) DeleteAllByBatch(ctx context.Context, batch string) error
 // - If the instance does not match any constraint, the earliest valid migration window is used.
 // - The earliest migration window valid for the the first matching constraint will be used otherwise.
 // - Returns a 404 if no migration window can be found, but the instance matched a constraint.
+// - Windows must be long enough to fit the forecast final import duration of the instance.
 func (s queueService) GetNextWindow(ctx context.Context, q QueueEntry) (*Window, error) {
+	batch, err := s.batch.GetByName(ctx, q.BatchName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get batch %q: %w", q.BatchName, err)
+	}
+
+	return s.nextWindow(ctx, q, *batch)
+}
+
+// nextWindow returns the next valid migration window for the instance in the given batch, as described for GetNextWindow.
+func (s queueService) nextWindow(ctx context.Context, q QueueEntry, batch Batch) (*Window, error) {
 	var entries QueueEntries
 	var instances Instances
 	var windows Windows
-	var batch *Batch
 	err := transaction.Do(ctx, func(ctx context.Context) error {
 		var err error
 		entries, err = s.GetAllByBatchAndState(ctx, q.BatchName, api.MIGRATIONSTATUS_IDLE, api.MIGRATIONSTATUS_FINAL_IMPORT, api.MIGRATIONSTATUS_POST_IMPORT, api.MIGRATIONSTATUS_WORKER_DONE)
@@ -196,11 +297,6 @@ func (s queueService) GetNextWindow(ctx context.Context, q QueueEntry) (*Window,
 			return fmt.Errorf("Failed to get idle instances for batch %q: %w", q.BatchName, err)
 		}
 
-		batch, err = s.batch.GetByName(ctx, q.BatchName)
-		if err != nil {
-			return fmt.Errorf("Failed to get batch %q: %w", q.BatchName, err)
-		}
-
 		// Filter out windows that are at capacity.
 		allEntries, err := s.GetAll(ctx)
 		if err != nil {
@@ -239,7 +335,7 @@ func (s queueService) GetNextWindow(ctx context.Context, q QueueEntry) (*Window,
 
 	// Use the most recently added constraint that matches this queue entry's instance.
 	var constraint *api.BatchConstraint
-	constraints := batch.Constraints
+	constraints := slices.Clone(batch.Constraints)
 	slices.Reverse(constraints)
 	for _, inst := range instances {
 		if inst.UUID != q.InstanceUUID {
@@ -261,9 +357,12 @@ func (s queueService) GetNextWindow(ctx context.Context, q QueueEntry) (*Window,
 		break
 	}
 
+	// Reserve enough time in the window for the final import, assuming the last background sync happened a full sync interval before the window starts.
+	finalImportTime := q.ForecastFinalImport(batch.Config.BackgroundSyncInterval.Duration)
+
 	// If there are no constraints on the batch, or if the instance matches none of them, just return the earliest migration window.
 	if constraint == nil {
-		return windows.GetEarliest(0)
+		return windows.GetEarliest(finalImportTime)
 	}
 
 	statusMap := make(map[uuid.UUID]api.MigrationStatusType, len(entries))
@@ -295,7 +394,7 @@ func (s queueService) GetNextWindow(ctx context.Context, q QueueEntry) (*Window,
 			minBootTime = constraint.MinInstanceBootTime.Duration
 		}
 
-		return windows.GetEarliest(minBootTime)
+		return windows.GetEarliest(minBootTime + finalImportTime)
 	}
 
 	// Return a 404 if this instance matched a constraint, but no valid migration window could be found.
@@ -321,13 +420,19 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 			return fmt.Errorf("Failed to get instance %q: %w", id, err)
 		}
 
+		// A canceled migration stops the worker, whatever it was doing.
+		abort := queueEntry.MigrationStatus == api.MIGRATIONSTATUS_CANCELED
+
+		// A worker only asks for a command while it isn't running one. If the migration is still in an import step, the worker either restarted,
+		// or never received the command it was given, so that command is sent again.
 		var restartWorker bool
-		if queueEntry.MigrationStatus != api.MIGRATIONSTATUS_IDLE {
-			if queueEntry.LastWorkerStatus != api.WORKERRESPONSE_RUNNING {
+		if !abort && queueEntry.MigrationStatus != api.MIGRATIONSTATUS_IDLE {
+			switch queueEntry.MigrationStatus {
+			case api.MIGRATIONSTATUS_BACKGROUND_IMPORT, api.MIGRATIONSTATUS_FINAL_IMPORT, api.MIGRATIONSTATUS_POST_IMPORT:
+				restartWorker = true
+			default:
 				return fmt.Errorf("Instance '%s' isn't idle: %s (%s): %w", instance.Properties.Location, queueEntry.MigrationStatus, queueEntry.MigrationStatusMessage, ErrOperationNotPermitted)
 			}
-
-			restartWorker = true
 		}
 
 		// Fetch the source for the instance.
@@ -336,6 +441,17 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 			return fmt.Errorf("Failed to get source %q: %w", instance.Source, err)
 		}
 
+		var sourceProperties api.VMwareProperties
+		err = json.Unmarshal(source.Properties, &sourceProperties)
+		if err != nil {
+			return fmt.Errorf("Failed to get source %q properties: %w", instance.Source, err)
+		}
+
+		batch, err := s.batch.GetByName(ctx, queueEntry.BatchName)
+		if err != nil {
+			return fmt.Errorf("Failed to get queue entry batch %q: %w", queueEntry.BatchName, err)
+		}
+
 		instance.Properties.Apply(instance.Overrides.InstancePropertiesConfigurable)
 		// Setup the default "idle" command
 
@@ -349,9 +465,16 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 			OSType:        instance.GetOSType(true),
 			Distro:        distro,
 			DistroVersion: distroVersion,
+			DiskStates:    queueEntry.DiskStates,
+			DroppedDisks:  DroppedDisks(queueEntry.Placement),
 		}
 
-		// If the last worker response was RUNNING, then skip validation and just send the response it wants.
+		if abort {
+			workerCommand.Command = api.WORKERCOMMAND_ABORT
+			return nil
+		}
+
+		// Skip validation when restarting the worker, and just send the command of the current step.
 		if restartWorker {
 			switch queueEntry.MigrationStatus {
 			case api.MIGRATIONSTATUS_BACKGROUND_IMPORT:
@@ -360,17 +483,23 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 				workerCommand.Command = api.WORKERCOMMAND_FINALIZE_IMPORT
 			case api.MIGRATIONSTATUS_POST_IMPORT:
 				workerCommand.Command = api.WORKERCOMMAND_POST_IMPORT
+				workerCommand.GuestCustomization = batch.Config.GuestCustomization.Apply(instance.Overrides.GuestCustomization)
+				return nil
 			default:
 				return fmt.Errorf("Unable to restart worker for instance in state %q: %w", queueEntry.MigrationStatus, ErrOperationNotPermitted)
 			}
 
-			return nil
-		}
+			workerCommand.TransferLimits, err = s.transferLimits(ctx, *queueEntry, *instance, *batch, sourceProperties)
+			if err != nil {
+				return err
+			}
 
-		var sourceProperties api.VMwareProperties
-		err = json.Unmarshal(source.Properties, &sourceProperties)
-		if err != nil {
-			return fmt.Errorf("Failed to get source %q properties: %w", instance.Source, err)
+			if workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
+				workerCommand.Verification = batch.Config.Verification
+				workerCommand.ShutdownPolicy = batch.Config.Shutdown.Apply(instance.Overrides.Shutdown)
+			}
+
+			return nil
 		}
 
 		target, err := s.target.GetByName(ctx, queueEntry.Placement.TargetName)
@@ -408,7 +537,7 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 			newStatus = api.MIGRATIONSTATUS_BACKGROUND_IMPORT
 			newStatusMessage = string(api.MIGRATIONSTATUS_BACKGROUND_IMPORT)
 		} else {
-			window, err := s.GetNextWindow(ctx, *queueEntry)
+			window, err := s.nextWindow(ctx, *queueEntry, *batch)
 			if err != nil && !incusAPI.StatusErrorCheck(err, http.StatusNotFound) {
 				return err
 			}
@@ -437,6 +566,7 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 					workerCommand.Command = api.WORKERCOMMAND_POST_IMPORT
 					newStatus = api.MIGRATIONSTATUS_POST_IMPORT
 					newStatusMessage = string(api.MIGRATIONSTATUS_POST_IMPORT)
+					workerCommand.GuestCustomization = batch.Config.GuestCustomization.Apply(instance.Overrides.GuestCustomization)
 				}
 			} else {
 				// Only perform background resync if it's supported and we haven't entered final migration anyway.
@@ -451,11 +581,6 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 					return nil
 				}
 
-				batch, err := s.batch.GetByName(ctx, queueEntry.BatchName)
-				if err != nil {
-					return fmt.Errorf("Failed to get queue entry batch %q: %w", queueEntry.BatchName, err)
-				}
-
 				now := time.Now().UTC()
 				var resync bool
 				// It has been more then BackgroundSyncInterval time since the last sync.
@@ -488,10 +613,23 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 		}
 
 		if newStatus != api.MIGRATIONSTATUS_IDLE && newStatus != api.MIGRATIONSTATUS_POST_IMPORT {
-			s.source.RecordActiveImport(instance.Source)
+			s.source.RecordActiveImport(*instance)
 			s.target.RecordActiveImport(queueEntry.Placement.TargetName)
 		}
 
+		// Share the source's transfer limit with the imports already running, including this one.
+		if workerCommand.Command == api.WORKERCOMMAND_IMPORT_DISKS || workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
+			workerCommand.TransferLimits, err = s.transferLimits(ctx, *queueEntry, *instance, *batch, sourceProperties)
+			if err != nil {
+				return err
+			}
+		}
+
+		if workerCommand.Command == api.WORKERCOMMAND_FINALIZE_IMPORT {
+			workerCommand.Verification = batch.Config.Verification
+			workerCommand.ShutdownPolicy = batch.Config.Shutdown.Apply(instance.Overrides.Shutdown)
+		}
+
 		// Update queueEntry in the database, and set the worker update time.
 		if newStatus != queueEntry.MigrationStatus || newStatusMessage != queueEntry.MigrationStatusMessage || newImportStage != queueEntry.ImportStage {
 			_, err = s.UpdateStatusByUUID(ctx, instance.UUID, newStatus, newStatusMessage, newImportStage, windowName)
@@ -509,6 +647,88 @@ func (s queueService) NewWorkerCommandByInstanceUUID(ctx context.Context, id uui
 	return workerCommand, nil
 }
 
+// GetTransferLimitsByUUID returns the current disk transfer limits for the worker of the instance with the given UUID.
+// Workers re-read these periodically, so that bandwidth schedules take effect during long transfers.
+func (s queueService) GetTransferLimitsByUUID(ctx context.Context, id uuid.UUID) (api.WorkerTransferLimits, error) {
+	var limits api.WorkerTransferLimits
+	err := transaction.Do(ctx, func(ctx context.Context) error {
+		queueEntry, err := s.repo.GetByInstanceUUID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("Failed to get queue entry for instance %q: %w", id, err)
+		}
+
+		instance, err := s.instance.GetByUUID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("Failed to get instance %q: %w", id, err)
+		}
+
+		source, err := s.source.GetByName(ctx, instance.Source)
+		if err != nil {
+			return fmt.Errorf("Failed to get source %q: %w", instance.Source, err)
+		}
+
+		var sourceProperties api.VMwareProperties
+		err = json.Unmarshal(source.Properties, &sourceProperties)
+		if err != nil {
+			return fmt.Errorf("Failed to get source %q properties: %w", instance.Source, err)
+		}
+
+		batch, err := s.batch.GetByName(ctx, queueEntry.BatchName)
+		if err != nil {
+			return fmt.Errorf("Failed to get queue entry batch %q: %w", queueEntry.BatchName, err)
+		}
+
+		limits, err = s.transferLimits(ctx, *queueEntry, *instance, *batch, sourceProperties)
+		return err
+	})
+	if err != nil {
+		return api.WorkerTransferLimits{}, err
+	}
+
+	return limits, nil
+}
+
+// transferLimits returns the disk transfer limits for the given queue entry, sharing the source and batch limits with other active imports.
+// The source's transfer limit is shared only with the imports reading from the same datastores as the instance.
+func (s queueService) transferLimits(ctx context.Context, queueEntry QueueEntry, instance Instance, batch Batch, sourceProperties api.VMwareProperties) (api.WorkerTransferLimits, error) {
+	sourceName := instance.Source
+	activeImports := s.source.GetCachedImports(sourceName)
+	limits := NewWorkerTransferLimits(sourceProperties, s.source.GetCachedDatastoreImports(instance))
+
+	now := time.Now().UTC()
+	sourceBandwidth, err := BandwidthLimitAt(sourceProperties.Bandwidth, now)
+	if err != nil {
+		return api.WorkerTransferLimits{}, fmt.Errorf("Failed to get source %q bandwidth limit: %w", sourceName, err)
+	}
+
+	limits.Bandwidth = shareBandwidth(sourceBandwidth, activeImports)
+
+	batchBandwidth, err := BandwidthLimitAt(batch.Config.Bandwidth, now)
+	if err != nil {
+		return api.WorkerTransferLimits{}, fmt.Errorf("Failed to get batch %q bandwidth limit: %w", batch.Name, err)
+	}
+
+	if batchBandwidth > 0 {
+		importing, err := s.repo.GetAllByBatchAndState(ctx, batch.Name, api.MIGRATIONSTATUS_BACKGROUND_IMPORT, api.MIGRATIONSTATUS_FINAL_IMPORT)
+		if err != nil {
+			return api.WorkerTransferLimits{}, fmt.Errorf("Failed to get importing queue entries for batch %q: %w", batch.Name, err)
+		}
+
+		// The queue entry may not have been moved into an import state yet.
+		transfers := len(importing)
+		if !slices.ContainsFunc(importing, func(q QueueEntry) bool { return q.InstanceUUID == queueEntry.InstanceUUID }) {
+			transfers++
+		}
+
+		batchBandwidth = shareBandwidth(batchBandwidth, transfers)
+		if limits.Bandwidth == 0 || batchBandwidth < limits.Bandwidth {
+			limits.Bandwidth = batchBandwidth
+		}
+	}
+
+	return limits, nil
+}
+
 func (s queueService) ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, workerResp api.WorkerResponse) (QueueEntry, error) {
 	var entry *QueueEntry
 
@@ -520,15 +740,45 @@ func (s queueService) ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, wor
 			return fmt.Errorf("Failed to get instance '%s': %w", id, err)
 		}
 
+		// Once canceled, only the worker's acknowledgement of the abort is recorded. Results of work that was already underway are dropped.
+		if entry.MigrationStatus == api.MIGRATIONSTATUS_CANCELED {
+			if workerResp.Status != api.WORKERRESPONSE_ABORTED {
+				return nil
+			}
+
+			entry.MigrationStatusMessage = workerResp.StatusMessage
+			entry.LastWorkerStatus = workerResp.Status
+			err = s.Update(ctx, entry)
+			if err != nil {
+				return fmt.Errorf("Failed updating instance '%s': %w", entry.InstanceUUID, err)
+			}
+
+			// The status doesn't change, but the acknowledgement is still worth a history entry.
+			_, err = s.repo.CreateHistory(ctx, NewQueueHistoryEntry(*entry, &workerResp))
+			if err != nil {
+				return fmt.Errorf("Failed to record history for queue entry %q: %w", entry.InstanceUUID, err)
+			}
+
+			return nil
+		}
+
 		// Don't update instances that aren't in the migration queue.
 		if !entry.IsMigrating() {
 			return fmt.Errorf("Instance %q isn't in the migration queue: %w", entry.InstanceUUID, ErrNotFound)
 		}
 
+		prevStatus := entry.MigrationStatus
+
+		// Each report carries every shutdown attempt so far, so it replaces the last one.
+		if len(workerResp.ShutdownAttempts) > 0 {
+			entry.Cutover.ShutdownAttempts = workerResp.ShutdownAttempts
+		}
+
 		// Process the response.
 		switch workerResp.Status {
 		case api.WORKERRESPONSE_RUNNING:
 			entry.MigrationStatusMessage = workerResp.StatusMessage
+			entry.CheckpointDisks(workerResp.DiskStates)
 
 		case api.WORKERRESPONSE_SUCCESS:
 			switch entry.MigrationStatus {
@@ -537,8 +787,28 @@ func (s queueService) ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, wor
 				entry.MigrationStatus = api.MIGRATIONSTATUS_IDLE
 				entry.MigrationStatusMessage = "Waiting for migration window"
 				entry.LastBackgroundSync = time.Now().UTC()
+				entry.RecordSync(workerResp.DiskSyncs, false, entry.LastBackgroundSync)
+				entry.DiskStates = workerResp.DiskStates
 
 			case api.MIGRATIONSTATUS_FINAL_IMPORT:
+				now := time.Now().UTC()
+				entry.RecordSync(workerResp.DiskSyncs, true, now)
+				entry.DiskStates = workerResp.DiskStates
+				entry.Cutover = api.QueueCutover{SourcePowerOff: workerResp.SourcePowerOff, FinalImportComplete: now, ShutdownAttempts: entry.Cutover.ShutdownAttempts}
+
+				batch, err := s.batch.GetByName(ctx, entry.BatchName)
+				if err != nil {
+					return fmt.Errorf("Failed to get batch %q: %w", entry.BatchName, err)
+				}
+
+				// Stop before cutover if the imported disks don't match the source.
+				err = verifyDiskSyncs(batch.Config.Verification, workerResp.DiskSyncs)
+				if err != nil {
+					entry.MigrationStatus = api.MIGRATIONSTATUS_ERROR
+					entry.MigrationStatusMessage = err.Error()
+					break
+				}
+
 				entry.ImportStage = IMPORTSTAGE_COMPLETE
 				entry.MigrationStatus = api.MIGRATIONSTATUS_IDLE
 				entry.MigrationStatusMessage = "Waiting for worker to begin post-import tasks"
@@ -570,7 +840,7 @@ func (s queueService) ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, wor
 				return fmt.Errorf("Failed to get instance %q: %w", id, err)
 			}
 
-			s.source.RemoveActiveImport(instance.Source)
+			s.source.RemoveActiveImport(*instance)
 			s.target.RemoveActiveImport(entry.Placement.TargetName)
 		}
 
@@ -582,7 +852,7 @@ func (s queueService) ProcessWorkerUpdate(ctx context.Context, id uuid.UUID, wor
 			return fmt.Errorf("Failed updating instance '%s': %w", uuid, err)
 		}
 
-		return nil
+		return s.recordHistory(ctx, prevStatus, *entry, &workerResp)
 	})
 	if err != nil {
 		return QueueEntry{}, err
@@ -609,6 +879,17 @@ func (s queueService) CancelByUUID(ctx context.Context, id uuid.UUID) (*QueueEnt
 			return fmt.Errorf("Queue entry %q is already finished", q.InstanceUUID)
 		}
 
+		// The worker aborts the running disk import, so its import slot is free for other instances.
+		if q.MigrationStatus == api.MIGRATIONSTATUS_BACKGROUND_IMPORT || q.MigrationStatus == api.MIGRATIONSTATUS_FINAL_IMPORT {
+			instance, err := s.instance.GetByUUID(ctx, id)
+			if err != nil {
+				return fmt.Errorf("Failed to get instance %q: %w", id, err)
+			}
+
+			s.source.RemoveActiveImport(*instance)
+			s.target.RemoveActiveImport(q.Placement.TargetName)
+		}
+
 		newQueue, err = s.UpdateStatusByUUID(ctx, q.InstanceUUID, api.MIGRATIONSTATUS_CANCELED, q.MigrationStatusMessage, IMPORTSTAGE_BACKGROUND, nil)
 		if err != nil {
 			return err
@@ -673,13 +954,22 @@ func (s queueService) RetryByUUID(ctx context.Context, id uuid.UUID, networkSvc
 			message = err.Error()
 		}
 
+		prevStatus := q.MigrationStatus
 		q.MigrationStatus = status
 		q.MigrationStatusMessage = message
 		q.ImportStage = IMPORTSTAGE_BACKGROUND
 		q.MigrationWindowName = sql.NullString{}
 		q.Placement = *placement
 
-		return s.Update(ctx, q)
+		// The target instance is recreated on retry, so previous disk syncs no longer apply.
+		q.DiskStates = nil
+
+		err = s.Update(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		return s.recordHistory(ctx, prevStatus, *q, nil)
 	})
 	if err != nil {
 		return nil, err
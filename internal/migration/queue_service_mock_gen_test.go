@@ -6,6 +6,7 @@ package migration_test
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/FuturFusion/migration-manager/internal/migration"
 	"github.com/FuturFusion/migration-manager/shared/api"
@@ -52,15 +53,30 @@ var _ migration.QueueService = &QueueServiceMock{}
 //			GetByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error) {
 //				panic("mock out the GetByInstanceUUID method")
 //			},
+//			GetHistoryByBatchFunc: func(ctx context.Context, batch string) (migration.QueueHistoryEntries, error) {
+//				panic("mock out the GetHistoryByBatch method")
+//			},
+//			GetHistoryByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error) {
+//				panic("mock out the GetHistoryByInstanceUUID method")
+//			},
 //			GetNextWindowFunc: func(ctx context.Context, q migration.QueueEntry) (*migration.Window, error) {
 //				panic("mock out the GetNextWindow method")
 //			},
+//			GetTransferLimitsByUUIDFunc: func(ctx context.Context, id uuid.UUID) (api.WorkerTransferLimits, error) {
+//				panic("mock out the GetTransferLimitsByUUID method")
+//			},
 //			NewWorkerCommandByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (migration.WorkerCommand, error) {
 //				panic("mock out the NewWorkerCommandByInstanceUUID method")
 //			},
 //			ProcessWorkerUpdateFunc: func(ctx context.Context, id uuid.UUID, workerResp api.WorkerResponse) (migration.QueueEntry, error) {
 //				panic("mock out the ProcessWorkerUpdate method")
 //			},
+//			RecordCutoverByUUIDFunc: func(ctx context.Context, id uuid.UUID, targetStart time.Time, agentReady time.Time) (*migration.QueueEntry, error) {
+//				panic("mock out the RecordCutoverByUUID method")
+//			},
+//			RecordSourceCleanupByUUIDFunc: func(ctx context.Context, id uuid.UUID, completed []api.SourceCleanupAction, cleanupErr error) (*migration.QueueEntry, error) {
+//				panic("mock out the RecordSourceCleanupByUUID method")
+//			},
 //			RetryByUUIDFunc: func(ctx context.Context, id uuid.UUID, networkSvc migration.NetworkService) (*migration.QueueEntry, error) {
 //				panic("mock out the RetryByUUID method")
 //			},
@@ -110,15 +126,30 @@ type QueueServiceMock struct {
 	// GetByInstanceUUIDFunc mocks the GetByInstanceUUID method.
 	GetByInstanceUUIDFunc func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error)
 
+	// GetHistoryByBatchFunc mocks the GetHistoryByBatch method.
+	GetHistoryByBatchFunc func(ctx context.Context, batch string) (migration.QueueHistoryEntries, error)
+
+	// GetHistoryByInstanceUUIDFunc mocks the GetHistoryByInstanceUUID method.
+	GetHistoryByInstanceUUIDFunc func(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error)
+
 	// GetNextWindowFunc mocks the GetNextWindow method.
 	GetNextWindowFunc func(ctx context.Context, q migration.QueueEntry) (*migration.Window, error)
 
+	// GetTransferLimitsByUUIDFunc mocks the GetTransferLimitsByUUID method.
+	GetTransferLimitsByUUIDFunc func(ctx context.Context, id uuid.UUID) (api.WorkerTransferLimits, error)
+
 	// NewWorkerCommandByInstanceUUIDFunc mocks the NewWorkerCommandByInstanceUUID method.
 	NewWorkerCommandByInstanceUUIDFunc func(ctx context.Context, id uuid.UUID) (migration.WorkerCommand, error)
 
 	// ProcessWorkerUpdateFunc mocks the ProcessWorkerUpdate method.
 	ProcessWorkerUpdateFunc func(ctx context.Context, id uuid.UUID, workerResp api.WorkerResponse) (migration.QueueEntry, error)
 
+	// RecordCutoverByUUIDFunc mocks the RecordCutoverByUUID method.
+	RecordCutoverByUUIDFunc func(ctx context.Context, id uuid.UUID, targetStart time.Time, agentReady time.Time) (*migration.QueueEntry, error)
+
+	// RecordSourceCleanupByUUIDFunc mocks the RecordSourceCleanupByUUID method.
+	RecordSourceCleanupByUUIDFunc func(ctx context.Context, id uuid.UUID, completed []api.SourceCleanupAction, cleanupErr error) (*migration.QueueEntry, error)
+
 	// RetryByUUIDFunc mocks the RetryByUUID method.
 	RetryByUUIDFunc func(ctx context.Context, id uuid.UUID, networkSvc migration.NetworkService) (*migration.QueueEntry, error)
 
@@ -205,6 +236,20 @@ type QueueServiceMock struct {
 			// ID is the id argument value.
 			ID uuid.UUID
 		}
+		// GetHistoryByBatch holds details about calls to the GetHistoryByBatch method.
+		GetHistoryByBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Batch is the batch argument value.
+			Batch string
+		}
+		// GetHistoryByInstanceUUID holds details about calls to the GetHistoryByInstanceUUID method.
+		GetHistoryByInstanceUUID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+		}
 		// GetNextWindow holds details about calls to the GetNextWindow method.
 		GetNextWindow []struct {
 			// Ctx is the ctx argument value.
@@ -212,6 +257,13 @@ type QueueServiceMock struct {
 			// Q is the q argument value.
 			Q migration.QueueEntry
 		}
+		// GetTransferLimitsByUUID holds details about calls to the GetTransferLimitsByUUID method.
+		GetTransferLimitsByUUID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+		}
 		// NewWorkerCommandByInstanceUUID holds details about calls to the NewWorkerCommandByInstanceUUID method.
 		NewWorkerCommandByInstanceUUID []struct {
 			// Ctx is the ctx argument value.
@@ -228,6 +280,28 @@ type QueueServiceMock struct {
 			// WorkerResp is the workerResp argument value.
 			WorkerResp api.WorkerResponse
 		}
+		// RecordCutoverByUUID holds details about calls to the RecordCutoverByUUID method.
+		RecordCutoverByUUID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+			// TargetStart is the targetStart argument value.
+			TargetStart time.Time
+			// AgentReady is the agentReady argument value.
+			AgentReady time.Time
+		}
+		// RecordSourceCleanupByUUID holds details about calls to the RecordSourceCleanupByUUID method.
+		RecordSourceCleanupByUUID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+			// Completed is the completed argument value.
+			Completed []api.SourceCleanupAction
+			// CleanupErr is the cleanupErr argument value.
+			CleanupErr error
+		}
 		// RetryByUUID holds details about calls to the RetryByUUID method.
 		RetryByUUID []struct {
 			// Ctx is the ctx argument value.
@@ -279,9 +353,14 @@ type QueueServiceMock struct {
 	lockGetAllByState                  sync.RWMutex
 	lockGetAllNeedingImport            sync.RWMutex
 	lockGetByInstanceUUID              sync.RWMutex
+	lockGetHistoryByBatch              sync.RWMutex
+	lockGetHistoryByInstanceUUID       sync.RWMutex
 	lockGetNextWindow                  sync.RWMutex
+	lockGetTransferLimitsByUUID        sync.RWMutex
 	lockNewWorkerCommandByInstanceUUID sync.RWMutex
 	lockProcessWorkerUpdate            sync.RWMutex
+	lockRecordCutoverByUUID            sync.RWMutex
+	lockRecordSourceCleanupByUUID      sync.RWMutex
 	lockRetryByUUID                    sync.RWMutex
 	lockUpdate                         sync.RWMutex
 	lockUpdatePlacementByUUID          sync.RWMutex
@@ -652,6 +731,78 @@ func (mock *QueueServiceMock) GetByInstanceUUIDCalls() []struct {
 	return calls
 }
 
+// GetHistoryByBatch calls GetHistoryByBatchFunc.
+func (mock *QueueServiceMock) GetHistoryByBatch(ctx context.Context, batch string) (migration.QueueHistoryEntries, error) {
+	if mock.GetHistoryByBatchFunc == nil {
+		panic("QueueServiceMock.GetHistoryByBatchFunc: method is nil but QueueService.GetHistoryByBatch was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Batch string
+	}{
+		Ctx:   ctx,
+		Batch: batch,
+	}
+	mock.lockGetHistoryByBatch.Lock()
+	mock.calls.GetHistoryByBatch = append(mock.calls.GetHistoryByBatch, callInfo)
+	mock.lockGetHistoryByBatch.Unlock()
+	return mock.GetHistoryByBatchFunc(ctx, batch)
+}
+
+// GetHistoryByBatchCalls gets all the calls that were made to GetHistoryByBatch.
+// Check the length with:
+//
+//	len(mockedQueueService.GetHistoryByBatchCalls())
+func (mock *QueueServiceMock) GetHistoryByBatchCalls() []struct {
+	Ctx   context.Context
+	Batch string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Batch string
+	}
+	mock.lockGetHistoryByBatch.RLock()
+	calls = mock.calls.GetHistoryByBatch
+	mock.lockGetHistoryByBatch.RUnlock()
+	return calls
+}
+
+// GetHistoryByInstanceUUID calls GetHistoryByInstanceUUIDFunc.
+func (mock *QueueServiceMock) GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error) {
+	if mock.GetHistoryByInstanceUUIDFunc == nil {
+		panic("QueueServiceMock.GetHistoryByInstanceUUIDFunc: method is nil but QueueService.GetHistoryByInstanceUUID was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGetHistoryByInstanceUUID.Lock()
+	mock.calls.GetHistoryByInstanceUUID = append(mock.calls.GetHistoryByInstanceUUID, callInfo)
+	mock.lockGetHistoryByInstanceUUID.Unlock()
+	return mock.GetHistoryByInstanceUUIDFunc(ctx, id)
+}
+
+// GetHistoryByInstanceUUIDCalls gets all the calls that were made to GetHistoryByInstanceUUID.
+// Check the length with:
+//
+//	len(mockedQueueService.GetHistoryByInstanceUUIDCalls())
+func (mock *QueueServiceMock) GetHistoryByInstanceUUIDCalls() []struct {
+	Ctx context.Context
+	ID  uuid.UUID
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}
+	mock.lockGetHistoryByInstanceUUID.RLock()
+	calls = mock.calls.GetHistoryByInstanceUUID
+	mock.lockGetHistoryByInstanceUUID.RUnlock()
+	return calls
+}
+
 // GetNextWindow calls GetNextWindowFunc.
 func (mock *QueueServiceMock) GetNextWindow(ctx context.Context, q migration.QueueEntry) (*migration.Window, error) {
 	if mock.GetNextWindowFunc == nil {
@@ -688,6 +839,42 @@ func (mock *QueueServiceMock) GetNextWindowCalls() []struct {
 	return calls
 }
 
+// GetTransferLimitsByUUID calls GetTransferLimitsByUUIDFunc.
+func (mock *QueueServiceMock) GetTransferLimitsByUUID(ctx context.Context, id uuid.UUID) (api.WorkerTransferLimits, error) {
+	if mock.GetTransferLimitsByUUIDFunc == nil {
+		panic("QueueServiceMock.GetTransferLimitsByUUIDFunc: method is nil but QueueService.GetTransferLimitsByUUID was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGetTransferLimitsByUUID.Lock()
+	mock.calls.GetTransferLimitsByUUID = append(mock.calls.GetTransferLimitsByUUID, callInfo)
+	mock.lockGetTransferLimitsByUUID.Unlock()
+	return mock.GetTransferLimitsByUUIDFunc(ctx, id)
+}
+
+// GetTransferLimitsByUUIDCalls gets all the calls that were made to GetTransferLimitsByUUID.
+// Check the length with:
+//
+//	len(mockedQueueService.GetTransferLimitsByUUIDCalls())
+func (mock *QueueServiceMock) GetTransferLimitsByUUIDCalls() []struct {
+	Ctx context.Context
+	ID  uuid.UUID
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}
+	mock.lockGetTransferLimitsByUUID.RLock()
+	calls = mock.calls.GetTransferLimitsByUUID
+	mock.lockGetTransferLimitsByUUID.RUnlock()
+	return calls
+}
+
 // NewWorkerCommandByInstanceUUID calls NewWorkerCommandByInstanceUUIDFunc.
 func (mock *QueueServiceMock) NewWorkerCommandByInstanceUUID(ctx context.Context, id uuid.UUID) (migration.WorkerCommand, error) {
 	if mock.NewWorkerCommandByInstanceUUIDFunc == nil {
@@ -764,6 +951,94 @@ func (mock *QueueServiceMock) ProcessWorkerUpdateCalls() []struct {
 	return calls
 }
 
+// RecordCutoverByUUID calls RecordCutoverByUUIDFunc.
+func (mock *QueueServiceMock) RecordCutoverByUUID(ctx context.Context, id uuid.UUID, targetStart time.Time, agentReady time.Time) (*migration.QueueEntry, error) {
+	if mock.RecordCutoverByUUIDFunc == nil {
+		panic("QueueServiceMock.RecordCutoverByUUIDFunc: method is nil but QueueService.RecordCutoverByUUID was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		ID          uuid.UUID
+		TargetStart time.Time
+		AgentReady  time.Time
+	}{
+		Ctx:         ctx,
+		ID:          id,
+		TargetStart: targetStart,
+		AgentReady:  agentReady,
+	}
+	mock.lockRecordCutoverByUUID.Lock()
+	mock.calls.RecordCutoverByUUID = append(mock.calls.RecordCutoverByUUID, callInfo)
+	mock.lockRecordCutoverByUUID.Unlock()
+	return mock.RecordCutoverByUUIDFunc(ctx, id, targetStart, agentReady)
+}
+
+// RecordCutoverByUUIDCalls gets all the calls that were made to RecordCutoverByUUID.
+// Check the length with:
+//
+//	len(mockedQueueService.RecordCutoverByUUIDCalls())
+func (mock *QueueServiceMock) RecordCutoverByUUIDCalls() []struct {
+	Ctx         context.Context
+	ID          uuid.UUID
+	TargetStart time.Time
+	AgentReady  time.Time
+} {
+	var calls []struct {
+		Ctx         context.Context
+		ID          uuid.UUID
+		TargetStart time.Time
+		AgentReady  time.Time
+	}
+	mock.lockRecordCutoverByUUID.RLock()
+	calls = mock.calls.RecordCutoverByUUID
+	mock.lockRecordCutoverByUUID.RUnlock()
+	return calls
+}
+
+// RecordSourceCleanupByUUID calls RecordSourceCleanupByUUIDFunc.
+func (mock *QueueServiceMock) RecordSourceCleanupByUUID(ctx context.Context, id uuid.UUID, completed []api.SourceCleanupAction, cleanupErr error) (*migration.QueueEntry, error) {
+	if mock.RecordSourceCleanupByUUIDFunc == nil {
+		panic("QueueServiceMock.RecordSourceCleanupByUUIDFunc: method is nil but QueueService.RecordSourceCleanupByUUID was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		ID         uuid.UUID
+		Completed  []api.SourceCleanupAction
+		CleanupErr error
+	}{
+		Ctx:        ctx,
+		ID:         id,
+		Completed:  completed,
+		CleanupErr: cleanupErr,
+	}
+	mock.lockRecordSourceCleanupByUUID.Lock()
+	mock.calls.RecordSourceCleanupByUUID = append(mock.calls.RecordSourceCleanupByUUID, callInfo)
+	mock.lockRecordSourceCleanupByUUID.Unlock()
+	return mock.RecordSourceCleanupByUUIDFunc(ctx, id, completed, cleanupErr)
+}
+
+// RecordSourceCleanupByUUIDCalls gets all the calls that were made to RecordSourceCleanupByUUID.
+// Check the length with:
+//
+//	len(mockedQueueService.RecordSourceCleanupByUUIDCalls())
+func (mock *QueueServiceMock) RecordSourceCleanupByUUIDCalls() []struct {
+	Ctx        context.Context
+	ID         uuid.UUID
+	Completed  []api.SourceCleanupAction
+	CleanupErr error
+} {
+	var calls []struct {
+		Ctx        context.Context
+		ID         uuid.UUID
+		Completed  []api.SourceCleanupAction
+		CleanupErr error
+	}
+	mock.lockRecordSourceCleanupByUUID.RLock()
+	calls = mock.calls.RecordSourceCleanupByUUID
+	mock.lockRecordSourceCleanupByUUID.RUnlock()
+	return calls
+}
+
 // RetryByUUID calls RetryByUUIDFunc.
 func (mock *QueueServiceMock) RetryByUUID(ctx context.Context, id uuid.UUID, networkSvc migration.NetworkService) (*migration.QueueEntry, error) {
 	if mock.RetryByUUIDFunc == nil {
@@ -342,14 +342,163 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: require.NoError,
 			wantWorkerCommand: migration.WorkerCommand{
-				Command:       api.WORKERCOMMAND_FINALIZE_IMPORT,
-				Location:      "/some/instance/A",
-				SourceType:    api.SOURCETYPE_VMWARE,
-				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(`{"import_limit": 1}`)},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(`{"import_limit": 1}`)},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
+			},
+			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
+			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
+		},
+		{
+			name:    "success - transfer limit shared with active imports",
+			uuidArg: uuidA,
+
+			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_IDLE, Placement: api.Placement{TargetName: "one"}},
+
+			batchSvcGetByName: migration.Batch{Defaults: defaultPlacement, Name: "one"},
+			instanceSvcGetByIDInstance: migration.Instance{
+				UUID:       uuidA,
+				Source:     "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: api.InstanceProperties{
+					Location:      "/some/instance/A",
+					OS:            "ubuntu",
+					OSDescription: "Ubuntu 24.04",
+				},
+			},
+			sourceSvcGetByIDSource: migration.Source{
+				ID:         1,
+				Name:       "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: []byte(`{"import_limit": 4, "disk_parallelism": 4, "disk_connections": 4, "transfer_limit": 8}`),
+			},
+			sourceImportLimit: 3,
+
+			targetSvcGetByIDTarget: migration.Target{
+				ID:         1,
+				Name:       "one",
+				TargetType: api.TARGETTYPE_INCUS,
+				Properties: []byte(`{"import_limit": 1}`),
+			},
+
+			assertErr: require.NoError,
+			wantWorkerCommand: migration.WorkerCommand{
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(`{"import_limit": 4, "disk_parallelism": 4, "disk_connections": 4, "transfer_limit": 8}`)},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 2},
+			},
+			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
+			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
+		},
+		{
+			name:    "success - disk states handed back to the worker",
+			uuidArg: uuidA,
+
+			repoGetByInstanceUUID: migration.QueueEntry{
+				InstanceUUID:    uuidA,
+				BatchName:       "one",
+				MigrationStatus: api.MIGRATIONSTATUS_IDLE,
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Placement:       api.Placement{TargetName: "one"},
+				DiskStates:      []api.WorkerDiskState{{Name: "[datastore] disk_1.vmdk", ChangeID: "52 d1/4", SnapshotRef: "snapshot-1", SyncedOffset: 1024}},
+			},
+
+			batchSvcGetByName: migration.Batch{Defaults: defaultPlacement, Name: "one"},
+			instanceSvcGetByIDInstance: migration.Instance{
+				UUID:       uuidA,
+				Source:     "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: api.InstanceProperties{
+					Location:      "/some/instance/A",
+					OS:            "ubuntu",
+					OSDescription: "Ubuntu 24.04",
+				},
+			},
+			sourceSvcGetByIDSource: migration.Source{
+				ID:         1,
+				Name:       "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: []byte(`{"import_limit": 1}`),
+			},
+
+			targetSvcGetByIDTarget: migration.Target{
+				ID:         1,
+				Name:       "one",
+				TargetType: api.TARGETTYPE_INCUS,
+				Properties: []byte(`{"import_limit": 1}`),
+			},
+
+			assertErr: require.NoError,
+			wantWorkerCommand: migration.WorkerCommand{
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(`{"import_limit": 1}`)},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
+				DiskStates:     []api.WorkerDiskState{{Name: "[datastore] disk_1.vmdk", ChangeID: "52 d1/4", SnapshotRef: "snapshot-1", SyncedOffset: 1024}},
+			},
+			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
+			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
+		},
+		{
+			name:    "success - final import with verification",
+			uuidArg: uuidA,
+
+			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_IDLE, ImportStage: migration.IMPORTSTAGE_FINAL, Placement: api.Placement{TargetName: "one"}},
+
+			batchSvcGetByName: migration.Batch{Defaults: defaultPlacement, Name: "one", Config: api.BatchConfig{Verification: api.VERIFICATIONMODE_SAMPLED}},
+			instanceSvcGetByIDInstance: migration.Instance{
+				UUID:       uuidA,
+				Source:     "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: api.InstanceProperties{
+					Location:      "/some/instance/A",
+					OS:            "ubuntu",
+					OSDescription: "Ubuntu 24.04",
+				},
+			},
+			sourceSvcGetByIDSource: migration.Source{
+				ID:         1,
+				Name:       "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: []byte(`{"import_limit": 1}`),
+			},
+
+			targetSvcGetByIDTarget: migration.Target{
+				ID:         1,
+				Name:       "one",
+				TargetType: api.TARGETTYPE_INCUS,
+				Properties: []byte(`{"import_limit": 1}`),
+			},
+
+			assertErr: require.NoError,
+			wantWorkerCommand: migration.WorkerCommand{
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(`{"import_limit": 1}`)},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
+				Verification:   api.VERIFICATIONMODE_SAMPLED,
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -387,14 +536,15 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: require.NoError,
 			wantWorkerCommand: migration.WorkerCommand{
-				Command:       api.WORKERCOMMAND_FINALIZE_IMPORT,
-				Location:      "/some/instance/A",
-				SourceType:    api.SOURCETYPE_VMWARE,
-				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -433,14 +583,15 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: require.NoError,
 			wantWorkerCommand: migration.WorkerCommand{
-				Command:       api.WORKERCOMMAND_FINALIZE_IMPORT,
-				Location:      "/some/instance/A",
-				SourceType:    api.SOURCETYPE_VMWARE,
-				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -485,10 +636,11 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 					SourceType: api.SOURCETYPE_VMWARE,
 					Properties: []byte("{}"),
 				},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_BACKGROUND_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_BACKGROUND_IMPORT),
@@ -534,10 +686,11 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 					SourceType: api.SOURCETYPE_VMWARE,
 					Properties: []byte("{}"),
 				},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -583,10 +736,11 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 					SourceType: api.SOURCETYPE_VMWARE,
 					Properties: []byte("{}"),
 				},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -725,14 +879,15 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: require.NoError,
 			wantWorkerCommand: migration.WorkerCommand{
-				Command:       api.WORKERCOMMAND_FINALIZE_IMPORT,
-				Location:      "/some/instance/A",
-				SourceType:    api.SOURCETYPE_VMWARE,
-				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -773,14 +928,15 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: require.NoError,
 			wantWorkerCommand: migration.WorkerCommand{
-				Command:       api.WORKERCOMMAND_FINALIZE_IMPORT,
-				Location:      "/some/instance/A",
-				SourceType:    api.SOURCETYPE_VMWARE,
-				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
-				Distro:        api.DISTRO_UBUNTU,
-				DistroVersion: "24.04",
-				OSType:        api.OSTYPE_LINUX,
-				Architecture:  osarch.ArchitectureDefault,
+				Command:        api.WORKERCOMMAND_FINALIZE_IMPORT,
+				Location:       "/some/instance/A",
+				SourceType:     api.SOURCETYPE_VMWARE,
+				Source:         migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:         api.DISTRO_UBUNTU,
+				DistroVersion:  "24.04",
+				OSType:         api.OSTYPE_LINUX,
+				Architecture:   osarch.ArchitectureDefault,
+				TransferLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
 			},
 			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
 			wantMigrationStatusMessage: string(api.MIGRATIONSTATUS_FINAL_IMPORT),
@@ -891,7 +1047,7 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 		{
 			name:                  "error - queue is not in idle state",
 			uuidArg:               uuidA,
-			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_BACKGROUND_IMPORT},
+			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_ERROR},
 
 			assertErr: func(tt require.TestingT, err error, a ...any) {
 				require.ErrorIs(tt, err, migration.ErrOperationNotPermitted, a...)
@@ -1005,6 +1161,77 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 
 			assertErr: boom.ErrorIs,
 		},
+		{
+			name:    "success - canceled migration aborts the worker",
+			uuidArg: uuidA,
+
+			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_CANCELED, LastWorkerStatus: api.WORKERRESPONSE_RUNNING, Placement: api.Placement{TargetName: "one"}},
+
+			instanceSvcGetByIDInstance: migration.Instance{
+				UUID:       uuidA,
+				Source:     "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: api.InstanceProperties{
+					Location:      "/some/instance/A",
+					OS:            "ubuntu",
+					OSDescription: "Ubuntu 24.04",
+				},
+			},
+			sourceSvcGetByIDSource: migration.Source{
+				ID:         1,
+				Name:       "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: []byte("{}"),
+			},
+
+			assertErr: require.NoError,
+			wantWorkerCommand: migration.WorkerCommand{
+				Command:       api.WORKERCOMMAND_ABORT,
+				Location:      "/some/instance/A",
+				SourceType:    api.SOURCETYPE_VMWARE,
+				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:        api.DISTRO_UBUNTU,
+				DistroVersion: "24.04",
+				OSType:        api.OSTYPE_LINUX,
+				Architecture:  osarch.ArchitectureDefault,
+			},
+		},
+		{
+			name:    "success - command of a running step is sent again",
+			uuidArg: uuidA,
+
+			repoGetByInstanceUUID: migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one", MigrationStatus: api.MIGRATIONSTATUS_POST_IMPORT, LastWorkerStatus: api.WORKERRESPONSE_SUCCESS, Placement: api.Placement{TargetName: "one"}},
+
+			batchSvcGetByName: migration.Batch{Defaults: defaultPlacement, Name: "one"},
+			instanceSvcGetByIDInstance: migration.Instance{
+				UUID:       uuidA,
+				Source:     "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: api.InstanceProperties{
+					Location:      "/some/instance/A",
+					OS:            "ubuntu",
+					OSDescription: "Ubuntu 24.04",
+				},
+			},
+			sourceSvcGetByIDSource: migration.Source{
+				ID:         1,
+				Name:       "one",
+				SourceType: api.SOURCETYPE_VMWARE,
+				Properties: []byte("{}"),
+			},
+
+			assertErr: require.NoError,
+			wantWorkerCommand: migration.WorkerCommand{
+				Command:       api.WORKERCOMMAND_POST_IMPORT,
+				Location:      "/some/instance/A",
+				SourceType:    api.SOURCETYPE_VMWARE,
+				Source:        migration.Source{ID: 1, Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte("{}")},
+				Distro:        api.DISTRO_UBUNTU,
+				DistroVersion: "24.04",
+				OSType:        api.OSTYPE_LINUX,
+				Architecture:  osarch.ArchitectureDefault,
+			},
+		},
 		{
 			name:                     "error - repo.GetByInstanceUUID",
 			repoGetByInstanceUUIDErr: boom.Error,
@@ -1057,6 +1284,10 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 					return tc.repoUpdateErr
 				},
 
+				CreateHistoryFunc: func(ctx context.Context, entry migration.QueueHistoryEntry) (int64, error) {
+					return 1, nil
+				},
+
 				GetAllByBatchAndStateFunc: func(ctx context.Context, batch string, statuses ...api.MigrationStatusType) (migration.QueueEntries, error) {
 					return tc.repoGetAll, tc.repoGetAllErr
 				},
@@ -1079,8 +1310,9 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 				GetByNameFunc: func(ctx context.Context, name string) (*migration.Source, error) {
 					return &tc.sourceSvcGetByIDSource, tc.sourceSvcGetByIDErr
 				},
-				RecordActiveImportFunc: func(targetName string) {},
-				GetCachedImportsFunc:   func(sourceName string) int { return tc.sourceImportLimit },
+				RecordActiveImportFunc:        func(instance migration.Instance) {},
+				GetCachedImportsFunc:          func(sourceName string) int { return tc.sourceImportLimit },
+				GetCachedDatastoreImportsFunc: func(instance migration.Instance) int { return tc.sourceImportLimit },
 			}
 
 			batchSvc := &BatchServiceMock{
@@ -1111,16 +1343,26 @@ func TestQueueService_NewWorkerCommandByInstanceUUID(t *testing.T) {
 			// Assert
 			tc.assertErr(t, err)
 			require.Equal(t, tc.wantWorkerCommand, workerCommand)
+			require.LessOrEqual(t, len(batchSvc.GetByNameCalls()), 1)
 		})
 	}
 }
 
 func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
+	powerOff := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	diskStates := []api.WorkerDiskState{{Name: "[datastore] disk_1.vmdk", ChangeID: "52 d1/4", SnapshotRef: "snapshot-1", SyncedOffset: 1024}}
+	shutdownAttempts := []api.ShutdownAttempt{
+		{Action: api.SHUTDOWNACTION_GUEST_SHUTDOWN, Time: powerOff.Add(-10 * time.Minute), Duration: api.AsDuration(10 * time.Minute), Error: "Guest OS did not shut down within 10m0s"},
+		{Action: api.SHUTDOWNACTION_POWER_OFF, Time: powerOff.Add(-time.Second), Duration: api.AsDuration(time.Second)},
+	}
+
 	tests := []struct {
 		name                  string
 		uuidArg               uuid.UUID
 		workerResponseTypeArg api.WorkerResponseType
 		statusStringArg       string
+		diskSyncsArg          []api.WorkerDiskSync
+		shutdownAttemptsArg   []api.ShutdownAttempt
 
 		repoGetByUUIDQueueEntry          *migration.QueueEntry
 		repoGetByUUIDErr                 error
@@ -1137,6 +1379,9 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 		wantMigrationStatus        api.MigrationStatusType
 		wantMigrationStatusMessage string
 		wantImportStage            migration.ImportStage
+		wantCutover                bool
+		wantDiskStates             bool
+		wantShutdownAttempts       []api.ShutdownAttempt
 	}{
 		{
 			name:                  "success - migration running",
@@ -1154,6 +1399,28 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			wantMigrationStatus:        api.MIGRATIONSTATUS_CREATING,
 			wantMigrationStatusMessage: "creating",
 			wantImportStage:            migration.IMPORTSTAGE_BACKGROUND,
+			wantDiskStates:             true,
+		},
+		{
+			name:                  "success - migration running source shutdown",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_RUNNING,
+			statusStringArg:       "Source VM power-off completed in 1s",
+			shutdownAttemptsArg:   shutdownAttempts,
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID:    uuidA,
+				MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Cutover:         api.QueueCutover{ShutdownAttempts: shutdownAttempts[:1]},
+			},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_FINAL_IMPORT,
+			wantMigrationStatusMessage: "Source VM power-off completed in 1s",
+			wantImportStage:            migration.IMPORTSTAGE_FINAL,
+			wantDiskStates:             true,
+			wantShutdownAttempts:       shutdownAttempts,
 		},
 		{
 			name:                  "success - migration success background import",
@@ -1175,6 +1442,7 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			wantMigrationStatus:        api.MIGRATIONSTATUS_IDLE,
 			wantMigrationStatusMessage: "Waiting for migration window",
 			wantImportStage:            migration.IMPORTSTAGE_FINAL,
+			wantDiskStates:             true,
 		},
 		{
 			name:                  "success - migration success final import (full initial import)",
@@ -1194,6 +1462,8 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			wantMigrationStatus:        api.MIGRATIONSTATUS_IDLE,
 			wantMigrationStatusMessage: "Waiting for worker to begin post-import tasks",
 			wantImportStage:            migration.IMPORTSTAGE_COMPLETE,
+			wantCutover:                true,
+			wantDiskStates:             true,
 		},
 		{
 			name:                  "success - migration success final import (incremental import)",
@@ -1213,6 +1483,104 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			wantMigrationStatus:        api.MIGRATIONSTATUS_IDLE,
 			wantMigrationStatusMessage: "Waiting for worker to begin post-import tasks",
 			wantImportStage:            migration.IMPORTSTAGE_COMPLETE,
+			wantCutover:                true,
+			wantDiskStates:             true,
+		},
+		{
+			name:                  "success - migration success final import (forced power off)",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_SUCCESS,
+			statusStringArg:       "done",
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Placement:       api.Placement{TargetName: "one"},
+				Cutover:         api.QueueCutover{ShutdownAttempts: shutdownAttempts},
+			},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_IDLE,
+			wantMigrationStatusMessage: "Waiting for worker to begin post-import tasks",
+			wantImportStage:            migration.IMPORTSTAGE_COMPLETE,
+			wantCutover:                true,
+			wantDiskStates:             true,
+			wantShutdownAttempts:       shutdownAttempts,
+		},
+		{
+			name:                  "success - migration success final import (verified)",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_SUCCESS,
+			statusStringArg:       "done",
+			diskSyncsArg: []api.WorkerDiskSync{
+				{Name: "[datastore] disk_1.vmdk", Verification: &api.WorkerDiskVerification{Mode: api.VERIFICATIONMODE_SAMPLED, VerifiedBytes: 1024, Checksum: "abc"}},
+			},
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Placement:       api.Placement{TargetName: "one"},
+			},
+			batchSvcGetByNameBatch: migration.Batch{Name: "one", Config: api.BatchConfig{Verification: api.VERIFICATIONMODE_SAMPLED}},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_IDLE,
+			wantMigrationStatusMessage: "Waiting for worker to begin post-import tasks",
+			wantImportStage:            migration.IMPORTSTAGE_COMPLETE,
+			wantCutover:                true,
+			wantDiskStates:             true,
+		},
+		{
+			name:                  "success - migration final import failed verification",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_SUCCESS,
+			statusStringArg:       "done",
+			diskSyncsArg: []api.WorkerDiskSync{
+				{Name: "[datastore] disk_1.vmdk", Verification: &api.WorkerDiskVerification{Mode: api.VERIFICATIONMODE_FULL, VerifiedBytes: 1024, MismatchedBlocks: 2, Checksum: "abc"}},
+			},
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Placement:       api.Placement{TargetName: "one"},
+			},
+			batchSvcGetByNameBatch: migration.Batch{Name: "one", Config: api.BatchConfig{Verification: api.VERIFICATIONMODE_FULL}},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_ERROR,
+			wantMigrationStatusMessage: `Disk "[datastore] disk_1.vmdk" failed full verification: 2 blocks differ from the source`,
+			wantImportStage:            migration.IMPORTSTAGE_FINAL,
+			wantCutover:                true,
+			wantDiskStates:             true,
+		},
+		{
+			name:                  "success - migration final import not verified",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_SUCCESS,
+			statusStringArg:       "done",
+			diskSyncsArg:          []api.WorkerDiskSync{{Name: "[datastore] disk_1.vmdk"}},
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_FINAL_IMPORT,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_FINAL,
+				Placement:       api.Placement{TargetName: "one"},
+			},
+			batchSvcGetByNameBatch: migration.Batch{Name: "one", Config: api.BatchConfig{Verification: api.VERIFICATIONMODE_SAMPLED}},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_ERROR,
+			wantMigrationStatusMessage: `Disk "[datastore] disk_1.vmdk" was not verified`,
+			wantImportStage:            migration.IMPORTSTAGE_FINAL,
+			wantCutover:                true,
+			wantDiskStates:             true,
 		},
 		{
 			name:                  "success - migration success post import",
@@ -1250,6 +1618,42 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			wantMigrationStatus:        api.MIGRATIONSTATUS_ERROR,
 			wantMigrationStatusMessage: "boom!",
 		},
+		{
+			name:                  "success - abort acknowledged for canceled migration",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_ABORTED,
+			statusStringArg:       "aborted",
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_CANCELED,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+				Placement:       api.Placement{TargetName: "one"},
+			},
+
+			assertErr:                  require.NoError,
+			wantMigrationStatus:        api.MIGRATIONSTATUS_CANCELED,
+			wantMigrationStatusMessage: "aborted",
+			wantImportStage:            migration.IMPORTSTAGE_BACKGROUND,
+		},
+		{
+			name:                  "success - results for canceled migration are dropped",
+			uuidArg:               uuidA,
+			workerResponseTypeArg: api.WORKERRESPONSE_SUCCESS,
+			statusStringArg:       "done",
+			repoGetByUUIDQueueEntry: &migration.QueueEntry{
+				InstanceUUID: uuidA,
+
+				MigrationStatus: api.MIGRATIONSTATUS_CANCELED,
+				BatchName:       "one",
+				ImportStage:     migration.IMPORTSTAGE_BACKGROUND,
+				Placement:       api.Placement{TargetName: "one"},
+			},
+
+			// The queue entry is left as is.
+			assertErr: require.NoError,
+		},
 		{
 			name:                  "error - GetByUUID",
 			uuidArg:               uuidA,
@@ -1293,6 +1697,7 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			assertErr:                  boom.ErrorIs,
 			wantMigrationStatus:        api.MIGRATIONSTATUS_CREATING,
 			wantMigrationStatusMessage: "creating",
+			wantDiskStates:             true,
 		},
 	}
 
@@ -1307,8 +1712,28 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 					require.Equal(t, tc.wantMigrationStatus, i.MigrationStatus)
 					require.Equal(t, tc.wantMigrationStatusMessage, i.MigrationStatusMessage)
 					require.Equal(t, tc.wantImportStage, i.ImportStage)
+					if tc.wantCutover {
+						require.Equal(t, powerOff, i.Cutover.SourcePowerOff)
+						require.False(t, i.Cutover.FinalImportComplete.IsZero())
+						require.Equal(t, tc.wantShutdownAttempts, i.Cutover.ShutdownAttempts)
+					} else {
+						require.Equal(t, api.QueueCutover{ShutdownAttempts: tc.wantShutdownAttempts}, i.Cutover)
+					}
+
+					if tc.wantDiskStates {
+						require.Equal(t, diskStates, i.DiskStates)
+					} else {
+						require.Empty(t, i.DiskStates)
+					}
+
 					return tc.repoUpdateStatusByUUIDErr
 				},
+				CreateHistoryFunc: func(ctx context.Context, h migration.QueueHistoryEntry) (int64, error) {
+					require.Equal(t, tc.wantMigrationStatus, h.MigrationStatus)
+					require.Equal(t, tc.workerResponseTypeArg, h.WorkerStatus)
+					require.Equal(t, tc.statusStringArg, h.WorkerStatusMessage)
+					return 1, nil
+				},
 			}
 
 			instanceSvc := &InstanceServiceMock{
@@ -1324,7 +1749,7 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 			}
 
 			sourceSvc := &SourceServiceMock{
-				RemoveActiveImportFunc: func(sourceName string) {},
+				RemoveActiveImportFunc: func(instance migration.Instance) {},
 			}
 
 			targetSvc := &TargetServiceMock{
@@ -1335,8 +1760,13 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 
 			// Run test
 			resp := api.WorkerResponse{
-				Status:        tc.workerResponseTypeArg,
-				StatusMessage: tc.statusStringArg,
+				Status:         tc.workerResponseTypeArg,
+				StatusMessage:  tc.statusStringArg,
+				SourcePowerOff: powerOff,
+				DiskSyncs:      tc.diskSyncsArg,
+				DiskStates:     diskStates,
+
+				ShutdownAttempts: tc.shutdownAttemptsArg,
 			}
 
 			_, err := queueSvc.ProcessWorkerUpdate(context.Background(), tc.uuidArg, resp)
@@ -1347,6 +1777,282 @@ func TestQueueService_ProcessWorkerUpdate(t *testing.T) {
 	}
 }
 
+func TestQueueService_RecordCutoverByUUID(t *testing.T) {
+	powerOff := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		targetStart      time.Time
+		agentReady       time.Time
+		repoGetByUUID    *migration.QueueEntry
+		repoGetByUUIDErr error
+		repoUpdateErr    error
+
+		assertErr    require.ErrorAssertionFunc
+		wantDowntime time.Duration
+	}{
+		{
+			name:          "success - agent ready",
+			targetStart:   powerOff.Add(10 * time.Minute),
+			agentReady:    powerOff.Add(11 * time.Minute),
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, Cutover: api.QueueCutover{SourcePowerOff: powerOff}},
+
+			assertErr:    require.NoError,
+			wantDowntime: 11 * time.Minute,
+		},
+		{
+			name:          "success - agent not ready",
+			targetStart:   powerOff.Add(10 * time.Minute),
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, Cutover: api.QueueCutover{SourcePowerOff: powerOff}},
+
+			assertErr:    require.NoError,
+			wantDowntime: 10 * time.Minute,
+		},
+		{
+			name:          "success - target not started",
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, Cutover: api.QueueCutover{SourcePowerOff: powerOff}},
+
+			assertErr: require.NoError,
+		},
+		{
+			name:             "error - GetByInstanceUUID",
+			repoGetByUUIDErr: boom.Error,
+
+			assertErr: boom.ErrorIs,
+		},
+		{
+			name:          "error - Update",
+			targetStart:   powerOff.Add(10 * time.Minute),
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, Cutover: api.QueueCutover{SourcePowerOff: powerOff}},
+			repoUpdateErr: boom.Error,
+
+			assertErr:    boom.ErrorIs,
+			wantDowntime: 10 * time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			repo := &mock.QueueRepoMock{
+				GetByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error) {
+					return tc.repoGetByUUID, tc.repoGetByUUIDErr
+				},
+				UpdateFunc: func(ctx context.Context, q migration.QueueEntry) error {
+					require.Equal(t, powerOff, q.Cutover.SourcePowerOff)
+					require.Equal(t, tc.targetStart, q.Cutover.TargetStart)
+					require.Equal(t, tc.agentReady, q.Cutover.AgentReady)
+					require.Equal(t, tc.wantDowntime, q.Cutover.Downtime.Duration)
+					return tc.repoUpdateErr
+				},
+			}
+
+			queueSvc := migration.NewQueueService(repo, nil, nil, nil, nil, nil)
+
+			// Run test
+			q, err := queueSvc.RecordCutoverByUUID(context.Background(), uuidA, tc.targetStart, tc.agentReady)
+
+			// Assert
+			tc.assertErr(t, err)
+			if err == nil {
+				require.Equal(t, tc.wantDowntime, q.Cutover.Downtime.Duration)
+			}
+		})
+	}
+}
+
+func TestQueueService_RecordSourceCleanupByUUID(t *testing.T) {
+	tests := []struct {
+		name             string
+		completed        []api.SourceCleanupAction
+		cleanupErr       error
+		repoGetByUUID    *migration.QueueEntry
+		repoGetByUUIDErr error
+		repoUpdateErr    error
+
+		assertErr     require.ErrorAssertionFunc
+		wantCompleted []api.SourceCleanupAction
+		wantLastError string
+	}{
+		{
+			name:          "success",
+			completed:     []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME},
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, SourceCleanup: api.QueueSourceCleanup{LastError: "boom!"}},
+
+			assertErr:     require.NoError,
+			wantCompleted: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME},
+		},
+		{
+			name:          "success - partial cleanup",
+			completed:     []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME},
+			cleanupErr:    boom.Error,
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA, SourceCleanup: api.QueueSourceCleanup{Completed: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT}}},
+
+			assertErr:     require.NoError,
+			wantCompleted: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_RENAME},
+			wantLastError: boom.Error.Error(),
+		},
+		{
+			name:             "error - GetByInstanceUUID",
+			repoGetByUUIDErr: boom.Error,
+
+			assertErr: boom.ErrorIs,
+		},
+		{
+			name:          "error - Update",
+			completed:     []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT},
+			repoGetByUUID: &migration.QueueEntry{InstanceUUID: uuidA},
+			repoUpdateErr: boom.Error,
+
+			assertErr:     boom.ErrorIs,
+			wantCompleted: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			repo := &mock.QueueRepoMock{
+				GetByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error) {
+					return tc.repoGetByUUID, tc.repoGetByUUIDErr
+				},
+				UpdateFunc: func(ctx context.Context, q migration.QueueEntry) error {
+					require.Equal(t, tc.wantCompleted, q.SourceCleanup.Completed)
+					require.Equal(t, tc.wantLastError, q.SourceCleanup.LastError)
+					require.False(t, q.SourceCleanup.LastAttempt.IsZero())
+					return tc.repoUpdateErr
+				},
+			}
+
+			queueSvc := migration.NewQueueService(repo, nil, nil, nil, nil, nil)
+
+			// Run test
+			q, err := queueSvc.RecordSourceCleanupByUUID(context.Background(), uuidA, tc.completed, tc.cleanupErr)
+
+			// Assert
+			tc.assertErr(t, err)
+			if err == nil {
+				require.Equal(t, tc.wantCompleted, q.SourceCleanup.Completed)
+				require.Equal(t, tc.wantLastError, q.SourceCleanup.LastError)
+			}
+		})
+	}
+}
+
+func TestQueueService_GetTransferLimitsByUUID(t *testing.T) {
+	tests := []struct {
+		name                string
+		sourceProperties    string
+		sourceActiveImports int
+		batchConfig         api.BatchConfig
+		repoImporting       migration.QueueEntries
+		repoGetByUUIDErr    error
+		repoImportingErr    error
+
+		assertErr  require.ErrorAssertionFunc
+		wantLimits api.WorkerTransferLimits
+	}{
+		{
+			name:             "success - unlimited",
+			sourceProperties: `{}`,
+
+			assertErr:  require.NoError,
+			wantLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1},
+		},
+		{
+			name:                "success - source bandwidth shared by active imports",
+			sourceProperties:    `{"disk_parallelism": 2, "disk_connections": 2, "bandwidth": {"limit": "100MB"}}`,
+			sourceActiveImports: 4,
+
+			assertErr:  require.NoError,
+			wantLimits: api.WorkerTransferLimits{Disks: 2, Connections: 2, Bandwidth: 25_000_000},
+		},
+		{
+			name:                "success - batch bandwidth lower than source bandwidth",
+			sourceProperties:    `{"bandwidth": {"limit": "100MB"}}`,
+			sourceActiveImports: 1,
+			batchConfig:         api.BatchConfig{Bandwidth: api.BandwidthPolicy{Limit: "30MB"}},
+			repoImporting:       migration.QueueEntries{{InstanceUUID: uuidA}, {InstanceUUID: uuidB}},
+
+			assertErr:  require.NoError,
+			wantLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1, Bandwidth: 15_000_000},
+		},
+		{
+			name:             "success - batch bandwidth counts the entry being started",
+			sourceProperties: `{}`,
+			batchConfig:      api.BatchConfig{Bandwidth: api.BandwidthPolicy{Limit: "30MB"}},
+			repoImporting:    migration.QueueEntries{{InstanceUUID: uuidB}},
+
+			assertErr:  require.NoError,
+			wantLimits: api.WorkerTransferLimits{Disks: 1, Connections: 1, Bandwidth: 15_000_000},
+		},
+		{
+			name:             "error - repo.GetByInstanceUUID",
+			sourceProperties: `{}`,
+			repoGetByUUIDErr: boom.Error,
+
+			assertErr: boom.ErrorIs,
+		},
+		{
+			name:             "error - invalid source bandwidth",
+			sourceProperties: `{"bandwidth": {"limit": "fast"}}`,
+
+			assertErr: require.Error,
+		},
+		{
+			name:             "error - repo.GetAllByBatchAndState",
+			sourceProperties: `{}`,
+			batchConfig:      api.BatchConfig{Bandwidth: api.BandwidthPolicy{Limit: "30MB"}},
+			repoImportingErr: boom.Error,
+
+			assertErr: boom.ErrorIs,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			repo := &mock.QueueRepoMock{
+				GetByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error) {
+					return &migration.QueueEntry{InstanceUUID: uuidA, BatchName: "one"}, tc.repoGetByUUIDErr
+				},
+				GetAllByBatchAndStateFunc: func(ctx context.Context, batch string, statuses ...api.MigrationStatusType) (migration.QueueEntries, error) {
+					return tc.repoImporting, tc.repoImportingErr
+				},
+			}
+
+			instanceSvc := &InstanceServiceMock{
+				GetByUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.Instance, error) {
+					return &migration.Instance{UUID: uuidA, Source: "one"}, nil
+				},
+			}
+
+			sourceSvc := &SourceServiceMock{
+				GetByNameFunc: func(ctx context.Context, name string) (*migration.Source, error) {
+					return &migration.Source{Name: "one", SourceType: api.SOURCETYPE_VMWARE, Properties: []byte(tc.sourceProperties)}, nil
+				},
+				GetCachedImportsFunc:          func(sourceName string) int { return tc.sourceActiveImports },
+				GetCachedDatastoreImportsFunc: func(instance migration.Instance) int { return tc.sourceActiveImports },
+			}
+
+			batchSvc := &BatchServiceMock{
+				GetByNameFunc: func(ctx context.Context, name string) (*migration.Batch, error) {
+					return &migration.Batch{Name: "one", Config: tc.batchConfig}, nil
+				},
+			}
+
+			queueSvc := migration.NewQueueService(repo, batchSvc, instanceSvc, sourceSvc, nil, nil)
+
+			// Run test
+			limits, err := queueSvc.GetTransferLimitsByUUID(context.Background(), uuidA)
+
+			// Assert
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantLimits, limits)
+		})
+	}
+}
+
 func TestQueueService_GetNextWindow(t *testing.T) {
 	type window struct {
 		s int
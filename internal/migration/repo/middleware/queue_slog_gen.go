@@ -47,6 +47,26 @@ func (_d QueueRepoWithSlog) Create(ctx context.Context, queue _sourceMigration.Q
 	return _d._base.Create(ctx, queue)
 }
 
+// CreateHistory implements _sourceMigration.QueueRepo
+func (_d QueueRepoWithSlog) CreateHistory(ctx context.Context, entry _sourceMigration.QueueHistoryEntry) (i1 int64, err error) {
+	_d._log.With(
+		slog.Any("ctx", ctx),
+		slog.Any("entry", entry),
+	).Debug("QueueRepoWithSlog: calling CreateHistory")
+	defer func() {
+		log := _d._log.With(
+			slog.Int64("i1", i1),
+			slog.Any("err", err),
+		)
+		if err != nil {
+			log.Error("QueueRepoWithSlog: method CreateHistory returned an error")
+		} else {
+			log.Debug("QueueRepoWithSlog: method CreateHistory finished")
+		}
+	}()
+	return _d._base.CreateHistory(ctx, entry)
+}
+
 // DeleteAllByBatch implements _sourceMigration.QueueRepo
 func (_d QueueRepoWithSlog) DeleteAllByBatch(ctx context.Context, batch string) (err error) {
 	_d._log.With(
@@ -206,6 +226,46 @@ func (_d QueueRepoWithSlog) GetByInstanceUUID(ctx context.Context, id uuid.UUID)
 	return _d._base.GetByInstanceUUID(ctx, id)
 }
 
+// GetHistoryByBatch implements _sourceMigration.QueueRepo
+func (_d QueueRepoWithSlog) GetHistoryByBatch(ctx context.Context, batch string) (q1 _sourceMigration.QueueHistoryEntries, err error) {
+	_d._log.With(
+		slog.Any("ctx", ctx),
+		slog.String("batch", batch),
+	).Debug("QueueRepoWithSlog: calling GetHistoryByBatch")
+	defer func() {
+		log := _d._log.With(
+			slog.Any("q1", q1),
+			slog.Any("err", err),
+		)
+		if err != nil {
+			log.Error("QueueRepoWithSlog: method GetHistoryByBatch returned an error")
+		} else {
+			log.Debug("QueueRepoWithSlog: method GetHistoryByBatch finished")
+		}
+	}()
+	return _d._base.GetHistoryByBatch(ctx, batch)
+}
+
+// GetHistoryByInstanceUUID implements _sourceMigration.QueueRepo
+func (_d QueueRepoWithSlog) GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (q1 _sourceMigration.QueueHistoryEntries, err error) {
+	_d._log.With(
+		slog.Any("ctx", ctx),
+		slog.Any("id", id),
+	).Debug("QueueRepoWithSlog: calling GetHistoryByInstanceUUID")
+	defer func() {
+		log := _d._log.With(
+			slog.Any("q1", q1),
+			slog.Any("err", err),
+		)
+		if err != nil {
+			log.Error("QueueRepoWithSlog: method GetHistoryByInstanceUUID returned an error")
+		} else {
+			log.Debug("QueueRepoWithSlog: method GetHistoryByInstanceUUID finished")
+		}
+	}()
+	return _d._base.GetHistoryByInstanceUUID(ctx, id)
+}
+
 // Update implements _sourceMigration.QueueRepo
 func (_d QueueRepoWithSlog) Update(ctx context.Context, entry _sourceMigration.QueueEntry) (err error) {
 	_d._log.With(
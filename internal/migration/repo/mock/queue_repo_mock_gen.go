@@ -25,6 +25,9 @@ var _ migration.QueueRepo = &QueueRepoMock{}
 //			CreateFunc: func(ctx context.Context, queue migration.QueueEntry) (int64, error) {
 //				panic("mock out the Create method")
 //			},
+//			CreateHistoryFunc: func(ctx context.Context, entry migration.QueueHistoryEntry) (int64, error) {
+//				panic("mock out the CreateHistory method")
+//			},
 //			DeleteAllByBatchFunc: func(ctx context.Context, batch string) error {
 //				panic("mock out the DeleteAllByBatch method")
 //			},
@@ -49,6 +52,12 @@ var _ migration.QueueRepo = &QueueRepoMock{}
 //			GetByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error) {
 //				panic("mock out the GetByInstanceUUID method")
 //			},
+//			GetHistoryByBatchFunc: func(ctx context.Context, batch string) (migration.QueueHistoryEntries, error) {
+//				panic("mock out the GetHistoryByBatch method")
+//			},
+//			GetHistoryByInstanceUUIDFunc: func(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error) {
+//				panic("mock out the GetHistoryByInstanceUUID method")
+//			},
 //			UpdateFunc: func(ctx context.Context, entry migration.QueueEntry) error {
 //				panic("mock out the Update method")
 //			},
@@ -62,6 +71,9 @@ type QueueRepoMock struct {
 	// CreateFunc mocks the Create method.
 	CreateFunc func(ctx context.Context, queue migration.QueueEntry) (int64, error)
 
+	// CreateHistoryFunc mocks the CreateHistory method.
+	CreateHistoryFunc func(ctx context.Context, entry migration.QueueHistoryEntry) (int64, error)
+
 	// DeleteAllByBatchFunc mocks the DeleteAllByBatch method.
 	DeleteAllByBatchFunc func(ctx context.Context, batch string) error
 
@@ -86,6 +98,12 @@ type QueueRepoMock struct {
 	// GetByInstanceUUIDFunc mocks the GetByInstanceUUID method.
 	GetByInstanceUUIDFunc func(ctx context.Context, id uuid.UUID) (*migration.QueueEntry, error)
 
+	// GetHistoryByBatchFunc mocks the GetHistoryByBatch method.
+	GetHistoryByBatchFunc func(ctx context.Context, batch string) (migration.QueueHistoryEntries, error)
+
+	// GetHistoryByInstanceUUIDFunc mocks the GetHistoryByInstanceUUID method.
+	GetHistoryByInstanceUUIDFunc func(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error)
+
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(ctx context.Context, entry migration.QueueEntry) error
 
@@ -98,6 +116,13 @@ type QueueRepoMock struct {
 			// Queue is the queue argument value.
 			Queue migration.QueueEntry
 		}
+		// CreateHistory holds details about calls to the CreateHistory method.
+		CreateHistory []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Entry is the entry argument value.
+			Entry migration.QueueHistoryEntry
+		}
 		// DeleteAllByBatch holds details about calls to the DeleteAllByBatch method.
 		DeleteAllByBatch []struct {
 			// Ctx is the ctx argument value.
@@ -156,6 +181,20 @@ type QueueRepoMock struct {
 			// ID is the id argument value.
 			ID uuid.UUID
 		}
+		// GetHistoryByBatch holds details about calls to the GetHistoryByBatch method.
+		GetHistoryByBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Batch is the batch argument value.
+			Batch string
+		}
+		// GetHistoryByInstanceUUID holds details about calls to the GetHistoryByInstanceUUID method.
+		GetHistoryByInstanceUUID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uuid.UUID
+		}
 		// Update holds details about calls to the Update method.
 		Update []struct {
 			// Ctx is the ctx argument value.
@@ -164,16 +203,19 @@ type QueueRepoMock struct {
 			Entry migration.QueueEntry
 		}
 	}
-	lockCreate                sync.RWMutex
-	lockDeleteAllByBatch      sync.RWMutex
-	lockDeleteByUUID          sync.RWMutex
-	lockGetAll                sync.RWMutex
-	lockGetAllByBatch         sync.RWMutex
-	lockGetAllByBatchAndState sync.RWMutex
-	lockGetAllByState         sync.RWMutex
-	lockGetAllNeedingImport   sync.RWMutex
-	lockGetByInstanceUUID     sync.RWMutex
-	lockUpdate                sync.RWMutex
+	lockCreate                   sync.RWMutex
+	lockCreateHistory            sync.RWMutex
+	lockDeleteAllByBatch         sync.RWMutex
+	lockDeleteByUUID             sync.RWMutex
+	lockGetAll                   sync.RWMutex
+	lockGetAllByBatch            sync.RWMutex
+	lockGetAllByBatchAndState    sync.RWMutex
+	lockGetAllByState            sync.RWMutex
+	lockGetAllNeedingImport      sync.RWMutex
+	lockGetByInstanceUUID        sync.RWMutex
+	lockGetHistoryByBatch        sync.RWMutex
+	lockGetHistoryByInstanceUUID sync.RWMutex
+	lockUpdate                   sync.RWMutex
 }
 
 // Create calls CreateFunc.
@@ -212,6 +254,42 @@ func (mock *QueueRepoMock) CreateCalls() []struct {
 	return calls
 }
 
+// CreateHistory calls CreateHistoryFunc.
+func (mock *QueueRepoMock) CreateHistory(ctx context.Context, entry migration.QueueHistoryEntry) (int64, error) {
+	if mock.CreateHistoryFunc == nil {
+		panic("QueueRepoMock.CreateHistoryFunc: method is nil but QueueRepo.CreateHistory was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Entry migration.QueueHistoryEntry
+	}{
+		Ctx:   ctx,
+		Entry: entry,
+	}
+	mock.lockCreateHistory.Lock()
+	mock.calls.CreateHistory = append(mock.calls.CreateHistory, callInfo)
+	mock.lockCreateHistory.Unlock()
+	return mock.CreateHistoryFunc(ctx, entry)
+}
+
+// CreateHistoryCalls gets all the calls that were made to CreateHistory.
+// Check the length with:
+//
+//	len(mockedQueueRepo.CreateHistoryCalls())
+func (mock *QueueRepoMock) CreateHistoryCalls() []struct {
+	Ctx   context.Context
+	Entry migration.QueueHistoryEntry
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Entry migration.QueueHistoryEntry
+	}
+	mock.lockCreateHistory.RLock()
+	calls = mock.calls.CreateHistory
+	mock.lockCreateHistory.RUnlock()
+	return calls
+}
+
 // DeleteAllByBatch calls DeleteAllByBatchFunc.
 func (mock *QueueRepoMock) DeleteAllByBatch(ctx context.Context, batch string) error {
 	if mock.DeleteAllByBatchFunc == nil {
@@ -504,6 +582,78 @@ func (mock *QueueRepoMock) GetByInstanceUUIDCalls() []struct {
 	return calls
 }
 
+// GetHistoryByBatch calls GetHistoryByBatchFunc.
+func (mock *QueueRepoMock) GetHistoryByBatch(ctx context.Context, batch string) (migration.QueueHistoryEntries, error) {
+	if mock.GetHistoryByBatchFunc == nil {
+		panic("QueueRepoMock.GetHistoryByBatchFunc: method is nil but QueueRepo.GetHistoryByBatch was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Batch string
+	}{
+		Ctx:   ctx,
+		Batch: batch,
+	}
+	mock.lockGetHistoryByBatch.Lock()
+	mock.calls.GetHistoryByBatch = append(mock.calls.GetHistoryByBatch, callInfo)
+	mock.lockGetHistoryByBatch.Unlock()
+	return mock.GetHistoryByBatchFunc(ctx, batch)
+}
+
+// GetHistoryByBatchCalls gets all the calls that were made to GetHistoryByBatch.
+// Check the length with:
+//
+//	len(mockedQueueRepo.GetHistoryByBatchCalls())
+func (mock *QueueRepoMock) GetHistoryByBatchCalls() []struct {
+	Ctx   context.Context
+	Batch string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Batch string
+	}
+	mock.lockGetHistoryByBatch.RLock()
+	calls = mock.calls.GetHistoryByBatch
+	mock.lockGetHistoryByBatch.RUnlock()
+	return calls
+}
+
+// GetHistoryByInstanceUUID calls GetHistoryByInstanceUUIDFunc.
+func (mock *QueueRepoMock) GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error) {
+	if mock.GetHistoryByInstanceUUIDFunc == nil {
+		panic("QueueRepoMock.GetHistoryByInstanceUUIDFunc: method is nil but QueueRepo.GetHistoryByInstanceUUID was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGetHistoryByInstanceUUID.Lock()
+	mock.calls.GetHistoryByInstanceUUID = append(mock.calls.GetHistoryByInstanceUUID, callInfo)
+	mock.lockGetHistoryByInstanceUUID.Unlock()
+	return mock.GetHistoryByInstanceUUIDFunc(ctx, id)
+}
+
+// GetHistoryByInstanceUUIDCalls gets all the calls that were made to GetHistoryByInstanceUUID.
+// Check the length with:
+//
+//	len(mockedQueueRepo.GetHistoryByInstanceUUIDCalls())
+func (mock *QueueRepoMock) GetHistoryByInstanceUUIDCalls() []struct {
+	Ctx context.Context
+	ID  uuid.UUID
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uuid.UUID
+	}
+	mock.lockGetHistoryByInstanceUUID.RLock()
+	calls = mock.calls.GetHistoryByInstanceUUID
+	mock.lockGetHistoryByInstanceUUID.RUnlock()
+	return calls
+}
+
 // Update calls UpdateFunc.
 func (mock *QueueRepoMock) Update(ctx context.Context, entry migration.QueueEntry) error {
 	if mock.UpdateFunc == nil {
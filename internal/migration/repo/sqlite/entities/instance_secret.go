@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// Code generation directives.
+//
+//generate-database:mapper target instance_secret.mapper.go
+//generate-database:mapper reset
+//
+//generate-database:mapper stmt -e instance_secret objects table=instance_secrets
+//generate-database:mapper stmt -e instance_secret objects-by-InstanceUUID table=instance_secrets
+//generate-database:mapper stmt -e instance_secret create-or-replace table=instance_secrets
+//generate-database:mapper stmt -e instance_secret delete-by-InstanceUUID table=instance_secrets
+//
+//generate-database:mapper method -e instance_secret GetMany table=instance_secrets
+//generate-database:mapper method -e instance_secret CreateOrReplace table=instance_secrets
+//generate-database:mapper method -e instance_secret DeleteMany-by-InstanceUUID table=instance_secrets
+
+type InstanceSecretFilter struct {
+	InstanceUUID *uuid.UUID
+}
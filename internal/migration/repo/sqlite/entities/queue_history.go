@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// Code generation directives.
+//
+//generate-database:mapper target queue_history.mapper.go
+//generate-database:mapper reset
+//
+//generate-database:mapper stmt -e queue_history_entry objects table=queue_history
+//generate-database:mapper stmt -e queue_history_entry objects-by-InstanceUUID table=queue_history
+//generate-database:mapper stmt -e queue_history_entry objects-by-BatchName table=queue_history
+//generate-database:mapper stmt -e queue_history_entry create table=queue_history
+//
+//generate-database:mapper method -e queue_history_entry GetMany table=queue_history
+//generate-database:mapper method -e queue_history_entry Create table=queue_history
+
+type QueueHistoryEntryFilter struct {
+	InstanceUUID *uuid.UUID
+	BatchName    *string
+}
@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/migration/repo"
+	"github.com/FuturFusion/migration-manager/internal/migration/repo/sqlite/entities"
+	"github.com/FuturFusion/migration-manager/internal/transaction"
+)
+
+type instanceSecret struct {
+	db repo.DBTX
+}
+
+func NewInstanceSecret(db repo.DBTX) migration.InstanceSecretRepo {
+	return &instanceSecret{
+		db: db,
+	}
+}
+
+// GetAllByInstanceUUID implements migration.InstanceSecretRepo.
+func (i *instanceSecret) GetAllByInstanceUUID(ctx context.Context, id uuid.UUID) (migration.InstanceSecrets, error) {
+	return entities.GetInstanceSecrets(ctx, transaction.GetDBTX(ctx, i.db), entities.InstanceSecretFilter{InstanceUUID: &id})
+}
+
+// Upsert implements migration.InstanceSecretRepo.
+func (i *instanceSecret) Upsert(ctx context.Context, secret migration.InstanceSecret) (int64, error) {
+	return entities.CreateOrReplaceInstanceSecret(ctx, transaction.GetDBTX(ctx, i.db), secret)
+}
+
+// DeleteAllByInstanceUUID implements migration.InstanceSecretRepo.
+func (i *instanceSecret) DeleteAllByInstanceUUID(ctx context.Context, id uuid.UUID) error {
+	return entities.DeleteInstanceSecrets(ctx, transaction.GetDBTX(ctx, i.db), id)
+}
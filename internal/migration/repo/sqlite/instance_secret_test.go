@@ -0,0 +1,81 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	dbschema "github.com/FuturFusion/migration-manager/internal/db"
+	dbdriver "github.com/FuturFusion/migration-manager/internal/db/sqlite"
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/migration/repo/sqlite"
+	"github.com/FuturFusion/migration-manager/internal/migration/repo/sqlite/entities"
+	"github.com/FuturFusion/migration-manager/internal/transaction"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestInstanceSecretDatabaseActions(t *testing.T) {
+	ctx := context.Background()
+
+	// Create a new temporary database.
+	tmpDir := t.TempDir()
+	db, err := dbdriver.Open(tmpDir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = db.Close()
+		require.NoError(t, err)
+	})
+
+	_, _, err = dbschema.EnsureSchema(db, tmpDir)
+	require.NoError(t, err)
+
+	tx := transaction.Enable(db)
+	entities.PreparedStmts, err = entities.PrepareStmts(tx, false)
+	require.NoError(t, err)
+
+	sourceSvc := migration.NewSourceService(sqlite.NewSource(tx))
+	instance := sqlite.NewInstance(tx)
+	secret := sqlite.NewInstanceSecret(tx)
+
+	_, err = sourceSvc.Create(ctx, testSource)
+	require.NoError(t, err)
+	_, err = instance.Create(ctx, instanceA)
+	require.NoError(t, err)
+
+	// Secrets can only be stored for known instances.
+	_, err = secret.Upsert(ctx, migration.InstanceSecret{InstanceUUID: instanceBUUID, Type: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, Value: "abc", LastUpdated: time.Now().UTC()})
+	require.Error(t, err)
+
+	secretA := migration.InstanceSecret{InstanceUUID: instanceAUUID, Type: api.INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, Value: "abc", LastUpdated: time.Now().UTC()}
+	_, err = secret.Upsert(ctx, secretA)
+	require.NoError(t, err)
+
+	// Upserting a secret of the same type replaces it.
+	secretA.Value = "def"
+	_, err = secret.Upsert(ctx, secretA)
+	require.NoError(t, err)
+
+	secrets, err := secret.GetAllByInstanceUUID(ctx, instanceAUUID)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	secrets[0].ID = 0
+	require.Equal(t, secretA, secrets[0])
+
+	secrets, err = secret.GetAllByInstanceUUID(ctx, instanceBUUID)
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+
+	// Removing the instance removes its secrets.
+	err = instance.DeleteByUUID(ctx, instanceAUUID)
+	require.NoError(t, err)
+	secrets, err = secret.GetAllByInstanceUUID(ctx, instanceAUUID)
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+
+	// Deleting the secrets of an instance without any is not an error.
+	err = secret.DeleteAllByInstanceUUID(ctx, instanceAUUID)
+	require.NoError(t, err)
+}
@@ -75,3 +75,15 @@ func (q queue) DeleteByUUID(ctx context.Context, id uuid.UUID) error {
 func (q queue) DeleteAllByBatch(ctx context.Context, batch string) error {
 	return entities.DeleteQueueEntries(ctx, transaction.GetDBTX(ctx, q.db), batch)
 }
+
+func (q queue) CreateHistory(ctx context.Context, entry migration.QueueHistoryEntry) (int64, error) {
+	return entities.CreateQueueHistoryEntry(ctx, transaction.GetDBTX(ctx, q.db), entry)
+}
+
+func (q queue) GetHistoryByInstanceUUID(ctx context.Context, id uuid.UUID) (migration.QueueHistoryEntries, error) {
+	return entities.GetQueueHistoryEntries(ctx, transaction.GetDBTX(ctx, q.db), entities.QueueHistoryEntryFilter{InstanceUUID: &id})
+}
+
+func (q queue) GetHistoryByBatch(ctx context.Context, batch string) (migration.QueueHistoryEntries, error) {
+	return entities.GetQueueHistoryEntries(ctx, transaction.GetDBTX(ctx, q.db), entities.QueueHistoryEntryFilter{BatchName: &batch})
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// windowsAbsPath matches a Windows path starting with a drive letter.
+var windowsAbsPath = regexp.MustCompile(`^[A-Za-z]:\\`)
+
+func validateShutdownPolicy(policy api.ShutdownPolicy) error {
+	if policy.GuestTimeout.Duration < 0 {
+		return fmt.Errorf("Invalid guest timeout %q: Must not be negative", policy.GuestTimeout.String())
+	}
+
+	if policy.PreShutdownTimeout.Duration < 0 {
+		return fmt.Errorf("Invalid pre-shutdown timeout %q: Must not be negative", policy.PreShutdownTimeout.String())
+	}
+
+	if policy.PreShutdownCommand == "" {
+		if policy.PreShutdownArguments != "" {
+			return fmt.Errorf("Pre-shutdown arguments require a pre-shutdown command")
+		}
+
+		return nil
+	}
+
+	// VMware Tools doesn't search the guest's PATH for the program.
+	if !strings.HasPrefix(policy.PreShutdownCommand, "/") && !windowsAbsPath.MatchString(policy.PreShutdownCommand) {
+		return fmt.Errorf("Invalid pre-shutdown command %q: Must be an absolute path", policy.PreShutdownCommand)
+	}
+
+	return nil
+}
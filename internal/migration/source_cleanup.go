@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func validateSourceCleanupPolicy(policy api.SourceCleanupPolicy) error {
+	if strings.Contains(policy.RenameSuffix, "/") {
+		return fmt.Errorf("Invalid rename suffix %q: Must not contain '/'", policy.RenameSuffix)
+	}
+
+	if policy.Folder != "" && !strings.HasPrefix(policy.Folder, "/") {
+		return fmt.Errorf("Invalid folder %q: Must be an absolute inventory path", policy.Folder)
+	}
+
+	for _, tag := range policy.Tags {
+		category, name, ok := strings.Cut(tag, ":")
+		if !ok || category == "" || name == "" {
+			return fmt.Errorf("Invalid tag %q: Must be in the form category:name", tag)
+		}
+	}
+
+	if policy.DeleteAfterDays < 0 {
+		return fmt.Errorf("Invalid delete_after_days %d: Must not be negative", policy.DeleteAfterDays)
+	}
+
+	return nil
+}
+
+// SourceCleanupActions returns the cleanup actions of the policy in the order they are applied.
+func SourceCleanupActions(policy api.SourceCleanupPolicy) []api.SourceCleanupAction {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	// The migration snapshot is never needed once the migration has finished, so it is always removed.
+	actions := []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT}
+	if policy.DisableChangeTracking {
+		actions = append(actions, api.SOURCECLEANUPACTION_DISABLE_CHANGE_TRACKING)
+	}
+
+	if policy.DisableAutostart {
+		actions = append(actions, api.SOURCECLEANUPACTION_DISABLE_AUTOSTART)
+	}
+
+	if policy.Annotation != "" {
+		actions = append(actions, api.SOURCECLEANUPACTION_ANNOTATE)
+	}
+
+	if len(policy.Tags) > 0 {
+		actions = append(actions, api.SOURCECLEANUPACTION_TAG)
+	}
+
+	if policy.RenameSuffix != "" {
+		actions = append(actions, api.SOURCECLEANUPACTION_RENAME)
+	}
+
+	if policy.Folder != "" {
+		actions = append(actions, api.SOURCECLEANUPACTION_MOVE)
+	}
+
+	if policy.DeleteAfterDays > 0 {
+		actions = append(actions, api.SOURCECLEANUPACTION_DELETE)
+	}
+
+	return actions
+}
+
+// SourceDeleteTime returns the time that the policy deletes the source VM of the queue entry, or the zero time if it is never deleted.
+func (q QueueEntry) SourceDeleteTime(policy api.SourceCleanupPolicy) time.Time {
+	if policy.DeleteAfterDays <= 0 || q.Cutover.FinalImportComplete.IsZero() {
+		return time.Time{}
+	}
+
+	return q.Cutover.FinalImportComplete.AddDate(0, 0, policy.DeleteAfterDays)
+}
+
+// PendingSourceCleanup returns the cleanup actions that are due for the source VM of the queue entry at the given time.
+// Only the source VMs of finished migrations are cleaned up, and a source VM is only deleted once its retention has passed.
+func (q QueueEntry) PendingSourceCleanup(policy api.SourceCleanupPolicy, now time.Time) []api.SourceCleanupAction {
+	if q.MigrationStatus != api.MIGRATIONSTATUS_FINISHED {
+		return nil
+	}
+
+	var pending []api.SourceCleanupAction
+	for _, action := range SourceCleanupActions(policy) {
+		if slices.Contains(q.SourceCleanup.Completed, action) {
+			continue
+		}
+
+		if action == api.SOURCECLEANUPACTION_DELETE {
+			deleteTime := q.SourceDeleteTime(policy)
+			if deleteTime.IsZero() || now.Before(deleteTime) {
+				continue
+			}
+		}
+
+		pending = append(pending, action)
+	}
+
+	return pending
+}
+
+// SourceCleanupPlan returns the cleanup of the source VM of the queue entry that the policy would apply at the given time.
+func (q QueueEntry) SourceCleanupPlan(policy api.SourceCleanupPolicy, instanceName string, now time.Time) api.SourceCleanupPlan {
+	completed := q.SourceCleanup.Completed
+	if completed == nil {
+		completed = []api.SourceCleanupAction{}
+	}
+
+	pending := q.PendingSourceCleanup(policy, now)
+	if pending == nil {
+		pending = []api.SourceCleanupAction{}
+	}
+
+	return api.SourceCleanupPlan{
+		InstanceUUID: q.InstanceUUID,
+		InstanceName: instanceName,
+		Completed:    completed,
+		Pending:      pending,
+		DeleteTime:   q.SourceDeleteTime(policy),
+		LastError:    q.SourceCleanup.LastError,
+	}
+}
@@ -0,0 +1,86 @@
+package migration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestQueueEntry_PendingSourceCleanup(t *testing.T) {
+	finalImport := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	policy := api.SourceCleanupPolicy{
+		RenameSuffix:     "-migrated",
+		Folder:           "/Datacenter/vm/quarantine",
+		DisableAutostart: true,
+		DeleteAfterDays:  7,
+	}
+
+	tests := []struct {
+		name      string
+		policy    api.SourceCleanupPolicy
+		status    api.MigrationStatusType
+		completed []api.SourceCleanupAction
+		now       time.Time
+
+		wantPending    []api.SourceCleanupAction
+		wantDeleteTime time.Time
+	}{
+		{
+			name:   "success - policy disabled",
+			status: api.MIGRATIONSTATUS_FINISHED,
+			now:    finalImport.Add(time.Hour),
+		},
+		{
+			name:   "success - migration not finished",
+			policy: policy,
+			status: api.MIGRATIONSTATUS_FINAL_IMPORT,
+			now:    finalImport.Add(time.Hour),
+
+			wantDeleteTime: finalImport.AddDate(0, 0, 7),
+		},
+		{
+			name:   "success - before retention",
+			policy: policy,
+			status: api.MIGRATIONSTATUS_FINISHED,
+			now:    finalImport.Add(time.Hour),
+
+			wantPending:    []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_DISABLE_AUTOSTART, api.SOURCECLEANUPACTION_RENAME, api.SOURCECLEANUPACTION_MOVE},
+			wantDeleteTime: finalImport.AddDate(0, 0, 7),
+		},
+		{
+			name:      "success - after retention",
+			policy:    policy,
+			status:    api.MIGRATIONSTATUS_FINISHED,
+			completed: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_DISABLE_AUTOSTART, api.SOURCECLEANUPACTION_RENAME},
+			now:       finalImport.AddDate(0, 0, 8),
+
+			wantPending:    []api.SourceCleanupAction{api.SOURCECLEANUPACTION_MOVE, api.SOURCECLEANUPACTION_DELETE},
+			wantDeleteTime: finalImport.AddDate(0, 0, 7),
+		},
+		{
+			name:      "success - all completed",
+			policy:    api.SourceCleanupPolicy{Annotation: "Migrated", Tags: []string{"migration:migrated"}},
+			status:    api.MIGRATIONSTATUS_FINISHED,
+			completed: []api.SourceCleanupAction{api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT, api.SOURCECLEANUPACTION_ANNOTATE, api.SOURCECLEANUPACTION_TAG},
+			now:       finalImport.AddDate(1, 0, 0),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := migration.QueueEntry{
+				MigrationStatus: tc.status,
+				Cutover:         api.QueueCutover{FinalImportComplete: finalImport},
+				SourceCleanup:   api.QueueSourceCleanup{Completed: tc.completed},
+			}
+
+			require.Equal(t, tc.wantPending, q.PendingSourceCleanup(tc.policy, tc.now))
+			require.Equal(t, tc.wantDeleteTime, q.SourceDeleteTime(tc.policy))
+		})
+	}
+}
@@ -167,6 +167,27 @@ func (s Source) validateSourceTypeVMware() error {
 		return NewValidationErrf("Invalid source, sync limit must be 1 or more")
 	}
 
+	if properties.DiskParallelism <= 0 {
+		return NewValidationErrf("Invalid source, disk parallelism must be 1 or more")
+	}
+
+	if properties.DiskConnections <= 0 {
+		return NewValidationErrf("Invalid source, disk connections must be 1 or more")
+	}
+
+	if properties.TransferLimit < 0 {
+		return NewValidationErrf("Invalid source, transfer limit %d cannot be negative", properties.TransferLimit)
+	}
+
+	err = validateBandwidthPolicy(properties.Bandwidth)
+	if err != nil {
+		return NewValidationErrf("Invalid source bandwidth: %v", err)
+	}
+
+	if properties.PerformanceWindow.Duration < time.Duration(0) {
+		return NewValidationErrf("Invalid source, performance window %q cannot be negative", properties.PerformanceWindow)
+	}
+
 	if slices.Contains(properties.Datacenters, "") {
 		return NewValidationErrf("Invalid source, specified datacenter must not be empty")
 	}
@@ -274,3 +295,27 @@ func (s Source) ToAPI() api.Source {
 		SourceType: s.SourceType,
 	}
 }
+
+// NewWorkerTransferLimits returns the disk transfer concurrency for a worker importing from a VMware source with the given number of active imports.
+// If the source has a transfer limit, it is shared evenly between the active imports, reducing the number of parallel disks before the connections per disk.
+func NewWorkerTransferLimits(properties api.VMwareProperties, activeImports int) api.WorkerTransferLimits {
+	limits := api.WorkerTransferLimits{
+		Disks:       max(properties.DiskParallelism, 1),
+		Connections: max(properties.DiskConnections, 1),
+	}
+
+	if properties.TransferLimit <= 0 {
+		return limits
+	}
+
+	share := max(properties.TransferLimit/max(activeImports, 1), 1)
+	for limits.Disks*limits.Connections > share {
+		if limits.Disks > 1 {
+			limits.Disks--
+		} else {
+			limits.Connections--
+		}
+	}
+
+	return limits
+}
@@ -17,10 +17,11 @@ type SourceService interface {
 	Update(ctx context.Context, name string, source *Source, instanceService InstanceService) error
 	DeleteByName(ctx context.Context, name string, instanceService InstanceService) error
 
-	InitImportCache(initial map[string]int) error
+	InitImportCache(instances Instances) error
 	GetCachedImports(sourceName string) int
-	RecordActiveImport(sourceName string)
-	RemoveActiveImport(sourceName string)
+	GetCachedDatastoreImports(instance Instance) int
+	RecordActiveImport(instance Instance)
+	RemoveActiveImport(instance Instance)
 }
 
 //go:generate go run github.com/matryer/moq -fmt goimports -pkg mock -out repo/mock/source_repo_mock_gen.go -rm . SourceRepo
@@ -15,19 +15,44 @@ type sourceService struct {
 	repo SourceRepo
 
 	importCache *util.Cache[string, int]
+
+	// datastoreImportCache counts the active imports reading from each datastore of a source.
+	datastoreImportCache *util.Cache[datastoreKey, int]
+}
+
+// datastoreKey identifies a datastore of a source.
+type datastoreKey struct {
+	source    string
+	datastore string
 }
 
 var _ SourceService = &sourceService{}
 
 func NewSourceService(repo SourceRepo) sourceService {
 	return sourceService{
-		repo:        repo,
-		importCache: util.NewCache[string, int](),
+		repo:                 repo,
+		importCache:          util.NewCache[string, int](),
+		datastoreImportCache: util.NewCache[datastoreKey, int](),
 	}
 }
 
-func (s sourceService) InitImportCache(initial map[string]int) error {
-	return s.importCache.Replace(initial)
+// InitImportCache replaces the counts of active imports with those of the given importing instances.
+func (s sourceService) InitImportCache(instances Instances) error {
+	sources := map[string]int{}
+	datastores := map[datastoreKey]int{}
+	for _, inst := range instances {
+		sources[inst.Source]++
+		for _, ds := range inst.Datastores() {
+			datastores[datastoreKey{source: inst.Source, datastore: ds}]++
+		}
+	}
+
+	err := s.importCache.Replace(sources)
+	if err != nil {
+		return err
+	}
+
+	return s.datastoreImportCache.Replace(datastores)
 }
 
 func (s sourceService) GetCachedImports(sourceName string) int {
@@ -35,20 +60,41 @@ func (s sourceService) GetCachedImports(sourceName string) int {
 	return val
 }
 
-func (s sourceService) RecordActiveImport(sourceName string) {
-	s.importCache.Write(sourceName, 1, func(existingVal, newVal int) int {
+// GetCachedDatastoreImports returns the number of active imports from the busiest datastore holding disks of the instance.
+func (s sourceService) GetCachedDatastoreImports(instance Instance) int {
+	var busiest int
+	for _, ds := range instance.Datastores() {
+		val, _ := s.datastoreImportCache.Read(datastoreKey{source: instance.Source, datastore: ds})
+		busiest = max(busiest, val)
+	}
+
+	return busiest
+}
+
+func (s sourceService) RecordActiveImport(instance Instance) {
+	add := func(existingVal, newVal int) int {
 		return existingVal + newVal
-	})
+	}
+
+	s.importCache.Write(instance.Source, 1, add)
+	for _, ds := range instance.Datastores() {
+		s.datastoreImportCache.Write(datastoreKey{source: instance.Source, datastore: ds}, 1, add)
+	}
 }
 
-func (s sourceService) RemoveActiveImport(sourceName string) {
-	s.importCache.Write(sourceName, 1, func(existingVal, newVal int) int {
+func (s sourceService) RemoveActiveImport(instance Instance) {
+	remove := func(existingVal, newVal int) int {
 		if existingVal > 0 {
 			return existingVal - newVal
 		}
 
 		return existingVal
-	})
+	}
+
+	s.importCache.Write(instance.Source, 1, remove)
+	for _, ds := range instance.Datastores() {
+		s.datastoreImportCache.Write(datastoreKey{source: instance.Source, datastore: ds}, 1, remove)
+	}
 }
 
 func (s sourceService) Create(ctx context.Context, newSource Source) (Source, error) {
@@ -36,16 +36,19 @@ var _ migration.SourceService = &SourceServiceMock{}
 //			GetByNameFunc: func(ctx context.Context, name string) (*migration.Source, error) {
 //				panic("mock out the GetByName method")
 //			},
+//			GetCachedDatastoreImportsFunc: func(instance migration.Instance) int {
+//				panic("mock out the GetCachedDatastoreImports method")
+//			},
 //			GetCachedImportsFunc: func(sourceName string) int {
 //				panic("mock out the GetCachedImports method")
 //			},
-//			InitImportCacheFunc: func(initial map[string]int) error {
+//			InitImportCacheFunc: func(instances migration.Instances) error {
 //				panic("mock out the InitImportCache method")
 //			},
-//			RecordActiveImportFunc: func(sourceName string)  {
+//			RecordActiveImportFunc: func(instance migration.Instance)  {
 //				panic("mock out the RecordActiveImport method")
 //			},
-//			RemoveActiveImportFunc: func(sourceName string)  {
+//			RemoveActiveImportFunc: func(instance migration.Instance)  {
 //				panic("mock out the RemoveActiveImport method")
 //			},
 //			UpdateFunc: func(ctx context.Context, name string, source *migration.Source, instanceService migration.InstanceService) error {
@@ -73,17 +76,20 @@ type SourceServiceMock struct {
 	// GetByNameFunc mocks the GetByName method.
 	GetByNameFunc func(ctx context.Context, name string) (*migration.Source, error)
 
+	// GetCachedDatastoreImportsFunc mocks the GetCachedDatastoreImports method.
+	GetCachedDatastoreImportsFunc func(instance migration.Instance) int
+
 	// GetCachedImportsFunc mocks the GetCachedImports method.
 	GetCachedImportsFunc func(sourceName string) int
 
 	// InitImportCacheFunc mocks the InitImportCache method.
-	InitImportCacheFunc func(initial map[string]int) error
+	InitImportCacheFunc func(instances migration.Instances) error
 
 	// RecordActiveImportFunc mocks the RecordActiveImport method.
-	RecordActiveImportFunc func(sourceName string)
+	RecordActiveImportFunc func(instance migration.Instance)
 
 	// RemoveActiveImportFunc mocks the RemoveActiveImport method.
-	RemoveActiveImportFunc func(sourceName string)
+	RemoveActiveImportFunc func(instance migration.Instance)
 
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(ctx context.Context, name string, source *migration.Source, instanceService migration.InstanceService) error
@@ -127,6 +133,11 @@ type SourceServiceMock struct {
 			// Name is the name argument value.
 			Name string
 		}
+		// GetCachedDatastoreImports holds details about calls to the GetCachedDatastoreImports method.
+		GetCachedDatastoreImports []struct {
+			// Instance is the instance argument value.
+			Instance migration.Instance
+		}
 		// GetCachedImports holds details about calls to the GetCachedImports method.
 		GetCachedImports []struct {
 			// SourceName is the sourceName argument value.
@@ -134,18 +145,18 @@ type SourceServiceMock struct {
 		}
 		// InitImportCache holds details about calls to the InitImportCache method.
 		InitImportCache []struct {
-			// Initial is the initial argument value.
-			Initial map[string]int
+			// Instances is the instances argument value.
+			Instances migration.Instances
 		}
 		// RecordActiveImport holds details about calls to the RecordActiveImport method.
 		RecordActiveImport []struct {
-			// SourceName is the sourceName argument value.
-			SourceName string
+			// Instance is the instance argument value.
+			Instance migration.Instance
 		}
 		// RemoveActiveImport holds details about calls to the RemoveActiveImport method.
 		RemoveActiveImport []struct {
-			// SourceName is the sourceName argument value.
-			SourceName string
+			// Instance is the instance argument value.
+			Instance migration.Instance
 		}
 		// Update holds details about calls to the Update method.
 		Update []struct {
@@ -159,16 +170,17 @@ type SourceServiceMock struct {
 			InstanceService migration.InstanceService
 		}
 	}
-	lockCreate             sync.RWMutex
-	lockDeleteByName       sync.RWMutex
-	lockGetAll             sync.RWMutex
-	lockGetAllNames        sync.RWMutex
-	lockGetByName          sync.RWMutex
-	lockGetCachedImports   sync.RWMutex
-	lockInitImportCache    sync.RWMutex
-	lockRecordActiveImport sync.RWMutex
-	lockRemoveActiveImport sync.RWMutex
-	lockUpdate             sync.RWMutex
+	lockCreate                    sync.RWMutex
+	lockDeleteByName              sync.RWMutex
+	lockGetAll                    sync.RWMutex
+	lockGetAllNames               sync.RWMutex
+	lockGetByName                 sync.RWMutex
+	lockGetCachedDatastoreImports sync.RWMutex
+	lockGetCachedImports          sync.RWMutex
+	lockInitImportCache           sync.RWMutex
+	lockRecordActiveImport        sync.RWMutex
+	lockRemoveActiveImport        sync.RWMutex
+	lockUpdate                    sync.RWMutex
 }
 
 // Create calls CreateFunc.
@@ -355,6 +367,38 @@ func (mock *SourceServiceMock) GetByNameCalls() []struct {
 	return calls
 }
 
+// GetCachedDatastoreImports calls GetCachedDatastoreImportsFunc.
+func (mock *SourceServiceMock) GetCachedDatastoreImports(instance migration.Instance) int {
+	if mock.GetCachedDatastoreImportsFunc == nil {
+		panic("SourceServiceMock.GetCachedDatastoreImportsFunc: method is nil but SourceService.GetCachedDatastoreImports was just called")
+	}
+	callInfo := struct {
+		Instance migration.Instance
+	}{
+		Instance: instance,
+	}
+	mock.lockGetCachedDatastoreImports.Lock()
+	mock.calls.GetCachedDatastoreImports = append(mock.calls.GetCachedDatastoreImports, callInfo)
+	mock.lockGetCachedDatastoreImports.Unlock()
+	return mock.GetCachedDatastoreImportsFunc(instance)
+}
+
+// GetCachedDatastoreImportsCalls gets all the calls that were made to GetCachedDatastoreImports.
+// Check the length with:
+//
+//	len(mockedSourceService.GetCachedDatastoreImportsCalls())
+func (mock *SourceServiceMock) GetCachedDatastoreImportsCalls() []struct {
+	Instance migration.Instance
+} {
+	var calls []struct {
+		Instance migration.Instance
+	}
+	mock.lockGetCachedDatastoreImports.RLock()
+	calls = mock.calls.GetCachedDatastoreImports
+	mock.lockGetCachedDatastoreImports.RUnlock()
+	return calls
+}
+
 // GetCachedImports calls GetCachedImportsFunc.
 func (mock *SourceServiceMock) GetCachedImports(sourceName string) int {
 	if mock.GetCachedImportsFunc == nil {
@@ -388,19 +432,19 @@ func (mock *SourceServiceMock) GetCachedImportsCalls() []struct {
 }
 
 // InitImportCache calls InitImportCacheFunc.
-func (mock *SourceServiceMock) InitImportCache(initial map[string]int) error {
+func (mock *SourceServiceMock) InitImportCache(instances migration.Instances) error {
 	if mock.InitImportCacheFunc == nil {
 		panic("SourceServiceMock.InitImportCacheFunc: method is nil but SourceService.InitImportCache was just called")
 	}
 	callInfo := struct {
-		Initial map[string]int
+		Instances migration.Instances
 	}{
-		Initial: initial,
+		Instances: instances,
 	}
 	mock.lockInitImportCache.Lock()
 	mock.calls.InitImportCache = append(mock.calls.InitImportCache, callInfo)
 	mock.lockInitImportCache.Unlock()
-	return mock.InitImportCacheFunc(initial)
+	return mock.InitImportCacheFunc(instances)
 }
 
 // InitImportCacheCalls gets all the calls that were made to InitImportCache.
@@ -408,10 +452,10 @@ func (mock *SourceServiceMock) InitImportCache(initial map[string]int) error {
 //
 //	len(mockedSourceService.InitImportCacheCalls())
 func (mock *SourceServiceMock) InitImportCacheCalls() []struct {
-	Initial map[string]int
+	Instances migration.Instances
 } {
 	var calls []struct {
-		Initial map[string]int
+		Instances migration.Instances
 	}
 	mock.lockInitImportCache.RLock()
 	calls = mock.calls.InitImportCache
@@ -420,19 +464,19 @@ func (mock *SourceServiceMock) InitImportCacheCalls() []struct {
 }
 
 // RecordActiveImport calls RecordActiveImportFunc.
-func (mock *SourceServiceMock) RecordActiveImport(sourceName string) {
+func (mock *SourceServiceMock) RecordActiveImport(instance migration.Instance) {
 	if mock.RecordActiveImportFunc == nil {
 		panic("SourceServiceMock.RecordActiveImportFunc: method is nil but SourceService.RecordActiveImport was just called")
 	}
 	callInfo := struct {
-		SourceName string
+		Instance migration.Instance
 	}{
-		SourceName: sourceName,
+		Instance: instance,
 	}
 	mock.lockRecordActiveImport.Lock()
 	mock.calls.RecordActiveImport = append(mock.calls.RecordActiveImport, callInfo)
 	mock.lockRecordActiveImport.Unlock()
-	mock.RecordActiveImportFunc(sourceName)
+	mock.RecordActiveImportFunc(instance)
 }
 
 // RecordActiveImportCalls gets all the calls that were made to RecordActiveImport.
@@ -440,10 +484,10 @@ func (mock *SourceServiceMock) RecordActiveImport(sourceName string) {
 //
 //	len(mockedSourceService.RecordActiveImportCalls())
 func (mock *SourceServiceMock) RecordActiveImportCalls() []struct {
-	SourceName string
+	Instance migration.Instance
 } {
 	var calls []struct {
-		SourceName string
+		Instance migration.Instance
 	}
 	mock.lockRecordActiveImport.RLock()
 	calls = mock.calls.RecordActiveImport
@@ -452,19 +496,19 @@ func (mock *SourceServiceMock) RecordActiveImportCalls() []struct {
 }
 
 // RemoveActiveImport calls RemoveActiveImportFunc.
-func (mock *SourceServiceMock) RemoveActiveImport(sourceName string) {
+func (mock *SourceServiceMock) RemoveActiveImport(instance migration.Instance) {
 	if mock.RemoveActiveImportFunc == nil {
 		panic("SourceServiceMock.RemoveActiveImportFunc: method is nil but SourceService.RemoveActiveImport was just called")
 	}
 	callInfo := struct {
-		SourceName string
+		Instance migration.Instance
 	}{
-		SourceName: sourceName,
+		Instance: instance,
 	}
 	mock.lockRemoveActiveImport.Lock()
 	mock.calls.RemoveActiveImport = append(mock.calls.RemoveActiveImport, callInfo)
 	mock.lockRemoveActiveImport.Unlock()
-	mock.RemoveActiveImportFunc(sourceName)
+	mock.RemoveActiveImportFunc(instance)
 }
 
 // RemoveActiveImportCalls gets all the calls that were made to RemoveActiveImport.
@@ -472,10 +516,10 @@ func (mock *SourceServiceMock) RemoveActiveImport(sourceName string) {
 //
 //	len(mockedSourceService.RemoveActiveImportCalls())
 func (mock *SourceServiceMock) RemoveActiveImportCalls() []struct {
-	SourceName string
+	Instance migration.Instance
 } {
 	var calls []struct {
-		SourceName string
+		Instance migration.Instance
 	}
 	mock.lockRemoveActiveImport.RLock()
 	calls = mock.calls.RemoveActiveImport
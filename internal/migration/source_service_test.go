@@ -42,7 +42,7 @@ func TestSourceService_Create(t *testing.T) {
 				ID:         1,
 				Name:       "one",
 				SourceType: api.SOURCETYPE_VMWARE,
-				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"10m0s","sync_timeout":"10s","sync_limit":1,"datacenters":["/..."]}`),
+				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"10m0s","sync_timeout":"10s","sync_limit":1,"datacenters":["/..."],"disk_parallelism":1,"disk_connections":1}`),
 			},
 
 			assertErr: require.NoError,
@@ -65,7 +65,7 @@ func TestSourceService_Create(t *testing.T) {
 				ID:         1,
 				Name:       "one",
 				SourceType: api.SOURCETYPE_NSX,
-				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"10m0s","sync_timeout":"10s","sync_limit":1,"datacenters":["/..."],"compute_managers":null,"segments":null,"edge_nodes":null,"policies":null}`),
+				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"10m0s","sync_timeout":"10s","sync_limit":1,"datacenters":["/..."],"disk_parallelism":1,"disk_connections":1,"compute_managers":null,"segments":null,"edge_nodes":null,"policies":null}`),
 			},
 
 			assertErr: require.NoError,
@@ -84,7 +84,10 @@ func TestSourceService_Create(t *testing.T) {
 	"connection_timeout":"5s",
 	"sync_timeout":"4s",
 	"sync_limit":2,
-	"datacenters":["dc1","dc2","/dc3/..."]
+	"datacenters":["dc1","dc2","/dc3/..."],
+	"disk_parallelism":2,
+	"disk_connections":4,
+	"transfer_limit":8
 }
 `),
 			},
@@ -92,7 +95,7 @@ func TestSourceService_Create(t *testing.T) {
 				ID:         1,
 				Name:       "one",
 				SourceType: api.SOURCETYPE_VMWARE,
-				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"5s","sync_timeout":"4s","sync_limit":2,"datacenters":["/dc1/...","/dc2/...","/dc3/..."]}`),
+				Properties: json.RawMessage(`{"endpoint":"endpoint.url","username":"user","password":"pass","connectivity_status":"OK","connection_timeout":"5s","sync_timeout":"4s","sync_limit":2,"datacenters":["/dc1/...","/dc2/...","/dc3/..."],"disk_parallelism":2,"disk_connections":4,"transfer_limit":8}`),
 			},
 
 			assertErr: require.NoError,
@@ -280,6 +283,50 @@ func TestSourceService_Create(t *testing.T) {
 	}
 }
 
+func TestSourceService_ImportCache(t *testing.T) {
+	newInstance := func(source string, disks ...string) migration.Instance {
+		inst := migration.Instance{Source: source}
+		for _, disk := range disks {
+			inst.Properties.Disks = append(inst.Properties.Disks, api.InstancePropertiesDisk{Name: disk, Supported: true})
+		}
+
+		return inst
+	}
+
+	one := newInstance("src", "[ds1] one/one.vmdk")
+	two := newInstance("src", "[ds1] two/two.vmdk", "[ds2] two/two_1.vmdk")
+	three := newInstance("src", "[ds2] three/three.vmdk")
+	other := newInstance("other", "[ds1] other/other.vmdk")
+
+	sourceSvc := migration.NewSourceService(nil)
+	err := sourceSvc.InitImportCache(migration.Instances{one, two})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, sourceSvc.GetCachedImports("src"))
+	require.Equal(t, 2, sourceSvc.GetCachedDatastoreImports(one))
+	require.Equal(t, 1, sourceSvc.GetCachedDatastoreImports(three))
+
+	// Datastores of different sources are counted separately.
+	sourceSvc.RecordActiveImport(other)
+	require.Equal(t, 1, sourceSvc.GetCachedDatastoreImports(other))
+	require.Equal(t, 2, sourceSvc.GetCachedDatastoreImports(one))
+
+	sourceSvc.RecordActiveImport(three)
+	require.Equal(t, 3, sourceSvc.GetCachedImports("src"))
+	require.Equal(t, 2, sourceSvc.GetCachedDatastoreImports(three))
+	require.Equal(t, 2, sourceSvc.GetCachedDatastoreImports(two))
+
+	sourceSvc.RemoveActiveImport(two)
+	require.Equal(t, 2, sourceSvc.GetCachedImports("src"))
+	require.Equal(t, 1, sourceSvc.GetCachedDatastoreImports(one))
+	require.Equal(t, 1, sourceSvc.GetCachedDatastoreImports(three))
+
+	// Counts never go below zero.
+	sourceSvc.RemoveActiveImport(two)
+	sourceSvc.RemoveActiveImport(two)
+	require.Equal(t, 0, sourceSvc.GetCachedDatastoreImports(two))
+}
+
 func TestSourceService_GetAll(t *testing.T) {
 	tests := []struct {
 		name              string
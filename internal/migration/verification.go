@@ -0,0 +1,27 @@
+package migration
+
+import (
+	"fmt"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// verifyDiskSyncs checks the disks synced by a final import against the verification mode of the batch.
+// It returns an error if a disk was not verified as required, or if any of its compared blocks differ from the source.
+func verifyDiskSyncs(mode api.VerificationMode, disks []api.WorkerDiskSync) error {
+	if !mode.Enabled() {
+		return nil
+	}
+
+	for _, disk := range disks {
+		if disk.Verification == nil {
+			return fmt.Errorf("Disk %q was not verified", disk.Name)
+		}
+
+		if disk.Verification.MismatchedBlocks > 0 {
+			return fmt.Errorf("Disk %q failed %s verification: %d blocks differ from the source", disk.Name, disk.Verification.Mode, disk.Verification.MismatchedBlocks)
+		}
+	}
+
+	return nil
+}
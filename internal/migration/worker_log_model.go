@@ -0,0 +1,26 @@
+package migration
+
+import (
+	"time"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+type WorkerLogAttempt struct {
+	Attempt int
+	Time    time.Time
+	Files   []string
+
+	// Combined size of the files in bytes.
+	Size int64
+}
+
+type WorkerLogAttempts []WorkerLogAttempt
+
+func (a WorkerLogAttempt) ToAPI() api.QueueLogAttempt {
+	return api.QueueLogAttempt{
+		Attempt: a.Attempt,
+		Time:    a.Time,
+		Files:   a.Files,
+	}
+}
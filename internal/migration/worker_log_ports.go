@@ -0,0 +1,16 @@
+package migration
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+)
+
+//go:generate go run github.com/matryer/moq -fmt goimports -pkg migration_test -out worker_log_service_mock_gen_test.go -rm . WorkerLogService
+
+type WorkerLogService interface {
+	WriteLogs(id uuid.UUID, reader io.Reader) (WorkerLogAttempt, error)
+	GetAllByInstanceUUID(id uuid.UUID) (WorkerLogAttempts, error)
+	FilePath(id uuid.UUID, attempt int, fileName string) (string, error)
+	DeleteByInstanceUUID(id uuid.UUID) error
+}
@@ -0,0 +1,253 @@
+package migration
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/FuturFusion/migration-manager/internal/server/sys"
+)
+
+// maxWorkerLogAttempts is the number of log uploads kept for each instance. The oldest are removed as new ones arrive.
+const maxWorkerLogAttempts = 10
+
+// maxWorkerLogSize is the limit on the combined size of the files in a single log upload.
+const maxWorkerLogSize = 256 * 1024 * 1024
+
+// maxWorkerLogInstanceSize is the limit on the combined size of the log uploads kept for each instance.
+// The oldest are removed until the kept uploads fit, but the latest upload is always kept.
+const maxWorkerLogInstanceSize = 512 * 1024 * 1024
+
+type workerLogService struct {
+	os *sys.OS
+
+	lock *sync.Mutex
+
+	maxInstanceSize int64
+}
+
+var _ WorkerLogService = &workerLogService{}
+
+type WorkerLogServiceOption func(s *workerLogService)
+
+func NewWorkerLogService(sysOS *sys.OS, opts ...WorkerLogServiceOption) WorkerLogService {
+	workerLogSvc := workerLogService{
+		os:              sysOS,
+		lock:            &sync.Mutex{},
+		maxInstanceSize: maxWorkerLogInstanceSize,
+	}
+
+	for _, opt := range opts {
+		opt(&workerLogSvc)
+	}
+
+	return workerLogSvc
+}
+
+// WriteLogs extracts the tar archive of log files uploaded by the worker into a new attempt directory at WorkerLogDir/{uuid}/{attempt}.
+func (s workerLogService) WriteLogs(id uuid.UUID, reader io.Reader) (WorkerLogAttempt, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	attempts, err := s.GetAllByInstanceUUID(id)
+	if err != nil {
+		return WorkerLogAttempt{}, err
+	}
+
+	next := 1
+	if len(attempts) > 0 {
+		next = attempts[len(attempts)-1].Attempt + 1
+	}
+
+	dir := s.attemptDirectory(id, next)
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return WorkerLogAttempt{}, fmt.Errorf("Failed to create directory %q: %w", dir, err)
+	}
+
+	err = extractWorkerLogs(dir, reader)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return WorkerLogAttempt{}, err
+	}
+
+	newAttempt, err := s.getAttempt(id, next)
+	if err != nil {
+		return WorkerLogAttempt{}, err
+	}
+
+	totalSize := newAttempt.Size
+	for _, a := range attempts {
+		totalSize += a.Size
+	}
+
+	for len(attempts) > 0 && (len(attempts) >= maxWorkerLogAttempts || totalSize > s.maxInstanceSize) {
+		err := os.RemoveAll(s.attemptDirectory(id, attempts[0].Attempt))
+		if err != nil {
+			return WorkerLogAttempt{}, fmt.Errorf("Failed to remove logs of attempt %d for instance %q: %w", attempts[0].Attempt, id, err)
+		}
+
+		totalSize -= attempts[0].Size
+		attempts = attempts[1:]
+	}
+
+	return newAttempt, nil
+}
+
+// DeleteByInstanceUUID removes all uploaded logs of the instance.
+func (s workerLogService) DeleteByInstanceUUID(id uuid.UUID) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	instanceDir := filepath.Join(s.os.WorkerLogDir, id.String())
+	err := os.RemoveAll(instanceDir)
+	if err != nil {
+		return fmt.Errorf("Failed to remove worker logs of instance %q: %w", id, err)
+	}
+
+	return nil
+}
+
+// GetAllByInstanceUUID returns the uploaded log attempts for the instance, oldest first.
+func (s workerLogService) GetAllByInstanceUUID(id uuid.UUID) (WorkerLogAttempts, error) {
+	instanceDir := filepath.Join(s.os.WorkerLogDir, id.String())
+	entries, err := os.ReadDir(instanceDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Failed to read directory %q: %w", instanceDir, err)
+	}
+
+	attempts := WorkerLogAttempts{}
+	for _, e := range entries {
+		attempt, err := strconv.Atoi(e.Name())
+		if err != nil || attempt < 1 || !e.IsDir() {
+			continue
+		}
+
+		a, err := s.getAttempt(id, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, a)
+	}
+
+	slices.SortFunc(attempts, func(a WorkerLogAttempt, b WorkerLogAttempt) int {
+		return a.Attempt - b.Attempt
+	})
+
+	return attempts, nil
+}
+
+// FilePath returns the path to a log file uploaded during the given attempt.
+func (s workerLogService) FilePath(id uuid.UUID, attempt int, fileName string) (string, error) {
+	err := validateWorkerLogName(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(s.attemptDirectory(id, attempt), fileName)
+	_, err = os.Stat(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("Log file %q of attempt %d for instance %q: %w", fileName, attempt, id, ErrNotFound)
+		}
+
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+func (s workerLogService) attemptDirectory(id uuid.UUID, attempt int) string {
+	return filepath.Join(s.os.WorkerLogDir, id.String(), strconv.Itoa(attempt))
+}
+
+func (s workerLogService) getAttempt(id uuid.UUID, attempt int) (WorkerLogAttempt, error) {
+	dir := s.attemptDirectory(id, attempt)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return WorkerLogAttempt{}, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return WorkerLogAttempt{}, fmt.Errorf("Failed to read directory %q: %w", dir, err)
+	}
+
+	var size int64
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		fileInfo, err := e.Info()
+		if err != nil {
+			return WorkerLogAttempt{}, err
+		}
+
+		size += fileInfo.Size()
+		files = append(files, e.Name())
+	}
+
+	return WorkerLogAttempt{Attempt: attempt, Time: info.ModTime().UTC(), Files: files, Size: size}, nil
+}
+
+// extractWorkerLogs writes the regular files of the tar archive into the directory.
+// The worker sends a flat archive, so entries with a path are rejected rather than written outside of the directory.
+func extractWorkerLogs(dir string, reader io.Reader) error {
+	tr := tar.NewReader(reader)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return NewValidationErrf("Failed to read worker logs: %v", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			return NewValidationErrf("Invalid log file %q: Not a regular file", hdr.Name)
+		}
+
+		err = validateWorkerLogName(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		total += hdr.Size
+		if total > maxWorkerLogSize {
+			return NewValidationErrf("Worker logs exceed the size limit of %d bytes", maxWorkerLogSize)
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, hdr.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("Failed to create log file %q: %w", hdr.Name, err)
+		}
+
+		_, err = io.CopyN(f, tr, hdr.Size)
+		if err != nil {
+			_ = f.Close()
+			return NewValidationErrf("Failed to read log file %q: %v", hdr.Name, err)
+		}
+
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func validateWorkerLogName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return NewValidationErrf("Invalid log file name %q", name)
+	}
+
+	return nil
+}
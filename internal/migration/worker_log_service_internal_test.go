@@ -0,0 +1,7 @@
+package migration
+
+func WithMaxInstanceSize(size int64) WorkerLogServiceOption {
+	return func(s *workerLogService) {
+		s.maxInstanceSize = size
+	}
+}
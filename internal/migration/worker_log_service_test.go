@@ -0,0 +1,179 @@
+package migration_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/server/sys"
+)
+
+func testLogArchive(t *testing.T, typeflag byte, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: typeflag, Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	return buf
+}
+
+func TestWorkerLogService_WriteLogs(t *testing.T) {
+	cases := []struct {
+		name     string
+		typeflag byte
+		files    map[string]string
+
+		assertErr require.ErrorAssertionFunc
+		wantFiles []string
+	}{
+		{
+			name:     "success",
+			typeflag: tar.TypeReg,
+			files:    map[string]string{"worker.log": "worker output", "nbdkit-2000.log": "nbdkit output"},
+
+			assertErr: require.NoError,
+			wantFiles: []string{"nbdkit-2000.log", "worker.log"},
+		},
+		{
+			name:     "error - path in file name",
+			typeflag: tar.TypeReg,
+			files:    map[string]string{"../worker.log": "worker output"},
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+		{
+			name:     "error - not a regular file",
+			typeflag: tar.TypeSymlink,
+			files:    map[string]string{"worker.log": ""},
+
+			assertErr: func(tt require.TestingT, err error, a ...any) {
+				var verr migration.ErrValidation
+				require.ErrorAs(tt, err, &verr, a...)
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Logf("\n\nTEST %02d: %s\n\n", i, tc.name)
+
+			instUUID := uuid.New()
+			workerLogSvc := migration.NewWorkerLogService(&sys.OS{WorkerLogDir: t.TempDir()})
+
+			attempt, err := workerLogSvc.WriteLogs(instUUID, testLogArchive(t, tc.typeflag, tc.files))
+			tc.assertErr(t, err)
+
+			attempts, err := workerLogSvc.GetAllByInstanceUUID(instUUID)
+			require.NoError(t, err)
+
+			// Failed uploads leave no attempt behind.
+			if tc.wantFiles == nil {
+				require.Empty(t, attempts)
+				return
+			}
+
+			require.Equal(t, migration.WorkerLogAttempts{attempt}, attempts)
+			require.Equal(t, 1, attempt.Attempt)
+			require.Equal(t, tc.wantFiles, attempt.Files)
+
+			for name, content := range tc.files {
+				path, err := workerLogSvc.FilePath(instUUID, attempt.Attempt, name)
+				require.NoError(t, err)
+
+				b, err := os.ReadFile(path)
+				require.NoError(t, err)
+				require.Equal(t, content, string(b))
+			}
+		})
+	}
+}
+
+func TestWorkerLogService_retention(t *testing.T) {
+	instUUID := uuid.New()
+	workerLogSvc := migration.NewWorkerLogService(&sys.OS{WorkerLogDir: t.TempDir()})
+
+	for range 12 {
+		_, err := workerLogSvc.WriteLogs(instUUID, testLogArchive(t, tar.TypeReg, map[string]string{"worker.log": "output"}))
+		require.NoError(t, err)
+	}
+
+	attempts, err := workerLogSvc.GetAllByInstanceUUID(instUUID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 10)
+	require.Equal(t, 3, attempts[0].Attempt)
+	require.Equal(t, 12, attempts[9].Attempt)
+
+	_, err = workerLogSvc.FilePath(instUUID, 1, "worker.log")
+	require.ErrorIs(t, err, migration.ErrNotFound)
+
+	_, err = workerLogSvc.FilePath(instUUID, 12, "../12/worker.log")
+	var verr migration.ErrValidation
+	require.ErrorAs(t, err, &verr)
+
+	// Other instances have no logs.
+	attempts, err = workerLogSvc.GetAllByInstanceUUID(uuid.New())
+	require.NoError(t, err)
+	require.Empty(t, attempts)
+}
+
+func TestWorkerLogService_sizeRetention(t *testing.T) {
+	instUUID := uuid.New()
+	workerLogSvc := migration.NewWorkerLogService(&sys.OS{WorkerLogDir: t.TempDir()}, migration.WithMaxInstanceSize(25))
+
+	for _, content := range []string{"0123456789", "0123456789", "0123456789"} {
+		_, err := workerLogSvc.WriteLogs(instUUID, testLogArchive(t, tar.TypeReg, map[string]string{"worker.log": content}))
+		require.NoError(t, err)
+	}
+
+	attempts, err := workerLogSvc.GetAllByInstanceUUID(instUUID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.Equal(t, 2, attempts[0].Attempt)
+	require.Equal(t, int64(10), attempts[0].Size)
+
+	// The latest upload is kept even if it exceeds the limit on its own.
+	attempt, err := workerLogSvc.WriteLogs(instUUID, testLogArchive(t, tar.TypeReg, map[string]string{"worker.log": "012345678901234567890123456789"}))
+	require.NoError(t, err)
+
+	attempts, err = workerLogSvc.GetAllByInstanceUUID(instUUID)
+	require.NoError(t, err)
+	require.Equal(t, migration.WorkerLogAttempts{attempt}, attempts)
+}
+
+func TestWorkerLogService_DeleteByInstanceUUID(t *testing.T) {
+	instUUID := uuid.New()
+	otherUUID := uuid.New()
+	workerLogSvc := migration.NewWorkerLogService(&sys.OS{WorkerLogDir: t.TempDir()})
+
+	for _, id := range []uuid.UUID{instUUID, otherUUID} {
+		_, err := workerLogSvc.WriteLogs(id, testLogArchive(t, tar.TypeReg, map[string]string{"worker.log": "output"}))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, workerLogSvc.DeleteByInstanceUUID(instUUID))
+
+	attempts, err := workerLogSvc.GetAllByInstanceUUID(instUUID)
+	require.NoError(t, err)
+	require.Empty(t, attempts)
+
+	attempts, err = workerLogSvc.GetAllByInstanceUUID(otherUUID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+
+	// Deleting an instance without logs succeeds.
+	require.NoError(t, workerLogSvc.DeleteByInstanceUUID(uuid.New()))
+}
@@ -27,6 +27,18 @@ type Handler struct {
 	window   migration.WindowService
 
 	workerUpdateCache *util.Cache[uuid.UUID, time.Time]
+
+	// Names of the target snapshots being taken, by instance.
+	targetSnapshots *util.Cache[uuid.UUID, string]
+
+	// Transfer limits last sent to the workers of running imports, by instance.
+	transferLimitsCache *util.Cache[uuid.UUID, cachedTransferLimits]
+}
+
+// cachedTransferLimits holds transfer limits until the start of the next minute, when a bandwidth schedule may begin or end.
+type cachedTransferLimits struct {
+	limits  api.WorkerTransferLimits
+	expires time.Time
 }
 
 // NewMigrationHandler creates a new handler for queued migrations.
@@ -34,6 +46,9 @@ func NewMigrationHandler(b migration.BatchService, i migration.InstanceService,
 	return &Handler{
 		batchLock:         util.NewIDLock[string](),
 		workerUpdateCache: util.NewCache[uuid.UUID, time.Time](),
+		targetSnapshots:   util.NewCache[uuid.UUID, string](),
+
+		transferLimitsCache: util.NewCache[uuid.UUID, cachedTransferLimits](),
 
 		batch:    b,
 		instance: i,
@@ -87,6 +102,43 @@ func (s *Handler) RemoveFromCache(instanceUUID uuid.UUID) {
 	s.workerUpdateCache.Delete(instanceUUID)
 }
 
+// StartTargetSnapshot records that a snapshot of the target instance is being taken.
+func (s *Handler) StartTargetSnapshot(instanceUUID uuid.UUID, snapshotName string) {
+	s.targetSnapshots.Write(instanceUUID, snapshotName, nil)
+}
+
+// FinishTargetSnapshot records that the snapshot of the target instance has finished, successfully or not.
+func (s *Handler) FinishTargetSnapshot(instanceUUID uuid.UUID) {
+	s.targetSnapshots.Delete(instanceUUID)
+}
+
+// TargetSnapshotPending returns whether a snapshot of the target instance is still being taken.
+func (s *Handler) TargetSnapshotPending(instanceUUID uuid.UUID) bool {
+	_, ok := s.targetSnapshots.Read(instanceUUID)
+	return ok
+}
+
+// CachedTransferLimits returns the transfer limits cached for the worker of the instance, if they are still current.
+func (s *Handler) CachedTransferLimits(instanceUUID uuid.UUID) (api.WorkerTransferLimits, bool) {
+	cached, ok := s.transferLimitsCache.Read(instanceUUID)
+	if !ok || !time.Now().Before(cached.expires) {
+		return api.WorkerTransferLimits{}, false
+	}
+
+	return cached.limits, true
+}
+
+// CacheTransferLimits caches the transfer limits for the worker of the instance.
+// Bandwidth schedules are set in whole minutes, so the limits are kept until the start of the next minute.
+func (s *Handler) CacheTransferLimits(instanceUUID uuid.UUID, limits api.WorkerTransferLimits) {
+	s.transferLimitsCache.Write(instanceUUID, cachedTransferLimits{limits: limits, expires: time.Now().Truncate(time.Minute).Add(time.Minute)}, nil)
+}
+
+// ResetTransferLimits drops all cached transfer limits, for when an import starts or ends and the limits are shared differently.
+func (s *Handler) ResetTransferLimits() {
+	_ = s.transferLimitsCache.Replace(map[uuid.UUID]cachedTransferLimits{})
+}
+
 // GetMigrationState fetches all migration state information corresponding to the given batch status and migration status.
 func (s *Handler) GetMigrationState(ctx context.Context, batchStatus api.BatchStatusType, migrationStatuses ...api.MigrationStatusType) (BatchMigrationState, error) {
 	migrationState := BatchMigrationState{}
@@ -167,6 +167,62 @@ func BatchPlacementRun(ctx context.Context, loader *scriptlet.Loader, instance a
 		return starlark.None, nil
 	}
 
+	setDiskFunc := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var diskName string
+		var grow string
+		var drop bool
+		var detach bool
+		var config *starlark.Dict
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "disk_name", &diskName, "grow?", &grow, "drop?", &drop, "detach?", &detach, "config?", &config)
+		if err != nil {
+			return nil, err
+		}
+
+		var diskExists bool
+		for _, d := range instance.Disks {
+			if d.Name == diskName && d.Supported {
+				diskExists = true
+				break
+			}
+		}
+
+		if !diskExists {
+			return nil, fmt.Errorf("No disk found with name %q on instance %q", diskName, instance.Location)
+		}
+
+		policy := api.DiskPolicy{
+			Grow:   grow,
+			Drop:   drop,
+			Detach: detach,
+		}
+
+		if config != nil {
+			policy.Config = make(map[string]string, config.Len())
+			for _, item := range config.Items() {
+				key, ok := starlark.AsString(item[0])
+				if !ok {
+					return nil, fmt.Errorf("Invalid volume config key %s for disk %q", item[0].String(), diskName)
+				}
+
+				value, ok := starlark.AsString(item[1])
+				if !ok {
+					return nil, fmt.Errorf("Invalid volume config value %s for key %q of disk %q", item[1].String(), key, diskName)
+				}
+
+				policy.Config[key] = value
+			}
+		}
+
+		if resp.Disks == nil {
+			resp.Disks = map[string]api.DiskPolicy{}
+		}
+
+		resp.Disks[diskName] = policy
+		slog.Info("Batch placement assigned disk policy for instance", slog.String("location", instance.Location), slog.String("disk", diskName), slog.String("grow", grow), slog.Bool("drop", drop), slog.Bool("detach", detach))
+
+		return starlark.None, nil
+	}
+
 	env := starlark.StringDict{
 		"log_info":  starlark.NewBuiltin("log_info", logFunc),
 		"log_warn":  starlark.NewBuiltin("log_warn", logFunc),
@@ -176,6 +232,7 @@ func BatchPlacementRun(ctx context.Context, loader *scriptlet.Loader, instance a
 		"set_project": starlark.NewBuiltin("set_project", setProjectFunc),
 		"set_pool":    starlark.NewBuiltin("set_pool", setPoolFunc),
 		"set_network": starlark.NewBuiltin("set_network", setNetworkFunc),
+		"set_disk":    starlark.NewBuiltin("set_disk", setDiskFunc),
 	}
 
 	prog, thread, err := BatchPlacementProgram(loader, batch.Name)
@@ -31,6 +31,7 @@ func BatchPlacementCompile(name string, src string) (*starlark.Program, error) {
 		"set_pool",
 		"set_network",
 		"set_vlan",
+		"set_disk",
 	})
 }
 
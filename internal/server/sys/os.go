@@ -2,6 +2,7 @@ package sys
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"io"
 	"os"
@@ -34,7 +35,10 @@ type OS struct {
 	DatabaseDir string // Location of the database files (e.g. /var/lib/migration-manager/database/).
 	ACMEDir     string // Location of ACME account files (e.g. /var/cache/migration-manager/acme/).
 
-	ConfigFile string // System config yaml file (e.g. /var/lib/migration-manager/config.yml).
+	WorkerLogDir string // Location of logs uploaded by migration workers (e.g. /var/lib/migration-manager/worker-logs/).
+
+	ConfigFile     string // System config yaml file (e.g. /var/lib/migration-manager/config.yml).
+	SecretsKeyFile string // Key used to encrypt stored instance secrets (e.g. /var/lib/migration-manager/secrets.key).
 }
 
 // DefaultOS returns a fresh uninitialized OS instance with default values.
@@ -51,6 +55,10 @@ func DefaultOS() *OS {
 		DatabaseDir: util.VarPath("database"),
 		ACMEDir:     util.CachePath("acme"),
 		ConfigFile:  util.VarPath("config.yml"),
+
+		WorkerLogDir: util.VarPath("worker-logs"),
+
+		SecretsKeyFile: util.VarPath("secrets.key"),
 	}
 
 	return newOS
@@ -68,6 +76,7 @@ func (s *OS) Init() error {
 		s.ArtifactDir,
 		s.ImageDir,
 		s.DatabaseDir,
+		s.WorkerLogDir,
 	} {
 		if !incusUtil.PathExists(dir) {
 			err := os.MkdirAll(dir, 0o755)
@@ -80,6 +89,38 @@ func (s *OS) Init() error {
 	return nil
 }
 
+// LoadSecretsKey returns the key used to encrypt stored instance secrets, generating it on first use.
+func (s *OS) LoadSecretsKey() ([]byte, error) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	key, err := os.ReadFile(s.SecretsKeyFile)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("Invalid secrets key %q: Expected 32 bytes, found %d", s.SecretsKeyFile, len(key))
+		}
+
+		return key, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Failed to read secrets key %q: %w", s.SecretsKeyFile, err)
+	}
+
+	key = make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate secrets key: %w", err)
+	}
+
+	err = os.WriteFile(s.SecretsKeyFile, key, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to write secrets key %q: %w", s.SecretsKeyFile, err)
+	}
+
+	return key, nil
+}
+
 // GetUnixSocket returns the full path to the unix.socket file that this daemon is listening on.
 func (s *OS) GetUnixSocket() string {
 	path := os.Getenv("MIGRATION_MANAGER_SOCKET")
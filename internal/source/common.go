@@ -49,14 +49,18 @@ func (s *InternalSource) DeleteVMSnapshot(ctx context.Context, vmName string, sn
 	return fmt.Errorf("Not implemented by InternalSource")
 }
 
-func (s *InternalSource) ImportDisks(ctx context.Context, vmName string, statusCallback func(string, bool)) error {
-	return fmt.Errorf("Not implemented by InternalSource")
+func (s *InternalSource) ImportDisks(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
+	return nil, nil, fmt.Errorf("Not implemented by InternalSource")
 }
 
 func (s *InternalSource) PowerOffVM(ctx context.Context, vmName string) error {
 	return fmt.Errorf("Not implemented by InternalSource")
 }
 
+func (s *InternalSource) ShutdownVM(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+	return fmt.Errorf("Not implemented by InternalSource")
+}
+
 func (s *InternalSource) Timeout() time.Duration {
 	return s.connectionTimeout
 }
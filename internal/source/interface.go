@@ -11,6 +11,39 @@ import (
 	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
+// DiskLocator returns the path of the target device for the named source disk, and whether it is the root disk.
+type DiskLocator func(ctx context.Context, diskName string) (string, bool, error)
+
+// ImportDisksOptions configures a disk import cycle.
+type ImportDisksOptions struct {
+	// Path to the VMware SDK.
+	SDKPath string
+
+	// Disks of the VM, as recorded when the instance was last synced. The import fails if they no longer match the source.
+	Disks []api.InstancePropertiesDisk
+
+	// Names of the disks that are expected on the source, but are not imported.
+	Dropped []string
+
+	// Finds the target device of each disk. Defaults to the disks attached to the locally running VM if nil.
+	Locator DiskLocator
+
+	// Disk states returned by the previous import, which determine whether a disk can be synced incrementally.
+	States []api.WorkerDiskState
+
+	// Bounds how many disks, and how many connections per disk, are copied concurrently.
+	Limits api.WorkerTransferLimits
+
+	// If enabled, each disk is compared with the source once it has been synced.
+	Verification api.VerificationMode
+
+	// Receives status messages, and whether they are important.
+	StatusCallback func(string, bool)
+
+	// Receives the checkpoints of full copies as they progress. An interrupted full copy resumes from the last checkpoint.
+	CheckpointCallback func(api.WorkerDiskState)
+}
+
 //go:generate go run github.com/matryer/moq -fmt goimports -out mock_gen.go -rm . Source
 
 // Source interface definition for all migration manager sources.
@@ -61,8 +94,8 @@ type Source interface {
 	// Important: This should only be called from the migration manager worker, as it will attempt to
 	// directly write to raw disk devices, overwriting any data that might already be present.
 	//
-	// Returns an error if there is a problem importing the disk(s).
-	ImportDisks(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error
+	// Returns statistics about the data copied and the resulting sync state for each disk, or an error if there is a problem importing the disk(s).
+	ImportDisks(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error)
 
 	// IsRunning returns whether the VM is running.
 	IsRunning(ctx context.Context, vmName string) (bool, error)
@@ -72,6 +105,11 @@ type Source interface {
 	// Returns an error if there was a problem shutting down the VM.
 	PowerOffVM(ctx context.Context, vmName string) error
 
+	// Shuts down a VM for the final import as described by the shutdown policy, calling attemptCallback as each step finishes.
+	//
+	// Returns an error if the VM could not be shut down.
+	ShutdownVM(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error
+
 	// Powers on a VM.
 	//
 	// Returns an error if there was a problem starting the VM.
@@ -90,4 +128,9 @@ type Source interface {
 
 	// EnableBackgroundImport enables background import support for the instance by its UUID.
 	EnableBackgroundImport(ctx context.Context, instUUID uuid.UUID) error
+
+	// CleanupVM applies the given cleanup actions of the policy to the VM by its UUID, once its migration has finished.
+	//
+	// Returns the actions that were applied, and an error for the first action that failed.
+	CleanupVM(ctx context.Context, instUUID uuid.UUID, policy api.SourceCleanupPolicy, actions []api.SourceCleanupAction) ([]api.SourceCleanupAction, error)
 }
@@ -24,6 +24,9 @@ var _ Source = &SourceMock{}
 //
 //		// make and configure a mocked Source
 //		mockedSource := &SourceMock{
+//			CleanupVMFunc: func(ctx context.Context, instUUID uuid.UUID, policy api.SourceCleanupPolicy, actions []api.SourceCleanupAction) ([]api.SourceCleanupAction, error) {
+//				panic("mock out the CleanupVM method")
+//			},
 //			ConnectFunc: func(ctx context.Context) error {
 //				panic("mock out the Connect method")
 //			},
@@ -51,7 +54,7 @@ var _ Source = &SourceMock{}
 //			GetNameFunc: func() string {
 //				panic("mock out the GetName method")
 //			},
-//			ImportDisksFunc: func(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error {
+//			ImportDisksFunc: func(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
 //				panic("mock out the ImportDisks method")
 //			},
 //			IsConnectedFunc: func() bool {
@@ -66,6 +69,9 @@ var _ Source = &SourceMock{}
 //			PowerOnVMFunc: func(ctx context.Context, vmName string) error {
 //				panic("mock out the PowerOnVM method")
 //			},
+//			ShutdownVMFunc: func(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+//				panic("mock out the ShutdownVM method")
+//			},
 //			TimeoutFunc: func() time.Duration {
 //				panic("mock out the Timeout method")
 //			},
@@ -82,6 +88,9 @@ var _ Source = &SourceMock{}
 //
 //	}
 type SourceMock struct {
+	// CleanupVMFunc mocks the CleanupVM method.
+	CleanupVMFunc func(ctx context.Context, instUUID uuid.UUID, policy api.SourceCleanupPolicy, actions []api.SourceCleanupAction) ([]api.SourceCleanupAction, error)
+
 	// ConnectFunc mocks the Connect method.
 	ConnectFunc func(ctx context.Context) error
 
@@ -110,7 +119,7 @@ type SourceMock struct {
 	GetNameFunc func() string
 
 	// ImportDisksFunc mocks the ImportDisks method.
-	ImportDisksFunc func(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error
+	ImportDisksFunc func(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error)
 
 	// IsConnectedFunc mocks the IsConnected method.
 	IsConnectedFunc func() bool
@@ -124,6 +133,9 @@ type SourceMock struct {
 	// PowerOnVMFunc mocks the PowerOnVM method.
 	PowerOnVMFunc func(ctx context.Context, vmName string) error
 
+	// ShutdownVMFunc mocks the ShutdownVM method.
+	ShutdownVMFunc func(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error
+
 	// TimeoutFunc mocks the Timeout method.
 	TimeoutFunc func() time.Duration
 
@@ -135,6 +147,17 @@ type SourceMock struct {
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// CleanupVM holds details about calls to the CleanupVM method.
+		CleanupVM []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstUUID is the instUUID argument value.
+			InstUUID uuid.UUID
+			// Policy is the policy argument value.
+			Policy api.SourceCleanupPolicy
+			// Actions is the actions argument value.
+			Actions []api.SourceCleanupAction
+		}
 		// Connect holds details about calls to the Connect method.
 		Connect []struct {
 			// Ctx is the ctx argument value.
@@ -192,12 +215,8 @@ type SourceMock struct {
 			Ctx context.Context
 			// VmName is the vmName argument value.
 			VmName string
-			// SdkPath is the sdkPath argument value.
-			SdkPath string
-			// Disks is the disks argument value.
-			Disks []api.InstancePropertiesDisk
-			// StatusCallback is the statusCallback argument value.
-			StatusCallback func(string, bool)
+			// Opts is the opts argument value.
+			Opts ImportDisksOptions
 		}
 		// IsConnected holds details about calls to the IsConnected method.
 		IsConnected []struct {
@@ -223,6 +242,19 @@ type SourceMock struct {
 			// VmName is the vmName argument value.
 			VmName string
 		}
+		// ShutdownVM holds details about calls to the ShutdownVM method.
+		ShutdownVM []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// VmName is the vmName argument value.
+			VmName string
+			// Policy is the policy argument value.
+			Policy api.ShutdownPolicy
+			// GuestPassword is the guestPassword argument value.
+			GuestPassword string
+			// AttemptCallback is the attemptCallback argument value.
+			AttemptCallback func(api.ShutdownAttempt)
+		}
 		// Timeout holds details about calls to the Timeout method.
 		Timeout []struct {
 		}
@@ -239,6 +271,7 @@ type SourceMock struct {
 			RootCert *x509.Certificate
 		}
 	}
+	lockCleanupVM                     sync.RWMutex
 	lockConnect                       sync.RWMutex
 	lockDeleteVMSnapshot              sync.RWMutex
 	lockDisconnect                    sync.RWMutex
@@ -253,11 +286,56 @@ type SourceMock struct {
 	lockIsRunning                     sync.RWMutex
 	lockPowerOffVM                    sync.RWMutex
 	lockPowerOnVM                     sync.RWMutex
+	lockShutdownVM                    sync.RWMutex
 	lockTimeout                       sync.RWMutex
 	lockVerifyBackgroundImport        sync.RWMutex
 	lockWithAdditionalRootCertificate sync.RWMutex
 }
 
+// CleanupVM calls CleanupVMFunc.
+func (mock *SourceMock) CleanupVM(ctx context.Context, instUUID uuid.UUID, policy api.SourceCleanupPolicy, actions []api.SourceCleanupAction) ([]api.SourceCleanupAction, error) {
+	if mock.CleanupVMFunc == nil {
+		panic("SourceMock.CleanupVMFunc: method is nil but Source.CleanupVM was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		InstUUID uuid.UUID
+		Policy   api.SourceCleanupPolicy
+		Actions  []api.SourceCleanupAction
+	}{
+		Ctx:      ctx,
+		InstUUID: instUUID,
+		Policy:   policy,
+		Actions:  actions,
+	}
+	mock.lockCleanupVM.Lock()
+	mock.calls.CleanupVM = append(mock.calls.CleanupVM, callInfo)
+	mock.lockCleanupVM.Unlock()
+	return mock.CleanupVMFunc(ctx, instUUID, policy, actions)
+}
+
+// CleanupVMCalls gets all the calls that were made to CleanupVM.
+// Check the length with:
+//
+//	len(mockedSource.CleanupVMCalls())
+func (mock *SourceMock) CleanupVMCalls() []struct {
+	Ctx      context.Context
+	InstUUID uuid.UUID
+	Policy   api.SourceCleanupPolicy
+	Actions  []api.SourceCleanupAction
+} {
+	var calls []struct {
+		Ctx      context.Context
+		InstUUID uuid.UUID
+		Policy   api.SourceCleanupPolicy
+		Actions  []api.SourceCleanupAction
+	}
+	mock.lockCleanupVM.RLock()
+	calls = mock.calls.CleanupVM
+	mock.lockCleanupVM.RUnlock()
+	return calls
+}
+
 // Connect calls ConnectFunc.
 func (mock *SourceMock) Connect(ctx context.Context) error {
 	if mock.ConnectFunc == nil {
@@ -557,27 +635,23 @@ func (mock *SourceMock) GetNameCalls() []struct {
 }
 
 // ImportDisks calls ImportDisksFunc.
-func (mock *SourceMock) ImportDisks(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error {
+func (mock *SourceMock) ImportDisks(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
 	if mock.ImportDisksFunc == nil {
 		panic("SourceMock.ImportDisksFunc: method is nil but Source.ImportDisks was just called")
 	}
 	callInfo := struct {
-		Ctx            context.Context
-		VmName         string
-		SdkPath        string
-		Disks          []api.InstancePropertiesDisk
-		StatusCallback func(string, bool)
+		Ctx    context.Context
+		VmName string
+		Opts   ImportDisksOptions
 	}{
-		Ctx:            ctx,
-		VmName:         vmName,
-		SdkPath:        sdkPath,
-		Disks:          disks,
-		StatusCallback: statusCallback,
+		Ctx:    ctx,
+		VmName: vmName,
+		Opts:   opts,
 	}
 	mock.lockImportDisks.Lock()
 	mock.calls.ImportDisks = append(mock.calls.ImportDisks, callInfo)
 	mock.lockImportDisks.Unlock()
-	return mock.ImportDisksFunc(ctx, vmName, sdkPath, disks, statusCallback)
+	return mock.ImportDisksFunc(ctx, vmName, opts)
 }
 
 // ImportDisksCalls gets all the calls that were made to ImportDisks.
@@ -585,18 +659,14 @@ func (mock *SourceMock) ImportDisks(ctx context.Context, vmName string, sdkPath
 //
 //	len(mockedSource.ImportDisksCalls())
 func (mock *SourceMock) ImportDisksCalls() []struct {
-	Ctx            context.Context
-	VmName         string
-	SdkPath        string
-	Disks          []api.InstancePropertiesDisk
-	StatusCallback func(string, bool)
+	Ctx    context.Context
+	VmName string
+	Opts   ImportDisksOptions
 } {
 	var calls []struct {
-		Ctx            context.Context
-		VmName         string
-		SdkPath        string
-		Disks          []api.InstancePropertiesDisk
-		StatusCallback func(string, bool)
+		Ctx    context.Context
+		VmName string
+		Opts   ImportDisksOptions
 	}
 	mock.lockImportDisks.RLock()
 	calls = mock.calls.ImportDisks
@@ -739,6 +809,54 @@ func (mock *SourceMock) PowerOnVMCalls() []struct {
 	return calls
 }
 
+// ShutdownVM calls ShutdownVMFunc.
+func (mock *SourceMock) ShutdownVM(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+	if mock.ShutdownVMFunc == nil {
+		panic("SourceMock.ShutdownVMFunc: method is nil but Source.ShutdownVM was just called")
+	}
+	callInfo := struct {
+		Ctx             context.Context
+		VmName          string
+		Policy          api.ShutdownPolicy
+		GuestPassword   string
+		AttemptCallback func(api.ShutdownAttempt)
+	}{
+		Ctx:             ctx,
+		VmName:          vmName,
+		Policy:          policy,
+		GuestPassword:   guestPassword,
+		AttemptCallback: attemptCallback,
+	}
+	mock.lockShutdownVM.Lock()
+	mock.calls.ShutdownVM = append(mock.calls.ShutdownVM, callInfo)
+	mock.lockShutdownVM.Unlock()
+	return mock.ShutdownVMFunc(ctx, vmName, policy, guestPassword, attemptCallback)
+}
+
+// ShutdownVMCalls gets all the calls that were made to ShutdownVM.
+// Check the length with:
+//
+//	len(mockedSource.ShutdownVMCalls())
+func (mock *SourceMock) ShutdownVMCalls() []struct {
+	Ctx             context.Context
+	VmName          string
+	Policy          api.ShutdownPolicy
+	GuestPassword   string
+	AttemptCallback func(api.ShutdownAttempt)
+} {
+	var calls []struct {
+		Ctx             context.Context
+		VmName          string
+		Policy          api.ShutdownPolicy
+		GuestPassword   string
+		AttemptCallback func(api.ShutdownAttempt)
+	}
+	mock.lockShutdownVM.RLock()
+	calls = mock.calls.ShutdownVM
+	mock.lockShutdownVM.RUnlock()
+	return calls
+}
+
 // Timeout calls TimeoutFunc.
 func (mock *SourceMock) Timeout() time.Duration {
 	if mock.TimeoutFunc == nil {
@@ -3,11 +3,13 @@ package source
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lxc/incus/v7/shared/osarch"
 	"github.com/stretchr/testify/require"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/performance"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 
@@ -573,3 +575,30 @@ func TestGetProperties(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePerformance(t *testing.T) {
+	entity := performance.EntityMetric{
+		Value: []performance.MetricSeries{
+			{Name: perfCPUUsage, Value: []int64{1000, 3000, -1}},
+			{Name: perfCPUReady, Value: []int64{400, 800}},
+			{Name: perfMemActive, Value: []int64{1024, 3072}},
+			{Name: perfDiskReadOps, Instance: "scsi0:0", Value: []int64{10, 20}},
+			{Name: perfDiskReadOps, Instance: "scsi0:1", Value: []int64{5, 5}},
+			{Name: perfDiskRead, Instance: "scsi0:0", Value: []int64{100, 200}},
+			{Name: perfDiskRead, Value: []int64{150, 250}},
+			{Name: perfNetReceive, Instance: "4000", Value: []int64{8, 8}},
+		},
+	}
+
+	perf := parsePerformance(entity, 20, api.AsDuration(time.Hour), 2)
+	require.Equal(t, api.AsDuration(time.Hour), perf.Window)
+	require.InDelta(t, 20.0, perf.CPUUsage, 0.001)
+	require.InDelta(t, 30.0, perf.CPUUsagePeak, 0.001)
+	require.InDelta(t, 1.5, perf.CPUReady, 0.001)
+	require.Equal(t, int64(2048*1024), perf.MemoryActive)
+	require.Equal(t, int64(3072*1024), perf.MemoryActivePeak)
+	require.InDelta(t, 20.0, perf.DiskReadIOPS, 0.001)
+	require.Equal(t, int64(200*1024), perf.DiskReadThroughput)
+	require.Equal(t, int64(8*1024), perf.NetworkReceiveThroughput)
+	require.Zero(t, perf.DiskWriteIOPS)
+}
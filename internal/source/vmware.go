@@ -21,16 +21,19 @@ import (
 	incusTLS "github.com/lxc/incus/v7/shared/tls"
 	"github.com/vmware/govmomi/fault"
 	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/guest"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
 	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/FuturFusion/migration-manager/internal"
 	internalAPI "github.com/FuturFusion/migration-manager/internal/api"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/vmware"
 	"github.com/FuturFusion/migration-manager/internal/migration"
@@ -47,6 +50,9 @@ type InternalVMwareSource struct {
 
 var _ Source = &InternalVMwareSource{}
 
+// defaultPreShutdownTimeout is how long to wait for the pre-shutdown command of a shutdown policy that doesn't set a timeout.
+const defaultPreShutdownTimeout = 5 * time.Minute
+
 var NewVMSource = func(s api.Source) (Source, error) {
 	switch s.SourceType {
 	case api.SOURCETYPE_VMWARE:
@@ -331,6 +337,8 @@ func (s *InternalVMwareSource) GetAllVMs(ctx context.Context, sourceSpecificIDs
 		return nil, nil, warnings, err
 	}
 
+	s.collectPerformance(ctx, vms)
+
 	return vms, networks, warnings, nil
 }
 
@@ -810,6 +818,178 @@ func (s *InternalVMwareSource) GetBackgroundImport(ctx context.Context, instUUID
 	return *vm.Config.ChangeTrackingEnabled, nil
 }
 
+// CleanupVM applies the given cleanup actions of the policy to the VM with the given UUID, in order, stopping at the first action that fails.
+// Returns the actions that were applied.
+func (s *InternalVMwareSource) CleanupVM(ctx context.Context, instUUID uuid.UUID, policy api.SourceCleanupPolicy, actions []api.SourceCleanupAction) ([]api.SourceCleanupAction, error) {
+	log := slog.With(slog.String("method", "CleanupVM"), slog.String("uuid", instUUID.String()))
+
+	obj, err := object.NewSearchIndex(s.govmomiClient.Client).FindByUuid(ctx, nil, instUUID.String(), true, ptr.To(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if obj == nil {
+		return nil, fmt.Errorf("No virtual machine found with UUID %q", instUUID)
+	}
+
+	vm, ok := obj.(*object.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("Object with UUID %q is not a virtual machine", instUUID)
+	}
+
+	completed := []api.SourceCleanupAction{}
+	for _, action := range actions {
+		var props mo.VirtualMachine
+		err := vm.Properties(ctx, vm.Reference(), []string{"name", "config.annotation", "runtime.host", "runtime.powerState"}, &props)
+		if err != nil {
+			return completed, fmt.Errorf("Failed to fetch VMware properties for VM %q: %w", instUUID, err)
+		}
+
+		log.Debug("Applying cleanup action", slog.String("action", string(action)))
+		switch action {
+		case api.SOURCECLEANUPACTION_REMOVE_SNAPSHOT:
+			err = s.removeVMSnapshot(ctx, vm, internal.IncusSnapshotName)
+		case api.SOURCECLEANUPACTION_DISABLE_CHANGE_TRACKING:
+			err = reconfigureVM(ctx, vm, types.VirtualMachineConfigSpec{ChangeTrackingEnabled: ptr.To(false)})
+		case api.SOURCECLEANUPACTION_DISABLE_AUTOSTART:
+			err = s.disableVMAutostart(ctx, vm, props)
+		case api.SOURCECLEANUPACTION_ANNOTATE:
+			annotation := ""
+			if props.Config != nil {
+				annotation = props.Config.Annotation
+			}
+
+			if !strings.Contains(annotation, policy.Annotation) {
+				if annotation != "" {
+					annotation += "\n"
+				}
+
+				err = reconfigureVM(ctx, vm, types.VirtualMachineConfigSpec{Annotation: annotation + policy.Annotation})
+			}
+
+		case api.SOURCECLEANUPACTION_TAG:
+			err = s.tagVM(ctx, vm, policy.Tags)
+		case api.SOURCECLEANUPACTION_RENAME:
+			if !strings.HasSuffix(props.Name, policy.RenameSuffix) {
+				var task *object.Task
+				task, err = vm.Rename(ctx, props.Name+policy.RenameSuffix)
+				if err == nil {
+					err = task.Wait(ctx)
+				}
+			}
+
+		case api.SOURCECLEANUPACTION_MOVE:
+			var folder *object.Folder
+			folder, err = find.NewFinder(s.govmomiClient.Client).Folder(ctx, policy.Folder)
+			if err == nil {
+				var task *object.Task
+				task, err = folder.MoveInto(ctx, []types.ManagedObjectReference{vm.Reference()})
+				if err == nil {
+					err = task.Wait(ctx)
+				}
+			}
+
+		case api.SOURCECLEANUPACTION_DELETE:
+			if props.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+				err = fmt.Errorf("VM is not powered off")
+				break
+			}
+
+			var task *object.Task
+			task, err = vm.Destroy(ctx)
+			if err == nil {
+				err = task.Wait(ctx)
+			}
+
+		default:
+			err = fmt.Errorf("Unknown cleanup action")
+		}
+
+		if err != nil {
+			return completed, fmt.Errorf("Failed to apply cleanup action %q to VM %q: %w", action, instUUID, err)
+		}
+
+		completed = append(completed, action)
+	}
+
+	return completed, nil
+}
+
+// disableVMAutostart stops the host of the VM from starting the VM automatically.
+func (s *InternalVMwareSource) disableVMAutostart(ctx context.Context, vm *object.VirtualMachine, props mo.VirtualMachine) error {
+	if props.Runtime.Host == nil {
+		return fmt.Errorf("VM has no host")
+	}
+
+	var host mo.HostSystem
+	err := vm.Properties(ctx, *props.Runtime.Host, []string{"configManager.autoStartManager"}, &host)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch host properties: %w", err)
+	}
+
+	if host.ConfigManager.AutoStartManager == nil {
+		return nil
+	}
+
+	req := types.ReconfigureAutostart{
+		This: *host.ConfigManager.AutoStartManager,
+		Spec: types.HostAutoStartManagerConfig{
+			PowerInfo: []types.AutoStartPowerInfo{{
+				Key:              vm.Reference(),
+				StartOrder:       -1,
+				StartDelay:       -1,
+				WaitForHeartbeat: types.AutoStartWaitHeartbeatSettingSystemDefault,
+				StartAction:      "none",
+				StopDelay:        -1,
+				StopAction:       "systemDefault",
+			}},
+		},
+	}
+
+	_, err = methods.ReconfigureAutostart(ctx, s.govmomiClient.Client, &req)
+	return err
+}
+
+// tagVM attaches the given tags, each in the form category:name, to the VM.
+func (s *InternalVMwareSource) tagVM(ctx context.Context, vm *object.VirtualMachine, vmTags []string) error {
+	if s.isESXI {
+		return fmt.Errorf("Tags are not supported by ESXi")
+	}
+
+	c := rest.NewClient(s.govmomiClient.Client)
+	err := c.Login(ctx, url.UserPassword(s.Username, s.Password))
+	if err != nil {
+		return fmt.Errorf("Failed to login to REST API: %w", err)
+	}
+
+	defer func() { _ = c.Logout(ctx) }()
+
+	tc := tags.NewManager(c)
+	for _, vmTag := range vmTags {
+		category, name, _ := strings.Cut(vmTag, ":")
+		tag, err := tc.GetTagForCategory(ctx, name, category)
+		if err != nil {
+			return fmt.Errorf("Failed to find tag %q: %w", vmTag, err)
+		}
+
+		err = tc.AttachTag(ctx, tag.ID, vm.Reference())
+		if err != nil {
+			return fmt.Errorf("Failed to attach tag %q: %w", vmTag, err)
+		}
+	}
+
+	return nil
+}
+
+func reconfigureVM(ctx context.Context, vm *object.VirtualMachine, spec types.VirtualMachineConfigSpec) error {
+	task, err := vm.Reconfigure(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	return task.Wait(ctx)
+}
+
 // VerifyBackgroundImport checks each supported disk for each VM for a corresponding ctk file for each VM that reports to support background import.
 // Returns the updated instance objects.
 func (s *InternalVMwareSource) VerifyBackgroundImport(ctx context.Context, instances migration.Instances) (migration.Instances, error) {
@@ -938,12 +1118,16 @@ func (s *InternalVMwareSource) DeleteVMSnapshot(ctx context.Context, vmName stri
 		return err
 	}
 
+	return s.removeVMSnapshot(ctx, vm, snapshotName)
+}
+
+func (s *InternalVMwareSource) removeVMSnapshot(ctx context.Context, vm *object.VirtualMachine, snapshotName string) error {
 	snapshotRef, _ := vm.FindSnapshot(ctx, snapshotName)
 	if snapshotRef == nil {
 		return nil
 	}
 
-	_, err = vm.RemoveSnapshot(ctx, snapshotRef.Value, false, ptr.To(true))
+	_, err := vm.RemoveSnapshot(ctx, snapshotRef.Value, false, ptr.To(true))
 	if err != nil {
 		return err
 	}
@@ -1042,8 +1226,6 @@ func (s *InternalVMwareSource) powerOffVM(ctx context.Context, vm *object.Virtua
 		return nil
 	}
 
-	// Another shutdown operation may have succeeded after we started, but before we ended.
-	// In such cases, if we return an error, we should re-check the VM state before erroring out.
 	tryShutdown := func() error {
 		// Attempt a clean shutdown if guest tools are installed in the VM.
 		err = vm.ShutdownGuest(ctx)
@@ -1053,15 +1235,7 @@ func (s *InternalVMwareSource) powerOffVM(ctx context.Context, vm *object.Virtua
 			}
 
 			// If guest tools aren't available, fall back to hard power off.
-			task, err := vm.PowerOff(ctx)
-			if err != nil {
-				return fmt.Errorf("Failed to power off VM: %w", err)
-			}
-
-			err = task.Wait(ctx)
-			if err != nil {
-				return fmt.Errorf("Failed to wait for power-off task: %w", err)
-			}
+			return hardPowerOffVM(ctx, vm)
 		}
 
 		// Wait until the VM has powered off.
@@ -1074,30 +1248,197 @@ func (s *InternalVMwareSource) powerOffVM(ctx context.Context, vm *object.Virtua
 	}
 
 	err = tryShutdown()
+	if err != nil && !confirmPoweredOff(ctx, vm, err) {
+		return err
+	}
+
+	return nil
+}
+
+// ShutdownVM shuts down the VM for the final import as described by the policy, calling attemptCallback as each step finishes.
+func (s *InternalVMwareSource) ShutdownVM(ctx context.Context, vmName string, policy api.ShutdownPolicy, guestPassword string, attemptCallback func(api.ShutdownAttempt)) error {
+	vm, err := s.getVMReference(ctx, vmName)
 	if err != nil {
-		// There appears to be a race on the VMware side where a VM will be internally considered "off" but the VM power state checked below will not reflect this.
-		// It is not sufficient to just check against all running tasks, as the corresponding task has already completed too.
-		if strings.HasSuffix(err.Error(), "The attempted operation cannot be performed in the current state (Powered off)") {
-			return nil
+		return err
+	}
+
+	state, err := vm.PowerState(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Don't do anything if the VM is already powered off, such as when the final import is restarted.
+	if state == types.VirtualMachinePowerStatePoweredOff {
+		return nil
+	}
+
+	record := func(action api.ShutdownAction, start time.Time, err error) {
+		attempt := api.ShutdownAttempt{Action: action, Time: start, Duration: api.AsDuration(time.Since(start).Truncate(time.Millisecond))}
+		if err != nil {
+			attempt.Error = err.Error()
 		}
 
-		// Check the power state again in case we got turned off by another task already.
-		state, err2 := vm.PowerState(ctx)
-		if err2 != nil {
-			slog.Error("Failed to check power state", slog.Any("error", err2))
-			return err
+		attemptCallback(attempt)
+	}
+
+	if policy.PreShutdownCommand != "" {
+		start := time.Now().UTC()
+		err := s.runPreShutdownCommand(ctx, vm, policy, guestPassword)
+		record(api.SHUTDOWNACTION_PRE_SHUTDOWN_COMMAND, start, err)
+		if err != nil {
+			return fmt.Errorf("Pre-shutdown command failed: %w", err)
 		}
+	}
 
-		if state == types.VirtualMachinePowerStatePoweredOff {
-			return nil
+	start := time.Now().UTC()
+	forcePowerOff := policy.ForcesPowerOff()
+	guestTimeout := policy.GuestShutdownTimeout()
+	err = vm.ShutdownGuest(ctx)
+	if err == nil {
+		waitCtx := ctx
+		if guestTimeout > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, guestTimeout)
+			defer cancel()
 		}
 
+		err = vm.WaitForPowerState(waitCtx, types.VirtualMachinePowerStatePoweredOff)
+		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				err = fmt.Errorf("Guest OS did not shut down within %s", api.AsDuration(guestTimeout).String())
+			} else {
+				err = fmt.Errorf("Failed to wait for VM to confirm off state: %w", err)
+			}
+		}
+	} else if fault.Is(err, &types.ToolsUnavailable{}) {
+		// Without guest tools, a hard power off is the only way to shut down the VM.
+		err = fmt.Errorf("Guest tools are unavailable")
+		forcePowerOff = true
+	} else {
+		err = fmt.Errorf("Failed to shutdown guest: %w", err)
+	}
+
+	record(api.SHUTDOWNACTION_GUEST_SHUTDOWN, start, err)
+	if err == nil || confirmPoweredOff(ctx, vm, err) {
+		return nil
+	}
+
+	if !forcePowerOff || ctx.Err() != nil {
+		return err
+	}
+
+	start = time.Now().UTC()
+	err = hardPowerOffVM(ctx, vm)
+	record(api.SHUTDOWNACTION_POWER_OFF, start, err)
+	if err != nil && !confirmPoweredOff(ctx, vm, err) {
 		return err
 	}
 
 	return nil
 }
 
+// runPreShutdownCommand runs the pre-shutdown command of the policy in the guest, and waits for it to exit successfully.
+func (s *InternalVMwareSource) runPreShutdownCommand(ctx context.Context, vm *object.VirtualMachine, policy api.ShutdownPolicy, guestPassword string) error {
+	if policy.GuestUsername == "" {
+		return fmt.Errorf("No guest username is set")
+	}
+
+	if guestPassword == "" {
+		return fmt.Errorf("No guest password is set for the instance")
+	}
+
+	timeout := policy.PreShutdownTimeout.Duration
+	if timeout == 0 {
+		timeout = defaultPreShutdownTimeout
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pm, err := guest.NewOperationsManager(s.govmomiClient.Client, vm.Reference()).ProcessManager(cmdCtx)
+	if err != nil {
+		return fmt.Errorf("Failed to get guest process manager: %w", err)
+	}
+
+	auth := &types.NamePasswordAuthentication{Username: policy.GuestUsername, Password: guestPassword}
+	pid, err := pm.StartProgram(cmdCtx, auth, &types.GuestProgramSpec{ProgramPath: policy.PreShutdownCommand, Arguments: policy.PreShutdownArguments})
+	if err != nil {
+		return fmt.Errorf("Failed to start %q: %w", policy.PreShutdownCommand, err)
+	}
+
+	for {
+		procs, err := pm.ListProcesses(cmdCtx, auth, []int64{pid})
+		if err != nil && cmdCtx.Err() == nil {
+			return fmt.Errorf("Failed to get status of %q: %w", policy.PreShutdownCommand, err)
+		}
+
+		if len(procs) == 1 && procs[0].EndTime != nil {
+			if procs[0].ExitCode != 0 {
+				return fmt.Errorf("%q exited with code %d", policy.PreShutdownCommand, procs[0].ExitCode)
+			}
+
+			return nil
+		}
+
+		select {
+		case <-cmdCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// Don't leave the command running in the guest once it has timed out.
+			termCtx, termCancel := context.WithTimeout(ctx, 30*time.Second)
+			err := pm.TerminateProcess(termCtx, auth, pid)
+			termCancel()
+			if err != nil {
+				slog.Warn("Failed to terminate pre-shutdown command", slog.String("command", policy.PreShutdownCommand), slog.Any("error", err))
+			}
+
+			return fmt.Errorf("%q did not exit within %s", policy.PreShutdownCommand, timeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// hardPowerOffVM powers off the VM without waiting for the guest OS.
+func hardPowerOffVM(ctx context.Context, vm *object.VirtualMachine) error {
+	task, err := vm.PowerOff(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to power off VM: %w", err)
+	}
+
+	err = task.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to wait for power-off task: %w", err)
+	}
+
+	err = vm.WaitForPowerState(ctx, types.VirtualMachinePowerStatePoweredOff)
+	if err != nil {
+		return fmt.Errorf("Failed to wait for VM to confirm off state: %w", err)
+	}
+
+	return nil
+}
+
+// confirmPoweredOff returns whether the VM is powered off in spite of the error from shutting it down.
+// Another shutdown operation may have succeeded after we started, but before we ended.
+func confirmPoweredOff(ctx context.Context, vm *object.VirtualMachine, err error) bool {
+	// There appears to be a race on the VMware side where a VM will be internally considered "off" but the VM power state checked below will not reflect this.
+	// It is not sufficient to just check against all running tasks, as the corresponding task has already completed too.
+	if strings.HasSuffix(err.Error(), "The attempted operation cannot be performed in the current state (Powered off)") {
+		return true
+	}
+
+	// Check the power state again in case we got turned off by another task already.
+	state, err := vm.PowerState(ctx)
+	if err != nil {
+		slog.Error("Failed to check power state", slog.Any("error", err))
+		return false
+	}
+
+	return state == types.VirtualMachinePowerStatePoweredOff
+}
+
 func (s *InternalVMwareSource) getVMReference(ctx context.Context, vmName string) (*object.VirtualMachine, error) {
 	finder := find.NewFinder(s.govmomiClient.Client)
 	return finder.VirtualMachine(ctx, vmName)
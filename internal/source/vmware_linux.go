@@ -15,6 +15,7 @@ import (
 	"github.com/FuturFusion/migration-manager/internal/migratekit/nbdkit"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/vmware"
 	"github.com/FuturFusion/migration-manager/internal/migratekit/vmware_nbdkit"
+	"github.com/FuturFusion/migration-manager/internal/worker"
 	"github.com/FuturFusion/migration-manager/shared/api"
 )
 
@@ -25,21 +26,30 @@ type InternalVMwareSourceSpecific struct {
 	vddkConfig    *vmware_nbdkit.VddkConfig
 }
 
-func (s *InternalVMwareSource) ImportDisks(ctx context.Context, vmName string, sdkPath string, disks []api.InstancePropertiesDisk, statusCallback func(string, bool)) error {
+func (s *InternalVMwareSource) ImportDisks(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
 	vm, err := s.getVMReference(ctx, vmName)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	NbdkitServers := vmware_nbdkit.NewNbdkitServers(s.vddkConfig, vm, sdkPath, statusCallback)
+	NbdkitServers := vmware_nbdkit.NewNbdkitServers(s.vddkConfig, vm, opts.SDKPath, opts.Limits, opts.StatusCallback)
+	NbdkitServers.RateFile = worker.BandwidthRateFile
+	NbdkitServers.LogDir = worker.NbdkitLogDir
+	NbdkitServers.CheckpointCallback = opts.CheckpointCallback
+	NbdkitServers.Verification = opts.Verification
+	NbdkitServers.Dropped = opts.Dropped
+	NbdkitServers.DiskLocator = opts.Locator
+	for _, state := range opts.States {
+		NbdkitServers.States[state.Name] = state
+	}
 
 	validator := func(srcDisks []*types.VirtualDisk) error {
-		if len(srcDisks) != len(disks) {
-			return fmt.Errorf("Disk count changed, expected %d, found %d", len(disks), len(srcDisks))
+		if len(srcDisks) != len(opts.Disks) {
+			return fmt.Errorf("Disk count changed, expected %d, found %d", len(opts.Disks), len(srcDisks))
 		}
 
-		diskMap := make(map[string]api.InstancePropertiesDisk, len(disks))
-		for _, d := range disks {
+		diskMap := make(map[string]api.InstancePropertiesDisk, len(opts.Disks))
+		for _, d := range opts.Disks {
 			diskMap[d.Name] = d
 		}
 
@@ -63,18 +73,29 @@ func (s *InternalVMwareSource) ImportDisks(ctx context.Context, vmName string, s
 	}
 
 	// Occasionally connecting to VMware via nbdkit is flaky, so retry a couple of times before returning an error.
+	var syncs []api.WorkerDiskSync
+	var diskStates []api.WorkerDiskState
 	for i := 0; i < 5; i++ {
-		err = NbdkitServers.MigrationCycle(ctx, validator, false)
+		syncs, diskStates, err = NbdkitServers.MigrationCycle(ctx, validator, false)
 		if err == nil {
 			break
 		}
 
+		// Don't retry an import that was aborted.
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+
 		slog.Error("Disk import attempt failed", slog.Int("attempt", i+1), slog.Any("error", err))
 
-		time.Sleep(time.Second * 30)
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(time.Second * 30):
+		}
 	}
 
-	return err
+	return syncs, diskStates, err
 }
 
 func (s *InternalVMwareSource) setVDDKConfig(endpointURL *url.URL, thumbprint string) {
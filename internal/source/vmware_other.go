@@ -19,8 +19,8 @@ type InternalVMwareSourceSpecific struct {
 	govmomiClient *govmomi.Client
 }
 
-func (s *InternalVMwareSource) ImportDisks(ctx context.Context, vmName string, statusCallback func(string, bool)) error {
-	return fmt.Errorf("ImportDisk is not implemented on %s", runtime.GOOS)
+func (s *InternalVMwareSource) ImportDisks(ctx context.Context, vmName string, opts ImportDisksOptions) ([]api.WorkerDiskSync, []api.WorkerDiskState, error) {
+	return nil, nil, fmt.Errorf("ImportDisk is not implemented on %s", runtime.GOOS)
 }
 
 // vddkConfig is only available on linux.
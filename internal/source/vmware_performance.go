@@ -0,0 +1,193 @@
+package source
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// Performance counters collected from vCenter for each VM.
+const (
+	perfCPUUsage     = "cpu.usage.average"
+	perfCPUReady     = "cpu.ready.summation"
+	perfMemActive    = "mem.active.average"
+	perfDiskReadOps  = "disk.numberReadAveraged.average"
+	perfDiskWriteOps = "disk.numberWriteAveraged.average"
+	perfDiskRead     = "disk.read.average"
+	perfDiskWrite    = "disk.write.average"
+	perfNetReceive   = "net.received.average"
+	perfNetTransmit  = "net.transmitted.average"
+)
+
+// perfQueryBatchSize is the maximum number of VMs included in a single performance query.
+const perfQueryBatchSize = 64
+
+// perfSampleInterval returns the vCenter sampling interval in seconds best suited for the given window.
+// Real-time statistics are only retained for an hour, so longer windows use the historical rollups.
+func perfSampleInterval(window time.Duration) int32 {
+	switch {
+	case window <= time.Hour:
+		return 20
+	case window <= 24*time.Hour:
+		return 300
+	case window <= 7*24*time.Hour:
+		return 1800
+	case window <= 30*24*time.Hour:
+		return 7200
+	default:
+		return 86400
+	}
+}
+
+// collectPerformance records performance statistics for all running instances over the configured performance window.
+// Failures are logged rather than returned so that a lack of statistics does not prevent the sync from completing.
+func (s *InternalVMwareSource) collectPerformance(ctx context.Context, vms migration.Instances) {
+	if s.PerformanceWindow.Duration <= 0 {
+		return
+	}
+
+	log := slog.With(slog.String("source", s.Name))
+
+	refs := make([]types.ManagedObjectReference, 0, len(vms))
+	cpus := map[string]int64{}
+	for _, vm := range vms {
+		if !vm.Properties.Running {
+			continue
+		}
+
+		var ref types.ManagedObjectReference
+		if !ref.FromString(vm.Properties.SourceSpecificID) {
+			continue
+		}
+
+		refs = append(refs, ref)
+		cpus[ref.String()] = vm.Properties.CPUs
+	}
+
+	interval := perfSampleInterval(s.PerformanceWindow.Duration)
+	maxSamples := max(int32(s.PerformanceWindow.Duration/(time.Duration(interval)*time.Second)), 1)
+
+	m := performance.NewManager(s.govmomiClient.Client)
+	metrics := []string{perfCPUUsage, perfCPUReady, perfMemActive, perfDiskReadOps, perfDiskWriteOps, perfDiskRead, perfDiskWrite, perfNetReceive, perfNetTransmit}
+	spec := types.PerfQuerySpec{IntervalId: interval, MaxSample: maxSamples}
+
+	// A failed batch only leaves its own VMs without statistics, so keep going with the rest.
+	stats := map[string]api.InstancePropertiesPerformance{}
+	for batch := range slices.Chunk(refs, perfQueryBatchSize) {
+		log.Debug("Fetching VM performance statistics", slog.Int("count", len(batch)))
+		sample, err := m.SampleByName(ctx, spec, metrics, batch)
+		if err != nil {
+			log.Warn("Failed to fetch VM performance statistics", slog.Int("count", len(batch)), slog.String("first", batch[0].String()), slog.Any("error", err))
+			continue
+		}
+
+		series, err := m.ToMetricSeries(ctx, sample)
+		if err != nil {
+			log.Warn("Failed to parse VM performance statistics", slog.Int("count", len(batch)), slog.String("first", batch[0].String()), slog.Any("error", err))
+			continue
+		}
+
+		for _, entity := range series {
+			ref := entity.Entity.String()
+			stats[ref] = parsePerformance(entity, interval, s.PerformanceWindow, cpus[ref])
+		}
+	}
+
+	now := time.Now().UTC()
+	for i, vm := range vms {
+		perf, ok := stats[vm.Properties.SourceSpecificID]
+		if !ok {
+			continue
+		}
+
+		perf.CollectedAt = now
+		vms[i].Properties.Performance = perf
+	}
+}
+
+// parsePerformance converts the sampled metrics of a VM into performance statistics.
+func parsePerformance(entity performance.EntityMetric, interval int32, window api.Duration, cpus int64) api.InstancePropertiesPerformance {
+	// Prefer the aggregate instance if it was reported, otherwise sum up the per-device instances.
+	values := map[string][]int64{}
+	hasAggregate := map[string]bool{}
+	for _, series := range entity.Value {
+		if series.Instance == "" {
+			values[series.Name] = validSamples(series.Value)
+			hasAggregate[series.Name] = true
+			continue
+		}
+
+		if hasAggregate[series.Name] {
+			continue
+		}
+
+		samples := validSamples(series.Value)
+		sum := values[series.Name]
+		for i, v := range samples {
+			if i < len(sum) {
+				sum[i] += v
+			} else {
+				sum = append(sum, v)
+			}
+		}
+
+		values[series.Name] = sum
+	}
+
+	perf := api.InstancePropertiesPerformance{Window: window}
+
+	// CPU usage is reported in hundredths of a percent.
+	perf.CPUUsage = average(values[perfCPUUsage]) / 100
+	perf.CPUUsagePeak = float64(peak(values[perfCPUUsage])) / 100
+
+	// CPU ready is reported as milliseconds spent waiting over the sample interval, summed across all virtual CPUs.
+	perf.CPUReady = average(values[perfCPUReady]) / (float64(interval) * 1000) * 100
+	if cpus > 1 {
+		perf.CPUReady /= float64(cpus)
+	}
+
+	// Memory and throughput counters are reported in kilobytes.
+	perf.MemoryActive = int64(average(values[perfMemActive]) * 1024)
+	perf.MemoryActivePeak = peak(values[perfMemActive]) * 1024
+	perf.DiskReadIOPS = average(values[perfDiskReadOps])
+	perf.DiskWriteIOPS = average(values[perfDiskWriteOps])
+	perf.DiskReadThroughput = int64(average(values[perfDiskRead]) * 1024)
+	perf.DiskWriteThroughput = int64(average(values[perfDiskWrite]) * 1024)
+	perf.NetworkReceiveThroughput = int64(average(values[perfNetReceive]) * 1024)
+	perf.NetworkTransmitThroughput = int64(average(values[perfNetTransmit]) * 1024)
+
+	return perf
+}
+
+// validSamples drops samples that vCenter reports as unavailable.
+func validSamples(samples []int64) []int64 {
+	return slices.DeleteFunc(slices.Clone(samples), func(v int64) bool { return v < 0 })
+}
+
+func average(samples []int64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	var sum int64
+	for _, v := range samples {
+		sum += v
+	}
+
+	return float64(sum) / float64(len(samples))
+}
+
+func peak(samples []int64) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	return slices.Max(samples)
+}
@@ -36,6 +36,13 @@ import (
 // DefaultConnectionTimeout is the default timeout for connecting to an Incus target.
 const DefaultConnectionTimeout = 5 * time.Minute
 
+// ImportAgentConfigKey is the instance config key that hands a stopped instance to the import agent on the target host.
+// Its value identifies the current import attempt, so that changing it restarts the agent's worker for the instance.
+const ImportAgentConfigKey = "user.migration.import_agent"
+
+// ImportAgentPoolDrivers are the storage pool drivers whose volumes the import agent can write to.
+var ImportAgentPoolDrivers = []string{"dir", "zfs"}
+
 type InternalIncusTarget struct {
 	InternalTarget      `yaml:",inline"`
 	api.IncusProperties `yaml:",inline"`
@@ -226,9 +233,8 @@ func (t *InternalIncusTarget) SetProject(project string) error {
 }
 
 // SetPostMigrationVMConfig stops the target instance and applies post-migration configuration before restarting it.
-func (t *InternalIncusTarget) SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry) error {
-	props := i.Properties
-	props.Apply(i.Overrides.InstancePropertiesConfigurable)
+func (t *InternalIncusTarget) SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry, customization api.GuestCustomization) error {
+	props := i.EffectiveProperties()
 
 	defs, err := properties.Definitions(t.TargetType, t.version)
 	if err != nil {
@@ -327,6 +333,13 @@ func (t *InternalIncusTarget) SetPostMigrationVMConfig(ctx context.Context, i mi
 		apiDef.Devices[nicDeviceName][hwAddrInfo.Key] = nic.HardwareAddress
 	}
 
+	// Detach any disks that should only be kept as storage volumes. The volumes are left in place on the pool.
+	for name, dev := range apiDef.Devices {
+		if dev["type"] == "disk" && q.Placement.Disks[dev["user.migration_source"]].Detach {
+			delete(apiDef.Devices, name)
+		}
+	}
+
 	// Remove the migration ISO image.
 	delete(apiDef.Devices, util.WorkerVolume(i.GetArchitecture()))
 	apiDef.Profiles = []string{"default"}
@@ -374,6 +387,26 @@ func (t *InternalIncusTarget) SetPostMigrationVMConfig(ctx context.Context, i mi
 		qemuCmdline = append(qemuCmdline, "-global virtio-net-pci.disable-legacy=off")
 	}
 
+	// Linux guests read the cloud-init configuration from a NoCloud config drive.
+	if osType == api.OSTYPE_LINUX && customization.HasCloudInit() {
+		cloudInit := map[string]string{
+			"cloud-init.user-data":      customization.CloudInitUserData,
+			"cloud-init.vendor-data":    customization.CloudInitVendorData,
+			"cloud-init.network-config": customization.CloudInitNetworkConfig,
+		}
+
+		for k, v := range cloudInit {
+			if v != "" {
+				apiDef.Config[k] = v
+			}
+		}
+
+		apiDef.Devices["cloud-init"] = map[string]string{
+			"type":   "disk",
+			"source": "cloud-init:config",
+		}
+	}
+
 	if !has9p {
 		apiDef.Devices["agent"] = map[string]string{
 			"type":   "disk",
@@ -446,26 +479,29 @@ func (t *InternalIncusTarget) SetPostMigrationVMConfig(ctx context.Context, i mi
 	return nil
 }
 
-func (t *InternalIncusTarget) fillInitialProperties(instance incusAPI.InstancesPost, inst migration.Instance, storagePool string, defs properties.RawPropertySet[api.TargetType]) (incusAPI.InstancesPost, error) {
+func (t *InternalIncusTarget) fillInitialProperties(instance incusAPI.InstancesPost, inst migration.Instance, storagePool string, rootPolicy api.DiskPolicy, defs properties.RawPropertySet[api.TargetType]) (incusAPI.InstancesPost, error) {
 	diskDefs, err := defs.GetSubProperties(properties.InstanceDisks)
 	if err != nil {
 		return incusAPI.InstancesPost{}, err
 	}
 
-	p := inst.Properties
-	p.Apply(inst.Overrides.InstancePropertiesConfigurable)
+	p := inst.EffectiveProperties()
 	osType := inst.GetOSType(true)
 
+	// The worker imports the disks from within the instance, so the instance is never given less than the worker needs.
+	// The exact limits are set by the post-migration configuration.
+	minMemory := int64(4 * 1024 * 1024 * 1024)
+	if p.SupportsBackgroundImport() || osType == api.OSTYPE_WINDOWS {
+		minMemory = 8 * 1024 * 1024 * 1024
+	}
+
 	instance.Config = map[string]string{}
 	for name, info := range defs.GetAll() {
 		switch name {
 		case properties.InstanceCPUs:
-			instance.Config[info.Key] = "2"
+			instance.Config[info.Key] = strconv.FormatInt(max(p.CPUs, 2), 10)
 		case properties.InstanceMemory:
-			instance.Config[info.Key] = "4GiB"
-			if p.SupportsBackgroundImport() || osType == api.OSTYPE_WINDOWS {
-				instance.Config[info.Key] = "8GiB"
-			}
+			instance.Config[info.Key] = fmt.Sprintf("%dB", max(p.Memory, minMemory))
 
 		case properties.InstanceLegacyBoot:
 			instance.Config[info.Key] = "false"
@@ -523,16 +559,26 @@ func (t *InternalIncusTarget) fillInitialProperties(instance incusAPI.InstancesP
 		return incusAPI.InstancesPost{}, err
 	}
 
+	rootSize, err := migration.DiskCapacity(rootPolicy, p.Disks[0].Capacity)
+	if err != nil {
+		return incusAPI.InstancesPost{}, err
+	}
+
 	instance.Devices = map[string]map[string]string{
 		"root": {
 			"path":                  "/",
 			"pool":                  storagePool,
 			"type":                  "disk",
 			"user.migration_source": p.Disks[0].Name,
-			sizeDef.Key:             strconv.Itoa(int(p.Disks[0].Capacity)) + "B",
+			sizeDef.Key:             strconv.FormatInt(rootSize, 10) + "B",
 		},
 	}
 
+	// The root volume is created along with the instance, so its volume config is passed through the device.
+	for k, v := range rootPolicy.Config {
+		instance.Devices["root"]["initial."+k] = v
+	}
+
 	return instance, nil
 }
 
@@ -548,8 +594,7 @@ func (t *InternalIncusTarget) CreateVMDefinition(instanceDef migration.Instance,
 		Type: incusAPI.InstanceTypeVM,
 	}
 
-	props := instanceDef.Properties
-	props.Apply(instanceDef.Overrides.InstancePropertiesConfigurable)
+	props := instanceDef.EffectiveProperties()
 
 	defs, err := properties.Definitions(t.TargetType, t.version)
 	if err != nil {
@@ -561,7 +606,7 @@ func (t *InternalIncusTarget) CreateVMDefinition(instanceDef migration.Instance,
 	}
 
 	rootDisk := instanceDef.Properties.Disks[0]
-	ret, err = t.fillInitialProperties(ret, instanceDef, q.Placement.StoragePools[rootDisk.Name], defs)
+	ret, err = t.fillInitialProperties(ret, instanceDef, q.Placement.StoragePools[rootDisk.Name], q.Placement.Disks[rootDisk.Name], defs)
 	if err != nil {
 		return incusAPI.InstancesPost{}, err
 	}
@@ -643,8 +688,7 @@ func (t *InternalIncusTarget) CreateNewVM(ctx context.Context, instDef migration
 func (t *InternalIncusTarget) SetupVM(ctx context.Context, instDef migration.Instance, apiDef incusAPI.InstancesPost, placement api.Placement) error {
 	reverter := revert.New()
 	defer reverter.Fail()
-	props := instDef.Properties
-	props.Apply(instDef.Overrides.InstancePropertiesConfigurable)
+	props := instDef.EffectiveProperties()
 	// After the scheduler places the instance, get its target and create storage volumes on that member.
 	if len(props.Disks) > 1 {
 		instInfo, etag, err := t.incusClient.GetInstance(apiDef.Name)
@@ -658,12 +702,36 @@ func (t *InternalIncusTarget) SetupVM(ctx context.Context, instDef migration.Ins
 			"dependent": "true",
 		}
 
+		// Guests without VirtIO SCSI drivers have their root disk on virtio-blk, so attach the remaining disks there as well.
+		distro, distroVer := instDef.GetDistribution(true)
+		hasVioSCSI, _, _, _, err := util.GetOSCompatibility(instDef.GetOSType(true), distro, distroVer)
+		if err != nil {
+			return fmt.Errorf("Failed to check %q OS version for disk setup: %w", props.Location, err)
+		}
+
+		if !hasVioSCSI {
+			defaultDiskDef["io.bus"] = "virtio-blk"
+		}
+
 		// Create volumes for the remaining disks.
 		for i, disk := range props.Disks[1:] {
-			if !disk.Supported {
+			policy := placement.Disks[disk.Name]
+			if !disk.Supported || policy.Drop {
 				continue
 			}
 
+			size, err := migration.DiskCapacity(policy, disk.Capacity)
+			if err != nil {
+				return err
+			}
+
+			volConfig := map[string]string{}
+			for k, v := range policy.Config {
+				volConfig[k] = v
+			}
+
+			volConfig["size"] = fmt.Sprintf("%dB", size)
+
 			storagePool := placement.StoragePools[disk.Name]
 			defaultDiskDef["pool"] = storagePool
 			diskKey := fmt.Sprintf("disk%d", i+1)
@@ -690,12 +758,11 @@ func (t *InternalIncusTarget) SetupVM(ctx context.Context, instDef migration.Ins
 				}
 			})
 
-			err := tgtClient.CreateStoragePoolVolume(storagePool, incusAPI.StorageVolumesPost{
+			// Incus only attaches block volumes to a VM as disks, so the disk policy can tune the volume but not change its content type.
+			err = tgtClient.CreateStoragePoolVolume(storagePool, incusAPI.StorageVolumesPost{
 				StorageVolumePut: incusAPI.StorageVolumePut{
 					Description: fmt.Sprintf("Migrated disk (%s)", disk.Name),
-					Config: map[string]string{
-						"size": fmt.Sprintf("%dB", disk.Capacity),
-					},
+					Config:      volConfig,
 				},
 				Name:        diskName,
 				Type:        "custom",
@@ -804,6 +871,59 @@ func (t *InternalIncusTarget) CleanupVM(ctx context.Context, name string, requir
 	return nil
 }
 
+func (t *InternalIncusTarget) CreateVMSnapshot(ctx context.Context, name string, snapshotName string) error {
+	instInfo, _, err := t.GetInstance(name)
+	if err != nil {
+		return fmt.Errorf("Failed to get target instance %q config: %w", name, err)
+	}
+
+	snapshots, err := t.incusClient.GetInstanceSnapshotNames(name)
+	if err != nil {
+		return fmt.Errorf("Failed to get snapshots of instance %q: %w", name, err)
+	}
+
+	if !slices.Contains(snapshots, snapshotName) {
+		op, err := t.incusClient.CreateInstanceSnapshot(name, incusAPI.InstanceSnapshotsPost{Name: snapshotName})
+		if err != nil {
+			return fmt.Errorf("Failed to send snapshot request for instance %q: %w", name, err)
+		}
+
+		err = op.WaitContext(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to wait for snapshot operation for instance %q: %w", name, err)
+		}
+	}
+
+	// Instance snapshots only hold the root disk, so snapshot the volumes of the other migrated disks alongside it.
+	tgtClient := t.incusClient.UseTarget(instInfo.Location)
+	for _, dev := range instInfo.Devices {
+		if dev["type"] != "disk" || dev["user.migration_source"] == "" || dev["source"] == "" || dev["pool"] == "" {
+			continue
+		}
+
+		snapshots, err := tgtClient.GetStoragePoolVolumeSnapshotNames(dev["pool"], "custom", dev["source"])
+		if err != nil {
+			return fmt.Errorf("Failed to get snapshots of storage volume %q on pool %q: %w", dev["source"], dev["pool"], err)
+		}
+
+		if slices.Contains(snapshots, snapshotName) {
+			continue
+		}
+
+		op, err := tgtClient.CreateStoragePoolVolumeSnapshot(dev["pool"], "custom", dev["source"], incusAPI.StorageVolumeSnapshotsPost{Name: snapshotName})
+		if err != nil {
+			return fmt.Errorf("Failed to send snapshot request for storage volume %q on pool %q: %w", dev["source"], dev["pool"], err)
+		}
+
+		err = op.WaitContext(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to wait for snapshot operation for storage volume %q on pool %q: %w", dev["source"], dev["pool"], err)
+		}
+	}
+
+	return nil
+}
+
 func (t *InternalIncusTarget) DeleteVM(ctx context.Context, name string) error {
 	op, err := t.incusClient.DeleteInstance(name)
 	if err != nil {
@@ -1215,6 +1335,7 @@ type IncusDetails struct {
 	Name               string
 	Projects           []string
 	StoragePools       []string
+	StoragePoolDrivers map[string]string
 	NetworksByProject  map[string][]incusAPI.Network
 	InstancesByProject map[string][]string
 }
@@ -1230,11 +1351,18 @@ func (t *InternalIncusTarget) GetDetails(ctx context.Context) (*IncusDetails, er
 		return nil, err
 	}
 
-	pools, err := t.incusClient.GetStoragePoolNames()
+	pools, err := t.incusClient.GetStoragePools()
 	if err != nil {
 		return nil, err
 	}
 
+	poolNames := make([]string, 0, len(pools))
+	poolDrivers := make(map[string]string, len(pools))
+	for _, pool := range pools {
+		poolNames = append(poolNames, pool.Name)
+		poolDrivers[pool.Name] = pool.Driver
+	}
+
 	networksByProject := map[string][]incusAPI.Network{}
 	for _, p := range projects {
 		client := t.incusClient.UseProject(p)
@@ -1260,7 +1388,8 @@ func (t *InternalIncusTarget) GetDetails(ctx context.Context) (*IncusDetails, er
 	return &IncusDetails{
 		Name:               t.GetName(),
 		Projects:           projects,
-		StoragePools:       pools,
+		StoragePools:       poolNames,
+		StoragePoolDrivers: poolDrivers,
 		NetworksByProject:  networksByProject,
 		InstancesByProject: instancesByProject,
 	}, nil
@@ -1371,6 +1500,10 @@ func CanPlaceInstance(ctx context.Context, info *IncusDetails, q migration.Queue
 		if !slices.Contains(info.StoragePools, pool) {
 			return fmt.Errorf("No Storage pool found with name %q on target %q in project %q", pool, info.Name, placement.TargetProject)
 		}
+
+		if batch.Config.ImportMode == api.IMPORTMODE_AGENT && !slices.Contains(ImportAgentPoolDrivers, info.StoragePoolDrivers[pool]) {
+			return fmt.Errorf("Storage pool %q on target %q uses the %q driver, but the import agent only supports %s", pool, info.Name, info.StoragePoolDrivers[pool], strings.Join(ImportAgentPoolDrivers, " and "))
+		}
 	}
 
 	return nil
@@ -0,0 +1,219 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	incus "github.com/lxc/incus/v7/client"
+	incusAPI "github.com/lxc/incus/v7/shared/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/migration"
+	"github.com/FuturFusion/migration-manager/internal/properties"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+type operation struct {
+	incus.Operation
+
+	err error
+}
+
+func (o *operation) WaitContext(ctx context.Context) error {
+	return o.err
+}
+
+type snapshotServer struct {
+	incus.InstanceServer
+
+	instance          incusAPI.Instance
+	instanceSnapshots []string
+	volumeSnapshots   map[string][]string
+	snapshotErr       error
+
+	location string
+}
+
+func (s *snapshotServer) UseTarget(name string) incus.InstanceServer {
+	s.location = name
+	return s
+}
+
+func (s *snapshotServer) GetInstance(name string) (*incusAPI.Instance, string, error) {
+	return &s.instance, "", nil
+}
+
+func (s *snapshotServer) GetInstanceSnapshotNames(instanceName string) ([]string, error) {
+	return s.instanceSnapshots, nil
+}
+
+func (s *snapshotServer) CreateInstanceSnapshot(instanceName string, snapshot incusAPI.InstanceSnapshotsPost) (incus.Operation, error) {
+	if s.snapshotErr != nil {
+		return nil, s.snapshotErr
+	}
+
+	s.instanceSnapshots = append(s.instanceSnapshots, snapshot.Name)
+	return &operation{}, nil
+}
+
+func (s *snapshotServer) GetStoragePoolVolumeSnapshotNames(pool string, volumeType string, volumeName string) ([]string, error) {
+	return s.volumeSnapshots[pool+"/"+volumeName], nil
+}
+
+func (s *snapshotServer) CreateStoragePoolVolumeSnapshot(pool string, volumeType string, volumeName string, snapshot incusAPI.StorageVolumeSnapshotsPost) (incus.Operation, error) {
+	s.volumeSnapshots[pool+"/"+volumeName] = append(s.volumeSnapshots[pool+"/"+volumeName], snapshot.Name)
+	return &operation{}, nil
+}
+
+func TestInternalIncusTarget_CreateVMSnapshot(t *testing.T) {
+	devices := map[string]map[string]string{
+		"root":  {"type": "disk", "path": "/", "pool": "default"},
+		"disk1": {"type": "disk", "pool": "pool1", "source": "vm1-disk1", "user.migration_source": "[ds] vm1/disk1.vmdk"},
+		"disk2": {"type": "disk", "pool": "default", "source": "data", "path": "/data"},
+		"eth0":  {"type": "nic", "network": "default"},
+	}
+
+	tests := []struct {
+		name              string
+		instanceSnapshots []string
+		volumeSnapshots   map[string][]string
+		snapshotErr       error
+
+		assertErr             require.ErrorAssertionFunc
+		wantInstanceSnapshots []string
+		wantVolumeSnapshots   map[string][]string
+	}{
+		{
+			name:                  "success - instance and migrated volumes",
+			volumeSnapshots:       map[string][]string{},
+			assertErr:             require.NoError,
+			wantInstanceSnapshots: []string{"snap"},
+			wantVolumeSnapshots:   map[string][]string{"pool1/vm1-disk1": {"snap"}},
+		},
+		{
+			name:                  "success - existing snapshots are kept",
+			instanceSnapshots:     []string{"snap"},
+			volumeSnapshots:       map[string][]string{"pool1/vm1-disk1": {"snap"}},
+			assertErr:             require.NoError,
+			wantInstanceSnapshots: []string{"snap"},
+			wantVolumeSnapshots:   map[string][]string{"pool1/vm1-disk1": {"snap"}},
+		},
+		{
+			name:                  "success - missing volume snapshot is added",
+			instanceSnapshots:     []string{"snap"},
+			volumeSnapshots:       map[string][]string{"pool1/vm1-disk1": {"other"}},
+			assertErr:             require.NoError,
+			wantInstanceSnapshots: []string{"snap"},
+			wantVolumeSnapshots:   map[string][]string{"pool1/vm1-disk1": {"other", "snap"}},
+		},
+		{
+			name:                "error - instance snapshot fails",
+			volumeSnapshots:     map[string][]string{},
+			snapshotErr:         errors.New("boom!"),
+			assertErr:           require.Error,
+			wantVolumeSnapshots: map[string][]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &snapshotServer{
+				instance: incusAPI.Instance{
+					Name:        "vm1",
+					Location:    "member1",
+					InstancePut: incusAPI.InstancePut{Devices: devices},
+				},
+				instanceSnapshots: tc.instanceSnapshots,
+				volumeSnapshots:   tc.volumeSnapshots,
+				snapshotErr:       tc.snapshotErr,
+			}
+
+			tgt := &InternalIncusTarget{incusClient: server}
+
+			err := tgt.CreateVMSnapshot(t.Context(), "vm1", "snap")
+			tc.assertErr(t, err)
+			require.Equal(t, tc.wantInstanceSnapshots, server.instanceSnapshots)
+			require.Equal(t, tc.wantVolumeSnapshots, server.volumeSnapshots)
+			if err == nil {
+				require.Equal(t, "member1", server.location)
+			}
+		})
+	}
+}
+
+func TestInternalIncusTarget_fillInitialProperties(t *testing.T) {
+	const GiB = 1024 * 1024 * 1024
+
+	require.NoError(t, properties.InitDefinitions())
+
+	performance := api.InstancePropertiesPerformance{
+		CollectedAt:      time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC),
+		CPUUsagePeak:     25,
+		MemoryActivePeak: 6 * GiB,
+	}
+
+	tests := []struct {
+		name      string
+		overrides api.InstanceOverride
+
+		wantCPUs   string
+		wantMemory string
+	}{
+		{
+			name:       "source sizing",
+			wantCPUs:   "8",
+			wantMemory: "17179869184B",
+		},
+		{
+			name:       "recommended sizing",
+			overrides:  api.InstanceOverride{UseRecommendedSizing: true},
+			wantCPUs:   "3",
+			wantMemory: "8053063680B",
+		},
+		{
+			name:       "overridden sizing",
+			overrides:  api.InstanceOverride{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 12, Memory: 24 * GiB}},
+			wantCPUs:   "12",
+			wantMemory: "25769803776B",
+		},
+		{
+			name:       "never less than the worker needs",
+			overrides:  api.InstanceOverride{InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 1, Memory: GiB}},
+			wantCPUs:   "2",
+			wantMemory: "4294967296B",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tgt := InternalIncusTarget{InternalTarget: NewInternalTarget(api.Target{TargetPut: api.TargetPut{Name: "tgt"}, TargetType: api.TARGETTYPE_INCUS}, "6.0")}
+			defs, err := properties.Definitions(tgt.TargetType, tgt.version)
+			require.NoError(t, err)
+
+			inst := migration.Instance{
+				Overrides: tc.overrides,
+				Properties: api.InstanceProperties{
+					InstancePropertiesConfigurable: api.InstancePropertiesConfigurable{CPUs: 8, Memory: 16 * GiB},
+					Location:                       "/dc/vm",
+					OS:                             "ubuntu64Guest",
+					Disks:                          []api.InstancePropertiesDisk{{Name: "disk0", Capacity: 10 * GiB, Supported: true}},
+					Performance:                    performance,
+				},
+			}
+
+			def, err := tgt.fillInitialProperties(incusAPI.InstancesPost{}, inst, "default", api.DiskPolicy{}, defs)
+			require.NoError(t, err)
+
+			cpuDef, err := defs.Get(properties.InstanceCPUs)
+			require.NoError(t, err)
+
+			memoryDef, err := defs.Get(properties.InstanceMemory)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantCPUs, def.Config[cpuDef.Key])
+			require.Equal(t, tc.wantMemory, def.Config[memoryDef.Key])
+		})
+	}
+}
@@ -76,7 +76,7 @@ type Target interface {
 	SetProject(project string) error
 
 	// SetPostMigrationVMConfig stops the target instance and applies post-migration configuration before restarting it.
-	SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry) error
+	SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry, customization api.GuestCustomization) error
 
 	// Creates a VM definition for use with the Incus REST API.
 	CreateVMDefinition(instanceDef migration.Instance, usedNetworks migration.Networks, q migration.QueueEntry, fingerprint string, endpoint string, targetNetwork api.MigrationNetworkPlacement) (incusAPI.InstancesPost, error)
@@ -126,6 +126,9 @@ type Target interface {
 	// CleanupVM fully deletes the VM and all of its volumes. If requireWorkerVolume is true, the worker volume must be present for the VM to be cleaned up.
 	CleanupVM(ctx context.Context, name string, requireWorkerVolume bool) error
 
+	// CreateVMSnapshot snapshots the instance and the custom volumes of its migrated disks, unless a snapshot with the same name already exists.
+	CreateVMSnapshot(ctx context.Context, name string, snapshotName string) error
+
 	// GetDetails fetches top-level details about the entities that exist on the target.
 	GetDetails(ctx context.Context) (*IncusDetails, error)
 }
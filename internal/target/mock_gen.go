@@ -47,6 +47,9 @@ var _ Target = &TargetMock{}
 //			CreateVMDefinitionFunc: func(instanceDef migration.Instance, usedNetworks migration.Networks, q migration.QueueEntry, fingerprint string, endpoint string, targetNetwork api.MigrationNetworkPlacement) (incusAPI.InstancesPost, error) {
 //				panic("mock out the CreateVMDefinition method")
 //			},
+//			CreateVMSnapshotFunc: func(ctx context.Context, name string, snapshotName string) error {
+//				panic("mock out the CreateVMSnapshot method")
+//			},
 //			DeleteVMFunc: func(ctx context.Context, name string) error {
 //				panic("mock out the DeleteVM method")
 //			},
@@ -89,7 +92,7 @@ var _ Target = &TargetMock{}
 //			SetClientTLSCredentialsFunc: func(key string, cert string) error {
 //				panic("mock out the SetClientTLSCredentials method")
 //			},
-//			SetPostMigrationVMConfigFunc: func(ctx context.Context, i migration.Instance, q migration.QueueEntry) error {
+//			SetPostMigrationVMConfigFunc: func(ctx context.Context, i migration.Instance, q migration.QueueEntry, customization api.GuestCustomization) error {
 //				panic("mock out the SetPostMigrationVMConfig method")
 //			},
 //			SetProjectFunc: func(project string) error {
@@ -141,6 +144,9 @@ type TargetMock struct {
 	// CreateVMDefinitionFunc mocks the CreateVMDefinition method.
 	CreateVMDefinitionFunc func(instanceDef migration.Instance, usedNetworks migration.Networks, q migration.QueueEntry, fingerprint string, endpoint string, targetNetwork api.MigrationNetworkPlacement) (incusAPI.InstancesPost, error)
 
+	// CreateVMSnapshotFunc mocks the CreateVMSnapshot method.
+	CreateVMSnapshotFunc func(ctx context.Context, name string, snapshotName string) error
+
 	// DeleteVMFunc mocks the DeleteVM method.
 	DeleteVMFunc func(ctx context.Context, name string) error
 
@@ -184,7 +190,7 @@ type TargetMock struct {
 	SetClientTLSCredentialsFunc func(key string, cert string) error
 
 	// SetPostMigrationVMConfigFunc mocks the SetPostMigrationVMConfig method.
-	SetPostMigrationVMConfigFunc func(ctx context.Context, i migration.Instance, q migration.QueueEntry) error
+	SetPostMigrationVMConfigFunc func(ctx context.Context, i migration.Instance, q migration.QueueEntry, customization api.GuestCustomization) error
 
 	// SetProjectFunc mocks the SetProject method.
 	SetProjectFunc func(project string) error
@@ -278,6 +284,15 @@ type TargetMock struct {
 			// TargetNetwork is the targetNetwork argument value.
 			TargetNetwork api.MigrationNetworkPlacement
 		}
+		// CreateVMSnapshot holds details about calls to the CreateVMSnapshot method.
+		CreateVMSnapshot []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// SnapshotName is the snapshotName argument value.
+			SnapshotName string
+		}
 		// DeleteVM holds details about calls to the DeleteVM method.
 		DeleteVM []struct {
 			// Ctx is the ctx argument value.
@@ -356,6 +371,8 @@ type TargetMock struct {
 			I migration.Instance
 			// Q is the q argument value.
 			Q migration.QueueEntry
+			// Customization is the customization argument value.
+			Customization api.GuestCustomization
 		}
 		// SetProject holds details about calls to the SetProject method.
 		SetProject []struct {
@@ -414,6 +431,7 @@ type TargetMock struct {
 	lockCreateStoragePoolVolumeFromBackup sync.RWMutex
 	lockCreateStoragePoolVolumeFromISO    sync.RWMutex
 	lockCreateVMDefinition                sync.RWMutex
+	lockCreateVMSnapshot                  sync.RWMutex
 	lockDeleteVM                          sync.RWMutex
 	lockDisconnect                        sync.RWMutex
 	lockDoBasicConnectivityCheck          sync.RWMutex
@@ -730,6 +748,46 @@ func (mock *TargetMock) CreateVMDefinitionCalls() []struct {
 	return calls
 }
 
+// CreateVMSnapshot calls CreateVMSnapshotFunc.
+func (mock *TargetMock) CreateVMSnapshot(ctx context.Context, name string, snapshotName string) error {
+	if mock.CreateVMSnapshotFunc == nil {
+		panic("TargetMock.CreateVMSnapshotFunc: method is nil but Target.CreateVMSnapshot was just called")
+	}
+	callInfo := struct {
+		Ctx          context.Context
+		Name         string
+		SnapshotName string
+	}{
+		Ctx:          ctx,
+		Name:         name,
+		SnapshotName: snapshotName,
+	}
+	mock.lockCreateVMSnapshot.Lock()
+	mock.calls.CreateVMSnapshot = append(mock.calls.CreateVMSnapshot, callInfo)
+	mock.lockCreateVMSnapshot.Unlock()
+	return mock.CreateVMSnapshotFunc(ctx, name, snapshotName)
+}
+
+// CreateVMSnapshotCalls gets all the calls that were made to CreateVMSnapshot.
+// Check the length with:
+//
+//	len(mockedTarget.CreateVMSnapshotCalls())
+func (mock *TargetMock) CreateVMSnapshotCalls() []struct {
+	Ctx          context.Context
+	Name         string
+	SnapshotName string
+} {
+	var calls []struct {
+		Ctx          context.Context
+		Name         string
+		SnapshotName string
+	}
+	mock.lockCreateVMSnapshot.RLock()
+	calls = mock.calls.CreateVMSnapshot
+	mock.lockCreateVMSnapshot.RUnlock()
+	return calls
+}
+
 // DeleteVM calls DeleteVMFunc.
 func (mock *TargetMock) DeleteVM(ctx context.Context, name string) error {
 	if mock.DeleteVMFunc == nil {
@@ -1173,23 +1231,25 @@ func (mock *TargetMock) SetClientTLSCredentialsCalls() []struct {
 }
 
 // SetPostMigrationVMConfig calls SetPostMigrationVMConfigFunc.
-func (mock *TargetMock) SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry) error {
+func (mock *TargetMock) SetPostMigrationVMConfig(ctx context.Context, i migration.Instance, q migration.QueueEntry, customization api.GuestCustomization) error {
 	if mock.SetPostMigrationVMConfigFunc == nil {
 		panic("TargetMock.SetPostMigrationVMConfigFunc: method is nil but Target.SetPostMigrationVMConfig was just called")
 	}
 	callInfo := struct {
-		Ctx context.Context
-		I   migration.Instance
-		Q   migration.QueueEntry
+		Ctx           context.Context
+		I             migration.Instance
+		Q             migration.QueueEntry
+		Customization api.GuestCustomization
 	}{
-		Ctx: ctx,
-		I:   i,
-		Q:   q,
+		Ctx:           ctx,
+		I:             i,
+		Q:             q,
+		Customization: customization,
 	}
 	mock.lockSetPostMigrationVMConfig.Lock()
 	mock.calls.SetPostMigrationVMConfig = append(mock.calls.SetPostMigrationVMConfig, callInfo)
 	mock.lockSetPostMigrationVMConfig.Unlock()
-	return mock.SetPostMigrationVMConfigFunc(ctx, i, q)
+	return mock.SetPostMigrationVMConfigFunc(ctx, i, q, customization)
 }
 
 // SetPostMigrationVMConfigCalls gets all the calls that were made to SetPostMigrationVMConfig.
@@ -1197,14 +1257,16 @@ func (mock *TargetMock) SetPostMigrationVMConfig(ctx context.Context, i migratio
 //
 //	len(mockedTarget.SetPostMigrationVMConfigCalls())
 func (mock *TargetMock) SetPostMigrationVMConfigCalls() []struct {
-	Ctx context.Context
-	I   migration.Instance
-	Q   migration.QueueEntry
+	Ctx           context.Context
+	I             migration.Instance
+	Q             migration.QueueEntry
+	Customization api.GuestCustomization
 } {
 	var calls []struct {
-		Ctx context.Context
-		I   migration.Instance
-		Q   migration.QueueEntry
+		Ctx           context.Context
+		I             migration.Instance
+		Q             migration.QueueEntry
+		Customization api.GuestCustomization
 	}
 	mock.lockSetPostMigrationVMConfig.RLock()
 	calls = mock.calls.SetPostMigrationVMConfig
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// MatchScriptArtifact returns whether the script artifact applies to a VM with the given OS, distribution, version, and architecture.
+// Empty distributions, versions, or architectures on the artifact match any VM.
+// A version on the artifact also matches more specific versions of the VM, so "9" matches "9.4".
+func MatchScriptArtifact(artifact api.Artifact, osType api.OSType, distro api.Distro, version string, arch string) bool {
+	if artifact.Type != api.ARTIFACTTYPE_SCRIPT || artifact.OS != osType {
+		return false
+	}
+
+	if len(artifact.Architectures) > 0 && MatchArchitecture(artifact.Architectures, arch) != nil {
+		return false
+	}
+
+	if len(artifact.Distributions) > 0 && !slices.Contains(artifact.Distributions, distro) {
+		return false
+	}
+
+	if len(artifact.Versions) == 0 {
+		return true
+	}
+
+	for _, v := range artifact.Versions {
+		if v == version || strings.HasPrefix(version, v+".") {
+			return true
+		}
+	}
+
+	return false
+}
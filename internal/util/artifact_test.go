@@ -0,0 +1,76 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/internal/util"
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestMatchScriptArtifact(t *testing.T) {
+	script := func(osType api.OSType, distros []api.Distro, versions []string, archs []string) api.Artifact {
+		return api.Artifact{ArtifactPost: api.ArtifactPost{
+			Type:        api.ARTIFACTTYPE_SCRIPT,
+			ArtifactPut: api.ArtifactPut{OS: osType, Distributions: distros, Versions: versions, Architectures: archs},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		artifact api.Artifact
+
+		want bool
+	}{
+		{
+			name:     "any linux",
+			artifact: script(api.OSTYPE_LINUX, nil, nil, nil),
+			want:     true,
+		},
+		{
+			name:     "matching distribution and major version",
+			artifact: script(api.OSTYPE_LINUX, []api.Distro{api.DISTRO_DEBIAN, api.DISTRO_RHEL}, []string{"8", "9"}, []string{"x86_64"}),
+			want:     true,
+		},
+		{
+			name:     "exact version",
+			artifact: script(api.OSTYPE_LINUX, nil, []string{"9.4"}, nil),
+			want:     true,
+		},
+		{
+			name:     "other distribution",
+			artifact: script(api.OSTYPE_LINUX, []api.Distro{api.DISTRO_UBUNTU}, nil, nil),
+			want:     false,
+		},
+		{
+			name:     "other version",
+			artifact: script(api.OSTYPE_LINUX, nil, []string{"9.5", "94"}, nil),
+			want:     false,
+		},
+		{
+			name:     "other architecture",
+			artifact: script(api.OSTYPE_LINUX, nil, nil, []string{"aarch64"}),
+			want:     false,
+		},
+		{
+			name:     "other OS",
+			artifact: script(api.OSTYPE_WINDOWS, nil, nil, nil),
+			want:     false,
+		},
+		{
+			name: "driver artifact",
+			artifact: api.Artifact{ArtifactPost: api.ArtifactPost{
+				Type:        api.ARTIFACTTYPE_DRIVER,
+				ArtifactPut: api.ArtifactPut{OS: api.OSTYPE_LINUX},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, util.MatchScriptArtifact(tc.artifact, api.OSTYPE_LINUX, api.DISTRO_RHEL, "9.4", "x86_64"))
+		})
+	}
+}
@@ -117,3 +117,27 @@ func (l LSBLKOutput) FindDisk(disk string) (string, string, error) {
 
 	return "", "", fmt.Errorf("Disk %q not found in block devices: %s", disk, string(b))
 }
+
+// LUKSVolumes returns the LUKS encrypted block devices that have not been opened yet.
+func (l LSBLKOutput) LUKSVolumes() []LSBLKFields {
+	var volumes []LSBLKFields
+	var recurse func(devices []LSBLKFields)
+	recurse = func(devices []LSBLKFields) {
+		for _, d := range devices {
+			if d.FSType == "crypto_LUKS" {
+				// An open volume has its mapping as a child.
+				if len(d.Children) == 0 {
+					volumes = append(volumes, d)
+				}
+
+				continue
+			}
+
+			recurse(d.Children)
+		}
+	}
+
+	recurse(l.BlockDevices)
+
+	return volumes
+}
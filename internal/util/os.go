@@ -125,6 +125,8 @@ func GetOSCompatibility(osType api.OSType, distro api.Distro, distroVersion stri
 
 	switch osType {
 	case api.OSTYPE_BSD:
+		// Disks are renamed to VirtIO block devices during post-migration, so they must be attached as such.
+		supportsSCSI = false
 	case api.OSTYPE_FORTIGATE:
 	case api.OSTYPE_LINUX:
 		var v int
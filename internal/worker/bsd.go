@@ -0,0 +1,367 @@
+package worker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v7/shared/subprocess"
+	"github.com/lxc/incus/v7/shared/util"
+	"golang.org/x/sys/unix"
+
+	internalUtil "github.com/FuturFusion/migration-manager/internal/util"
+)
+
+// bsdLoaderModules are the VirtIO kernel modules loaded at boot. Loading a module already compiled into the kernel is harmless.
+var bsdLoaderModules = []string{"virtio", "virtio_pci", "virtio_blk", "virtio_scsi", "virtio_balloon", "virtio_console", "if_vtnet"}
+
+// bsdDiskNames matches disk device names in /etc/fstab. SCSI (da) and SATA (ada) disks are attached with virtio-blk (vtbd) on the target.
+var bsdDiskNames = regexp.MustCompile(`(^|[^A-Za-z0-9])/dev/(a?da)([0-9]+)`)
+
+// bsdNICNames matches VMXNET3 interface names, which become VirtIO (vtnet) interfaces on the target.
+var bsdNICNames = regexp.MustCompile(`(^|[^A-Za-z0-9])vmx([0-9]+)`)
+
+// bsdVMwareServices matches rc.conf variables enabling the open-vm-tools services.
+var bsdVMwareServices = regexp.MustCompile(`^\s*(vmware_guest[A-Za-z0-9_]*_enable)\s*=`)
+
+// BSDDoPostMigrationConfig mounts the root file system of a FreeBSD based VM and prepares it to boot with VirtIO devices.
+func BSDDoPostMigrationConfig(dryRun bool) error {
+	slog.Info("Preparing to perform post-migration configuration of VM")
+
+	partition, fsType, err := determineBSDRootPartition()
+	if err != nil {
+		return err
+	}
+
+	// Check for UFS write support on the dry-run as well, so that a missing kernel feature is found before the final import.
+	if fsType == "ufs" {
+		err := checkUFSWriteSupport()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The dry-run only inspects the file system, so it can be mounted read-only without cloning the disk.
+	unmount, err := mountBSDRootPartition(partition, fsType, dryRun)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = unmount() }()
+
+	// pfSense and OPNsense regenerate loader.conf on boot, so their modules go to loader.conf.local instead.
+	appliance := util.PathExists(filepath.Join(chrootMountPath, "conf", "config.xml"))
+	loaderConf := "boot/loader.conf"
+	if appliance {
+		loaderConf = "boot/loader.conf.local"
+	}
+
+	edits := map[string]func(string) string{
+		"etc/fstab":   rewriteBSDFstab,
+		loaderConf:    addBSDLoaderModules,
+		"etc/rc.conf": rewriteBSDRCConf,
+	}
+
+	if appliance {
+		edits["conf/config.xml"] = rewriteBSDInterfaces
+	}
+
+	for name, edit := range edits {
+		err := editBSDFile(filepath.Join(chrootMountPath, name), edit, dryRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Packages can only be removed from within the guest, so leave a script that runs on first boot.
+	if !dryRun && !appliance {
+		err := injectScript("freebsd-purge-open-vm-tools.sh", filepath.Join(chrootMountPath, "usr/local/etc/rc.d/migration_manager_purge_vm_tools"), false)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(chrootMountPath, "firstboot"), nil, 0o644)
+		if err != nil {
+			return fmt.Errorf("Failed to create firstboot sentinel: %w", err)
+		}
+	}
+
+	slog.Info("Post-migration configuration complete!")
+	return nil
+}
+
+// determineBSDRootPartition returns the partition and file system type of the root file system on the root disk.
+// For ZFS, the partition is the name of the pool.
+func determineBSDRootPartition() (string, string, error) {
+	partitions, err := internalUtil.ScanPartitions("")
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, dev := range partitions.BlockDevices {
+		if dev.Serial != "incus_root" {
+			continue
+		}
+
+		for _, p := range dev.Children {
+			switch p.FSType {
+			case "zfs_member":
+				if p.Label == "" {
+					return "", "", fmt.Errorf("Unable to determine ZFS pool on %q", p.Path)
+				}
+
+				return p.Label, p.FSType, nil
+			case "ufs":
+				if looksLikeBSDRootPartition("/dev/"+p.Name, p.FSType) {
+					return "/dev/" + p.Name, p.FSType, nil
+				}
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("Failed to determine the root partition")
+}
+
+func looksLikeBSDRootPartition(partition string, fsType string) bool {
+	unmount, err := mountBSDRootPartition(partition, fsType, true)
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = unmount() }()
+
+	return isRootFS(chrootMountPath) && util.PathExists(filepath.Join(chrootMountPath, "boot"))
+}
+
+// mountBSDRootPartition mounts the UFS partition or the boot file system of the ZFS pool, and returns a function to unmount it.
+func mountBSDRootPartition(partition string, fsType string, readOnly bool) (func() error, error) {
+	if fsType == "ufs" {
+		opts := []string{"-t", "ufs", "-o", "ufstype=ufs2"}
+		if readOnly {
+			opts[3] += ",ro"
+		}
+
+		err := DoMount(partition, chrootMountPath, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to mount UFS partition %q: %w", partition, err)
+		}
+
+		// Without UFS write support, the kernel silently mounts the file system read-only.
+		if !readOnly {
+			readOnlyMount, err := isReadOnlyMount(chrootMountPath)
+			if err == nil && readOnlyMount {
+				err = errUFSReadOnly
+			}
+
+			if err != nil {
+				_ = DoUnmount(chrootMountPath)
+				return nil, err
+			}
+		}
+
+		return func() error { return DoUnmount(chrootMountPath) }, nil
+	}
+
+	// The pool was last imported by the source VM, so it has to be forced.
+	args := []string{"import", "-f", "-N", "-R", chrootMountPath}
+	if readOnly {
+		args = append(args, "-o", "readonly=on")
+	}
+
+	_, err := subprocess.RunCommand("zpool", append(args, partition)...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to import ZFS pool %q: %w", partition, err)
+	}
+
+	unmount := func() error {
+		_, err := subprocess.RunCommand("zpool", "export", partition)
+		return err
+	}
+
+	bootfs, err := subprocess.RunCommand("zpool", "get", "-H", "-o", "value", "bootfs", partition)
+	if err != nil {
+		_ = unmount()
+		return nil, err
+	}
+
+	bootfs = strings.TrimSpace(bootfs)
+	if bootfs == "" || bootfs == "-" {
+		_ = unmount()
+		return nil, fmt.Errorf("ZFS pool %q has no boot file system", partition)
+	}
+
+	_, err = subprocess.RunCommand("zfs", "mount", bootfs)
+	if err != nil {
+		_ = unmount()
+		return nil, fmt.Errorf("Failed to mount ZFS file system %q: %w", bootfs, err)
+	}
+
+	return unmount, nil
+}
+
+// errUFSReadOnly is returned if the kernel of the worker can't write to UFS file systems.
+var errUFSReadOnly = errors.New("The worker kernel lacks UFS write support (CONFIG_UFS_FS_WRITE), so the UFS root file system can't be updated")
+
+// checkUFSWriteSupport returns errUFSReadOnly if the config of the running kernel shows that it can't write to UFS file systems.
+// Nothing is reported if the kernel config isn't available, as the file system is then checked once it is mounted read-write.
+func checkUFSWriteSupport() error {
+	var uname unix.Utsname
+	err := unix.Uname(&uname)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile("/boot/config-" + unix.ByteSliceToString(uname.Release[:]))
+	if err != nil {
+		slog.Warn("Unable to check the kernel for UFS write support", slog.Any("error", err))
+		return nil
+	}
+
+	if !kernelConfigEnabled(string(content), "CONFIG_UFS_FS_WRITE") {
+		return errUFSReadOnly
+	}
+
+	return nil
+}
+
+// kernelConfigEnabled returns whether the option is built in, or built as a module, in the kernel config.
+func kernelConfigEnabled(config string, option string) bool {
+	for line := range strings.Lines(config) {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), option+"=")
+		if ok {
+			return value == "y" || value == "m"
+		}
+	}
+
+	return false
+}
+
+// isReadOnlyMount returns whether the file system mounted at the path is read-only.
+func isReadOnlyMount(path string) (bool, error) {
+	var st unix.Statfs_t
+	err := unix.Statfs(path, &st)
+	if err != nil {
+		return false, fmt.Errorf("Failed to check mount %q: %w", path, err)
+	}
+
+	return st.Flags&unix.ST_RDONLY != 0, nil
+}
+
+// editBSDFile applies the edit to the file, if it exists. On a dry-run, the changes are only logged.
+func editBSDFile(path string, edit func(string) string, dryRun bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Only loader.conf files are created if missing.
+	if err != nil && !strings.HasPrefix(filepath.Base(path), "loader.conf") {
+		return nil
+	}
+
+	newContent := edit(string(content))
+	if newContent == string(content) {
+		return nil
+	}
+
+	slog.Info("Updating guest configuration", slog.String("file", path), slog.Bool("dry_run", dryRun))
+	if dryRun {
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(newContent), 0o644)
+}
+
+// rewriteBSDFstab renames SCSI and SATA disks to VirtIO block devices.
+// The VirtIO disks are numbered in the order they are attached to the target, which follows the order of the disk devices of the source VM.
+// VMware lists SCSI disks before IDE and SATA disks, so the da disks come first, followed by the ada disks.
+// FreeBSD numbers the disks of each kind without gaps, so disks that aren't in /etc/fstab are still counted.
+func rewriteBSDFstab(content string) string {
+	count := map[string]int{}
+	for _, match := range bsdDiskNames.FindAllStringSubmatch(content, -1) {
+		unit, err := strconv.Atoi(match[3])
+		if err != nil {
+			continue
+		}
+
+		count[match[2]] = max(count[match[2]], unit+1)
+	}
+
+	return bsdDiskNames.ReplaceAllStringFunc(content, func(device string) string {
+		match := bsdDiskNames.FindStringSubmatch(device)
+		unit, err := strconv.Atoi(match[3])
+		if err != nil {
+			return device
+		}
+
+		if match[2] == "ada" {
+			unit += count["da"]
+		}
+
+		return fmt.Sprintf("%s/dev/vtbd%d", match[1], unit)
+	})
+}
+
+// rewriteBSDInterfaces renames VMXNET3 interfaces to VirtIO interfaces.
+func rewriteBSDInterfaces(content string) string {
+	return bsdNICNames.ReplaceAllString(content, "${1}vtnet${2}")
+}
+
+// rewriteBSDRCConf renames network interfaces and disables the open-vm-tools services.
+func rewriteBSDRCConf(content string) string {
+	lines := []string{}
+	sc := bufio.NewScanner(strings.NewReader(rewriteBSDInterfaces(content)))
+	for sc.Scan() {
+		line := sc.Text()
+		match := bsdVMwareServices.FindStringSubmatch(line)
+		if match != nil {
+			line = match[1] + `="NO"`
+		}
+
+		lines = append(lines, line)
+	}
+
+	return joinBSDLines(lines)
+}
+
+// addBSDLoaderModules enables the VirtIO kernel modules in loader.conf, replacing any existing setting.
+func addBSDLoaderModules(content string) string {
+	lines := []string{}
+	found := map[string]bool{}
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := sc.Text()
+		key, _, ok := strings.Cut(strings.TrimSpace(line), "=")
+		key = strings.TrimSpace(key)
+		module, isLoad := strings.CutSuffix(key, "_load")
+		if ok && isLoad && slices.Contains(bsdLoaderModules, module) {
+			line = key + `="YES"`
+			found[module] = true
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, m := range bsdLoaderModules {
+		if !found[m] {
+			lines = append(lines, m+`_load="YES"`)
+		}
+	}
+
+	return joinBSDLines(lines)
+}
+
+func joinBSDLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
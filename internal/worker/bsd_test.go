@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteBSDFstab(t *testing.T) {
+	tests := []struct {
+		name  string
+		fstab string
+
+		want string
+	}{
+		{
+			name: "SCSI disks",
+			fstab: `# Device	Mountpoint	FStype	Options	Dump	Pass#
+/dev/da0p2	/	ufs	rw	1	1
+/dev/da1s1a	/data	ufs	rw	2	2
+/dev/da0p3.eli	none	swap	sw	0	0
+/dev/gpt/rootfs	/mnt	ufs	rw	2	2
+`,
+			want: `# Device	Mountpoint	FStype	Options	Dump	Pass#
+/dev/vtbd0p2	/	ufs	rw	1	1
+/dev/vtbd1s1a	/data	ufs	rw	2	2
+/dev/vtbd0p3.eli	none	swap	sw	0	0
+/dev/gpt/rootfs	/mnt	ufs	rw	2	2
+`,
+		},
+		{
+			name: "SATA disks",
+			fstab: `/dev/ada0p2	/	ufs	rw	1	1
+/dev/ada1p1	/data	ufs	rw	2	2
+`,
+			want: `/dev/vtbd0p2	/	ufs	rw	1	1
+/dev/vtbd1p1	/data	ufs	rw	2	2
+`,
+		},
+		{
+			name: "SATA disks follow SCSI disks",
+			fstab: `/dev/da0p2	/	ufs	rw	1	1
+/dev/ada0p1	/data	ufs	rw	2	2
+`,
+			want: `/dev/vtbd0p2	/	ufs	rw	1	1
+/dev/vtbd1p1	/data	ufs	rw	2	2
+`,
+		},
+		{
+			name: "disks missing from fstab are counted",
+			fstab: `/dev/da0p2	/	ufs	rw	1	1
+/dev/da2p1	/data	ufs	rw	2	2
+/dev/ada1p1	/backup	ufs	rw	2	2
+`,
+			want: `/dev/vtbd0p2	/	ufs	rw	1	1
+/dev/vtbd2p1	/data	ufs	rw	2	2
+/dev/vtbd4p1	/backup	ufs	rw	2	2
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, rewriteBSDFstab(tc.fstab))
+		})
+	}
+}
+
+func TestKernelConfigEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+
+		want bool
+	}{
+		{
+			name:   "built in",
+			config: "CONFIG_UFS_FS=m\nCONFIG_UFS_FS_WRITE=y\n",
+			want:   true,
+		},
+		{
+			name:   "not set",
+			config: "CONFIG_UFS_FS=m\n# CONFIG_UFS_FS_WRITE is not set\n",
+			want:   false,
+		},
+		{
+			name:   "missing",
+			config: "CONFIG_UFS_FS=m\n",
+			want:   false,
+		},
+		{
+			name:   "longer option",
+			config: "CONFIG_UFS_FS_WRITE_EXTRA=y\n",
+			want:   false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, kernelConfigEnabled(tc.config, "CONFIG_UFS_FS_WRITE"))
+		})
+	}
+}
+
+func TestIsReadOnlyMount(t *testing.T) {
+	readOnly, err := isReadOnlyMount(t.TempDir())
+	require.NoError(t, err)
+	require.False(t, readOnly)
+
+	_, err = isReadOnlyMount(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestRewriteBSDRCConf(t *testing.T) {
+	rcConf := `hostname="fw01"
+ifconfig_vmx0="DHCP"
+ifconfig_vmx1_ipv6="inet6 accept_rtadv"
+ifconfig_bridge0="addm vmx10 up"
+vmware_guestd_enable="YES"
+vmware_guest_vmblock_enable="YES"
+sshd_enable="YES"
+`
+
+	want := `hostname="fw01"
+ifconfig_vtnet0="DHCP"
+ifconfig_vtnet1_ipv6="inet6 accept_rtadv"
+ifconfig_bridge0="addm vtnet10 up"
+vmware_guestd_enable="NO"
+vmware_guest_vmblock_enable="NO"
+sshd_enable="YES"
+`
+
+	require.Equal(t, want, rewriteBSDRCConf(rcConf))
+}
+
+func TestAddBSDLoaderModules(t *testing.T) {
+	tests := []struct {
+		name       string
+		loaderConf string
+
+		want string
+	}{
+		{
+			name:       "empty",
+			loaderConf: "",
+
+			want: `virtio_load="YES"
+virtio_pci_load="YES"
+virtio_blk_load="YES"
+virtio_scsi_load="YES"
+virtio_balloon_load="YES"
+virtio_console_load="YES"
+if_vtnet_load="YES"
+`,
+		},
+		{
+			name: "existing settings",
+			loaderConf: `autoboot_delay="3"
+virtio_blk_load="NO"
+# if_vtnet_load="NO"
+if_vtnet_load = "NO"
+`,
+
+			want: `autoboot_delay="3"
+virtio_blk_load="YES"
+# if_vtnet_load="NO"
+if_vtnet_load="YES"
+virtio_load="YES"
+virtio_pci_load="YES"
+virtio_scsi_load="YES"
+virtio_balloon_load="YES"
+virtio_console_load="YES"
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, addBSDLoaderModules(tc.loaderConf))
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lxc/incus/v7/shared/util"
+	"gopkg.in/yaml.v3"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// cloudInitSeedDir is where the NoCloud datasource looks for its configuration in the guest file system.
+const cloudInitSeedDir = "var/lib/cloud/seed/nocloud"
+
+// cloudInitDatasourceFile enables the NoCloud datasource. cloud.cfg.d files are applied in lexical order, so the name sorts after those of distributions and VMware tools.
+const cloudInitDatasourceFile = "etc/cloud/cloud.cfg.d/zz-migration-manager.cfg"
+
+// cloudInitInstanceIDFile records the instance ID of the last boot of the guest with cloud-init.
+const cloudInitInstanceIDFile = "var/lib/cloud/data/instance-id"
+
+// cloudInitCleanupScript is run by cloud-init at the end of the first boot, and restores the guest's own datasources by removing the seed and the datasource drop-in.
+const cloudInitCleanupScript = "var/lib/cloud/scripts/per-boot/zz-migration-manager-cleanup.sh"
+
+// injectCloudInitSeed writes the cloud-init configuration into the guest for the NoCloud datasource, if the guest's cloud-init doesn't already consider it.
+// Otherwise, cloud-init reads the configuration from the config drive attached to the target instance.
+// The seed keeps the instance ID the guest last booted with, if any, so cloud-init doesn't treat the migrated VM as a new instance.
+// Both the seed and the NoCloud datasource are removed again once the first boot completes.
+func injectCloudInitSeed(root string, instanceID string, customization api.GuestCustomization) error {
+	if !customization.HasCloudInit() {
+		return nil
+	}
+
+	if !util.PathExists(filepath.Join(root, "etc/cloud/cloud.cfg")) {
+		slog.Warn("cloud-init is not installed in the guest, skipping cloud-init customization")
+		return nil
+	}
+
+	datasources, err := cloudInitDatasources(root)
+	if err != nil {
+		return err
+	}
+
+	// An empty list lets cloud-init detect the datasource, which includes NoCloud.
+	if len(datasources) == 0 || slices.Contains(datasources, "NoCloud") {
+		return nil
+	}
+
+	slog.Info("Injecting cloud-init NoCloud seed", slog.Any("datasources", datasources))
+
+	seedDir := filepath.Join(root, cloudInitSeedDir)
+	err = os.MkdirAll(seedDir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	// NoCloud requires both meta-data and user-data to be present.
+	userData := customization.CloudInitUserData
+	if userData == "" {
+		userData = "#cloud-config\n"
+	}
+
+	// Reusing the instance ID keeps per-instance state, such as the SSH host keys, and only runs the per-boot modules again.
+	content, err := os.ReadFile(filepath.Join(root, cloudInitInstanceIDFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if strings.TrimSpace(string(content)) != "" {
+		instanceID = strings.TrimSpace(string(content))
+	}
+
+	files := map[string]string{
+		"meta-data":      fmt.Sprintf("instance-id: %s\n", instanceID),
+		"user-data":      userData,
+		"vendor-data":    customization.CloudInitVendorData,
+		"network-config": customization.CloudInitNetworkConfig,
+	}
+
+	cleanup := []string{"/" + cloudInitDatasourceFile}
+	for name, content := range files {
+		if content == "" {
+			continue
+		}
+
+		err := os.WriteFile(filepath.Join(seedDir, name), []byte(content), 0o600)
+		if err != nil {
+			return fmt.Errorf("Failed to write cloud-init %s: %w", name, err)
+		}
+
+		cleanup = append(cleanup, filepath.Join("/", cloudInitSeedDir, name))
+	}
+
+	slices.Sort(cleanup)
+
+	err = os.MkdirAll(filepath.Dir(filepath.Join(root, cloudInitCleanupScript)), 0o755)
+	if err != nil {
+		return err
+	}
+
+	script := "#!/bin/sh\n# Restore the cloud-init datasources of the guest once the migration customization has been applied.\n"
+	for _, f := range cleanup {
+		script += fmt.Sprintf("rm -f %q\n", f)
+	}
+
+	script += "rm -f \"$0\"\n"
+
+	err = os.WriteFile(filepath.Join(root, cloudInitCleanupScript), []byte(script), 0o755)
+	if err != nil {
+		return fmt.Errorf("Failed to write cloud-init cleanup script: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(filepath.Join(root, cloudInitDatasourceFile)), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(root, cloudInitDatasourceFile), []byte("datasource_list: [NoCloud, None]\n"), 0o644)
+}
+
+// cloudInitDatasources returns the datasource_list configured for cloud-init in the guest, with later files taking precedence.
+func cloudInitDatasources(root string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(root, "etc/cloud/cloud.cfg.d/*.cfg"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Glob sorts the files, and cloud.cfg is read before all of them.
+	files = append([]string{filepath.Join(root, "etc/cloud/cloud.cfg")}, files...)
+
+	var datasources []string
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		var cfg struct {
+			DatasourceList []string `yaml:"datasource_list"`
+		}
+
+		err = yaml.Unmarshal(content, &cfg)
+		if err != nil {
+			slog.Warn("Failed to parse cloud-init configuration", slog.String("file", f), slog.Any("error", err))
+			continue
+		}
+
+		if cfg.DatasourceList != nil {
+			datasources = cfg.DatasourceList
+		}
+	}
+
+	return datasources, nil
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+func TestInjectCloudInitSeed(t *testing.T) {
+	customization := api.GuestCustomization{
+		CloudInitUserData:      "#cloud-config\nhostname: vm01\n",
+		CloudInitNetworkConfig: "version: 2\n",
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+
+		wantSeed       bool
+		wantInstanceID string
+	}{
+		{
+			name: "no cloud-init",
+		},
+		{
+			name: "detected datasource",
+			files: map[string]string{
+				"etc/cloud/cloud.cfg": "users:\n  - default\n",
+			},
+		},
+		{
+			name: "NoCloud enabled",
+			files: map[string]string{
+				"etc/cloud/cloud.cfg":                  "datasource_list: [VMware, None]\n",
+				"etc/cloud/cloud.cfg.d/90_dpkg.cfg":    "datasource_list: [ NoCloud, VMware, None ]\n",
+				"etc/cloud/cloud.cfg.d/README":         "datasource_list: [VMware]\n",
+				"etc/cloud/cloud.cfg.d/05_logging.cfg": "output: {all: '| tee -a /var/log/cloud-init-output.log'}\n",
+			},
+		},
+		{
+			name: "NoCloud disabled",
+			files: map[string]string{
+				"etc/cloud/cloud.cfg":                 "datasource_list: [NoCloud, None]\n",
+				"etc/cloud/cloud.cfg.d/99_vmware.cfg": "datasource_list:\n  - VMware\n",
+			},
+
+			wantSeed:       true,
+			wantInstanceID: "a2095069-a527-4b2a-ab23-1739325dcac7",
+		},
+		{
+			name: "NoCloud disabled, keeps source instance ID",
+			files: map[string]string{
+				"etc/cloud/cloud.cfg":            "datasource_list: [VMware, None]\n",
+				"var/lib/cloud/data/instance-id": "4217c1c6-0e4c-1d2b-cf3a-3f4b1b6b8a9c\n",
+			},
+
+			wantSeed:       true,
+			wantInstanceID: "4217c1c6-0e4c-1d2b-cf3a-3f4b1b6b8a9c",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tc.files {
+				require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+			}
+
+			require.NoError(t, injectCloudInitSeed(root, "a2095069-a527-4b2a-ab23-1739325dcac7", customization))
+
+			seedDir := filepath.Join(root, cloudInitSeedDir)
+			if !tc.wantSeed {
+				require.NoDirExists(t, seedDir)
+				require.NoFileExists(t, filepath.Join(root, cloudInitDatasourceFile))
+				return
+			}
+
+			for name, want := range map[string]string{
+				"meta-data":      "instance-id: " + tc.wantInstanceID + "\n",
+				"user-data":      customization.CloudInitUserData,
+				"network-config": customization.CloudInitNetworkConfig,
+			} {
+				content, err := os.ReadFile(filepath.Join(seedDir, name))
+				require.NoError(t, err)
+				require.Equal(t, want, string(content))
+			}
+
+			require.NoFileExists(t, filepath.Join(seedDir, "vendor-data"))
+
+			datasources, err := cloudInitDatasources(root)
+			require.NoError(t, err)
+			require.Equal(t, []string{"NoCloud", "None"}, datasources)
+
+			script, err := os.ReadFile(filepath.Join(root, cloudInitCleanupScript))
+			require.NoError(t, err)
+			require.Equal(t, `#!/bin/sh
+# Restore the cloud-init datasources of the guest once the migration customization has been applied.
+rm -f "/etc/cloud/cloud.cfg.d/zz-migration-manager.cfg"
+rm -f "/var/lib/cloud/seed/nocloud/meta-data"
+rm -f "/var/lib/cloud/seed/nocloud/network-config"
+rm -f "/var/lib/cloud/seed/nocloud/user-data"
+rm -f "$0"
+`, string(script))
+		})
+	}
+}
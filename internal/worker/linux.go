@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -55,7 +56,7 @@ const (
 	logDir          string = "migration-manager"
 )
 
-func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, distro api.Distro, distroVersion string, dryRun bool) error {
+func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, distro api.Distro, distroVersion string, luksKey LUKSKey, customization api.GuestCustomization, scripts []string, dryRun bool) error {
 	// Clear any existing logs from a previousr run.
 	err := os.RemoveAll(filepath.Join("/tmp", logDir))
 	if err != nil {
@@ -75,6 +76,14 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 
 	slog.Info("Preparing to perform post-migration configuration of VM")
 
+	var versionInt int
+	if distro != api.DISTRO_UBUNTU && distroVersion != "" {
+		versionInt, err = strconv.Atoi(distroVersion)
+		if err != nil {
+			return fmt.Errorf("Failed to parse distro version %q for distro %q: %w", distroVersion, distro, err)
+		}
+	}
+
 	err = cleanupClones()
 	if err != nil {
 		return fmt.Errorf("Failed to attempt cleanup of stale clone state")
@@ -82,6 +91,40 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 
 	defer func() { _ = cleanupClones() }()
 
+	// Open any LUKS encrypted volumes, so the root partition and any LVM volumes on them can be found.
+	partitions, err := internalUtil.ScanPartitions("")
+	if err != nil {
+		return err
+	}
+
+	luksVolumes := partitions.LUKSVolumes()
+	if len(luksVolumes) > 0 {
+		// The key is only sent for the post-import tasks, so the dry-run can't open the volumes, nor check anything that may be stored on them.
+		if dryRun {
+			volumes := make([]string, 0, len(luksVolumes))
+			for _, v := range luksVolumes {
+				volumes = append(volumes, "/dev/"+v.Name)
+			}
+
+			slog.Warn("Skipping the dry-run checks that need the file systems, as LUKS encrypted volumes can't be opened before the post-import tasks",
+				slog.Any("volumes", volumes),
+				slog.Any("skipped", []string{"root partition detection", "fstab mounts", "incus-agent installation", "open-vm-tools removal", "initramfs drivers", "network configuration", "guest customization", "scripts"}))
+			return nil
+		}
+
+		if luksKey.IsEmpty() {
+			return fmt.Errorf("LUKS encrypted volumes detected, but no passphrase or keyfile is set for the instance")
+		}
+
+		names, err := openLUKSVolumes(luksVolumes, luksKey)
+		if err != nil {
+			return err
+		}
+
+		// Any volume groups on the LUKS volumes are deactivated by the time this runs, as they are activated later on.
+		defer func() { _ = closeLUKSVolumes(names) }()
+	}
+
 	// Determine the root partition.
 	rootParent, rootPart, rootType, rootOpts, err := determineRootPartition(looksLikeLinuxRootPartition)
 	if err != nil {
@@ -247,14 +290,6 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 		defer func() { _ = DoUnmount(filepath.Join(chrootMountPath, mnt["path"])) }() //nolint: revive
 	}
 
-	var versionInt int
-	if distro != api.DISTRO_UBUNTU && distroVersion != "" {
-		versionInt, err = strconv.Atoi(distroVersion)
-		if err != nil {
-			return fmt.Errorf("Failed to parse distro version %q for distro %q: %w", distroVersion, distro, err)
-		}
-	}
-
 	// Install incus-agent into the VM.
 	err = runScriptInChroot("install-incus-agent.sh")
 	if err != nil {
@@ -267,7 +302,7 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 			return err
 		}
 
-		err = runScriptInChroot("dracut-add-virtio-drivers.sh", string(rootType))
+		err = runScriptInChroot("dracut-add-virtio-drivers.sh", string(rootType), strconv.FormatBool(len(luksVolumes) > 0))
 		if err != nil {
 			return err
 		}
@@ -305,7 +340,7 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 			return err
 		}
 
-		err = runScriptInChroot("dracut-add-virtio-drivers.sh", string(rootType))
+		err = runScriptInChroot("dracut-add-virtio-drivers.sh", string(rootType), strconv.FormatBool(len(luksVolumes) > 0))
 		if err != nil {
 			return err
 		}
@@ -339,6 +374,17 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 		}
 	}
 
+	// Rename and re-address interfaces in the guest's network configuration.
+	err = translateNetworkConfig(chrootMountPath, instance.Overrides.NICs)
+	if err != nil {
+		return err
+	}
+
+	err = injectCloudInitSeed(chrootMountPath, instance.UUID.String(), customization)
+	if err != nil {
+		return err
+	}
+
 	if !instance.LegacyBoot {
 		err := runScriptInChroot("reinstall-grub-uefi.sh")
 		if err != nil {
@@ -346,6 +392,18 @@ func LinuxDoPostMigrationConfig(ctx context.Context, instance api.Instance, dist
 		}
 	}
 
+	// User-supplied scripts may reach out to external services, so they are only run for the real migration.
+	if dryRun && len(scripts) > 0 {
+		slog.Info("Skipping dry-run of script artifacts", slog.Int("count", len(scripts)))
+	} else {
+		for i, path := range scripts {
+			err := runUserScriptInChroot(i, path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if !dryRun {
 		srcDir := filepath.Join("/tmp", logDir)
 		tgtDir := filepath.Join(chrootMountPath, "var/log", logDir)
@@ -416,21 +474,22 @@ func determineRootPartition(looksLikeRootPartition func(partition string, opts [
 				return "", "", PARTITION_TYPE_UNKNOWN, nil, fmt.Errorf("Unable to determine root disk: %+v", dev)
 			}
 
-			partition := "/dev/" + p.Name
 			parent := "/dev/" + p.PKName
+			for _, c := range rootPartitionCandidates(p) {
+				partition, fsType := c.path, c.fsType
+				if fsType == "btrfs" {
+					btrfsSubvol, err := getBTRFSTopSubvol(partition)
+					if err != nil {
+						return "", "", PARTITION_TYPE_UNKNOWN, nil, err
+					}
 
-			if p.FSType == "btrfs" {
-				btrfsSubvol, err := getBTRFSTopSubvol(partition)
-				if err != nil {
-					return "", "", PARTITION_TYPE_UNKNOWN, nil, err
-				}
-
-				opts := []string{"-o", btrfsSubvol}
-				if looksLikeRootPartition(partition, opts) {
-					return parent, partition, PARTITION_TYPE_PLAIN, opts, nil
+					opts := []string{"-o", btrfsSubvol}
+					if looksLikeRootPartition(partition, opts) {
+						return parent, partition, PARTITION_TYPE_PLAIN, opts, nil
+					}
+				} else if looksLikeRootPartition(partition, nil) {
+					return parent, partition, PARTITION_TYPE_PLAIN, nil, nil
 				}
-			} else if looksLikeRootPartition(partition, nil) {
-				return parent, partition, PARTITION_TYPE_PLAIN, nil, nil
 			}
 		}
 	}
@@ -438,9 +497,32 @@ func determineRootPartition(looksLikeRootPartition func(partition string, opts [
 	return "", "", PARTITION_TYPE_UNKNOWN, nil, fmt.Errorf("Failed to determine the root partition")
 }
 
-func runScriptInChroot(scriptName string, args ...string) error {
-	logFile, _ := strings.CutSuffix(scriptName, ".sh")
+// partitionCandidate is a device that may hold the root file system.
+type partitionCandidate struct {
+	path   string
+	fsType string
+}
+
+// rootPartitionCandidates returns the devices of the partition that may hold the root file system, sorted by path so the same root partition is found on every run.
+// An open LUKS volume holds its file system in the mapping below it.
+func rootPartitionCandidates(p internalUtil.LSBLKFields) []partitionCandidate {
+	if p.FSType != "crypto_LUKS" {
+		return []partitionCandidate{{path: "/dev/" + p.Name, fsType: p.FSType}}
+	}
+
+	candidates := make([]partitionCandidate, 0, len(p.Children))
+	for _, mapping := range p.Children {
+		candidates = append(candidates, partitionCandidate{path: "/dev/mapper/" + mapping.Name, fsType: mapping.FSType})
+	}
 
+	slices.SortFunc(candidates, func(a partitionCandidate, b partitionCandidate) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	return candidates
+}
+
+func runScriptInChroot(scriptName string, args ...string) error {
 	slog.Info("Executing script", slog.String("command", strings.Join(append([]string{scriptName}, args...), " ")))
 	// Get the embedded script's contents.
 	script, err := embeddedScripts.ReadFile(filepath.Join("scripts/", scriptName))
@@ -448,8 +530,44 @@ func runScriptInChroot(scriptName string, args ...string) error {
 		return err
 	}
 
+	return runInChroot(scriptName, script, args...)
+}
+
+// runUserScriptInChroot runs the script artifact at the given path within the chroot. The index is its position in the run order.
+func runUserScriptInChroot(index int, path string) error {
+	// Script artifacts are downloaded to a directory named after the artifact UUID.
+	scriptName := fmt.Sprintf("script-%02d-%s.sh", index, filepath.Base(filepath.Dir(path)))
+	slog.Info("Executing script artifact", slog.String("script", scriptName))
+
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read script artifact %q: %w", path, err)
+	}
+
+	err = runInChroot(scriptName, script)
+	if err != nil {
+		return fmt.Errorf("Script artifact %q failed: %w", scriptName, err)
+	}
+
+	// The output is only summarized, as it can be arbitrarily long. The full output is kept in the log file, which is copied into the VM.
+	logFile, _ := strings.CutSuffix(scriptName, ".sh")
+	logFile += ".log"
+	var outputSize int64
+	info, err := os.Stat(filepath.Join("/tmp", logDir, logFile))
+	if err == nil {
+		outputSize = info.Size()
+	}
+
+	slog.Info("Script artifact completed", slog.String("script", scriptName), slog.Int64("output_bytes", outputSize), slog.String("log", filepath.Join("/var/log", logDir, logFile)))
+	return nil
+}
+
+// runInChroot writes the script into the chroot and runs it, logging its output to the log directory.
+func runInChroot(scriptName string, script []byte, args ...string) error {
+	logFile, _ := strings.CutSuffix(scriptName, ".sh")
+
 	// Write script to tmp file.
-	err = os.WriteFile(filepath.Join(chrootMountPath, scriptName), script, 0o755)
+	err := os.WriteFile(filepath.Join(chrootMountPath, scriptName), script, 0o755)
 	if err != nil {
 		return err
 	}
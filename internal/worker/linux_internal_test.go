@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	internalUtil "github.com/FuturFusion/migration-manager/internal/util"
+)
+
+func TestRootPartitionCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition internalUtil.LSBLKFields
+
+		want []partitionCandidate
+	}{
+		{
+			name:      "plain partition",
+			partition: internalUtil.LSBLKFields{Name: "sda2", FSType: "ext4"},
+			want:      []partitionCandidate{{path: "/dev/sda2", fsType: "ext4"}},
+		},
+		{
+			name: "open LUKS volume",
+			partition: internalUtil.LSBLKFields{Name: "sda3", FSType: "crypto_LUKS", Children: []internalUtil.LSBLKFields{
+				{Name: "luks-b", FSType: "xfs"},
+				{Name: "luks-a", FSType: "btrfs"},
+			}},
+			want: []partitionCandidate{{path: "/dev/mapper/luks-a", fsType: "btrfs"}, {path: "/dev/mapper/luks-b", fsType: "xfs"}},
+		},
+		{
+			name:      "closed LUKS volume",
+			partition: internalUtil.LSBLKFields{Name: "sda3", FSType: "crypto_LUKS"},
+			want:      []partitionCandidate{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, rootPartitionCandidates(tc.partition))
+		})
+	}
+}
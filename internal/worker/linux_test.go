@@ -88,3 +88,53 @@ func TestLVSUnmarshaling(t *testing.T) {
 	require.Equal(t, "ubuntu-vg", onevg.Report[0].LV[0].VGName)
 	require.Equal(t, "ubuntu-lv", onevg.Report[0].LV[0].LVName)
 }
+
+var lsblkLUKS = `
+{
+   "blockdevices": [
+      {
+         "name": "sda",
+         "fstype": null,
+         "children": [
+            {
+               "name": "sda1",
+               "fstype": "ext4"
+            },{
+               "name": "sda2",
+               "fstype": "crypto_LUKS",
+               "path": "/dev/sda2",
+               "uuid": "9c1e4a0e-0d2a-4f4c-9a55-3b2b7b7c8a11",
+               "children": [
+                  {
+                     "name": "luks-9c1e4a0e-0d2a-4f4c-9a55-3b2b7b7c8a11",
+                     "fstype": "LVM2_member"
+                  }
+               ]
+            }
+         ]
+      },{
+         "name": "sdb",
+         "fstype": "crypto_LUKS",
+         "path": "/dev/sdb",
+         "uuid": "0b6f0a3e-1e5f-4d1b-8f0e-6f1f2b5c9d22"
+      }
+   ]
+}
+`
+
+func TestLSBLKLUKSVolumes(t *testing.T) {
+	lsblkOutput := util.LSBLKOutput{}
+	err := json.Unmarshal([]byte(lsblkLUKS), &lsblkOutput)
+	require.NoError(t, err)
+
+	// Only the volume that hasn't been opened yet is returned.
+	volumes := lsblkOutput.LUKSVolumes()
+	require.Len(t, volumes, 1)
+	require.Equal(t, "/dev/sdb", volumes[0].Path)
+	require.Equal(t, "0b6f0a3e-1e5f-4d1b-8f0e-6f1f2b5c9d22", volumes[0].UUID)
+
+	lsblkOutput = util.LSBLKOutput{}
+	err = json.Unmarshal([]byte(lsblk), &lsblkOutput)
+	require.NoError(t, err)
+	require.Empty(t, lsblkOutput.LUKSVolumes())
+}
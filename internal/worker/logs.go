@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveLogs returns a tar archive of the given files, along with the post-migration and nbdkit logs written in the worker.
+// Files are named by their base name in the archive, and missing files are skipped.
+func ArchiveLogs(files ...string) ([]byte, error) {
+	for _, dir := range []string{filepath.Join("/tmp", logDir), NbdkitLogDir} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	return archiveFiles(files)
+}
+
+func archiveFiles(files []string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	seen := map[string]bool{}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() || seen[info.Name()] {
+			continue
+		}
+
+		// Read the whole file up front, as logs may still be written to while archiving.
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     info.Name(),
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			ModTime:  info.ModTime().Truncate(time.Second),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tw.Write(content)
+		if err != nil {
+			return nil, err
+		}
+
+		seen[info.Name()] = true
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "worker.log"), []byte("worker output"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "worker.log"), []byte("duplicate"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "nbdkit-2000.log"), []byte("nbdkit output"), 0o644))
+
+	content, err := archiveFiles([]string{
+		filepath.Join(dir, "worker.log"),
+		filepath.Join(dir, "missing.log"),
+		filepath.Join(dir, "sub"),
+		filepath.Join(dir, "sub", "worker.log"),
+		filepath.Join(dir, "sub", "nbdkit-2000.log"),
+	})
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	tr := tar.NewReader(bytes.NewReader(content))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		b, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(b)
+	}
+
+	require.Equal(t, map[string]string{"worker.log": "worker output", "nbdkit-2000.log": "nbdkit output"}, files)
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/lxc/incus/v7/shared/subprocess"
+
+	internalUtil "github.com/FuturFusion/migration-manager/internal/util"
+)
+
+// LUKSKey unlocks the LUKS encrypted volumes of an instance, with either a passphrase or the contents of a keyfile.
+type LUKSKey struct {
+	Passphrase string
+	KeyFile    []byte
+}
+
+// IsEmpty returns whether neither a passphrase nor a keyfile is set.
+func (k LUKSKey) IsEmpty() bool {
+	return k.Passphrase == "" && len(k.KeyFile) == 0
+}
+
+// openLUKSVolumes opens the given LUKS volumes with the key, and returns the names of their mappings.
+// Volumes are mapped as luks-<UUID>, the name most installers use in /etc/crypttab, so that fstab entries referring to the mapping keep working.
+func openLUKSVolumes(volumes []internalUtil.LSBLKFields, key LUKSKey) ([]string, error) {
+	keyFile, err := writeLUKSKeyFile(key)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = os.Remove(keyFile) }()
+
+	names := make([]string, 0, len(volumes))
+	for _, v := range volumes {
+		name := "luks-" + v.UUID
+		slog.Info("Opening LUKS volume", slog.String("device", v.Path), slog.String("name", name))
+		_, err := subprocess.RunCommand("cryptsetup", "open", "--type", "luks", "--key-file", keyFile, v.Path, name)
+		if err != nil {
+			_ = closeLUKSVolumes(names)
+			return nil, fmt.Errorf("Failed to open LUKS volume %q: %w", v.Path, err)
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// closeLUKSVolumes closes the mappings of the LUKS volumes in the reverse order they were opened.
+func closeLUKSVolumes(names []string) error {
+	var errs []error
+	for i := len(names) - 1; i >= 0; i-- {
+		_, err := subprocess.RunCommand("cryptsetup", "close", names[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to close LUKS volume %q: %w", names[i], err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeLUKSKeyFile writes the key to a file only readable by root on /run, so it never reaches a disk.
+// A passphrase is written without a trailing newline, as cryptsetup would otherwise include it in the key.
+func writeLUKSKeyFile(key LUKSKey) (string, error) {
+	data := key.KeyFile
+	if len(data) == 0 {
+		data = []byte(key.Passphrase)
+	}
+
+	f, err := os.CreateTemp("/run", "luks-key-")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create LUKS keyfile: %w", err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	_, err = f.Write(data)
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("Failed to write LUKS keyfile: %w", err)
+	}
+
+	return f.Name(), nil
+}
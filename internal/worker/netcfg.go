@@ -0,0 +1,330 @@
+package worker
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+// udevNetRulesFile holds the rules written by add-udev-network-rules.sh, pinning interface names to hardware addresses.
+const udevNetRulesFile = "etc/udev/rules.d/00-net-symlink.rules"
+
+var udevNetRule = regexp.MustCompile(`ATTR\{address\}=="([^"]+)", NAME="([^"]+)"`)
+
+// interfaceToken matches a token in a configuration file that may name an interface.
+var interfaceToken = regexp.MustCompile(`[^\s,;'"=]+`)
+
+// guestNIC is the configuration applied to a network interface in the guest.
+type guestNIC struct {
+	api.NICConfig
+
+	hwaddr string
+	name   string
+}
+
+// newName returns the name of the interface after migration.
+func (n guestNIC) newName() string {
+	if n.Name != "" {
+		return n.Name
+	}
+
+	return n.name
+}
+
+// addresses returns the addresses of the given family in CIDR notation.
+func (n guestNIC) addresses(ipv6 bool) []string {
+	return filterFamily(n.Addresses, ipv6)
+}
+
+// nameservers returns the DNS servers of the given family.
+func (n guestNIC) nameservers(ipv6 bool) []string {
+	return filterFamily(n.Nameservers, ipv6)
+}
+
+func (n guestNIC) gateway(ipv6 bool) string {
+	if ipv6 {
+		return n.Gateway6
+	}
+
+	return n.Gateway4
+}
+
+func (n guestNIC) hasDNS() bool {
+	return len(n.Nameservers) > 0 || len(n.SearchDomains) > 0
+}
+
+// isIPv6 reports whether the address, with or without a prefix, is an IPv6 address.
+func isIPv6(address string) bool {
+	addr, _, _ := strings.Cut(address, "/")
+	ip, err := netip.ParseAddr(addr)
+	return err == nil && ip.Is6()
+}
+
+func filterFamily(addresses []string, ipv6 bool) []string {
+	ret := []string{}
+	for _, addr := range addresses {
+		if isIPv6(addr) == ipv6 {
+			ret = append(ret, addr)
+		}
+	}
+
+	return ret
+}
+
+// translateNetworkConfig renames and re-addresses the network interfaces of the guest mounted at root, according to the NIC overrides of the instance.
+// Interfaces are identified through the udev rules written by add-udev-network-rules.sh, so it must have run first.
+func translateNetworkConfig(root string, overrides map[string]api.NICConfig) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	rules, err := os.ReadFile(filepath.Join(root, udevNetRulesFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	names := map[string]string{}
+	for _, match := range udevNetRule.FindAllStringSubmatch(string(rules), -1) {
+		names[strings.ToLower(match[1])] = match[2]
+	}
+
+	nics := []guestNIC{}
+	for hwaddr, cfg := range overrides {
+		hwaddr = strings.ToLower(hwaddr)
+
+		// add-udev-network-rules.sh names interfaces by NIC order, which is wrong if the NICs are laid out differently on the target.
+		// Guest configuration that matches the interface by hardware address is more reliable, so it takes precedence.
+		name, err := configuredInterfaceName(root, hwaddr)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			name = names[hwaddr]
+		} else if names[hwaddr] != "" && names[hwaddr] != name {
+			slog.Warn("Guest configuration names NIC differently from its udev rule, using the guest configuration", slog.String("hwaddr", hwaddr), slog.String("name", name), slog.String("udev_name", names[hwaddr]))
+		}
+
+		if name == "" {
+			slog.Warn("Skipping network configuration for NIC without a known interface name", slog.String("hwaddr", hwaddr))
+			continue
+		}
+
+		nics = append(nics, guestNIC{NICConfig: cfg, hwaddr: hwaddr, name: name})
+	}
+
+	if len(nics) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(nics, func(a guestNIC, b guestNIC) int { return strings.Compare(a.name, b.name) })
+
+	// Pin the interfaces to their names by hardware address, so they keep them regardless of the NIC order on the target.
+	err = os.MkdirAll(filepath.Dir(filepath.Join(root, udevNetRulesFile)), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(root, udevNetRulesFile), []byte(translateUdevNetRules(string(rules), nics)), 0o644)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]func(string) (string, error){
+		"etc/network/interfaces": func(content string) (string, error) {
+			return translateInterfaces(content, nics), nil
+		},
+		"etc/sysconfig/network/config": func(content string) (string, error) {
+			return translateWickedConfig(content, nics), nil
+		},
+	}
+
+	globs := map[string]func(string) (string, error){
+		"etc/netplan/*.yaml": func(content string) (string, error) { return translateNetplan(content, nics) },
+		"etc/NetworkManager/system-connections/*.nmconnection": func(content string) (string, error) {
+			return translateNMKeyfile(content, nics), nil
+		},
+		"etc/network/interfaces.d/*": func(content string) (string, error) {
+			return translateInterfaces(content, nics), nil
+		},
+	}
+
+	for pattern, translate := range globs {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			rel, err := filepath.Rel(root, match)
+			if err != nil {
+				return err
+			}
+
+			files[rel] = translate
+		}
+	}
+
+	for name, translate := range files {
+		err := translateFile(filepath.Join(root, name), translate)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Interface config scripts are named after the interface, so they may need to be renamed.
+	err = translateIfcfgDir(filepath.Join(root, "etc/sysconfig/network-scripts"), nics, false)
+	if err != nil {
+		return err
+	}
+
+	return translateIfcfgDir(filepath.Join(root, "etc/sysconfig/network"), nics, true)
+}
+
+// translateFile applies the translation to the file, if it exists.
+func translateFile(path string, translate func(string) (string, error)) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	newContent, err := translate(string(content))
+	if err != nil {
+		return fmt.Errorf("Failed to translate network configuration %q: %w", path, err)
+	}
+
+	if newContent == string(content) {
+		return nil
+	}
+
+	slog.Info("Translating guest network configuration", slog.String("file", path))
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(newContent), info.Mode().Perm())
+}
+
+// renameInterfaces replaces whole tokens naming a renamed interface, including VLAN (eth0.100) and alias (eth0:1) interfaces on top of it.
+func renameInterfaces(value string, nics []guestNIC) string {
+	return interfaceToken.ReplaceAllStringFunc(value, func(token string) string {
+		for _, nic := range nics {
+			if nic.Name == "" {
+				continue
+			}
+
+			if token == nic.name {
+				return nic.newName()
+			}
+
+			for _, sep := range []string{".", ":"} {
+				suffix, ok := strings.CutPrefix(token, nic.name+sep)
+				if ok {
+					return nic.newName() + sep + suffix
+				}
+			}
+		}
+
+		return token
+	})
+}
+
+// translateUdevNetRules pins the hardware addresses of the interfaces to their names after migration, adding rules for any that don't have one.
+// Rules pinning other hardware addresses to one of those names are removed, as they would conflict.
+func translateUdevNetRules(content string, nics []guestNIC) string {
+	lines := []string{}
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	pinned := map[string]bool{}
+	lines = slices.DeleteFunc(lines, func(line string) bool {
+		match := udevNetRule.FindStringSubmatch(line)
+		if match == nil {
+			return false
+		}
+
+		for _, nic := range nics {
+			if strings.EqualFold(match[1], nic.hwaddr) {
+				return false
+			}
+		}
+
+		for _, nic := range nics {
+			if match[2] == nic.newName() {
+				slog.Warn("Removing conflicting udev network rule", slog.String("hwaddr", match[1]), slog.String("name", match[2]))
+				return true
+			}
+		}
+
+		return false
+	})
+
+	for i, line := range lines {
+		lines[i] = udevNetRule.ReplaceAllStringFunc(line, func(rule string) string {
+			match := udevNetRule.FindStringSubmatch(rule)
+			for _, nic := range nics {
+				if strings.EqualFold(match[1], nic.hwaddr) {
+					pinned[nic.hwaddr] = true
+					return fmt.Sprintf(`ATTR{address}=="%s", NAME="%s"`, match[1], nic.newName())
+				}
+			}
+
+			return rule
+		})
+	}
+
+	for _, nic := range nics {
+		if !pinned[nic.hwaddr] {
+			lines = append(lines, fmt.Sprintf(`SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="%s", NAME="%s"`, nic.hwaddr, nic.newName()))
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// configuredInterfaceName returns the name of the interface that the guest's network configuration matches by hardware address, if any.
+func configuredInterfaceName(root string, hwaddr string) (string, error) {
+	lookups := []struct {
+		pattern string
+		name    func(path string, content string) string
+	}{
+		{pattern: "etc/netplan/*.yaml", name: func(_ string, content string) string { return netplanInterfaceName(content, hwaddr) }},
+		{pattern: "etc/NetworkManager/system-connections/*.nmconnection", name: func(_ string, content string) string { return nmInterfaceName(content, hwaddr) }},
+		{pattern: "etc/sysconfig/network-scripts/ifcfg-*", name: func(path string, content string) string { return ifcfgInterfaceName(path, content, hwaddr) }},
+		{pattern: "etc/sysconfig/network/ifcfg-*", name: func(path string, content string) string { return ifcfgInterfaceName(path, content, hwaddr) }},
+	}
+
+	for _, lookup := range lookups {
+		matches, err := filepath.Glob(filepath.Join(root, lookup.pattern))
+		if err != nil {
+			return "", err
+		}
+
+		for _, path := range matches {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+
+			name := lookup.name(path, string(content))
+			if name != "" {
+				return name, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,344 @@
+package worker
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v7/shared/util"
+)
+
+// shellVar is a KEY=value assignment of an ifcfg file.
+var shellVar = regexp.MustCompile(`^\s*([A-Za-z0-9_]+)=(.*)$`)
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// shellVars are the assignments of an ifcfg file, in order.
+type shellVars struct {
+	lines []string
+	quote string
+}
+
+func parseShellVars(content string, quote string) *shellVars {
+	return &shellVars{lines: strings.Split(strings.TrimSuffix(content, "\n"), "\n"), quote: quote}
+}
+
+func (v *shellVars) String() string {
+	return strings.Join(v.lines, "\n") + "\n"
+}
+
+func (v *shellVars) get(key string) string {
+	for _, line := range v.lines {
+		match := shellVar.FindStringSubmatch(line)
+		if match != nil && match[1] == key {
+			return unquote(match[2])
+		}
+	}
+
+	return ""
+}
+
+func (v *shellVars) set(key string, value string) {
+	line := key + "=" + v.quote + value + v.quote
+	for i, existing := range v.lines {
+		match := shellVar.FindStringSubmatch(existing)
+		if match != nil && match[1] == key {
+			v.lines[i] = line
+			return
+		}
+	}
+
+	v.lines = append(v.lines, line)
+}
+
+// deleteFunc removes all assignments for which remove returns true.
+func (v *shellVars) deleteFunc(remove func(key string, value string) bool) {
+	v.lines = slices.DeleteFunc(v.lines, func(line string) bool {
+		match := shellVar.FindStringSubmatch(line)
+		return match != nil && remove(match[1], unquote(match[2]))
+	})
+}
+
+// renameValues renames interfaces referenced by the given keys.
+func (v *shellVars) renameValues(keys *regexp.Regexp, nics []guestNIC) {
+	for i, line := range v.lines {
+		match := shellVar.FindStringSubmatch(line)
+		if match != nil && keys.MatchString(match[1]) {
+			v.lines[i] = match[1] + "=" + renameInterfaces(match[2], nics)
+		}
+	}
+}
+
+var (
+	// ifcfgInterfaceKeys reference interfaces in RHEL and SUSE ifcfg files.
+	ifcfgInterfaceKeys = regexp.MustCompile(`^(DEVICE|NAME|BRIDGE_PORTS|BONDING_SLAVE_?[0-9]*|ETHERDEVICE|PHYSDEV)$`)
+
+	rhelIPv4Keys = regexp.MustCompile(`^(IPADDR|PREFIX|NETMASK)[0-9]*$`)
+	rhelIPv6Keys = regexp.MustCompile(`^(IPV6ADDR|IPV6ADDR_SECONDARIES)$`)
+	rhelDNSKeys  = regexp.MustCompile(`^DNS[0-9]+$`)
+)
+
+// ifcfgInterfaceName returns the name of the interface of an ifcfg file that matches it by hardware address.
+// RHEL uses HWADDR, and SUSE uses LLADDR.
+func ifcfgInterfaceName(path string, content string, hwaddr string) string {
+	vars := parseShellVars(content, `"`)
+	if !strings.EqualFold(vars.get("HWADDR"), hwaddr) && !strings.EqualFold(vars.get("LLADDR"), hwaddr) {
+		return ""
+	}
+
+	if vars.get("DEVICE") != "" {
+		return vars.get("DEVICE")
+	}
+
+	_, iface, _ := strings.Cut(filepath.Base(path), "-")
+	return iface
+}
+
+// translateIfcfgDir translates the ifcfg files in the directory, and renames those named after a renamed interface.
+func translateIfcfgDir(dir string, nics []guestNIC, suse bool) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "if*-*"))
+	if err != nil {
+		return err
+	}
+
+	renamed := map[string]string{}
+	for _, path := range matches {
+		base := filepath.Base(path)
+		prefix, iface, _ := strings.Cut(base, "-")
+		if prefix != "ifcfg" && prefix != "ifroute" {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var newContent string
+		if prefix == "ifroute" {
+			newContent = translateIfroute(string(content), iface, nics)
+		} else {
+			newContent = translateIfcfg(string(content), iface, nics, suse)
+		}
+
+		newPath := filepath.Join(dir, prefix+"-"+renameInterfaces(iface, nics))
+		if newContent == string(content) && newPath == path {
+			continue
+		}
+
+		slog.Info("Translating guest network configuration", slog.String("file", path), slog.String("target", newPath))
+		renamed[newPath] = newContent
+		if newPath != path {
+			err := os.Remove(path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write the files once all renamed files are removed, in case interfaces swap names.
+	for path, content := range renamed {
+		err := os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	// SUSE keeps the default gateway in a separate route file.
+	if suse {
+		for _, nic := range nics {
+			if nic.Gateway4 == "" && nic.Gateway6 == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, "ifroute-"+nic.newName())
+			if !util.PathExists(filepath.Join(dir, "ifcfg-"+nic.newName())) || util.PathExists(path) {
+				continue
+			}
+
+			err := os.WriteFile(path, []byte(translateIfroute("", nic.newName(), []guestNIC{nic})), 0o644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// translateIfcfg renames and re-addresses the interface of a RHEL or SUSE ifcfg file named after iface.
+func translateIfcfg(content string, iface string, nics []guestNIC, suse bool) string {
+	quote := `"`
+	if suse {
+		quote = "'"
+	}
+
+	vars := parseShellVars(content, quote)
+	var nic *guestNIC
+	for i := range nics {
+		if iface == nics[i].name || vars.get("DEVICE") == nics[i].name || strings.EqualFold(vars.get("HWADDR"), nics[i].hwaddr) || strings.EqualFold(vars.get("LLADDR"), nics[i].hwaddr) {
+			nic = &nics[i]
+			break
+		}
+	}
+
+	vars.renameValues(ifcfgInterfaceKeys, nics)
+	if nic == nil {
+		return vars.String()
+	}
+
+	if suse {
+		translateSUSEIfcfg(vars, *nic)
+	} else {
+		translateRHELIfcfg(vars, *nic)
+	}
+
+	return vars.String()
+}
+
+func translateRHELIfcfg(vars *shellVars, nic guestNIC) {
+	addresses := nic.addresses(false)
+	if len(addresses) > 0 {
+		vars.deleteFunc(func(key string, _ string) bool { return rhelIPv4Keys.MatchString(key) })
+		vars.set("BOOTPROTO", "none")
+		for i, addr := range addresses {
+			ip, prefix, _ := strings.Cut(addr, "/")
+			vars.set("IPADDR"+strconv.Itoa(i), ip)
+			vars.set("PREFIX"+strconv.Itoa(i), prefix)
+		}
+	}
+
+	addresses = nic.addresses(true)
+	if len(addresses) > 0 {
+		vars.deleteFunc(func(key string, _ string) bool { return rhelIPv6Keys.MatchString(key) })
+		vars.set("IPV6INIT", "yes")
+		vars.set("IPV6_AUTOCONF", "no")
+		vars.set("IPV6ADDR", addresses[0])
+		if len(addresses) > 1 {
+			vars.set("IPV6ADDR_SECONDARIES", strings.Join(addresses[1:], " "))
+		}
+	}
+
+	if nic.Gateway4 != "" {
+		vars.deleteFunc(func(key string, _ string) bool { return strings.HasPrefix(key, "GATEWAY") })
+		vars.set("GATEWAY", nic.Gateway4)
+	}
+
+	if nic.Gateway6 != "" {
+		vars.set("IPV6_DEFAULTGW", nic.Gateway6)
+	}
+
+	if len(nic.Nameservers) > 0 {
+		vars.deleteFunc(func(key string, _ string) bool { return rhelDNSKeys.MatchString(key) })
+		for i, server := range nic.Nameservers {
+			vars.set("DNS"+strconv.Itoa(i+1), server)
+		}
+
+		vars.set("PEERDNS", "no")
+	}
+
+	if len(nic.SearchDomains) > 0 {
+		vars.set("DOMAIN", strings.Join(nic.SearchDomains, " "))
+	}
+}
+
+func translateSUSEIfcfg(vars *shellVars, nic guestNIC) {
+	for _, ipv6 := range []bool{false, true} {
+		addresses := nic.addresses(ipv6)
+		if len(addresses) == 0 {
+			continue
+		}
+
+		// Addresses of both families share the IPADDR variables, distinguished by their suffix.
+		suffixes := map[string]bool{}
+		vars.deleteFunc(func(key string, value string) bool {
+			suffix, ok := strings.CutPrefix(key, "IPADDR")
+			if ok && isIPv6(value) == ipv6 {
+				suffixes[suffix] = true
+				return true
+			}
+
+			return false
+		})
+
+		vars.deleteFunc(func(key string, _ string) bool {
+			for _, prefix := range []string{"NETMASK", "PREFIXLEN"} {
+				suffix, ok := strings.CutPrefix(key, prefix)
+				if ok && suffixes[suffix] {
+					return true
+				}
+			}
+
+			return false
+		})
+
+		vars.set("BOOTPROTO", "static")
+		next := 0
+		for _, addr := range addresses {
+			for {
+				key := "IPADDR_" + strconv.Itoa(next)
+				if next == 0 {
+					key = "IPADDR"
+				}
+
+				next++
+				if vars.get(key) == "" {
+					vars.set(key, addr)
+					break
+				}
+			}
+		}
+	}
+}
+
+// translateIfroute renames and replaces the default routes of a SUSE ifroute file for iface.
+func translateIfroute(content string, iface string, nics []guestNIC) string {
+	lines := []string{}
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	var nic *guestNIC
+	for i := range nics {
+		if iface == nics[i].name || iface == nics[i].newName() {
+			nic = &nics[i]
+		}
+	}
+
+	if nic == nil {
+		return content
+	}
+
+	for _, ipv6 := range []bool{false, true} {
+		gateway := nic.gateway(ipv6)
+		if gateway == "" {
+			continue
+		}
+
+		lines = slices.DeleteFunc(lines, func(line string) bool {
+			fields := strings.Fields(line)
+			return len(fields) >= 2 && slices.Contains([]string{"default", "0.0.0.0/0", "::/0"}, fields[0]) && isIPv6(fields[1]) == ipv6
+		})
+
+		lines = append(lines, "default "+gateway+" - "+nic.newName())
+	}
+
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines[i] = renameInterfaces(line, nics)
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
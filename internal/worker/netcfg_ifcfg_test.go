@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateIfcfg(t *testing.T) {
+	tests := []struct {
+		name    string
+		iface   string
+		content string
+		suse    bool
+
+		want string
+	}{
+		{
+			name:  "rhel",
+			iface: "ens192",
+			content: `TYPE=Ethernet
+BOOTPROTO=dhcp
+DEVICE=ens192
+NAME=ens192
+ONBOOT=yes
+`,
+
+			want: `TYPE=Ethernet
+BOOTPROTO="none"
+DEVICE=eth0
+NAME=eth0
+ONBOOT=yes
+IPADDR0="10.0.1.10"
+PREFIX0="24"
+IPV6INIT="yes"
+IPV6_AUTOCONF="no"
+IPV6ADDR="fd42::10/64"
+GATEWAY="10.0.1.1"
+IPV6_DEFAULTGW="fd42::1"
+DNS1="10.0.1.2"
+PEERDNS="no"
+DOMAIN="example.com"
+`,
+		},
+		{
+			name:  "suse",
+			iface: "ens192",
+			content: `BOOTPROTO='static'
+STARTMODE='auto'
+IPADDR='192.168.0.10/24'
+IPADDR_1='fd00::10/64'
+`,
+			suse: true,
+
+			want: `BOOTPROTO='static'
+STARTMODE='auto'
+IPADDR='10.0.1.10/24'
+IPADDR_1='fd42::10/64'
+`,
+		},
+		{
+			name:  "suse bridge port",
+			iface: "br0",
+			content: `BOOTPROTO='dhcp'
+BRIDGE='yes'
+BRIDGE_PORTS='ens224'
+`,
+			suse: true,
+
+			want: `BOOTPROTO='dhcp'
+BRIDGE='yes'
+BRIDGE_PORTS='eth1'
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, translateIfcfg(tc.content, tc.iface, testNICs, tc.suse))
+		})
+	}
+}
+
+func TestTranslateIfroute(t *testing.T) {
+	ifroute := `default 192.168.0.1 - ens192
+10.10.0.0/16 192.168.0.254 - ens192
+`
+
+	want := `10.10.0.0/16 192.168.0.254 - eth0
+default 10.0.1.1 - eth0
+default fd42::1 - eth0
+`
+
+	require.Equal(t, want, translateIfroute(ifroute, "ens192", testNICs))
+}
+
+func TestIfcfgInterfaceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+
+		want string
+	}{
+		{
+			name:    "rhel device",
+			path:    "/etc/sysconfig/network-scripts/ifcfg-eth0",
+			content: "DEVICE=ens192\nHWADDR=00:0C:29:A1:76:30\n",
+			want:    "ens192",
+		},
+		{
+			name:    "suse file name",
+			path:    "/etc/sysconfig/network/ifcfg-ens192",
+			content: "BOOTPROTO='dhcp'\nLLADDR='00:0c:29:a1:76:30'\n",
+			want:    "ens192",
+		},
+		{
+			name:    "other hardware address",
+			path:    "/etc/sysconfig/network-scripts/ifcfg-ens224",
+			content: "DEVICE=ens224\nHWADDR=00:0c:29:a1:76:31\n",
+			want:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ifcfgInterfaceName(tc.path, tc.content, "00:0c:29:a1:76:30"))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ifupdownStanza matches the keywords that start a new stanza in /etc/network/interfaces.
+var ifupdownStanza = regexp.MustCompile(`^(iface|auto|allow-[a-z]+|mapping|source|source-directory|rename)\b`)
+
+// ifupdownAddressKeys are the options of an iface stanza replaced when re-addressing the interface.
+var ifupdownAddressKeys = []string{"address", "netmask", "broadcast", "network", "gateway"}
+
+// translateInterfaces renames and re-addresses interfaces in an ifupdown configuration.
+func translateInterfaces(content string, nics []guestNIC) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines[i] = renameInterfaces(line, nics)
+		}
+	}
+
+	for _, nic := range nics {
+		for _, ipv6 := range []bool{false, true} {
+			family := "inet"
+			if ipv6 {
+				family = "inet6"
+			}
+
+			addresses := nic.addresses(ipv6)
+			gateway := nic.gateway(ipv6)
+			withDNS := !ipv6 && nic.hasDNS()
+			if len(addresses) == 0 && gateway == "" && !withDNS {
+				continue
+			}
+
+			// Find the stanza of the interface for this address family.
+			start, end := -1, len(lines)
+			for i, line := range lines {
+				fields := strings.Fields(line)
+				if start < 0 && len(fields) >= 3 && fields[0] == "iface" && fields[1] == nic.newName() && fields[2] == family {
+					start = i
+					continue
+				}
+
+				if start >= 0 && ifupdownStanza.MatchString(line) {
+					end = i
+					break
+				}
+			}
+
+			if start < 0 {
+				if len(addresses) == 0 {
+					continue
+				}
+
+				lines = append(lines, "", "iface "+nic.newName()+" "+family+" static")
+				start, end = len(lines)-1, len(lines)
+			}
+
+			// Drop trailing blank lines from the stanza, so new options are added right after the existing ones.
+			for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+
+			removed := []string{}
+			if len(addresses) > 0 {
+				lines[start] = "iface " + nic.newName() + " " + family + " static"
+				removed = append(removed, ifupdownAddressKeys...)
+			} else if gateway != "" {
+				removed = append(removed, "gateway")
+			}
+
+			if withDNS {
+				if len(nic.Nameservers) > 0 {
+					removed = append(removed, "dns-nameservers")
+				}
+
+				if len(nic.SearchDomains) > 0 {
+					removed = append(removed, "dns-search")
+				}
+			}
+
+			body := slices.DeleteFunc(slices.Clone(lines[start+1:end]), func(line string) bool {
+				fields := strings.Fields(line)
+				return len(fields) > 0 && slices.Contains(removed, fields[0])
+			})
+
+			for _, addr := range addresses {
+				body = append(body, "    address "+addr)
+			}
+
+			if gateway != "" {
+				body = append(body, "    gateway "+gateway)
+			}
+
+			if withDNS && len(nic.Nameservers) > 0 {
+				body = append(body, "    dns-nameservers "+strings.Join(nic.Nameservers, " "))
+			}
+
+			if withDNS && len(nic.SearchDomains) > 0 {
+				body = append(body, "    dns-search "+strings.Join(nic.SearchDomains, " "))
+			}
+
+			lines = slices.Concat(lines[:start+1], body, lines[end:])
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
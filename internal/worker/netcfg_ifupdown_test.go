@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateInterfaces(t *testing.T) {
+	interfaces := `source /etc/network/interfaces.d/*
+
+auto lo
+iface lo inet loopback
+
+# The primary network interface
+allow-hotplug ens192
+iface ens192 inet static
+    address 192.168.0.10/24
+    gateway 192.168.0.1
+    dns-nameservers 192.168.0.2
+    mtu 9000
+
+auto ens224
+iface ens224 inet dhcp
+`
+
+	want := `source /etc/network/interfaces.d/*
+
+auto lo
+iface lo inet loopback
+
+# The primary network interface
+allow-hotplug eth0
+iface eth0 inet static
+    mtu 9000
+    address 10.0.1.10/24
+    gateway 10.0.1.1
+    dns-nameservers 10.0.1.2
+    dns-search example.com
+
+auto eth1
+iface eth1 inet dhcp
+
+iface eth0 inet6 static
+    address fd42::10/64
+    gateway fd42::1
+`
+
+	require.Equal(t, want, translateInterfaces(interfaces, testNICs))
+}
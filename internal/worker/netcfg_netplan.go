@@ -0,0 +1,235 @@
+package worker
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// translateNetplan renames and re-addresses interfaces in a netplan configuration.
+func translateNetplan(content string, nics []guestNIC) (string, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(content), &doc)
+	if err != nil {
+		return "", err
+	}
+
+	if len(doc.Content) == 0 {
+		return content, nil
+	}
+
+	network := yamlMapValue(doc.Content[0], "network")
+	if network == nil {
+		return content, nil
+	}
+
+	before, err := encodeYAML(&doc)
+	if err != nil {
+		return "", err
+	}
+
+	// Rename the interfaces, and any references to them from virtual interfaces.
+	for _, section := range []string{"ethernets", "bonds", "bridges", "vlans"} {
+		devices := yamlMapValue(network, section)
+		if devices == nil || devices.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for i := 0; i < len(devices.Content); i += 2 {
+			devices.Content[i].Value = renameInterfaces(devices.Content[i].Value, nics)
+			device := devices.Content[i+1]
+			for _, key := range []string{"set-name", "link"} {
+				value := yamlMapValue(device, key)
+				if value != nil {
+					value.Value = renameInterfaces(value.Value, nics)
+				}
+			}
+
+			interfaces := yamlMapValue(device, "interfaces")
+			if interfaces != nil {
+				for _, iface := range interfaces.Content {
+					iface.Value = renameInterfaces(iface.Value, nics)
+				}
+			}
+		}
+	}
+
+	ethernets := yamlMapValue(network, "ethernets")
+	for _, nic := range nics {
+		device := yamlMapValue(ethernets, nic.newName())
+		if device == nil || device.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for _, ipv6 := range []bool{false, true} {
+			dhcpKey, gatewayKey := "dhcp4", "gateway4"
+			if ipv6 {
+				dhcpKey, gatewayKey = "dhcp6", "gateway6"
+			}
+
+			addresses := nic.addresses(ipv6)
+			if len(addresses) > 0 {
+				values := []string{}
+				existing := yamlMapValue(device, "addresses")
+				if existing != nil {
+					for _, addr := range existing.Content {
+						if addr.Kind == yaml.ScalarNode && isIPv6(addr.Value) != ipv6 {
+							values = append(values, addr.Value)
+						}
+					}
+				}
+
+				yamlMapSet(device, "addresses", yamlSeq(append(values, addresses...)))
+				yamlMapSet(device, dhcpKey, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+			}
+
+			gateway := nic.gateway(ipv6)
+			if gateway != "" {
+				yamlMapDelete(device, gatewayKey)
+				routes := &yaml.Node{Kind: yaml.SequenceNode}
+				existing := yamlMapValue(device, "routes")
+				if existing != nil {
+					for _, route := range existing.Content {
+						to := yamlMapValue(route, "to")
+						via := yamlMapValue(route, "via")
+						if to != nil && via != nil && isIPv6(via.Value) == ipv6 && slices.Contains([]string{"default", "0.0.0.0/0", "::/0"}, to.Value) {
+							continue
+						}
+
+						routes.Content = append(routes.Content, route)
+					}
+				}
+
+				route := &yaml.Node{Kind: yaml.MappingNode}
+				yamlMapSet(route, "to", yamlScalar("default"))
+				yamlMapSet(route, "via", yamlScalar(gateway))
+				routes.Content = append(routes.Content, route)
+				yamlMapSet(device, "routes", routes)
+			}
+		}
+
+		if nic.hasDNS() {
+			nameservers := yamlMapValue(device, "nameservers")
+			if nameservers == nil || nameservers.Kind != yaml.MappingNode {
+				nameservers = &yaml.Node{Kind: yaml.MappingNode}
+				yamlMapSet(device, "nameservers", nameservers)
+			}
+
+			if len(nic.Nameservers) > 0 {
+				yamlMapSet(nameservers, "addresses", yamlSeq(nic.Nameservers))
+			}
+
+			if len(nic.SearchDomains) > 0 {
+				yamlMapSet(nameservers, "search", yamlSeq(nic.SearchDomains))
+			}
+		}
+	}
+
+	after, err := encodeYAML(&doc)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the original formatting if nothing changed.
+	if after == before {
+		return content, nil
+	}
+
+	return after, nil
+}
+
+// netplanInterfaceName returns the name of the ethernet device that the netplan configuration matches by hardware address.
+func netplanInterfaceName(content string, hwaddr string) string {
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(content), &doc)
+	if err != nil || len(doc.Content) == 0 {
+		return ""
+	}
+
+	ethernets := yamlMapValue(yamlMapValue(doc.Content[0], "network"), "ethernets")
+	if ethernets == nil {
+		return ""
+	}
+
+	for i := 0; i+1 < len(ethernets.Content); i += 2 {
+		macaddress := yamlMapValue(yamlMapValue(ethernets.Content[i+1], "match"), "macaddress")
+		if macaddress == nil || !strings.EqualFold(macaddress.Value, hwaddr) {
+			continue
+		}
+
+		setName := yamlMapValue(ethernets.Content[i+1], "set-name")
+		if setName != nil {
+			return setName.Value
+		}
+
+		return ethernets.Content[i].Value
+	}
+
+	return ""
+}
+
+func encodeYAML(node *yaml.Node) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(node)
+	if err != nil {
+		return "", err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func yamlMapSet(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, yamlScalar(key), value)
+}
+
+func yamlMapDelete(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = slices.Delete(node.Content, i, i+2)
+			return
+		}
+	}
+}
+
+func yamlScalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func yamlSeq(values []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, value := range values {
+		node.Content = append(node.Content, yamlScalar(value))
+	}
+
+	return node
+}
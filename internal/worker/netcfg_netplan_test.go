@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateNetplan(t *testing.T) {
+	netplan := `network:
+  version: 2
+  ethernets:
+    ens192:
+      addresses:
+        - 192.168.0.10/24
+        - fd00::10/64
+      gateway4: 192.168.0.1
+      nameservers:
+        addresses: [192.168.0.2]
+    ens224:
+      dhcp4: true
+  vlans:
+    ens224.100:
+      id: 100
+      link: ens224
+`
+
+	want := `network:
+  version: 2
+  ethernets:
+    eth0:
+      addresses:
+        - 10.0.1.10/24
+        - fd42::10/64
+      nameservers:
+        addresses:
+          - 10.0.1.2
+        search:
+          - example.com
+      dhcp4: false
+      routes:
+        - to: default
+          via: 10.0.1.1
+        - to: default
+          via: fd42::1
+      dhcp6: false
+    eth1:
+      dhcp4: true
+  vlans:
+    eth1.100:
+      id: 100
+      link: eth1
+`
+
+	got, err := translateNetplan(netplan, testNICs)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// Unrelated configuration is left untouched.
+	unrelated := "network:\n    ethernets:\n        eth5: {dhcp4: true}\n"
+	got, err = translateNetplan(unrelated, testNICs)
+	require.NoError(t, err)
+	require.Equal(t, unrelated, got)
+}
+
+func TestNetplanInterfaceName(t *testing.T) {
+	netplan := `network:
+  version: 2
+  ethernets:
+    lan:
+      match:
+        macaddress: "00:0C:29:A1:76:30"
+      set-name: ens192
+    id1:
+      match:
+        macaddress: "00:0c:29:a1:76:31"
+    ens256:
+      dhcp4: true
+`
+
+	tests := []struct {
+		name   string
+		hwaddr string
+
+		want string
+	}{
+		{name: "set-name", hwaddr: "00:0c:29:a1:76:30", want: "ens192"},
+		{name: "device ID", hwaddr: "00:0c:29:a1:76:31", want: "id1"},
+		{name: "no match", hwaddr: "00:0c:29:a1:76:32", want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, netplanInterfaceName(netplan, tc.hwaddr))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package worker
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// iniSection is a section of a NetworkManager keyfile.
+type iniSection struct {
+	name  string
+	lines []string
+}
+
+func parseINI(content string) []*iniSection {
+	sections := []*iniSection{{}}
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			sections = append(sections, &iniSection{name: strings.Trim(trimmed, "[]")})
+		}
+
+		sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, line)
+	}
+
+	return sections
+}
+
+func formatINI(sections []*iniSection) string {
+	lines := []string{}
+	for _, section := range sections {
+		lines = append(lines, section.lines...)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func findINISection(sections []*iniSection, name string) *iniSection {
+	for _, section := range sections {
+		if section.name == name {
+			return section
+		}
+	}
+
+	return nil
+}
+
+func (s *iniSection) get(key string) string {
+	for _, line := range s.lines {
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+
+	return ""
+}
+
+// set replaces the first assignment of key, or adds one after the last assignment in the section.
+func (s *iniSection) set(key string, value string) {
+	last := 0
+	for i, line := range s.lines {
+		k, _, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		if strings.TrimSpace(k) == key {
+			s.lines[i] = key + "=" + value
+			return
+		}
+
+		last = i
+	}
+
+	s.lines = slices.Insert(s.lines, last+1, key+"="+value)
+}
+
+// delete removes all assignments of keys matching the pattern.
+func (s *iniSection) delete(pattern *regexp.Regexp) {
+	s.lines = slices.DeleteFunc(s.lines, func(line string) bool {
+		k, _, ok := strings.Cut(line, "=")
+		return ok && pattern.MatchString(strings.TrimSpace(k))
+	})
+}
+
+var (
+	nmAddressKey = regexp.MustCompile(`^address(es)?[0-9]*$`)
+	nmGatewayKey = regexp.MustCompile(`^gateway$`)
+)
+
+// nmInterfaceName returns the name of the interface of a NetworkManager connection that matches it by hardware address.
+func nmInterfaceName(content string, hwaddr string) string {
+	sections := parseINI(content)
+	connection := findINISection(sections, "connection")
+	ethernet := findINISection(sections, "ethernet")
+	if connection == nil || ethernet == nil || !strings.EqualFold(ethernet.get("mac-address"), hwaddr) {
+		return ""
+	}
+
+	return connection.get("interface-name")
+}
+
+// translateNMKeyfile renames and re-addresses the interface of a NetworkManager connection.
+func translateNMKeyfile(content string, nics []guestNIC) string {
+	sections := parseINI(content)
+	connection := findINISection(sections, "connection")
+	if connection == nil {
+		return content
+	}
+
+	var nic *guestNIC
+	ethernet := findINISection(sections, "ethernet")
+	for i := range nics {
+		if connection.get("interface-name") == nics[i].name || (ethernet != nil && strings.EqualFold(ethernet.get("mac-address"), nics[i].hwaddr)) {
+			nic = &nics[i]
+			break
+		}
+	}
+
+	if nic == nil {
+		return content
+	}
+
+	if connection.get("interface-name") == nic.name {
+		connection.set("interface-name", nic.newName())
+	}
+
+	if connection.get("id") == nic.name {
+		connection.set("id", nic.newName())
+	}
+
+	for _, ipv6 := range []bool{false, true} {
+		name := "ipv4"
+		if ipv6 {
+			name = "ipv6"
+		}
+
+		addresses := nic.addresses(ipv6)
+		gateway := nic.gateway(ipv6)
+		nameservers := nic.nameservers(ipv6)
+		searchDomains := []string{}
+		if !ipv6 {
+			searchDomains = nic.SearchDomains
+		}
+
+		if len(addresses) == 0 && gateway == "" && len(nameservers) == 0 && len(searchDomains) == 0 {
+			continue
+		}
+
+		section := findINISection(sections, name)
+		if section == nil {
+			section = &iniSection{name: name, lines: []string{"", "[" + name + "]"}}
+			sections = append(sections, section)
+		}
+
+		if len(addresses) > 0 {
+			section.delete(nmAddressKey)
+			section.set("method", "manual")
+			for i, addr := range addresses {
+				section.set("address"+strconv.Itoa(i+1), addr)
+			}
+		}
+
+		if gateway != "" {
+			// Gateways may also be given along with the first address.
+			for i, line := range section.lines {
+				k, v, ok := strings.Cut(line, "=")
+				if ok && nmAddressKey.MatchString(strings.TrimSpace(k)) {
+					addr, _, _ := strings.Cut(v, ",")
+					section.lines[i] = strings.TrimSpace(k) + "=" + addr
+				}
+			}
+
+			section.delete(nmGatewayKey)
+			section.set("gateway", gateway)
+		}
+
+		if len(nameservers) > 0 {
+			section.set("dns", strings.Join(nameservers, ";")+";")
+			section.set("ignore-auto-dns", "true")
+		}
+
+		if len(searchDomains) > 0 {
+			section.set("dns-search", strings.Join(searchDomains, ";")+";")
+		}
+	}
+
+	return formatINI(sections)
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateNMKeyfile(t *testing.T) {
+	keyfile := `[connection]
+id=ens192
+type=ethernet
+interface-name=ens192
+
+[ipv4]
+address1=192.168.0.10/24,192.168.0.1
+dns=192.168.0.2;
+method=manual
+
+[ipv6]
+method=auto
+`
+
+	want := `[connection]
+id=eth0
+type=ethernet
+interface-name=eth0
+
+[ipv4]
+dns=10.0.1.2;
+method=manual
+address1=10.0.1.10/24
+gateway=10.0.1.1
+ignore-auto-dns=true
+dns-search=example.com;
+
+[ipv6]
+method=manual
+address1=fd42::10/64
+gateway=fd42::1
+`
+
+	require.Equal(t, want, translateNMKeyfile(keyfile, testNICs))
+}
+
+func TestNMInterfaceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyfile string
+
+		want string
+	}{
+		{
+			name:    "matched by hardware address",
+			keyfile: "[connection]\nid=lan\ninterface-name=ens192\n\n[ethernet]\nmac-address=00:0C:29:A1:76:30\n",
+			want:    "ens192",
+		},
+		{
+			name:    "other hardware address",
+			keyfile: "[connection]\nid=lan\ninterface-name=ens192\n\n[ethernet]\nmac-address=00:0c:29:a1:76:31\n",
+			want:    "",
+		},
+		{
+			name:    "matched by name",
+			keyfile: "[connection]\nid=lan\ninterface-name=ens192\n",
+			want:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, nmInterfaceName(tc.keyfile, "00:0c:29:a1:76:30"))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/FuturFusion/migration-manager/shared/api"
+)
+
+var testNICs = []guestNIC{
+	{
+		hwaddr: "00:0c:29:a1:76:30",
+		name:   "ens192",
+		NICConfig: api.NICConfig{
+			Name:          "eth0",
+			Addresses:     []string{"10.0.1.10/24", "fd42::10/64"},
+			Gateway4:      "10.0.1.1",
+			Gateway6:      "fd42::1",
+			Nameservers:   []string{"10.0.1.2"},
+			SearchDomains: []string{"example.com"},
+		},
+	},
+	{
+		hwaddr:    "00:0c:29:a1:76:31",
+		name:      "ens224",
+		NICConfig: api.NICConfig{Name: "eth1"},
+	},
+}
+
+func TestTranslateUdevNetRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+
+		want string
+	}{
+		{
+			name: "renamed",
+			rules: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="ens192"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens224"
+`,
+
+			want: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="eth0"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="eth1"
+`,
+		},
+		{
+			name: "conflicting rule removed",
+			rules: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:32", NAME="eth0"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:33", NAME="ens256"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens224"
+`,
+
+			want: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:33", NAME="ens256"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="eth1"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="eth0"
+`,
+		},
+		{
+			name: "no rules",
+
+			want: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="eth0"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="eth1"
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, translateUdevNetRules(tc.rules, testNICs))
+		})
+	}
+}
+
+func TestTranslateNetworkConfig(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		udevNetRulesFile: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="ens192"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens224"
+`,
+		"etc/sysconfig/network-scripts/ifcfg-ens192": "DEVICE=ens192\nBOOTPROTO=dhcp\n",
+		"etc/sysconfig/network-scripts/ifcfg-ens224": "DEVICE=ens224\nBOOTPROTO=dhcp\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+
+	// Swap the interface names, and re-address the first one.
+	err := translateNetworkConfig(root, map[string]api.NICConfig{
+		"00:0C:29:A1:76:30": {Name: "ens224", Addresses: []string{"10.0.1.10/24"}},
+		"00:0c:29:a1:76:31": {Name: "ens192"},
+	})
+	require.NoError(t, err)
+
+	rules, err := os.ReadFile(filepath.Join(root, udevNetRulesFile))
+	require.NoError(t, err)
+	require.Equal(t, `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="ens224"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens192"
+`, string(rules))
+
+	ifcfg, err := os.ReadFile(filepath.Join(root, "etc/sysconfig/network-scripts/ifcfg-ens224"))
+	require.NoError(t, err)
+	require.Equal(t, "DEVICE=ens224\nBOOTPROTO=\"none\"\nIPADDR0=\"10.0.1.10\"\nPREFIX0=\"24\"\n", string(ifcfg))
+
+	ifcfg, err = os.ReadFile(filepath.Join(root, "etc/sysconfig/network-scripts/ifcfg-ens192"))
+	require.NoError(t, err)
+	require.Equal(t, "DEVICE=ens192\nBOOTPROTO=dhcp\n", string(ifcfg))
+}
+
+func TestTranslateNetworkConfigByHardwareAddress(t *testing.T) {
+	root := t.TempDir()
+
+	// The udev rules were written by NIC order, but the NICs are in a different order on the target.
+	files := map[string]string{
+		udevNetRulesFile: `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:30", NAME="ens192"
+SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens224"
+`,
+		"etc/sysconfig/network-scripts/ifcfg-ens192": "DEVICE=ens192\nHWADDR=00:0c:29:a1:76:31\nBOOTPROTO=dhcp\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+
+	err := translateNetworkConfig(root, map[string]api.NICConfig{
+		"00:0c:29:a1:76:31": {Addresses: []string{"10.0.1.10/24"}},
+	})
+	require.NoError(t, err)
+
+	rules, err := os.ReadFile(filepath.Join(root, udevNetRulesFile))
+	require.NoError(t, err)
+	require.Equal(t, `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="00:0c:29:a1:76:31", NAME="ens192"
+`, string(rules))
+
+	ifcfg, err := os.ReadFile(filepath.Join(root, "etc/sysconfig/network-scripts/ifcfg-ens192"))
+	require.NoError(t, err)
+	require.Equal(t, "DEVICE=ens192\nHWADDR=00:0c:29:a1:76:31\nBOOTPROTO=\"none\"\nIPADDR0=\"10.0.1.10\"\nPREFIX0=\"24\"\n", string(ifcfg))
+}
@@ -0,0 +1,21 @@
+package worker
+
+import (
+	"strings"
+)
+
+// translateWickedConfig sets the static DNS configuration of SUSE guests.
+func translateWickedConfig(content string, nics []guestNIC) string {
+	vars := parseShellVars(content, `"`)
+	for _, nic := range nics {
+		if len(nic.Nameservers) > 0 {
+			vars.set("NETCONFIG_DNS_STATIC_SERVERS", strings.Join(nic.Nameservers, " "))
+		}
+
+		if len(nic.SearchDomains) > 0 {
+			vars.set("NETCONFIG_DNS_STATIC_SEARCHLIST", strings.Join(nic.SearchDomains, " "))
+		}
+	}
+
+	return vars.String()
+}
@@ -0,0 +1,20 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateWickedConfig(t *testing.T) {
+	config := `NETCONFIG_DNS_POLICY="auto"
+NETCONFIG_DNS_STATIC_SERVERS="192.168.0.2"
+`
+
+	want := `NETCONFIG_DNS_POLICY="auto"
+NETCONFIG_DNS_STATIC_SERVERS="10.0.1.2"
+NETCONFIG_DNS_STATIC_SEARCHLIST="example.com"
+`
+
+	require.Equal(t, want, translateWickedConfig(config, testNICs))
+}
@@ -3,6 +3,7 @@
 set -ex
 
 disk_type="${1:-"plain"}"
+encrypted="${2:-"false"}"
 
 if test -e '/etc/dracut.conf.d' ; then
   echo "Loading drivers via dracut"
@@ -14,6 +15,16 @@ if test -e '/etc/dracut.conf.d' ; then
    echo "${line}" >> "${conf_file}"
   fi
 
+  if [ "${encrypted}" = "true" ] ; then
+    # Add the crypt module to the initrd so the LUKS volumes can be unlocked at boot.
+    line='add_dracutmodules+=" crypt "'
+    conf_file="/etc/dracut.conf.d/crypt.conf"
+    if ! test -e "${conf_file}" || ! tail -1 "${conf_file}" | grep -q "^${line}$" ; then
+     echo "Adding crypt module to dracut.conf.d"
+     echo "${line}" >> "${conf_file}"
+    fi
+  fi
+
   if dracut --help 2>&1 | grep -q -- "--regenerate-all" ; then
     dracut --regenerate-all -f
   else
@@ -36,12 +47,16 @@ if test -e '/etc/dracut.conf.d' ; then
 else
   echo "Loading drivers via mkinitrd"
   modules="$(grep "^INITRD_MODULES=" /etc/sysconfig/kernel | head -1)"
+  extra_modules=""
+  if [ "${encrypted}" = "true" ] ; then
+    extra_modules=" dm_crypt"
+  fi
 
   if [ -z "${modules}" ] ; then
     echo "Failed to find INITRD_MODULES, creating key"
-    echo 'INITRD_MODULES="virtio virtio_blk virtio_net virtio_pci"' >> /etc/sysconfig/kernel
+    echo "INITRD_MODULES=\"virtio virtio_blk virtio_net virtio_pci${extra_modules}\"" >> /etc/sysconfig/kernel
   else
-    modules="$(echo "${modules}" |cut -d'"' -f1-2) virtio virtio_blk virtio_net virtio_pci\""
+    modules="$(echo "${modules}" |cut -d'"' -f1-2) virtio virtio_blk virtio_net virtio_pci${extra_modules}\""
     sed -e "s/^INITRD_MODULES=.*/${modules}/" -i /etc/sysconfig/kernel
   fi
 
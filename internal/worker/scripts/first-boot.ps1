@@ -34,3 +34,26 @@ if (test-path "C:\migration-manager-virtio-assign-netcfg.ps1") {
   start-process powershell.exe -argumentlist $cmd -wait
 }
 
+# Run any script artifacts in order, then remove them.
+if (test-path "C:\migration-manager-scripts") {
+  foreach ($script in get-childitem -path "C:\migration-manager-scripts" -filter "*.ps1" | sort-object name) {
+    add-content -path "C:\AppData\migration-manager\first-boot.log" -value "Running script $($script.Name)"
+
+    $cmd = '-executionpolicy bypass -command "& ''{0}'' *> ''C:\AppData\migration-manager\script-{1}.log''"' -f $script.FullName, $script.BaseName
+    $proc = start-process powershell.exe -argumentlist $cmd -wait -passthru
+    add-content -path "C:\AppData\migration-manager\first-boot.log" -value "Script $($script.Name) exited with code $($proc.ExitCode)"
+  }
+
+  remove-item -recurse "C:\migration-manager-scripts"
+}
+
+# Run the customization payload, if present.
+if (test-path "C:\migration-manager-customization.ps1") {
+  add-content -path "C:\AppData\migration-manager\first-boot.log" -value "Running customization payload"
+
+  $cmd = '-executionpolicy bypass -command "& ''C:\migration-manager-customization.ps1'' *> ''C:\AppData\migration-manager\customization.log''"'
+  $proc = start-process powershell.exe -argumentlist $cmd -wait -passthru
+  add-content -path "C:\AppData\migration-manager\first-boot.log" -value "Customization payload exited with code $($proc.ExitCode)"
+
+  remove-item "C:\migration-manager-customization.ps1"
+}
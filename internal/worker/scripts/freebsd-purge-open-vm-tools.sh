@@ -0,0 +1,32 @@
+#!/bin/sh
+
+# PROVIDE: migration_manager_purge_vm_tools
+# REQUIRE: NETWORKING
+# KEYWORD: firstboot
+
+# Purge VMware tools from the migrated system on its first boot, then remove this script.
+
+. /etc/rc.subr
+
+name="migration_manager_purge_vm_tools"
+rcvar="migration_manager_purge_vm_tools_enable"
+start_cmd="migration_manager_purge_vm_tools_start"
+stop_cmd=":"
+
+: "${migration_manager_purge_vm_tools_enable:="YES"}"
+
+migration_manager_purge_vm_tools_start()
+{
+	if pkg -N >/dev/null 2>&1 ; then
+		for tools in open-vm-tools open-vm-tools-nox11 ; do
+			if pkg info -e "${tools}" ; then
+				pkg delete -y "${tools}"
+			fi
+		done
+	fi
+
+	rm -f /usr/local/etc/rc.d/migration_manager_purge_vm_tools
+}
+
+load_rc_config "${name}"
+run_rc_command "$1"
@@ -22,6 +22,13 @@ import (
 
 const VMwareSDKPath = "/tmp/vmware/vmware-vix-disklib-distrib"
 
+// BandwidthRateFile holds the transfer rate of each disk in bits per second, and is re-read by nbdkit while disks are importing.
+const BandwidthRateFile = "/tmp/migration-manager-bandwidth"
+
+// NbdkitLogDir holds the output of the nbdkit server of each disk.
+// It is kept apart from the post-migration logs, which are cleared whenever the post-migration steps run.
+const NbdkitLogDir = "/tmp/migration-manager-nbdkit"
+
 func DoMount(device string, path string, options []string) error {
 	if !util.PathExists(path) {
 		err := os.MkdirAll(path, 0o755)
@@ -40,6 +40,7 @@ const (
 
 const (
 	bitLockerMountPath       string = "/run/mount/dislocker/"
+	bitLockerMapperName      string = "bitlocker-main"
 	driversMountPath         string = "/run/mount/drivers/"
 	windowsMainMountPath     string = "/run/mount/win_main/"
 	windowsRecoveryMountPath string = "/run/mount/win_recovery/"
@@ -156,24 +157,47 @@ func WindowsDetectBitLockerStatus(partition string) (BitLockerState, error) {
 	return BITLOCKERSTATE_UNKNOWN, fmt.Errorf("Failed to determine BitLocker status for %s", partition)
 }
 
-func WindowsOpenBitLockerPartition(partition string, encryptionKey string) error {
-	if !util.PathExists(bitLockerMountPath) {
-		err := os.MkdirAll(bitLockerMountPath, 0o755)
+// WindowsOpenBitLockerPartition opens the BitLocker partition, and returns the path of the decrypted volume along with its mount options, and a function closing it.
+// A clear-key volume is opened with dislocker. An encrypted volume is opened with cryptsetup, which reads the recovery password from a file only readable by root so it never appears on the command line.
+func WindowsOpenBitLockerPartition(partition string, encryptionKey string) (string, []string, func() error, error) {
+	if encryptionKey == "" {
+		if !util.PathExists(bitLockerMountPath) {
+			err := os.MkdirAll(bitLockerMountPath, 0o755)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("Failed to create mount target %q", bitLockerMountPath)
+			}
+		}
+
+		_, err := subprocess.RunCommand("dislocker-fuse", "-V", partition, "--clearkey", "--", bitLockerMountPath)
 		if err != nil {
-			return fmt.Errorf("Failed to create mount target %q", bitLockerMountPath)
+			return "", nil, nil, err
 		}
+
+		// Sometimes mount gets confused and tries to mount as ext4, so explicitly set ntfs-3g.
+		return filepath.Join(bitLockerMountPath, "dislocker-file"), []string{"-o", "loop", "-t", "ntfs-3g"}, func() error { return DoUnmount(bitLockerMountPath) }, nil
 	}
 
-	if encryptionKey == "" {
-		_, err := subprocess.RunCommand("dislocker-fuse", "-V", partition, "--clearkey", "--", bitLockerMountPath)
+	keyFile, err := writeLUKSKeyFile(LUKSKey{Passphrase: encryptionKey})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	defer func() { _ = os.Remove(keyFile) }()
+
+	_, err = subprocess.RunCommand("cryptsetup", "open", "--type", "bitlk", "--key-file", keyFile, partition, bitLockerMapperName)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("Failed to open BitLocker partition %q: %w", partition, err)
+	}
+
+	closeFunc := func() error {
+		_, err := subprocess.RunCommand("cryptsetup", "close", bitLockerMapperName)
 		return err
 	}
 
-	_, err := subprocess.RunCommand("dislocker-fuse", "-V", partition, "--recovery-password="+encryptionKey, "--", bitLockerMountPath)
-	return err
+	return filepath.Join("/dev/mapper", bitLockerMapperName), []string{"-t", "ntfs-3g"}, closeFunc, nil
 }
 
-func WindowsInjectDrivers(ctx context.Context, distroVersion string, osArchitecture, isoFile string, dryRun bool) error {
+func WindowsInjectDrivers(ctx context.Context, distroVersion string, osArchitecture, isoFile string, recoveryPassword string, firstBootScript string, scripts []string, dryRun bool) error {
 	slog.Info("Preparing to inject Windows drivers into VM")
 	// Clear any existing logs from a previousr run.
 	err := os.RemoveAll(filepath.Join("/tmp", logDir))
@@ -308,24 +332,37 @@ func WindowsInjectDrivers(ctx context.Context, distroVersion string, osArchitect
 		}
 
 		defer func() { _ = DoUnmount(windowsMainMountPath) }()
-	case BITLOCKERSTATE_CLEARKEY:
-		err = WindowsOpenBitLockerPartition(mainPartition, "")
+	case BITLOCKERSTATE_CLEARKEY, BITLOCKERSTATE_ENCRYPTED:
+		encryptionKey := ""
+		if bitLockerStatus == BITLOCKERSTATE_ENCRYPTED {
+			if recoveryPassword == "" {
+				// The recovery password is only sent for the post-import tasks, so the dry-run can't open the volume.
+				if dryRun {
+					slog.Warn("Skipping dry-run of driver injection for BitLocker encrypted partition")
+					return nil
+				}
+
+				return fmt.Errorf("BitLocker encrypted partition detected, but no recovery password is set for the instance")
+			}
+
+			encryptionKey = recoveryPassword
+		}
+
+		volume, mountOptions, closeVolume, err := WindowsOpenBitLockerPartition(mainPartition, encryptionKey)
 		if err != nil {
 			return err
 		}
 
-		defer func() { _ = DoUnmount(bitLockerMountPath) }()
+		defer func() { _ = closeVolume() }()
 
-		// Sometimes mount gets confused and tries to mount as ext4, so explicitly set ntfs-3g.
-		err = DoMount(filepath.Join(bitLockerMountPath, "dislocker-file"), windowsMainMountPath, []string{"-o", "loop", "-t", "ntfs-3g"})
+		err = DoMount(volume, windowsMainMountPath, mountOptions)
 		if err != nil {
 			return err
 		}
 
 		defer func() { _ = DoUnmount(windowsMainMountPath) }()
 	default:
-		// TODO -- Handle passing in of a recovery key for mounting BitLocker partition.
-		return fmt.Errorf("BitLocker without a clear key detected; bailing out for now")
+		return fmt.Errorf("Unable to determine the BitLocker state of %q", mainPartition)
 	}
 
 	// Mount the Windows recovery partition.
@@ -372,6 +409,10 @@ func WindowsInjectDrivers(ctx context.Context, distroVersion string, osArchitect
 		return err
 	}
 
+	if versionCode == "2k3" && (len(scripts) > 0 || firstBootScript != "") {
+		slog.Warn("First-boot scripts are not supported on Windows Server 2003, skipping", slog.Int("script_artifacts", len(scripts)))
+	}
+
 	if versionCode != "2k3" {
 		// Disable VM tools.
 		err = injectScript("hivex-disable-vm-tools.sh", filepath.Join("/tmp", "hivex-disable-vm-tools.sh"), true)
@@ -391,6 +432,20 @@ func WindowsInjectDrivers(ctx context.Context, distroVersion string, osArchitect
 			return err
 		}
 
+		// Add the script artifacts for the first-boot script to run.
+		err = injectWindowsScriptArtifacts(scripts)
+		if err != nil {
+			return err
+		}
+
+		// Add the user-provided payload, which the first-boot script runs last.
+		if firstBootScript != "" {
+			err = os.WriteFile(filepath.Join(windowsMainMountPath, "migration-manager-customization.ps1"), []byte(firstBootScript), 0o755)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Inject the service for the first-boot script.
 		err = injectScript("hivex-first-boot.sh", filepath.Join("/tmp", "hivex-first-boot.sh"), true)
 		if err != nil {
@@ -577,3 +632,33 @@ func toHex(in *pongo2.Value, param *pongo2.Value) (out *pongo2.Value, err *pongo
 
 	return pongo2.AsValue(strings.TrimSuffix(builder.String(), ",")), nil
 }
+
+// injectWindowsScriptArtifacts copies the script artifacts to C:\migration-manager-scripts, named so the first-boot script runs them in order.
+func injectWindowsScriptArtifacts(scripts []string) error {
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(windowsMainMountPath, "migration-manager-scripts")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	for i, path := range scripts {
+		script, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed to read script artifact %q: %w", path, err)
+		}
+
+		// Script artifacts are downloaded to a directory named after the artifact UUID.
+		scriptName := fmt.Sprintf("%02d-%s.ps1", i, filepath.Base(filepath.Dir(path)))
+		slog.Info("Injecting script artifact", slog.String("script", scriptName))
+		err = os.WriteFile(filepath.Join(dir, scriptName), script, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
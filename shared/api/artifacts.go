@@ -13,6 +13,7 @@ const (
 	ARTIFACTTYPE_SDK     ArtifactType = "sdk"
 	ARTIFACTTYPE_OSIMAGE ArtifactType = "os-image"
 	ARTIFACTTYPE_DRIVER  ArtifactType = "driver"
+	ARTIFACTTYPE_SCRIPT  ArtifactType = "script"
 )
 
 // Artifact represents external resources uploaded to Migration Manager.
@@ -68,6 +69,14 @@ type ArtifactPut struct {
 	// Source type that the artifact relates to.
 	// Example: vmware
 	SourceType SourceType `json:"source_type,omitempty" yaml:"source_type,omitempty"`
+
+	// Distributions used to match VMs to a script artifact. If empty, the script applies to all distributions of the OS.
+	// Example: ["ubuntu", "debian"]
+	Distributions []Distro `json:"distributions,omitempty" yaml:"distributions,omitempty"`
+
+	// Order in which matching script artifacts are run, lowest first.
+	// Example: 10
+	Order int `json:"order,omitempty" yaml:"order,omitempty"`
 }
 
 // DefaultArtifactFile returns the default file name expected for a given artifact parent (OS name or source type).
@@ -91,6 +100,18 @@ func (a Artifact) DefaultArtifactFile() (string, error) {
 			return "", fmt.Errorf("Unknown artifact OS %q", a.OS)
 		}
 
+	case ARTIFACTTYPE_SCRIPT:
+		switch a.OS {
+		case OSTYPE_LINUX:
+			// Linux scripts are run from within a chroot of the VM's root file system.
+			return "script.sh", nil
+		case OSTYPE_WINDOWS:
+			// Windows scripts are run by PowerShell when the VM first boots.
+			return "script.ps1", nil
+		default:
+			return "", fmt.Errorf("Unknown artifact OS %q", a.OS)
+		}
+
 	case ARTIFACTTYPE_SDK:
 		switch a.SourceType {
 		case SOURCETYPE_VMWARE:
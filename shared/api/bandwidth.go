@@ -0,0 +1,38 @@
+package api
+
+// BandwidthPolicy limits the bandwidth used for disk transfers, optionally varying by time of day.
+//
+// swagger:model
+type BandwidthPolicy struct {
+	// Bandwidth limit per second that applies outside of any schedule. Unlimited if empty.
+	// Example: 200MB
+	Limit string `json:"limit,omitempty" yaml:"limit,omitempty"`
+
+	// IANA time zone in which schedule times are interpreted. Defaults to UTC.
+	// Example: Europe/Berlin
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Time-of-day limits that replace the default limit while they are active. The first matching schedule applies.
+	Schedules []BandwidthSchedule `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+}
+
+// BandwidthSchedule applies a bandwidth limit during a daily time range.
+//
+// swagger:model
+type BandwidthSchedule struct {
+	// Time of day the schedule begins, in 24-hour HH:MM format.
+	// Example: 08:00
+	Start string `json:"start" yaml:"start"`
+
+	// Time of day the schedule ends, in 24-hour HH:MM format. Must differ from the start. A range ending before it starts wraps past midnight.
+	// Example: 18:00
+	End string `json:"end" yaml:"end"`
+
+	// Days of the week the schedule applies on, as three letter abbreviations. Applies every day if empty.
+	// Example: ["mon", "tue", "wed", "thu", "fri"]
+	Days []string `json:"days,omitempty" yaml:"days,omitempty"`
+
+	// Bandwidth limit per second while the schedule is active. Unlimited if empty.
+	// Example: 50MB
+	Limit string `json:"limit,omitempty" yaml:"limit,omitempty"`
+}
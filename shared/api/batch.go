@@ -15,6 +15,61 @@ const (
 	BATCHSTATUS_ERROR    BatchStatusType = "Error"
 )
 
+type VerificationMode string
+
+const (
+	VERIFICATIONMODE_NONE    VerificationMode = "none"
+	VERIFICATIONMODE_SAMPLED VerificationMode = "sampled"
+	VERIFICATIONMODE_FULL    VerificationMode = "full"
+)
+
+// Validate ensures the VerificationMode is valid.
+func (v VerificationMode) Validate() error {
+	switch v {
+	case "":
+	case VERIFICATIONMODE_NONE:
+	case VERIFICATIONMODE_SAMPLED:
+	case VERIFICATIONMODE_FULL:
+	default:
+		return fmt.Errorf("%s is not a valid verification mode", v)
+	}
+
+	return nil
+}
+
+// Enabled returns whether disks are verified at all in this mode.
+func (v VerificationMode) Enabled() bool {
+	return v != "" && v != VERIFICATIONMODE_NONE
+}
+
+type ImportMode string
+
+const (
+	IMPORTMODE_WORKER ImportMode = "worker"
+	IMPORTMODE_AGENT  ImportMode = "agent"
+)
+
+// Validate ensures the ImportMode is valid.
+func (m ImportMode) Validate() error {
+	switch m {
+	case "":
+	case IMPORTMODE_WORKER:
+	case IMPORTMODE_AGENT:
+	default:
+		return fmt.Errorf("%s is not a valid import mode", m)
+	}
+
+	return nil
+}
+
+const (
+	// TargetSnapshotBackgroundImport is the name of the snapshot taken on the target after the first background import of an instance.
+	TargetSnapshotBackgroundImport = "migration-background-import"
+
+	// TargetSnapshotFinalImport is the name of the snapshot taken on the target after the final import, before post-import tasks change the guest.
+	TargetSnapshotFinalImport = "migration-final-import"
+)
+
 const (
 	DefaultTarget        = "default"
 	DefaultTargetProject = "default"
@@ -136,6 +191,35 @@ type BatchConfig struct {
 
 	// The minimum amount of time before the migration window begins that background sync can be re-attempted.
 	FinalBackgroundSyncLimit Duration `json:"final_background_sync_limit" yaml:"final_background_sync_limit"`
+
+	// Bandwidth limit for disk transfers, shared by all instances of the batch that are importing at once.
+	Bandwidth BandwidthPolicy `json:"bandwidth,omitzero" yaml:"bandwidth,omitempty"`
+
+	// How the imported disks are compared with the source after the final import, before cutover. One of none, sampled or full. Defaults to none.
+	// Example: sampled
+	Verification VerificationMode `json:"verification,omitempty" yaml:"verification,omitempty"`
+
+	// How disks are imported on the target. One of worker, which boots a worker VM for each instance, or agent, which imports directly into storage volumes from an import agent on the Incus host. Defaults to worker.
+	// Example: agent
+	ImportMode ImportMode `json:"import_mode,omitempty" yaml:"import_mode,omitempty"`
+
+	// Whether to snapshot instances on the target after the first background import, and after the final import.
+	// Example: true
+	TargetSnapshots bool `json:"target_snapshots,omitempty" yaml:"target_snapshots,omitempty"`
+
+	// What happens to source VMs once their migration has finished.
+	SourceCleanup SourceCleanupPolicy `json:"source_cleanup,omitzero" yaml:"source_cleanup,omitempty"`
+
+	// Customization applied to guests when they first boot on the target. Fields set in instance overrides take precedence.
+	GuestCustomization GuestCustomization `json:"guest_customization,omitzero" yaml:"guest_customization,omitempty"`
+
+	// How source VMs are shut down for the final import. Fields set in instance overrides take precedence.
+	Shutdown ShutdownPolicy `json:"shutdown,omitzero" yaml:"shutdown,omitempty"`
+
+	// Amount by which all migrated disks are grown on the target, either as a percentage of the source disk capacity or as a byte size.
+	// Disk policies set by the placement scriptlet or in instance overrides take precedence.
+	// Example: 20%
+	DiskGrowth string `json:"disk_growth,omitempty" yaml:"disk_growth,omitempty"`
 }
 
 // BatchConstraint is a constraint to be applied to a batch to determine which instances can be migrated.
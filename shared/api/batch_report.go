@@ -0,0 +1,104 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BatchReportFormat is the output format of a batch report.
+type BatchReportFormat string
+
+const (
+	BATCHREPORTFORMAT_JSON BatchReportFormat = "json"
+	BATCHREPORTFORMAT_CSV  BatchReportFormat = "csv"
+	BATCHREPORTFORMAT_HTML BatchReportFormat = "html"
+)
+
+// BatchReport summarizes the migration of every instance queued by a batch.
+//
+// swagger:model
+type BatchReport struct {
+	// Name of the batch.
+	// Example: MyBatch
+	Batch string `json:"batch" yaml:"batch"`
+
+	// Status of the batch at the time the report was generated.
+	// Example: Running
+	Status BatchStatusType `json:"status" yaml:"status"`
+
+	// Time the report was generated.
+	// Example: 2025-01-01 01:00:00
+	GeneratedAt time.Time `json:"generated_at" yaml:"generated_at"`
+
+	// Per-instance migration details.
+	Instances []BatchReportInstance `json:"instances" yaml:"instances"`
+}
+
+// BatchReportInstance summarizes the migration of a single instance.
+//
+// swagger:model
+type BatchReportInstance struct {
+	// UUID of the instance.
+	// Example: a2095069-a527-4b2a-ab23-1739325dcac7
+	UUID uuid.UUID `json:"uuid" yaml:"uuid"`
+
+	// Name of the instance.
+	// Example: UbuntuVM
+	Name string `json:"name" yaml:"name"`
+
+	// Source the instance is migrated from.
+	// Example: vcenter01
+	Source string `json:"source" yaml:"source"`
+
+	// Location of the instance on the source.
+	// Example: /SHF/vm/Migration Tests/UbuntuVM
+	Location string `json:"location" yaml:"location"`
+
+	// Target placement of the instance.
+	Placement Placement `json:"placement" yaml:"placement"`
+
+	// Final migration status of the instance.
+	// Example: Finished
+	MigrationStatus MigrationStatusType `json:"migration_status" yaml:"migration_status"`
+
+	// Final migration status message of the instance.
+	// Example: Migration finished
+	MigrationStatusMessage string `json:"migration_status_message" yaml:"migration_status_message"`
+
+	// Migration phases the instance went through, in order.
+	Phases []BatchReportPhase `json:"phases" yaml:"phases"`
+
+	// Total number of bytes copied from the source.
+	// Example: 1073741824
+	BytesTransferred int64 `json:"bytes_transferred" yaml:"bytes_transferred"`
+
+	// Time between the source instance being powered off and the target instance being started.
+	// Example: 5m
+	Downtime Duration `json:"downtime,omitzero" yaml:"downtime,omitempty"`
+
+	// Warnings raised for the instance.
+	// Example: ["No NSX source for network \"mynet\""]
+	Warnings []string `json:"warnings" yaml:"warnings"`
+
+	// Comment recorded on the instance overrides.
+	// Example: Manually tweak number of CPUs
+	Comment string `json:"comment" yaml:"comment"`
+}
+
+// BatchReportPhase is a single migration status an instance spent time in.
+//
+// swagger:model
+type BatchReportPhase struct {
+	// Migration status of the phase.
+	// Example: Performing background import tasks
+	Status MigrationStatusType `json:"status" yaml:"status"`
+
+	// Time the phase began.
+	// Example: 2025-01-01 01:00:00
+	Start time.Time `json:"start" yaml:"start"`
+
+	// Time the phase ended. Empty if the instance is still in this phase.
+	// Example: 2025-01-01 02:00:00
+	End time.Time `json:"end,omitzero" yaml:"end,omitempty"`
+}
@@ -0,0 +1,22 @@
+package api
+
+// DiskPolicy defines how a source disk is laid out on the target.
+//
+// swagger:model
+type DiskPolicy struct {
+	// Amount by which the disk is grown on the target, either as a percentage of the source disk capacity or as a byte size.
+	// Example: 20%
+	Grow string `json:"grow,omitempty" yaml:"grow,omitempty"`
+
+	// Storage volume configuration used when creating the disk on the target pool. For the root disk, it is applied through the `initial.*` keys of the root device.
+	// Example: {"zfs.blocksize": "64KiB"}
+	Config map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+
+	// If true, the disk is not migrated.
+	// Example: false
+	Drop bool `json:"drop,omitempty" yaml:"drop,omitempty"`
+
+	// If true, the disk is migrated to a storage volume on the target, but is not attached to the instance after migration.
+	// Example: false
+	Detach bool `json:"detach,omitempty" yaml:"detach,omitempty"`
+}
@@ -19,7 +19,7 @@ const (
 	// migration-final-started (final migration has started, source instance is offline).
 	MigrationFinalStarted api.LifecycleAction = "migration-final-started"
 
-	// migration-final-completed (final migration has completed).
+	// migration-final-completed (final migration has completed, target instance is configured and ready).
 	MigrationFinalCompleted api.LifecycleAction = "migration-final-completed"
 )
 
@@ -0,0 +1,48 @@
+package api
+
+// GuestCustomization defines customization applied to the guest when it first boots on the target.
+//
+// swagger:model
+type GuestCustomization struct {
+	// cloud-init user-data for Linux guests.
+	// Example: "#cloud-config\nhostname: vm01"
+	CloudInitUserData string `json:"cloud_init_user_data,omitempty" yaml:"cloud_init_user_data,omitempty"`
+
+	// cloud-init vendor-data for Linux guests.
+	// Example: "#cloud-config\npackages: [qemu-guest-agent]"
+	CloudInitVendorData string `json:"cloud_init_vendor_data,omitempty" yaml:"cloud_init_vendor_data,omitempty"`
+
+	// cloud-init network-config for Linux guests.
+	// Example: "version: 2\nethernets:\n  eth0:\n    dhcp4: true"
+	CloudInitNetworkConfig string `json:"cloud_init_network_config,omitempty" yaml:"cloud_init_network_config,omitempty"`
+
+	// PowerShell script run by the first-boot service of Windows guests, after drivers and network configuration are set up.
+	// Example: "Rename-Computer -NewName vm01"
+	WindowsFirstBootScript string `json:"windows_first_boot_script,omitempty" yaml:"windows_first_boot_script,omitempty"`
+}
+
+// HasCloudInit returns whether any cloud-init configuration is set.
+func (g GuestCustomization) HasCloudInit() bool {
+	return g.CloudInitUserData != "" || g.CloudInitVendorData != "" || g.CloudInitNetworkConfig != ""
+}
+
+// Apply returns the customization with each field that is set in the override replacing its counterpart.
+func (g GuestCustomization) Apply(override GuestCustomization) GuestCustomization {
+	if override.CloudInitUserData != "" {
+		g.CloudInitUserData = override.CloudInitUserData
+	}
+
+	if override.CloudInitVendorData != "" {
+		g.CloudInitVendorData = override.CloudInitVendorData
+	}
+
+	if override.CloudInitNetworkConfig != "" {
+		g.CloudInitNetworkConfig = override.CloudInitNetworkConfig
+	}
+
+	if override.WindowsFirstBootScript != "" {
+		g.WindowsFirstBootScript = override.WindowsFirstBootScript
+	}
+
+	return g
+}
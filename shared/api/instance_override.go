@@ -46,4 +46,38 @@ type InstanceOverride struct {
 	// If true, after migration the associated target VM will be left stopped.
 	// Example: true
 	StoppedAfterMigration bool `json:"stopped_after_migration" yaml:"stopped_after_migration"`
+
+	// If true, the recommended CPU and memory limits will be used for any limits that are not explicitly overridden.
+	// Example: true
+	UseRecommendedSizing bool `json:"use_recommended_sizing" yaml:"use_recommended_sizing"`
+
+	// Disk policies keyed by disk name. These take precedence over any set by the batch placement scriptlet.
+	// Example: {"[my-datastore] vmname.vmdk": {"grow": "20%"}}
+	Disks map[string]DiskPolicy `json:"disks,omitempty" yaml:"disks,omitempty"`
+
+	// Guest network configuration keyed by the hardware address of the NIC. Only applied to Linux guests.
+	// Example: {"00:0c:29:a1:76:30": {"addresses": ["10.0.1.10/24"], "gateway4": "10.0.1.1"}}
+	NICs map[string]NICConfig `json:"nics,omitempty" yaml:"nics,omitempty"`
+
+	// Customization applied to the guest when it first boots on the target. Fields set here replace those set by the batch.
+	GuestCustomization GuestCustomization `json:"guest_customization,omitzero" yaml:"guest_customization,omitempty"`
+
+	// How the source VM is shut down for the final import. Fields set here replace those set by the batch.
+	Shutdown ShutdownPolicy `json:"shutdown,omitzero" yaml:"shutdown,omitempty"`
+
+	// Right-sizing recommendation derived from the instance's performance statistics. This field is read-only.
+	Recommendation *InstanceSizingRecommendation `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+}
+
+// InstanceSizingRecommendation defines the recommended resource limits for an instance.
+//
+// swagger:model
+type InstanceSizingRecommendation struct {
+	// Recommended number of CPUs.
+	// Example: 2
+	CPUs int64 `json:"cpus" yaml:"cpus"`
+
+	// Recommended memory in bytes.
+	// Example: 2147483648
+	Memory int64 `json:"memory" yaml:"memory"`
 }
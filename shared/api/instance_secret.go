@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstanceSecretType is the kind of secret stored for an instance.
+type InstanceSecretType string
+
+const (
+	INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD InstanceSecretType = "bitlocker-recovery-password"
+	INSTANCESECRETTYPE_LUKS_PASSPHRASE             InstanceSecretType = "luks-passphrase"
+	INSTANCESECRETTYPE_LUKS_KEYFILE                InstanceSecretType = "luks-keyfile"
+	INSTANCESECRETTYPE_GUEST_PASSWORD              InstanceSecretType = "guest-password"
+)
+
+// Validate ensures the secret type is known.
+func (t InstanceSecretType) Validate() error {
+	switch t {
+	case INSTANCESECRETTYPE_BITLOCKER_RECOVERY_PASSWORD, INSTANCESECRETTYPE_LUKS_PASSPHRASE, INSTANCESECRETTYPE_LUKS_KEYFILE, INSTANCESECRETTYPE_GUEST_PASSWORD:
+		return nil
+	default:
+		return fmt.Errorf("Unknown secret type %q", t)
+	}
+}
+
+// InstanceSecret describes a secret stored for an instance. The value of a secret is never returned.
+//
+// swagger:model
+type InstanceSecret struct {
+	// Type of the secret
+	// Example: bitlocker-recovery-password
+	Type InstanceSecretType `json:"type" yaml:"type"`
+
+	// Time in UTC that the secret was last set
+	// Example: 2025-01-01 01:00:00
+	LastUpdated time.Time `json:"last_updated" yaml:"last_updated"`
+}
+
+// InstanceSecretPut sets a secret for an instance, replacing any existing secret of the same type.
+//
+// swagger:model
+type InstanceSecretPut struct {
+	// Type of the secret
+	// Example: bitlocker-recovery-password
+	Type InstanceSecretType `json:"type" yaml:"type"`
+
+	// Value of the secret. LUKS keyfiles are base64 encoded.
+	// Example: 123456-123456-123456-123456-123456-123456-123456-123456
+	Value string `json:"value" yaml:"value"`
+}
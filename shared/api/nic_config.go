@@ -0,0 +1,30 @@
+package api
+
+// NICConfig defines how the guest network configuration of a NIC is translated during migration.
+//
+// swagger:model
+type NICConfig struct {
+	// Name of the network interface in the guest after migration. If empty, the name from the source is kept.
+	// Example: eth0
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Static addresses in CIDR notation. For each address family given, these replace the addresses configured in the guest.
+	// Example: ["10.0.1.10/24", "fd42::10/64"]
+	Addresses []string `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+
+	// IPv4 default gateway.
+	// Example: 10.0.1.1
+	Gateway4 string `json:"gateway4,omitempty" yaml:"gateway4,omitempty"`
+
+	// IPv6 default gateway.
+	// Example: fd42::1
+	Gateway6 string `json:"gateway6,omitempty" yaml:"gateway6,omitempty"`
+
+	// DNS servers, replacing those configured for the interface in the guest.
+	// Example: ["10.0.1.2"]
+	Nameservers []string `json:"nameservers,omitempty" yaml:"nameservers,omitempty"`
+
+	// DNS search domains, replacing those configured for the interface in the guest.
+	// Example: ["example.com"]
+	SearchDomains []string `json:"search_domains,omitempty" yaml:"search_domains,omitempty"`
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"maps"
 	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -67,6 +68,9 @@ type InstanceProperties struct {
 
 	// List of snapshots for the Instance.
 	Snapshots []InstancePropertiesSnapshot `json:"snapshots" yaml:"snapshots" expr:"snapshots"`
+
+	// Resource usage statistics of the Instance, if collected by the source.
+	Performance InstancePropertiesPerformance `json:"performance" yaml:"performance" expr:"performance"`
 }
 
 // InstancePropertiesConfigurable are the configurable properties of an instance.
@@ -148,6 +152,61 @@ type InstancePropertiesSnapshot struct {
 	Name string `json:"name" yaml:"name" expr:"name"`
 }
 
+// InstancePropertiesPerformance are resource usage statistics collected from the source over a sampling window.
+type InstancePropertiesPerformance struct {
+	// Length of the sampling window the statistics were collected over.
+	// Example: 1h
+	Window Duration `json:"window" yaml:"window" expr:"window"`
+
+	// Time at which the statistics were collected.
+	// Example: 2025-01-01 01:00:00
+	CollectedAt time.Time `json:"collected_at" yaml:"collected_at" expr:"collected_at"`
+
+	// Average CPU usage as a percentage of the configured CPUs.
+	// Example: 12.5
+	CPUUsage float64 `json:"cpu_usage" yaml:"cpu_usage" expr:"cpu_usage"`
+
+	// Peak CPU usage as a percentage of the configured CPUs.
+	// Example: 40.2
+	CPUUsagePeak float64 `json:"cpu_usage_peak" yaml:"cpu_usage_peak" expr:"cpu_usage_peak"`
+
+	// Average percentage of time a virtual CPU was ready to run but could not be scheduled.
+	// Example: 1.5
+	CPUReady float64 `json:"cpu_ready" yaml:"cpu_ready" expr:"cpu_ready"`
+
+	// Average amount of actively used memory in bytes.
+	// Example: 536870912
+	MemoryActive int64 `json:"memory_active" yaml:"memory_active" expr:"memory_active"`
+
+	// Peak amount of actively used memory in bytes.
+	// Example: 805306368
+	MemoryActivePeak int64 `json:"memory_active_peak" yaml:"memory_active_peak" expr:"memory_active_peak"`
+
+	// Average number of disk read operations per second.
+	// Example: 120.5
+	DiskReadIOPS float64 `json:"disk_read_iops" yaml:"disk_read_iops" expr:"disk_read_iops"`
+
+	// Average number of disk write operations per second.
+	// Example: 80.25
+	DiskWriteIOPS float64 `json:"disk_write_iops" yaml:"disk_write_iops" expr:"disk_write_iops"`
+
+	// Average disk read throughput in bytes per second.
+	// Example: 1048576
+	DiskReadThroughput int64 `json:"disk_read_throughput" yaml:"disk_read_throughput" expr:"disk_read_throughput"`
+
+	// Average disk write throughput in bytes per second.
+	// Example: 524288
+	DiskWriteThroughput int64 `json:"disk_write_throughput" yaml:"disk_write_throughput" expr:"disk_write_throughput"`
+
+	// Average network receive throughput in bytes per second.
+	// Example: 262144
+	NetworkReceiveThroughput int64 `json:"network_receive_throughput" yaml:"network_receive_throughput" expr:"network_receive_throughput"`
+
+	// Average network transmit throughput in bytes per second.
+	// Example: 131072
+	NetworkTransmitThroughput int64 `json:"network_transmit_throughput" yaml:"network_transmit_throughput" expr:"network_transmit_throughput"`
+}
+
 // SupportsBackgroundImport returns whether the instance has background import support, and all supported disks have been verified.
 func (i InstanceProperties) SupportsBackgroundImport() bool {
 	if i.BackgroundImport {
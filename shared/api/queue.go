@@ -80,6 +80,136 @@ type QueueEntry struct {
 
 	// Configuration for which target the instance will be placed on.
 	Placement Placement `json:"placement" yaml:"placement"`
+
+	// Disk transfer statistics of each completed background and final import.
+	SyncHistory []QueueSyncRecord `json:"sync_history" yaml:"sync_history"`
+
+	// Estimated duration of the final import, based on the observed disk change rate and copy throughput.
+	// Example: 5m
+	FinalImportForecast Duration `json:"final_import_forecast" yaml:"final_import_forecast"`
+
+	// Timestamps and measured downtime of the cutover from the source to the target instance.
+	Cutover QueueCutover `json:"cutover" yaml:"cutover"`
+
+	// Progress of the cleanup of the source VM after the migration has finished.
+	SourceCleanup QueueSourceCleanup `json:"source_cleanup,omitzero" yaml:"source_cleanup,omitempty"`
+}
+
+// QueueCutover records the timestamps of the cutover from the source instance to the target instance, and how the source VM was shut down.
+type QueueCutover struct {
+	// Time in UTC that the source VM was powered off for the final import.
+	// Example: 2025-01-01 01:00:00
+	SourcePowerOff time.Time `json:"source_power_off,omitzero" yaml:"source_power_off,omitempty"`
+
+	// Time in UTC that the final import completed.
+	// Example: 2025-01-01 01:05:00
+	FinalImportComplete time.Time `json:"final_import_complete,omitzero" yaml:"final_import_complete,omitempty"`
+
+	// Time in UTC that the target instance was started.
+	// Example: 2025-01-01 01:10:00
+	TargetStart time.Time `json:"target_start,omitzero" yaml:"target_start,omitempty"`
+
+	// Time in UTC that the guest agent of the target instance became ready.
+	// Example: 2025-01-01 01:11:00
+	AgentReady time.Time `json:"agent_ready,omitzero" yaml:"agent_ready,omitempty"`
+
+	// Measured downtime, from the source VM powering off until the target instance's guest agent is ready,
+	// or until the target instance started if the guest agent did not report readiness.
+	// Example: 11m
+	Downtime Duration `json:"downtime,omitzero" yaml:"downtime,omitempty"`
+
+	// Steps taken to shut down the source VM for the final import, in the order they were taken.
+	ShutdownAttempts []ShutdownAttempt `json:"shutdown_attempts,omitempty" yaml:"shutdown_attempts,omitempty"`
+}
+
+// MeasuredDowntime returns the time between the source VM powering off and the target instance becoming available.
+// Returns 0 if either end has not been recorded.
+func (c QueueCutover) MeasuredDowntime() time.Duration {
+	end := c.AgentReady
+	if end.IsZero() {
+		end = c.TargetStart
+	}
+
+	if c.SourcePowerOff.IsZero() || end.IsZero() {
+		return 0
+	}
+
+	return end.Sub(c.SourcePowerOff)
+}
+
+// QueueSyncRecord records the data transferred by a single disk import of a queue entry.
+type QueueSyncRecord struct {
+	// Time in UTC that the disk import completed.
+	// Example: 2025-01-01 01:00:00
+	Time time.Time `json:"time" yaml:"time"`
+
+	// Time elapsed since the previous disk import completed, or zero if this was the first.
+	// Example: 10m
+	Interval Duration `json:"interval" yaml:"interval"`
+
+	// Whether this was the final import, performed after the source VM was powered off.
+	// Example: false
+	Final bool `json:"final" yaml:"final"`
+
+	// Per-disk transfer statistics.
+	Disks []WorkerDiskSync `json:"disks" yaml:"disks"`
+}
+
+// QueueHistoryEntry records a change to the migration status of an instance.
+//
+// swagger:model
+type QueueHistoryEntry struct {
+	// UUID for the instance
+	// Example: 26fa4eb7-8d4f-4bf8-9a6a-dd95d166dfad
+	InstanceUUID uuid.UUID `json:"instance_uuid" yaml:"instance_uuid"`
+
+	// The name of the batch the instance was queued by
+	// Example: MyBatch
+	BatchName string `json:"batch_name" yaml:"batch_name"`
+
+	// Time in UTC that the change was recorded
+	// Example: 2025-01-01 01:00:00
+	Time time.Time `json:"time" yaml:"time"`
+
+	// The migration status of the instance after the change
+	// Example: Idle
+	MigrationStatus MigrationStatusType `json:"migration_status" yaml:"migration_status"`
+
+	// A free-form string to provide additional information about the migration status
+	// Example: Waiting for migration window
+	MigrationStatusMessage string `json:"migration_status_message" yaml:"migration_status_message"`
+
+	// The status reported by the migration worker, if the change was caused by a worker response
+	// Example: 2
+	WorkerStatus WorkerResponseType `json:"worker_status" yaml:"worker_status"`
+
+	// The message reported by the migration worker, if the change was caused by a worker response
+	// Example: Disk import completed successfully
+	WorkerStatusMessage string `json:"worker_status_message" yaml:"worker_status_message"`
+
+	// Configuration for which target the instance was placed on at the time of the change
+	Placement Placement `json:"placement" yaml:"placement"`
+
+	// The error message, if the change put the migration into an error state
+	// Example: Failed to import disks
+	Error string `json:"error" yaml:"error"`
+}
+
+// QueueLogAttempt lists the log files uploaded by the migration worker of an instance at the end of an attempt.
+//
+// swagger:model
+type QueueLogAttempt struct {
+	// Sequence number of the upload for the instance, starting at 1
+	// Example: 1
+	Attempt int `json:"attempt" yaml:"attempt"`
+
+	// Time in UTC that the logs were received
+	// Example: 2025-01-01 01:00:00
+	Time time.Time `json:"time" yaml:"time"`
+
+	// Names of the uploaded log files
+	// Example: ["worker.log", "nbdkit-2000.log"]
+	Files []string `json:"files" yaml:"files"`
 }
 
 // Placement indicates the destination for a queue entry's instance.
@@ -100,6 +230,10 @@ type Placement struct {
 	// Example: {"00:00:00:00:00:01": "incusbr0"}
 	Networks map[string]NetworkPlacement `json:"networks" yaml:"networks"`
 
+	// Disk policies keyed by attached disk name.
+	// Example: {"[my-datastore] vmname.vmdk": {"grow": "20%"}}
+	Disks map[string]DiskPolicy `json:"disks,omitempty" yaml:"disks,omitempty"`
+
 	// Whether the target instance should be running after migration is complete.
 	// Example: true
 	Running bool `json:"running" yaml:"running"`
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"time"
+)
+
+// ShutdownAction is a step taken to shut down the source VM for the final import.
+type ShutdownAction string
+
+const (
+	SHUTDOWNACTION_PRE_SHUTDOWN_COMMAND ShutdownAction = "pre-shutdown-command"
+	SHUTDOWNACTION_GUEST_SHUTDOWN       ShutdownAction = "guest-shutdown"
+	SHUTDOWNACTION_POWER_OFF            ShutdownAction = "power-off"
+)
+
+// DefaultForcedGuestTimeout is how long to wait for the guest OS to shut down before a forced power off, if the policy doesn't set a timeout.
+const DefaultForcedGuestTimeout = 10 * time.Minute
+
+// ShutdownPolicy defines how the source VM is shut down for the final import.
+//
+// swagger:model
+type ShutdownPolicy struct {
+	// How long to wait for the guest OS to shut down. If unset, the wait is not limited, unless ForcePowerOff is set, in which case it defaults to 10 minutes.
+	// Example: 10m
+	GuestTimeout Duration `json:"guest_timeout,omitzero" yaml:"guest_timeout,omitempty"`
+
+	// Whether to power off the VM if the guest OS has not shut down within the timeout. If unset, the VM is not powered off.
+	// Example: true
+	ForcePowerOff *bool `json:"force_power_off,omitempty" yaml:"force_power_off,omitempty"`
+
+	// Absolute path of a program run in the guest through VMware Tools before the guest OS is shut down.
+	// Example: /usr/bin/systemctl
+	PreShutdownCommand string `json:"pre_shutdown_command,omitempty" yaml:"pre_shutdown_command,omitempty"`
+
+	// Arguments passed to the pre-shutdown command.
+	// Example: stop postgresql
+	PreShutdownArguments string `json:"pre_shutdown_arguments,omitempty" yaml:"pre_shutdown_arguments,omitempty"`
+
+	// How long to wait for the pre-shutdown command to exit. Defaults to 5 minutes.
+	// Example: 2m
+	PreShutdownTimeout Duration `json:"pre_shutdown_timeout,omitzero" yaml:"pre_shutdown_timeout,omitempty"`
+
+	// Guest user that runs the pre-shutdown command. Its password is the guest-password secret of the instance.
+	// Example: root
+	GuestUsername string `json:"guest_username,omitempty" yaml:"guest_username,omitempty"`
+}
+
+// Apply returns the policy with each field that is set in the override replacing its counterpart.
+// An override can turn off a forced power off by setting ForcePowerOff to false.
+func (p ShutdownPolicy) Apply(override ShutdownPolicy) ShutdownPolicy {
+	if override.GuestTimeout.Duration != 0 {
+		p.GuestTimeout = override.GuestTimeout
+	}
+
+	if override.ForcePowerOff != nil {
+		p.ForcePowerOff = override.ForcePowerOff
+	}
+
+	if override.PreShutdownCommand != "" {
+		p.PreShutdownCommand = override.PreShutdownCommand
+	}
+
+	if override.PreShutdownArguments != "" {
+		p.PreShutdownArguments = override.PreShutdownArguments
+	}
+
+	if override.PreShutdownTimeout.Duration != 0 {
+		p.PreShutdownTimeout = override.PreShutdownTimeout
+	}
+
+	if override.GuestUsername != "" {
+		p.GuestUsername = override.GuestUsername
+	}
+
+	return p
+}
+
+// ForcesPowerOff returns whether the VM is powered off if the guest OS has not shut down within the timeout.
+func (p ShutdownPolicy) ForcesPowerOff() bool {
+	return p.ForcePowerOff != nil && *p.ForcePowerOff
+}
+
+// GuestShutdownTimeout returns how long to wait for the guest OS to shut down, or 0 if the wait is not limited.
+// A forced power off always has a timeout, as otherwise it would never happen for a guest that ignores the shutdown.
+func (p ShutdownPolicy) GuestShutdownTimeout() time.Duration {
+	if p.GuestTimeout.Duration <= 0 && p.ForcesPowerOff() {
+		return DefaultForcedGuestTimeout
+	}
+
+	return p.GuestTimeout.Duration
+}
+
+// ShutdownAttempt records a step taken to shut down the source VM for the final import.
+type ShutdownAttempt struct {
+	// The step that was taken.
+	// Example: guest-shutdown
+	Action ShutdownAction `json:"action" yaml:"action"`
+
+	// Time in UTC that the step started.
+	// Example: 2025-01-01 01:00:00
+	Time time.Time `json:"time" yaml:"time"`
+
+	// Time spent on the step.
+	// Example: 10m
+	Duration Duration `json:"duration" yaml:"duration"`
+
+	// Why the step failed, or empty if it succeeded.
+	// Example: Guest OS did not shut down within 10m0s
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
@@ -93,6 +93,25 @@ type VMwareProperties struct {
 
 	// Datacenters to search for VMs, networks, and datastores. Defaults to all datacenters.
 	Datacenters []string `json:"datacenters" yaml:"datacenters"`
+
+	// Number of disks of a single VM that are transferred concurrently.
+	// Example: 2
+	DiskParallelism int `json:"disk_parallelism,omitempty" yaml:"disk_parallelism,omitempty"`
+
+	// Number of concurrent NBD connections used to transfer each disk.
+	// Example: 4
+	DiskConnections int `json:"disk_connections,omitempty" yaml:"disk_connections,omitempty"`
+
+	// Maximum number of concurrent NBD connections across all imports from a single datastore of the source. Unlimited if unset.
+	// Example: 16
+	TransferLimit int `json:"transfer_limit,omitempty" yaml:"transfer_limit,omitempty"`
+
+	// Bandwidth limit for disk transfers, shared by all instances importing from the source at once.
+	Bandwidth BandwidthPolicy `json:"bandwidth,omitzero" yaml:"bandwidth,omitempty"`
+
+	// Window over which performance statistics are collected for running VMs. Collection is disabled if unset.
+	// Example: 1h
+	PerformanceWindow Duration `json:"performance_window,omitzero" yaml:"performance_window,omitempty"`
 }
 
 // SetDefaults sets default values for source properties.
@@ -109,6 +128,14 @@ func (s *VMwareProperties) SetDefaults() {
 		s.SyncLimit = 1
 	}
 
+	if s.DiskParallelism == 0 {
+		s.DiskParallelism = 1
+	}
+
+	if s.DiskConnections == 0 {
+		s.DiskConnections = 1
+	}
+
 	datacenters := []string{}
 	// TODO: Check if vCenter allows '.' as a datacenter name because filepath.Clean won't work then.
 	for _, p := range s.Datacenters {
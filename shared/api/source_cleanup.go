@@ -0,0 +1,103 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SourceCleanupAction is a step of the cleanup of a source VM after its migration has finished.
+type SourceCleanupAction string
+
+const (
+	SOURCECLEANUPACTION_REMOVE_SNAPSHOT         SourceCleanupAction = "remove-snapshot"
+	SOURCECLEANUPACTION_DISABLE_CHANGE_TRACKING SourceCleanupAction = "disable-change-tracking"
+	SOURCECLEANUPACTION_DISABLE_AUTOSTART       SourceCleanupAction = "disable-autostart"
+	SOURCECLEANUPACTION_ANNOTATE                SourceCleanupAction = "annotate"
+	SOURCECLEANUPACTION_TAG                     SourceCleanupAction = "tag"
+	SOURCECLEANUPACTION_RENAME                  SourceCleanupAction = "rename"
+	SOURCECLEANUPACTION_MOVE                    SourceCleanupAction = "move"
+	SOURCECLEANUPACTION_DELETE                  SourceCleanupAction = "delete"
+)
+
+// SourceCleanupPolicy defines what happens to source VMs once their migration has finished.
+//
+// swagger:model
+type SourceCleanupPolicy struct {
+	// Suffix appended to the name of the source VM.
+	// Example: -migrated
+	RenameSuffix string `json:"rename_suffix,omitempty" yaml:"rename_suffix,omitempty"`
+
+	// Inventory path of the folder that the source VM is moved to.
+	// Example: /Datacenter/vm/quarantine
+	Folder string `json:"folder,omitempty" yaml:"folder,omitempty"`
+
+	// Tags attached to the source VM, each in the form category:name.
+	// Example: ["migration:migrated"]
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Text added to the notes of the source VM.
+	// Example: Migrated to Incus, do not power on.
+	Annotation string `json:"annotation,omitempty" yaml:"annotation,omitempty"`
+
+	// Whether to disable the automatic start of the source VM with its host.
+	// Example: true
+	DisableAutostart bool `json:"disable_autostart,omitempty" yaml:"disable_autostart,omitempty"`
+
+	// Whether to disable change block tracking on the source VM.
+	// Example: true
+	DisableChangeTracking bool `json:"disable_change_tracking,omitempty" yaml:"disable_change_tracking,omitempty"`
+
+	// Number of days after the final import that the source VM is deleted, or 0 to never delete it.
+	// Example: 30
+	DeleteAfterDays int `json:"delete_after_days,omitempty" yaml:"delete_after_days,omitempty"`
+}
+
+// Enabled returns whether the policy cleans up source VMs at all.
+func (p SourceCleanupPolicy) Enabled() bool {
+	return p.RenameSuffix != "" || p.Folder != "" || len(p.Tags) > 0 || p.Annotation != "" || p.DisableAutostart || p.DisableChangeTracking || p.DeleteAfterDays > 0
+}
+
+// QueueSourceCleanup records the progress of the cleanup of a source VM.
+type QueueSourceCleanup struct {
+	// Cleanup actions that have been applied to the source VM.
+	// Example: ["remove-snapshot", "rename"]
+	Completed []SourceCleanupAction `json:"completed,omitempty" yaml:"completed,omitempty"`
+
+	// Time in UTC of the last cleanup attempt.
+	// Example: 2025-01-01 02:00:00
+	LastAttempt time.Time `json:"last_attempt,omitzero" yaml:"last_attempt,omitempty"`
+
+	// Error of the last cleanup attempt, if it failed.
+	// Example: Failed to rename source VM: permission denied
+	LastError string `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+}
+
+// SourceCleanupPlan describes the cleanup of the source VM of a finished migration, without applying it.
+//
+// swagger:model
+type SourceCleanupPlan struct {
+	// UUID of the instance.
+	// Example: 26fa4eb7-8d4f-4bf8-9a6a-dd95d166dfad
+	InstanceUUID uuid.UUID `json:"instance_uuid" yaml:"instance_uuid"`
+
+	// Name of the instance.
+	// Example: UbuntuServer
+	InstanceName string `json:"instance_name" yaml:"instance_name"`
+
+	// Cleanup actions that have already been applied.
+	// Example: ["remove-snapshot"]
+	Completed []SourceCleanupAction `json:"completed" yaml:"completed"`
+
+	// Cleanup actions that will be applied by the next cleanup run.
+	// Example: ["rename", "move"]
+	Pending []SourceCleanupAction `json:"pending" yaml:"pending"`
+
+	// Time in UTC that the source VM will be deleted, if the policy deletes it.
+	// Example: 2025-01-31 01:05:00
+	DeleteTime time.Time `json:"delete_time,omitzero" yaml:"delete_time,omitempty"`
+
+	// Error of the last cleanup attempt, if it failed.
+	// Example: Failed to rename source VM: permission denied
+	LastError string `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+}
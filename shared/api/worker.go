@@ -2,8 +2,15 @@ package api
 
 import (
 	"encoding/json"
+	"time"
 )
 
+// WorkerSecretHeader holds the secret token that a migration worker authenticates its requests with.
+const WorkerSecretHeader = "X-MigrationManager-Worker-Secret"
+
+// WorkerInstanceHeader holds the UUID of the instance that a migration worker belongs to, for requests outside of /internal/worker/{uuid}.
+const WorkerInstanceHeader = "X-MigrationManager-Worker-Instance"
+
 type WorkerCommandType int
 
 const (
@@ -12,6 +19,7 @@ const (
 	WORKERCOMMAND_IMPORT_DISKS
 	WORKERCOMMAND_FINALIZE_IMPORT
 	WORKERCOMMAND_POST_IMPORT
+	WORKERCOMMAND_ABORT
 )
 
 type WorkerResponseType int
@@ -21,6 +29,7 @@ const (
 	WORKERRESPONSE_RUNNING
 	WORKERRESPONSE_SUCCESS
 	WORKERRESPONSE_FAILED
+	WORKERRESPONSE_ABORTED
 )
 
 // WorkerCommand defines a command sent from the migration manager to a worker.
@@ -55,6 +64,77 @@ type WorkerCommand struct {
 	// Architecture of the instance
 	// Example: x86_64
 	Architecture string `json:"architecture" yaml:"architecture"`
+
+	// Concurrency limits for disk transfers.
+	TransferLimits WorkerTransferLimits `json:"transfer_limits" yaml:"transfer_limits"`
+
+	// Sync state of each disk as of the last completed disk import.
+	DiskStates []WorkerDiskState `json:"disk_states,omitempty" yaml:"disk_states,omitempty"`
+
+	// How the imported disks should be compared with the source after the final import.
+	// Example: sampled
+	Verification VerificationMode `json:"verification,omitempty" yaml:"verification,omitempty"`
+
+	// Names of the source disks that are not migrated.
+	// Example: ["[my-datastore] vmname_1.vmdk"]
+	DroppedDisks []string `json:"dropped_disks,omitempty" yaml:"dropped_disks,omitempty"`
+
+	// BitLocker recovery password of the instance, only sent with the post-import command.
+	// Example: 123456-123456-123456-123456-123456-123456-123456-123456
+	BitLockerRecoveryPassword string `json:"bitlocker_recovery_password,omitempty" yaml:"bitlocker_recovery_password,omitempty"`
+
+	// Passphrase of the instance's LUKS encrypted volumes, only sent with the post-import command.
+	LUKSPassphrase string `json:"luks_passphrase,omitempty" yaml:"luks_passphrase,omitempty"`
+
+	// Keyfile of the instance's LUKS encrypted volumes, only sent with the post-import command.
+	LUKSKeyFile []byte `json:"luks_keyfile,omitempty" yaml:"luks_keyfile,omitempty"`
+
+	// Customization of the guest from the batch and instance overrides, only sent with the post-import command.
+	GuestCustomization GuestCustomization `json:"guest_customization,omitzero" yaml:"guest_customization,omitempty"`
+
+	// How the source VM is shut down, only sent with the final import command.
+	ShutdownPolicy ShutdownPolicy `json:"shutdown_policy,omitzero" yaml:"shutdown_policy,omitempty"`
+
+	// Password of the guest user of the shutdown policy, only sent with the final import command.
+	GuestPassword string `json:"guest_password,omitempty" yaml:"guest_password,omitempty"`
+
+	// Script artifacts that apply to the instance, in the order they run, only sent with the post-import command.
+	ScriptArtifacts []Artifact `json:"script_artifacts,omitempty" yaml:"script_artifacts,omitempty"`
+}
+
+// WorkerTransferLimits bounds the concurrency of the disk transfers performed by a worker.
+type WorkerTransferLimits struct {
+	// Number of disks transferred at once.
+	// Example: 2
+	Disks int `json:"disks" yaml:"disks"`
+
+	// Number of concurrent NBD connections used for each disk.
+	// Example: 4
+	Connections int `json:"connections" yaml:"connections"`
+
+	// Bandwidth limit in bytes per second across all disks. Unlimited if 0.
+	// Example: 50000000
+	Bandwidth int64 `json:"bandwidth" yaml:"bandwidth"`
+}
+
+// WorkerUpdateResponse is returned to a worker for a status update of a running command.
+type WorkerUpdateResponse struct {
+	// Set to WORKERCOMMAND_ABORT if the worker should stop the running command.
+	// Example: WORKERCOMMAND_ABORT
+	Command WorkerCommandType `json:"command,omitempty" yaml:"command,omitempty"`
+
+	// Current transfer limits, sent while a disk import is running.
+	TransferLimits *WorkerTransferLimits `json:"transfer_limits,omitempty" yaml:"transfer_limits,omitempty"`
+}
+
+// WorkerChannelMessage is pushed to a worker over its control channel.
+// The worker sends its status back over the same channel as WorkerResponse messages.
+type WorkerChannelMessage struct {
+	// Next command for an idle worker.
+	Command *WorkerCommand `json:"command,omitempty" yaml:"command,omitempty"`
+
+	// Change to the running command, either in reply to a status update, or because the migration was canceled or the transfer limits changed.
+	Update *WorkerUpdateResponse `json:"update,omitempty" yaml:"update,omitempty"`
 }
 
 // WorkerResponse defines a response received from a worker.
@@ -69,4 +149,77 @@ type WorkerResponse struct {
 
 	// Additional data included with the response.
 	Metadata []byte `json:"metadata" yaml:"metadata"`
+
+	// Per-disk transfer statistics of a completed disk import.
+	DiskSyncs []WorkerDiskSync `json:"disk_syncs,omitempty" yaml:"disk_syncs,omitempty"`
+
+	// Time in UTC that the worker powered off the source VM, if it did so for the final import.
+	// Example: 2025-01-01 01:00:00
+	SourcePowerOff time.Time `json:"source_power_off,omitzero" yaml:"source_power_off,omitempty"`
+
+	// Sync state of each disk after a completed disk import.
+	DiskStates []WorkerDiskState `json:"disk_states,omitempty" yaml:"disk_states,omitempty"`
+
+	// Every step taken so far to shut down the source VM for the final import.
+	ShutdownAttempts []ShutdownAttempt `json:"shutdown_attempts,omitempty" yaml:"shutdown_attempts,omitempty"`
+}
+
+// WorkerDiskSync describes the data transferred for a single disk during a disk import.
+type WorkerDiskSync struct {
+	// Name of the disk and associated datastore.
+	// Example: [mydatastore] disk_1.vmdk
+	Name string `json:"name" yaml:"name"`
+
+	// Whether the whole disk was copied, rather than only the areas changed since the previous sync.
+	// Example: false
+	FullCopy bool `json:"full_copy" yaml:"full_copy"`
+
+	// Number of bytes copied to the target.
+	// Example: 1073741824
+	CopiedBytes int64 `json:"copied_bytes" yaml:"copied_bytes"`
+
+	// Time spent copying the disk.
+	// Example: 2m30s
+	Duration Duration `json:"duration" yaml:"duration"`
+
+	// Result of comparing the imported disk with the source, if it was verified.
+	Verification *WorkerDiskVerification `json:"verification,omitempty" yaml:"verification,omitempty"`
+}
+
+// WorkerDiskVerification describes the comparison of an imported disk with its source.
+type WorkerDiskVerification struct {
+	// Verification mode that was used.
+	// Example: sampled
+	Mode VerificationMode `json:"mode" yaml:"mode"`
+
+	// Number of bytes compared between the source and target disks.
+	// Example: 1073741824
+	VerifiedBytes int64 `json:"verified_bytes" yaml:"verified_bytes"`
+
+	// Number of compared blocks whose contents differ between the source and target disks.
+	// Example: 0
+	MismatchedBlocks int64 `json:"mismatched_blocks" yaml:"mismatched_blocks"`
+
+	// SHA-256 checksum of the compared blocks of the source disk. It also holds for the target disk if no blocks differ.
+	// Example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	Checksum string `json:"checksum" yaml:"checksum"`
+}
+
+// WorkerDiskState records how far a disk has been synced, so that later disk imports can continue from it.
+type WorkerDiskState struct {
+	// Name of the disk and associated datastore.
+	// Example: [mydatastore] disk_1.vmdk
+	Name string `json:"name" yaml:"name"`
+
+	// CBT change ID of the source disk that the target disk matches. Empty if the next import must copy the whole disk.
+	// Example: 52 d1 3c 9f 6b 4e a2 d9-a8 7e 1f 3b 2c 0d 5e 6f/4
+	ChangeID string `json:"change_id" yaml:"change_id"`
+
+	// Reference of the source snapshot the disk was synced from.
+	// Example: snapshot-123
+	SnapshotRef string `json:"snapshot_ref" yaml:"snapshot_ref"`
+
+	// Offset in bytes up to which the disk has been synced.
+	// Example: 1073741824
+	SyncedOffset int64 `json:"synced_offset" yaml:"synced_offset"`
 }